@@ -2,12 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
 	"expvar"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -17,13 +20,15 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/markbates/goth"
 	"github.com/markbates/goth/gothic"
-	"github.com/markbates/goth/providers/google"
 	"github.com/mochaeng/sapphire-backend/internal/app"
 	"github.com/mochaeng/sapphire-backend/internal/config"
 	"github.com/mochaeng/sapphire-backend/internal/cronjobs"
 	"github.com/mochaeng/sapphire-backend/internal/database"
 	"github.com/mochaeng/sapphire-backend/internal/env"
+	"github.com/mochaeng/sapphire-backend/internal/events"
 	"github.com/mochaeng/sapphire-backend/internal/mailer"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/oauth"
 	"github.com/mochaeng/sapphire-backend/internal/ratelimiter"
 	service "github.com/mochaeng/sapphire-backend/internal/services"
 	redisstore "github.com/mochaeng/sapphire-backend/internal/store/cache/redis"
@@ -51,7 +56,7 @@ var migrationsPath = fmt.Sprintf("file://%s", filepath.Join("migrate", "migratio
 // @securitydefinitions.apikey	ApiKeyAuth
 // @in							header
 // @name						Authorization
-// @description
+// @description				Personal access token, sent as "Bearer {token}". Browsers use the session cookie instead
 func main() {
 	logger := zap.Must(zap.NewProduction()).Sugar()
 	defer logger.Sync()
@@ -82,9 +87,14 @@ func main() {
 		Version:     "0.0.1",
 		MediaFolder: "data",
 		FrontedURL:  env.GetString("FRONTED_URL", "http://localhost:5173"),
+		// only behind a proxy that overwrites the header
+		ProxyIPHeader: env.GetString("PROXY_IP_HEADER", ""),
 		Mail: config.MailCfg{
-			Expired:   1 * time.Minute,
-			FromEmail: env.GetString("FROM_EMAIL", ""),
+			Expired:            time.Duration(env.GetInt("INVITATION_EXPIRES_HOURS", 24)) * time.Hour,
+			ResendInterval:     time.Duration(env.GetInt("INVITATION_RESEND_MINUTES", 2)) * time.Minute,
+			ResetExpired:       time.Duration(env.GetInt("PASSWORD_RESET_EXPIRES_MINUTES", 30)) * time.Minute,
+			EmailChangeExpired: time.Duration(env.GetInt("EMAIL_CHANGE_EXPIRES_HOURS", 24)) * time.Hour,
+			FromEmail:          env.GetString("FROM_EMAIL", ""),
 		},
 		Auth: config.AuthCfg{
 			Basic: config.BasicAuthCfg{
@@ -96,6 +106,29 @@ func main() {
 				Expired: 24 * 7 * time.Hour,
 				Issuer:  "sapphire",
 			},
+			TwoFactor: config.TwoFactorCfg{
+				Issuer:              "Sapphire",
+				PendingLoginExpired: 5 * time.Minute,
+			},
+			Passkey: config.PasskeyCfg{
+				RPID:             env.GetString("WEBAUTHN_RP_ID", ""),
+				RPName:           "Sapphire",
+				Origin:           env.GetString("WEBAUTHN_ORIGIN", ""),
+				ChallengeExpired: 5 * time.Minute,
+			},
+			Lockout: config.LockoutCfg{
+				AccountFreeAttempts: 3,
+				IPFreeAttempts:      20,
+				BaseDelay:           time.Second,
+				MaxDelay:            time.Minute,
+				MaxAccountFailures:  env.GetInt("LOGIN_MAX_ACCOUNT_FAILURES", 10),
+				MaxIPFailures:       env.GetInt("LOGIN_MAX_IP_FAILURES", 100),
+				Duration:            time.Duration(env.GetInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+			},
+			MagicLink: config.MagicLinkCfg{
+				Enabled: env.GetBool("MAGIC_LINK_ENABLED", false),
+				Expired: time.Duration(env.GetInt("MAGIC_LINK_EXPIRES_MINUTES", 15)) * time.Minute,
+			},
 		},
 		RateLimiter: config.RateLimiterConfig{
 			RequestPerTimeFrame: env.GetInt("RATE_LIMITER_REQUESTS_COUNT", 20),
@@ -103,12 +136,68 @@ func main() {
 			IsEnable:            env.GetBool("RATE_LIMITER_ENABLED", true),
 		},
 		OAuth: config.OAuthConfig{
-			Google: config.GoogleOAuth{
-				Key:         env.GetString("GOOGLE_KEY", ""),
-				Secret:      env.GetString("GOOGLE_SECRET", ""),
-				CallbackURI: env.GetString("GOOGLE_CALLBACK_URI", ""),
-			},
+			Providers:        oauthProvidersFromEnv(env.GetStrings("OAUTH_PROVIDERS", nil)),
+			RedirectPath:     env.GetString("OAUTH_REDIRECT_PATH", "/auth/oauth-success"),
+			LinkRedirectPath: env.GetString("OAUTH_LINK_REDIRECT_PATH", "/settings/accounts"),
 		},
+		Reactions: config.ReactionCfg{
+			Kinds: env.GetStrings("REACTION_KINDS", []string{"like", "love", "haha", "wow", "sad", "angry"}),
+		},
+		Trending: config.TrendingCfg{
+			Window:          time.Duration(env.GetInt("TRENDING_WINDOW_HOURS", 24)) * time.Hour,
+			RefreshInterval: time.Duration(env.GetInt("TRENDING_REFRESH_MINUTES", 10)) * time.Minute,
+			Size:            env.GetInt("TRENDING_SIZE", 50),
+		},
+		Purge: config.PurgeCfg{
+			Interval:    time.Duration(env.GetInt("PURGE_INTERVAL_MINUTES", 60)) * time.Minute,
+			GracePeriod: time.Duration(env.GetInt("PURGE_GRACE_PERIOD_HOURS", 72)) * time.Hour,
+		},
+	}
+
+	// two-factor secrets
+	if key := env.GetString("TOTP_ENCRYPTION_KEY", ""); key != "" {
+		encryptionKey, err := hex.DecodeString(key)
+		if err != nil || len(encryptionKey) != 32 {
+			logger.Fatalw("TOTP_ENCRYPTION_KEY must be 32 bytes encoded as hex", "err", err)
+		}
+		cfg.Auth.TwoFactor.EncryptionKey = encryptionKey
+	} else {
+		logger.Warn("TOTP_ENCRYPTION_KEY is not set, two-factor authentication is disabled")
+	}
+
+	// password hashing
+	models.PasswordHashing = models.PasswordParams{
+		Algorithm:     env.GetString("PASSWORD_HASH_ALGORITHM", models.DefaultPasswordParams.Algorithm),
+		BcryptCost:    env.GetInt("BCRYPT_COST", models.DefaultPasswordParams.BcryptCost),
+		Argon2Memory:  uint32(env.GetInt("ARGON2_MEMORY_KIB", int(models.DefaultPasswordParams.Argon2Memory))),
+		Argon2Time:    uint32(env.GetInt("ARGON2_ITERATIONS", int(models.DefaultPasswordParams.Argon2Time))),
+		Argon2Threads: uint8(env.GetInt("ARGON2_PARALLELISM", int(models.DefaultPasswordParams.Argon2Threads))),
+	}
+	if err := models.PasswordHashing.Check(); err != nil {
+		logger.Fatalw("password hashing is not configured right", "err", err)
+	}
+
+	// background jobs
+	if err := cfg.Trending.Check(); err != nil {
+		logger.Fatalw("trending tags are not configured right", "err", err)
+	}
+	if err := cfg.Purge.Check(); err != nil {
+		logger.Fatalw("unconfirmed accounts purge is not configured right", "err", err)
+	}
+	if err := cfg.Auth.Lockout.Check(); err != nil {
+		logger.Fatalw("sign in lockout is not configured right", "err", err)
+	}
+
+	// passkeys default to the frontend address
+	if cfg.Auth.Passkey.Origin == "" {
+		cfg.Auth.Passkey.Origin = cfg.FrontedURL
+	}
+	if cfg.Auth.Passkey.RPID == "" {
+		origin, err := url.Parse(cfg.Auth.Passkey.Origin)
+		if err != nil {
+			logger.Fatalw("WEBAUTHN_ORIGIN is not a valid URL", "err", err)
+		}
+		cfg.Auth.Passkey.RPID = origin.Hostname()
 	}
 
 	// media folder
@@ -166,6 +255,14 @@ func main() {
 	}
 	cacheStore := redisstore.NewRedisStore(rdb)
 
+	// real-time events
+	eventsCtx, eventsCancel := context.WithCancel(context.Background())
+	defer eventsCancel()
+	var broker events.Broker = events.NewLocalBroker()
+	if cfg.Cacher.IsEnable {
+		broker = events.NewRedisBroker(eventsCtx, rdb, logger)
+	}
+
 	// smtp
 	smtpServer := env.GetString("SMTP_SERVER", "gmail")
 	fromEmail := env.GetString("FROM_EMAIL", "email")
@@ -188,6 +285,7 @@ func main() {
 		Cfg:        cfg,
 		Mailer:     clientMailer,
 		CacheStore: cacheStore,
+		Events:     broker,
 	}
 	services := service.NewServices(&serviceCfg)
 
@@ -199,20 +297,18 @@ func main() {
 	cookieStore.Options.HttpOnly = true
 	cookieStore.Options.SameSite = http.SameSiteLaxMode
 	gothic.Store = cookieStore
-	goth.UseProviders(
-		google.New(
-			cfg.OAuth.Google.Key,
-			cfg.OAuth.Google.Secret,
-			cfg.OAuth.Google.CallbackURI,
-			"email", "profile",
-		),
-	)
+	providers, err := setupOAuthProviders(&cfg.OAuth, logger)
+	if err != nil {
+		logger.Fatalw("could not set up the oauth providers", "err", err)
+	}
+	goth.UseProviders(providers...)
 
 	app := &app.Application{
 		Config:      cfg,
 		Service:     services,
 		Logger:      logger,
 		RateLimiter: rateLimiter,
+		Events:      broker,
 	}
 
 	expvar.NewString("version").Set(cfg.Version)
@@ -224,7 +320,12 @@ func main() {
 	}))
 
 	cronCtx, cronCancel := context.WithCancel(context.Background())
-	cronjobs.PurgeUnconfirmedUsers(cronCtx, store, 1*time.Minute, logger)
+	cronjobs.PurgeUnconfirmedUsers(cronCtx, store, &cfg.Purge, logger)
+	cronjobs.RefreshTrendingTags(cronCtx, store, &cfg.Trending, logger)
+	if !cfg.Cacher.IsEnable {
+		cronjobs.PurgeLoginFailures(cronCtx, store, &cfg.Auth.Lockout, logger)
+	}
+	services.Notification.Start(cronCtx)
 
 	mux := app.Mount()
 	if err := app.Run(mux); err != nil {
@@ -233,3 +334,38 @@ func main() {
 
 	cronCancel()
 }
+
+// setupOAuthProviders builds the providers that have credentials. The others
+// are left out with a warning, their routes answer 404
+func setupOAuthProviders(cfg *config.OAuthConfig, logger *zap.SugaredLogger) ([]goth.Provider, error) {
+	for _, name := range oauth.SkipUnconfigured(cfg) {
+		logger.Warnw("oauth provider is not configured, skipping it", "provider", name)
+	}
+	return oauth.NewProviders(cfg)
+}
+
+// oauthProvidersFromEnv reads the OAUTH_<NAME>_* variables of every provider.
+// The kind defaults to the name, so "google" and "github" need no OAUTH_<NAME>_TYPE,
+// and google still reads the older GOOGLE_* variables
+func oauthProvidersFromEnv(names []string) []config.OAuthProviderCfg {
+	providers := make([]config.OAuthProviderCfg, len(names))
+	for idx, name := range names {
+		prefix := "OAUTH_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := config.OAuthProviderCfg{
+			Name:         name,
+			Kind:         env.GetString(prefix+"TYPE", name),
+			Key:          env.GetString(prefix+"KEY", ""),
+			Secret:       env.GetString(prefix+"SECRET", ""),
+			CallbackURI:  env.GetString(prefix+"CALLBACK_URI", ""),
+			DiscoveryURL: env.GetString(prefix+"DISCOVERY_URL", ""),
+			Scopes:       env.GetStrings(prefix+"SCOPES", nil),
+		}
+		if name == config.OAuthGoogle {
+			provider.Key = env.GetString(prefix+"KEY", env.GetString("GOOGLE_KEY", ""))
+			provider.Secret = env.GetString(prefix+"SECRET", env.GetString("GOOGLE_SECRET", ""))
+			provider.CallbackURI = env.GetString(prefix+"CALLBACK_URI", env.GetString("GOOGLE_CALLBACK_URI", ""))
+		}
+		providers[idx] = provider
+	}
+	return providers
+}
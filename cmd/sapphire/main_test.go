@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/env"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSetupOAuthProviders(t *testing.T) {
+	logger := zap.NewNop().Sugar()
+
+	t.Run("starts without oauth variables", func(t *testing.T) {
+		t.Setenv("OAUTH_PROVIDERS", "")
+		t.Setenv("GOOGLE_KEY", "")
+
+		cfg := config.OAuthConfig{Providers: oauthProvidersFromEnv(env.GetStrings("OAUTH_PROVIDERS", nil))}
+		providers, err := setupOAuthProviders(&cfg, logger)
+		require.NoError(t, err)
+		assert.Empty(t, providers)
+	})
+
+	t.Run("skips providers without a key", func(t *testing.T) {
+		t.Setenv("OAUTH_PROVIDERS", "google")
+		t.Setenv("GOOGLE_KEY", "")
+		t.Setenv("GOOGLE_CALLBACK_URI", "http://api/v1/auth/google/callback")
+
+		cfg := config.OAuthConfig{Providers: oauthProvidersFromEnv(env.GetStrings("OAUTH_PROVIDERS", nil))}
+		providers, err := setupOAuthProviders(&cfg, logger)
+		require.NoError(t, err)
+		assert.Empty(t, providers)
+		assert.False(t, cfg.IsEnabled("google"))
+	})
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+func newAccessTokenResponse(accessToken *models.AccessToken) responses.AccessTokenResponse {
+	response := responses.AccessTokenResponse{
+		ID:        accessToken.ID,
+		Name:      accessToken.Name,
+		Scopes:    accessToken.Scopes,
+		Token:     accessToken.Token,
+		CreatedAt: accessToken.CreatedAt,
+	}
+	if accessToken.ExpiresAt.Valid {
+		response.ExpiresAt = &accessToken.ExpiresAt.Time
+	}
+	if accessToken.LastUsedAt.Valid {
+		response.LastUsedAt = &accessToken.LastUsedAt.Time
+	}
+	return response
+}
+
+// CreateAccessTokenHandler godoc
+//
+//	@Summary		Creates a personal access token
+//	@Description	Creates a token API clients send as "Authorization: Bearer". The read scope allows GET requests and the write scope the others. The token is only shown in this answer
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		payloads.CreateAccessTokenPayload	true	"Token name, scopes and lifetime"
+//	@Success		201		{object}	responses.AccessTokenResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/tokens [post]
+func (app *Application) createAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.CreateAccessTokenPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	accessToken, err := app.Service.AccessToken.Create(r.Context(), user, &payload)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusCreated, newAccessTokenResponse(accessToken)); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetAccessTokensHandler godoc
+//
+//	@Summary		Lists the personal access tokens
+//	@Description	Lists the access tokens of the authenticated user, the newest first. The tokens themselves are not shown
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	responses.GetAccessTokensResponse
+//	@Failure		401	{object}	error
+//	@Failure		403	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/tokens [get]
+func (app *Application) getAccessTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	accessTokens, err := app.Service.AccessToken.GetFromUser(r.Context(), user.ID)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	response := responses.GetAccessTokensResponse{
+		AccessTokens: make([]responses.AccessTokenResponse, len(accessTokens)),
+	}
+	for idx, accessToken := range accessTokens {
+		response.AccessTokens[idx] = newAccessTokenResponse(accessToken)
+	}
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// RevokeAccessTokenHandler godoc
+//
+//	@Summary		Revokes a personal access token
+//	@Tags			auth
+//	@Produce		json
+//	@Param			tokenID	path	int	true	"Access token ID"
+//	@Success		204		"access token revoked"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/tokens/{tokenID} [delete]
+func (app *Application) revokeAccessTokenHandler(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := strconv.ParseInt(chi.URLParam(r, "tokenID"), 10, 64)
+	if err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	if err := app.Service.AccessToken.Revoke(r.Context(), user.ID, tokenID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+// UnlockUserHandler godoc
+//
+//	@Summary		Unlocks an account
+//	@Description	Forgets the failed sign ins of an account locked out after too many, so its owner can sign in again
+//	@Tags			admin
+//	@Produce		json
+//	@Param			userID	path	int	true	"User ID"
+//	@Success		204		"account unlocked"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/users/{userID}/lockout [delete]
+func (app *Application) unlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.Service.Auth.Unlock(r.Context(), getUserFromContext(r), userID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
+// GetAuditEventsHandler godoc
+//
+//	@Summary		Lists the audit log
+//	@Description	Lists who did what to accounts and content, the newest first. Every filter that is sent has to match
+//	@Tags			admin
+//	@Produce		json
+//	@Param			action		query		string	false	"Action, such as signin.failed"
+//	@Param			actor_id	query		int		false	"User who did it"
+//	@Param			target_type	query		string	false	"Kind of target, such as user or post"
+//	@Param			target_id	query		string	false	"Target ID"
+//	@Param			ip			query		string	false	"Address of the request"
+//	@Param			since		query		string	false	"From this date or time"
+//	@Param			until		query		string	false	"Before this date or time"
+//	@Param			limit		query		string	false	"Limit"
+//	@Param			cursor		query		string	false	"Cursor"
+//	@Success		200			{object}	responses.GetAuditEventsResponse
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/admin/audit-events [get]
+func (app *Application) getAuditEventsHandler(w http.ResponseWriter, r *http.Request) {
+	var auditEvents pagination.AuditEvents
+	if err := auditEvents.Parse(r.URL.Query()); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	events, err := app.Service.Audit.GetEvents(r.Context(), &auditEvents)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	response := responses.GetAuditEventsResponse{
+		Events:     make([]responses.AuditEventResponse, len(events)),
+		NextCursor: auditEvents.NextCursor,
+	}
+	for idx, auditEvent := range events {
+		response.Events[idx] = responses.AuditEventResponse{
+			ID:         auditEvent.ID,
+			Action:     auditEvent.Action,
+			TargetType: auditEvent.TargetType,
+			TargetID:   auditEvent.TargetID,
+			IP:         auditEvent.IP,
+			RequestID:  auditEvent.RequestID,
+			Metadata:   auditEvent.Metadata,
+			CreatedAt:  auditEvent.CreatedAt,
+		}
+		if auditEvent.ActorID.Valid {
+			response.Events[idx].ActorID = &auditEvent.ActorID.Int64
+		}
+	}
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
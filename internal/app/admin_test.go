@@ -0,0 +1,103 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditEventsHandler(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	admin := &models.User{ID: 1, Username: "hutao", Role: models.Role{Level: config.Roles["admin"].Level}}
+	moderator := &models.User{ID: 2, Username: "chaee", Role: models.Role{Level: config.Roles["moderator"].Level}}
+	adminCookie := withTestSession(t, app, admin, &models.Session{ID: "admin", UserID: admin.ID})
+	moderatorCookie := withTestSession(t, app, moderator, &models.Session{ID: "moderator", UserID: moderator.ID})
+
+	auditService := app.Service.Audit.(*mocks.MockAuditService)
+	withFilters := mock.MatchedBy(func(auditEvents *pagination.AuditEvents) bool {
+		return auditEvents.Action == sql.NullString{String: models.AuditSigninFailed, Valid: true} &&
+			auditEvents.TargetType == sql.NullString{String: models.AuditTargetUser, Valid: true} &&
+			auditEvents.ActorID == sql.NullInt64{} &&
+			auditEvents.Since.Valid &&
+			auditEvents.Limit == 2
+	})
+	auditService.On("GetEvents", mock.Anything, withFilters).Run(func(args mock.Arguments) {
+		args.Get(1).(*pagination.AuditEvents).NextCursor = "40"
+	}).Return([]*models.AuditEvent{
+		{
+			ID:         41,
+			Action:     models.AuditSigninFailed,
+			TargetType: models.AuditTargetUser,
+			TargetID:   "2",
+			IP:         "192.0.2.1",
+			RequestID:  "host/abc-000001",
+			Metadata:   map[string]string{"method": "password", "reason": "wrong_password"},
+			CreatedAt:  time.Now(),
+		},
+		{
+			ID:         40,
+			Action:     models.AuditSigninSucceeded,
+			ActorID:    sql.NullInt64{Int64: 2, Valid: true},
+			TargetType: models.AuditTargetUser,
+			TargetID:   "2",
+			CreatedAt:  time.Now(),
+		},
+	}, nil)
+
+	t.Run("lists the events matching the filters", func(t *testing.T) {
+		path := "/v1/admin/audit-events?action=signin.failed&target_type=user&since=2024-01-01&limit=2"
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		req.AddCookie(adminCookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetAuditEventsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Data.Events, 2)
+		assert.Equal(t, "40", response.Data.NextCursor)
+		assert.Nil(t, response.Data.Events[0].ActorID)
+		assert.Equal(t, "wrong_password", response.Data.Events[0].Metadata["reason"])
+		require.NotNil(t, response.Data.Events[1].ActorID)
+		assert.Equal(t, int64(2), *response.Data.Events[1].ActorID)
+	})
+
+	t.Run("returns status 400 for invalid filters", func(t *testing.T) {
+		for _, query := range []string{"actor_id=hutao", "cursor=-1", "since=yesterday"} {
+			req, err := http.NewRequest(http.MethodGet, "/v1/admin/audit-events?"+query, nil)
+			require.NoError(t, err)
+			req.AddCookie(adminCookie)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+
+	t.Run("returns status 403 for users below admin", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/admin/audit-events", nil)
+		require.NoError(t, err)
+		req.AddCookie(moderatorCookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		auditService.AssertNumberOfCalls(t, "GetEvents", 1)
+	})
+}
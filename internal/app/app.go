@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/go-chi/cors"
 	"github.com/mochaeng/sapphire-backend/docs"
 	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/events"
 	"github.com/mochaeng/sapphire-backend/internal/ratelimiter"
 	"github.com/mochaeng/sapphire-backend/internal/services"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -27,6 +29,11 @@ type Application struct {
 	Config      *config.Cfg
 	Logger      *zap.SugaredLogger
 	RateLimiter ratelimiter.RateLimiter
+	Events      events.Broker
+
+	// connections tracks the hijacked WebSocket connections, which the
+	// server shutdown does not wait for
+	connections sync.WaitGroup
 }
 
 func (app *Application) Mount() http.Handler {
@@ -34,9 +41,10 @@ func (app *Application) Mount() http.Handler {
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(app.realIPMiddleware)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
+	r.Use(app.auditIPMiddleware)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins: []string{
 			"http://localhost:7777", "https://localhost:7777",
@@ -48,7 +56,7 @@ func (app *Application) Mount() http.Handler {
 			"http://sapphire.mochaeng.xyz", "https://sapphire.mochaeng.xyz",
 		},
 		// AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
@@ -58,67 +66,186 @@ func (app *Application) Mount() http.Handler {
 	if app.Config.RateLimiter.IsEnable {
 		r.Use(app.rateLimiterMiddleware)
 	}
-	r.Use(middleware.Timeout(60 * time.Second))
+	timeout := middleware.Timeout(60 * time.Second)
 
-	r.Get("/health", app.healthCheckHandler)
+	r.With(timeout).Get("/health", app.healthCheckHandler)
 
 	r.Route("/v1", func(r chi.Router) {
-		r.With(app.basicAuthMiddleware).Get("/debug/vars", expvar.Handler().ServeHTTP)
-		r.With(app.basicAuthMiddleware).Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL(docsURL)))
+		// long-lived connections are kept out of the request timeout
+		r.With(app.authTokenMiddleware).Get("/events", app.eventStreamHandler)
+		r.With(app.authTokenMiddleware).Get("/ws", app.webSocketHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(timeout)
+			r.With(app.basicAuthMiddleware).Get("/debug/vars", expvar.Handler().ServeHTTP)
+			r.With(app.basicAuthMiddleware).Get("/swagger/*", httpSwagger.Handler(httpSwagger.URL(docsURL)))
 
-		r.Route("/user", func(r chi.Router) {
-			r.Route("/posts", func(r chi.Router) {
-				r.Get("/{username}", app.getUserPosts)
+			r.Route("/user", func(r chi.Router) {
+				r.Route("/posts", func(r chi.Router) {
+					r.With(app.optionalAuthTokenMiddleware).Get("/{username}", app.getUserPosts)
+				})
+				r.Route("/profile", func(r chi.Router) {
+					r.With(app.authTokenMiddleware).Patch("/", app.updateUserProfileHandler)
+					r.Get("/{username}", app.getUserProfile)
+				})
+				r.Route("/{userID}", func(r chi.Router) {
+					r.With(app.userContextMiddleware).Get("/", app.getUserHandler)
+					r.Group(func(r chi.Router) {
+						r.Use(app.authTokenMiddleware)
+						r.Put("/follow", app.followUserHandler)
+						r.Put("/unfollow", app.unfollowUserHandler)
+						r.Put("/block", app.blockUserHandler)
+						r.Put("/unblock", app.unblockUserHandler)
+					})
+				})
+				r.With(app.authTokenMiddleware).Post("/feed", app.GetUserFeedHandler)
+				r.With(app.authTokenMiddleware).Get("/bookmarks", app.getUserBookmarksHandler)
+				r.With(app.authTokenMiddleware).Get("/mentions", app.getUserMentionsHandler)
+				r.With(app.authTokenMiddleware, app.sessionOnlyMiddleware).Put("/email", app.changeUserEmailHandler)
+				r.Route("/by", func(r chi.Router) {
+					r.Get("/{username}", app.getUserByUsername)
+				})
 			})
-			r.Route("/profile", func(r chi.Router) {
-				r.Get("/{username}", app.getUserProfile)
+
+			r.Route("/post", func(r chi.Router) {
+				r.With(app.authTokenMiddleware).Post("/", app.createPostHandler)
+				r.Route("/{postID}", func(r chi.Router) {
+					r.With(app.optionalAuthTokenMiddleware, app.postContextMiddleware).Get("/", app.getPostHandler)
+					r.Group(func(r chi.Router) {
+						r.Use(app.authTokenMiddleware)
+						r.Use(app.postContextMiddleware)
+						r.Patch("/", app.checkPostOwnership(config.Roles["moderator"].Level, app.updatePostHandler))
+						r.Delete("/", app.checkPostOwnership(config.Roles["admin"].Level, app.deletePostHandler))
+						r.Put("/reactions", app.reactToPostHandler)
+						r.Delete("/reactions", app.removePostReactionHandler)
+						r.Post("/repost", app.repostPostHandler)
+						r.Delete("/repost", app.undoRepostHandler)
+						r.Post("/quote", app.quotePostHandler)
+						r.Put("/bookmark", app.bookmarkPostHandler)
+						r.Delete("/bookmark", app.removeBookmarkHandler)
+					})
+					r.Route("/comments", func(r chi.Router) {
+						r.Use(app.postContextMiddleware)
+						r.Get("/", app.getPostCommentsHandler)
+						r.With(app.authTokenMiddleware).Post("/", app.createCommentHandler)
+						r.Route("/{commentID}", func(r chi.Router) {
+							r.Use(app.commentContextMiddleware)
+							r.Get("/replies", app.getCommentRepliesHandler)
+							r.Group(func(r chi.Router) {
+								r.Use(app.authTokenMiddleware)
+								r.Patch("/", app.checkCommentOwnership(config.Roles["moderator"].Level, app.updateCommentHandler))
+								r.Delete("/", app.checkCommentOwnership(config.Roles["admin"].Level, app.deleteCommentHandler))
+							})
+						})
+					})
+				})
 			})
-			r.Route("/{userID}", func(r chi.Router) {
-				r.With(app.userContextMiddleware).Get("/", app.getUserHandler)
-				r.Group(func(r chi.Router) {
-					r.Use(app.authTokenMiddleware)
-					r.Put("/follow", app.followUserHandler)
-					r.Put("/unfollow", app.unfollowUserHandler)
+
+			r.Route("/conversations", func(r chi.Router) {
+				r.Use(app.authTokenMiddleware)
+				r.Get("/", app.getConversationsHandler)
+				r.Post("/", app.createConversationHandler)
+				r.Route("/{conversationID}", func(r chi.Router) {
+					r.Use(app.conversationContextMiddleware)
+					r.Get("/messages", app.getConversationMessagesHandler)
+					r.Post("/messages", app.sendMessageHandler)
+					r.Put("/read", app.markConversationReadHandler)
 				})
 			})
-			r.With(app.authTokenMiddleware).Post("/feed", app.GetUserFeedHandler)
-			r.Route("/by", func(r chi.Router) {
-				r.Get("/{username}", app.getUserByUsername)
+
+			r.Route("/notifications", func(r chi.Router) {
+				r.Use(app.authTokenMiddleware)
+				r.Get("/", app.getNotificationsHandler)
+				r.Get("/unread-count", app.getUnreadNotificationsCountHandler)
+				r.Put("/read", app.markAllNotificationsReadHandler)
+				r.Put("/{notificationID}/read", app.markNotificationReadHandler)
 			})
-		})
 
-		r.Route("/post", func(r chi.Router) {
-			r.With(app.authTokenMiddleware).Post("/", app.createPostHandler)
-			r.Route("/{postID}", func(r chi.Router) {
-				r.With(app.postContextMiddleware).Get("/", app.getPostHandler)
-				r.Group(func(r chi.Router) {
-					r.Use(app.authTokenMiddleware)
-					r.Use(app.postContextMiddleware)
-					r.Patch("/", app.checkPostOwnership(config.Roles["moderator"].Level, app.updatePostHandler))
-					r.Delete("/", app.checkPostOwnership(config.Roles["admin"].Level, app.deletePostHandler))
-				})
+			r.Get("/search", app.searchHandler)
+
+			r.Route("/tags", func(r chi.Router) {
+				r.Get("/trending", app.getTrendingTagsHandler)
+				r.Get("/{tag}/posts", app.getTagPostsHandler)
 			})
-		})
 
-		r.Route("/auth", func(r chi.Router) {
-			r.Post("/signup", app.signupHandler)
-			r.Post("/signin", app.signinHandler)
-			r.With(app.authTokenMiddleware).Post("/signout", app.signoutHandler)
-			r.With(app.authTokenMiddleware).Post("/status", app.authStatusHandler)
-			r.With(app.authTokenMiddleware).Post("/me", app.authMeHandler)
+			r.Route("/auth", func(r chi.Router) {
+				r.Post("/signup", app.signupHandler)
+				r.Post("/signin", app.signinHandler)
+				r.Post("/resend-activation", app.resendActivationHandler)
+				r.Post("/forgot-password", app.forgotPasswordHandler)
+				r.Post("/reset-password", app.resetPasswordHandler)
+				r.Post("/magic-link", app.requestMagicLinkHandler)
+				r.Post("/magic-link/signin", app.magicLinkSigninHandler)
+				r.Route("/magic-link/preference", func(r chi.Router) {
+					r.Use(app.authTokenMiddleware, app.sessionOnlyMiddleware)
+					r.Get("/", app.getMagicLinkPreferenceHandler)
+					r.Put("/", app.setMagicLinkPreferenceHandler)
+				})
+				r.With(app.authTokenMiddleware, app.sessionOnlyMiddleware).Post("/signout", app.signoutHandler)
+				r.With(app.authTokenMiddleware).Post("/status", app.authStatusHandler)
+				r.With(app.authTokenMiddleware).Post("/me", app.authMeHandler)
+				r.Route("/sessions", func(r chi.Router) {
+					r.Use(app.authTokenMiddleware, app.sessionOnlyMiddleware)
+					r.Get("/", app.getSessionsHandler)
+					r.Delete("/", app.revokeOtherSessionsHandler)
+					r.Delete("/{sessionID}", app.revokeSessionHandler)
+				})
+				r.Route("/2fa", func(r chi.Router) {
+					r.Post("/verify", app.verifyTwoFactorHandler)
+					r.Group(func(r chi.Router) {
+						r.Use(app.authTokenMiddleware, app.sessionOnlyMiddleware)
+						r.Post("/setup", app.setupTwoFactorHandler)
+						r.Post("/enable", app.enableTwoFactorHandler)
+						r.Post("/disable", app.disableTwoFactorHandler)
+					})
+				})
+				r.Route("/passkeys", func(r chi.Router) {
+					r.Post("/login/begin", app.beginPasskeyLoginHandler)
+					r.Post("/login/finish", app.finishPasskeyLoginHandler)
+					r.Group(func(r chi.Router) {
+						r.Use(app.authTokenMiddleware, app.sessionOnlyMiddleware)
+						r.Get("/", app.getPasskeysHandler)
+						r.Post("/register/begin", app.beginPasskeyRegistrationHandler)
+						r.Post("/register/finish", app.finishPasskeyRegistrationHandler)
+						r.Patch("/{passkeyID}", app.renamePasskeyHandler)
+						r.Delete("/{passkeyID}", app.deletePasskeyHandler)
+					})
+				})
 
-			// oauth
-			r.Get("/{provider}/login", app.OAuthLoginHandler)
-			r.Get("/{provider}/callback", app.OAuthCallbackHandler)
-		})
+				r.Route("/tokens", func(r chi.Router) {
+					r.Use(app.authTokenMiddleware, app.sessionOnlyMiddleware)
+					r.Get("/", app.getAccessTokensHandler)
+					r.Post("/", app.createAccessTokenHandler)
+					r.Delete("/{tokenID}", app.revokeAccessTokenHandler)
+				})
+
+				r.Route("/identities", func(r chi.Router) {
+					r.Use(app.authTokenMiddleware, app.sessionOnlyMiddleware)
+					r.Get("/", app.getOAuthAccountsHandler)
+					r.Delete("/{provider}/{providerUserID}", app.unlinkOAuthAccountHandler)
+				})
+
+				// oauth
+				r.Get("/{provider}/login", app.OAuthLoginHandler)
+				r.With(app.authTokenMiddleware, app.sessionOnlyMiddleware).Get("/{provider}/link", app.OAuthLinkHandler)
+				r.With(app.optionalAuthTokenMiddleware).Get("/{provider}/callback", app.OAuthCallbackHandler)
+			})
+
+			r.Route("/admin", func(r chi.Router) {
+				r.Use(app.authTokenMiddleware)
+				r.Delete("/users/{userID}/lockout", app.checkRoleLevel(config.Roles["admin"].Level, app.unlockUserHandler))
+				r.Get("/audit-events", app.checkRoleLevel(config.Roles["admin"].Level, app.getAuditEventsHandler))
+			})
 
-		r.Route("/verify-email", func(r chi.Router) {
-			r.Put("/{token}", app.activateUserHandler)
+			r.Route("/verify-email", func(r chi.Router) {
+				r.Put("/{token}", app.activateUserHandler)
+				r.Put("/change/{token}", app.confirmUserEmailHandler)
+			})
 		})
 	})
 
 	fs := http.FileServer(http.Dir(app.Config.MediaFolder))
-	r.Handle(fmt.Sprintf("/%s/*", app.Config.MediaFolder), http.StripPrefix(fmt.Sprintf("/%s/", app.Config.MediaFolder), fs))
+	r.With(timeout).Handle(fmt.Sprintf("/%s/*", app.Config.MediaFolder), http.StripPrefix(fmt.Sprintf("/%s/", app.Config.MediaFolder), fs))
 	return r
 }
 
@@ -135,6 +262,10 @@ func (app *Application) Run(mux http.Handler) error {
 		IdleTimeout:  time.Minute,
 	}
 
+	// the event streams and sockets would otherwise hold the shutdown until
+	// its timeout
+	server.RegisterOnShutdown(app.Events.Close)
+
 	shutdown := make(chan error)
 	go func() {
 		quit := make(chan os.Signal, 1)
@@ -143,7 +274,11 @@ func (app *Application) Run(mux http.Handler) error {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 		app.Logger.Infow("signal caught", "signal", s.String())
-		shutdown <- server.Shutdown(ctx)
+		err := server.Shutdown(ctx)
+		if waitErr := app.waitConnections(ctx); err == nil {
+			err = waitErr
+		}
+		shutdown <- err
 	}()
 
 	app.Logger.Infow("server has started", "addr", app.Config.Addr, "env", app.Config.Env)
@@ -158,3 +293,19 @@ func (app *Application) Run(mux http.Handler) error {
 	app.Logger.Infow("server has stopped", "addr", app.Config.Addr, "env", app.Config.Env)
 	return nil
 }
+
+// waitConnections waits for the WebSocket connections to say goodbye, or for
+// the context to be done
+func (app *Application) waitConnections(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		app.connections.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
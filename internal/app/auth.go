@@ -1,9 +1,14 @@
 package app
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models"
 	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
 	"github.com/mochaeng/sapphire-backend/internal/models/responses"
 	service "github.com/mochaeng/sapphire-backend/internal/services"
@@ -63,8 +68,10 @@ func (app *Application) signupHandler(w http.ResponseWriter, r *http.Request) {
 //	@Produce		json
 //	@Param			payload	body		responses.CreateUserTokenPayload	true	"User credentials"
 //	@Success		204		"user has signin"
+//	@Success		202		{object}	responses.SigninChallengeResponse	"two-factor code required"
 //	@Failure		400		{object}	error
 //	@Failure		401		{object}	error
+//	@Failure		429		{object}	error	"too many failed sign ins"
 //	@Failure		500		{object}	error
 //	@Router			/auth/signin [post]
 func (app *Application) signinHandler(w http.ResponseWriter, r *http.Request) {
@@ -73,10 +80,14 @@ func (app *Application) signinHandler(w http.ResponseWriter, r *http.Request) {
 		app.BadRequestResponse(w, r, err)
 		return
 	}
-	user, err := app.Service.Auth.Authenticate(r.Context(), &payload)
+	user, err := app.Service.Auth.Authenticate(r.Context(), &payload, getSessionClient(r).IP)
 	if err != nil {
-		switch err {
-		case store.ErrNotFound:
+		var throttled *service.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			retryAfter := int64(math.Ceil(throttled.RetryAfter.Seconds()))
+			app.RateLimitExceededResponse(w, r, strconv.FormatInt(retryAfter, 10))
+		case err == store.ErrNotFound:
 			app.UnauthorizedErrorResponse(w, r, err)
 		default:
 			app.InternalServerErrorResponse(w, r, err)
@@ -105,13 +116,144 @@ func (app *Application) signinHandler(w http.ResponseWriter, r *http.Request) {
 	// 	MaxAge:   int(time.Until(session.ExpiresAt).Seconds()),
 	// 	SameSite: http.SameSiteLaxMode,
 	// }
-	cookie, err := app.Service.Auth.GetCookieSession(user.ID)
+	app.completeSignin(w, r, user, "")
+}
+
+// completeSignin sets the session cookie of a user who proved who they are,
+// or answers with the challenge when two-factor is on. Once signed in, the
+// user is sent to redirectURL, or gets no content when it is empty
+func (app *Application) completeSignin(w http.ResponseWriter, r *http.Request, user *models.User, redirectURL string) {
+	pendingLogin, err := app.Service.TwoFactor.BeginLogin(r.Context(), user)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+	if pendingLogin != nil {
+		response := responses.SigninChallengeResponse{
+			TwoFactorRequired: true,
+			Token:             pendingLogin.Token,
+			ExpiresAt:         pendingLogin.ExpiresAt,
+		}
+		if err := httpio.JsonResponse(w, http.StatusAccepted, response); err != nil {
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	cookie, err := app.Service.Auth.GetCookieSession(user.ID, getSessionClient(r))
 	if err != nil {
 		app.InternalServerErrorResponse(w, r, err)
 		return
 	}
 
 	http.SetCookie(w, cookie)
+	if redirectURL != "" {
+		http.Redirect(w, r, redirectURL, http.StatusPermanentRedirect)
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
+// ResendActivationHandler godoc
+//
+//	@Summary		Resends the activation e-mail
+//	@Description	E-mails a new activation link to an account that was not activated yet. The previous link stops working. The answer is the same for unknown addresses
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	payloads.ResendActivationPayload	true	"User e-mail"
+//	@Success		204		"activation e-mail sent if the account is pending"
+//	@Failure		400		{object}	error
+//	@Failure		429		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/resend-activation [post]
+func (app *Application) resendActivationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.ResendActivationPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.Service.Auth.ResendActivation(r.Context(), &payload); err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		case service.ErrResendTooSoon:
+			app.RateLimitExceededResponse(w, r, app.Config.Mail.ResendInterval.String())
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	httpio.NoContentResponse(w)
+}
+
+// ForgotPasswordHandler godoc
+//
+//	@Summary		Asks for a password reset
+//	@Description	E-mails a single-use link to reset the password when the address belongs to an active user. The answer is the same for unknown addresses
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	payloads.ForgotPasswordPayload	true	"User e-mail"
+//	@Success		204		"reset e-mail sent if the user exists"
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/forgot-password [post]
+func (app *Application) forgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.ForgotPasswordPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.Service.Auth.ForgotPassword(r.Context(), &payload); err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	httpio.NoContentResponse(w)
+}
+
+// ResetPasswordHandler godoc
+//
+//	@Summary		Resets the user password
+//	@Description	Sets a new password using the token sent by e-mail. The token can be used only once and every session of the user is signed out
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	payloads.ResetPasswordPayload	true	"Reset token and new password"
+//	@Success		204		"password changed"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/reset-password [post]
+func (app *Application) resetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.ResetPasswordPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.Service.Auth.ResetPassword(r.Context(), &payload); err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.deleteUserSessionCookie(w)
 	httpio.NoContentResponse(w)
 }
 
@@ -133,7 +275,7 @@ func (app *Application) signoutHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := app.Service.Auth.InvalidateSession(session.ID)
+	err := app.Service.Auth.InvalidateSession(r.Context(), session)
 	if err != nil {
 		app.InternalServerErrorResponse(w, r, err)
 		return
@@ -144,6 +286,106 @@ func (app *Application) signoutHandler(w http.ResponseWriter, r *http.Request) {
 	httpio.NoContentResponse(w)
 }
 
+// GetSessionsHandler godoc
+//
+//	@Summary		Lists the user sessions
+//	@Description	Lists the devices the user is signed in on, the most recently used first
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	responses.GetSessionsResponse
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/sessions [get]
+func (app *Application) getSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	current := getSessionFromContext(r)
+
+	sessions, err := app.Service.Auth.GetSessions(r.Context(), user.ID)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	response := responses.GetSessionsResponse{
+		Sessions: make([]responses.SessionResponse, len(sessions)),
+	}
+	for idx, session := range sessions {
+		response.Sessions[idx] = responses.SessionResponse{
+			ID:         session.ID,
+			IP:         session.IP,
+			UserAgent:  session.UserAgent,
+			Current:    current != nil && session.ID == current.ID,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+		}
+	}
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// RevokeSessionHandler godoc
+//
+//	@Summary		Revokes a session
+//	@Description	Signs the user out of one of their devices
+//	@Tags			auth
+//	@Produce		json
+//	@Param			sessionID	path	string	true	"Session ID"
+//	@Success		204			"session revoked"
+//	@Failure		401			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/sessions/{sessionID} [delete]
+func (app *Application) revokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	current := getSessionFromContext(r)
+	sessionID := chi.URLParam(r, "sessionID")
+
+	if err := app.Service.Auth.RevokeSession(r.Context(), user.ID, sessionID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if current != nil && current.ID == sessionID {
+		app.deleteUserSessionCookie(w)
+	}
+	httpio.NoContentResponse(w)
+}
+
+// RevokeOtherSessionsHandler godoc
+//
+//	@Summary		Signs out everywhere else
+//	@Description	Revokes every session of the user except the one making the request
+//	@Tags			auth
+//	@Produce		json
+//	@Success		204	"other sessions revoked"
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/sessions [delete]
+func (app *Application) revokeOtherSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	current := getSessionFromContext(r)
+	if current == nil {
+		app.InternalServerErrorResponse(w, r, ErrSessionContextNotFound)
+		return
+	}
+
+	if err := app.Service.Auth.RevokeOtherSessions(r.Context(), user.ID, current.ID); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
 // AuthStatusHandler godoc
 //
 //	@Summary		Check the auth status of a user
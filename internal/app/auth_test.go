@@ -0,0 +1,513 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordResetHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	authService := app.Service.Auth.(*mocks.MockAuthService)
+	authService.On(
+		"ForgotPassword",
+		mock.Anything,
+		&payloads.ForgotPasswordPayload{Email: "hutao@sapphire.com"},
+	).Return(nil)
+	authService.On(
+		"ResetPassword",
+		mock.Anything,
+		&payloads.ResetPasswordPayload{Token: "valid", Password: "new-password"},
+	).Return(nil)
+	authService.On(
+		"ResetPassword",
+		mock.Anything,
+		&payloads.ResetPasswordPayload{Token: "expired", Password: "new-password"},
+	).Return(store.ErrNotFound)
+
+	t.Run("returns status 204 when asking for a reset", func(t *testing.T) {
+		body := strings.NewReader(`{"email": "hutao@sapphire.com"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/forgot-password", body)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("returns status 204 after resetting the password", func(t *testing.T) {
+		body := strings.NewReader(`{"token": "valid", "password": "new-password"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/reset-password", body)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("returns status 404 for an expired token", func(t *testing.T) {
+		body := strings.NewReader(`{"token": "expired", "password": "new-password"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/reset-password", body)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestEmailChangeHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	authService := app.Service.Auth.(*mocks.MockAuthService)
+	authService.On("ConfirmEmailChange", mock.Anything, "valid").Return(nil)
+	authService.On("ConfirmEmailChange", mock.Anything, "expired").Return(store.ErrNotFound)
+	authService.On("ConfirmEmailChange", mock.Anything, "taken").Return(store.ErrDuplicateEmail)
+
+	t.Run("returns status 401 without a session", func(t *testing.T) {
+		body := strings.NewReader(`{"email": "hutao@sapphire.com"}`)
+		req, err := http.NewRequest(http.MethodPut, "/v1/user/email", body)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("confirms the new e-mail", func(t *testing.T) {
+		tests := []struct {
+			token string
+			code  int
+		}{
+			{"valid", http.StatusNoContent},
+			{"expired", http.StatusNotFound},
+			{"taken", http.StatusConflict},
+		}
+		for _, test := range tests {
+			req, err := http.NewRequest(http.MethodPut, "/v1/verify-email/change/"+test.token, nil)
+			require.NoError(t, err)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, test.code, rr.Code, test.token)
+		}
+	})
+}
+
+func TestResendActivationHandler(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	app.Config.Mail.ResendInterval = 2 * time.Minute
+	mux := app.Mount()
+
+	authService := app.Service.Auth.(*mocks.MockAuthService)
+	authService.On(
+		"ResendActivation",
+		mock.Anything,
+		&payloads.ResendActivationPayload{Email: "hutao@sapphire.com"},
+	).Return(nil)
+	authService.On(
+		"ResendActivation",
+		mock.Anything,
+		&payloads.ResendActivationPayload{Email: "chaee@sapphire.com"},
+	).Return(service.ErrResendTooSoon)
+
+	t.Run("returns status 204 when the e-mail is sent", func(t *testing.T) {
+		body := strings.NewReader(`{"email": "hutao@sapphire.com"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/resend-activation", body)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("returns status 429 when asked again too soon", func(t *testing.T) {
+		body := strings.NewReader(`{"email": "chaee@sapphire.com"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/resend-activation", body)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "2m0s", rr.Header().Get("Retry-After"))
+	})
+}
+
+func TestSessionHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	user := &models.User{ID: 1, Username: "hutao"}
+	current := &models.Session{ID: "current", UserID: user.ID}
+	cookie := withTestSession(t, app, user, current)
+
+	authService := app.Service.Auth.(*mocks.MockAuthService)
+	authService.On("GetSessions", mock.Anything, user.ID).Return([]*models.Session{
+		current,
+		{ID: "phone", UserID: user.ID, IP: "10.0.0.2", UserAgent: "phone"},
+	}, nil)
+	authService.On("RevokeSession", mock.Anything, user.ID, "phone").Return(nil)
+	authService.On("RevokeSession", mock.Anything, user.ID, "unknown").Return(store.ErrNotFound)
+	authService.On("RevokeOtherSessions", mock.Anything, user.ID, "current").Return(nil)
+
+	t.Run("returns status 401 without a session", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/auth/sessions", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("lists the sessions marking the current one", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/auth/sessions", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetSessionsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Data.Sessions, 2)
+		assert.True(t, response.Data.Sessions[0].Current)
+		assert.False(t, response.Data.Sessions[1].Current)
+		assert.Equal(t, "10.0.0.2", response.Data.Sessions[1].IP)
+	})
+
+	t.Run("revokes sessions", func(t *testing.T) {
+		tests := []struct {
+			path string
+			code int
+		}{
+			{"/v1/auth/sessions/phone", http.StatusNoContent},
+			{"/v1/auth/sessions/unknown", http.StatusNotFound},
+			{"/v1/auth/sessions", http.StatusNoContent},
+		}
+		for _, test := range tests {
+			req, err := http.NewRequest(http.MethodDelete, test.path, nil)
+			require.NoError(t, err)
+			req.AddCookie(cookie)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, test.code, rr.Code, test.path)
+		}
+		authService.AssertCalled(t, "RevokeOtherSessions", mock.Anything, user.ID, "current")
+	})
+}
+
+func TestTwoFactorHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	hutao := &models.User{ID: 1, Username: "hutao"}
+	chaee := &models.User{ID: 2, Username: "chaee"}
+	pendingLogin := &models.PendingLogin{Token: "pending", User: chaee, ExpiresAt: time.Now().Add(5 * time.Minute)}
+	cookie := &http.Cookie{Name: service.AuthTokenKey, Value: "new-session"}
+
+	authService := app.Service.Auth.(*mocks.MockAuthService)
+	authService.On(
+		"Authenticate",
+		mock.Anything,
+		&payloads.SigninPayload{Email: "hutao@sapphire.com", Password: "password"},
+		mock.Anything,
+	).Return(hutao, nil)
+	authService.On(
+		"Authenticate",
+		mock.Anything,
+		&payloads.SigninPayload{Email: "chaee@sapphire.com", Password: "password"},
+		mock.Anything,
+	).Return(chaee, nil)
+	authService.On("GetCookieSession", mock.Anything, mock.Anything).Return(cookie, nil)
+
+	twoFactorService := app.Service.TwoFactor.(*mocks.MockTwoFactorService)
+	twoFactorService.On("BeginLogin", mock.Anything, hutao).Return(nil, nil)
+	twoFactorService.On("BeginLogin", mock.Anything, chaee).Return(pendingLogin, nil)
+	twoFactorService.On(
+		"CompleteLogin",
+		mock.Anything,
+		&payloads.VerifyTwoFactorPayload{Token: "pending", Code: "123456"},
+		mock.Anything,
+	).Return(chaee, nil)
+	twoFactorService.On(
+		"CompleteLogin",
+		mock.Anything,
+		&payloads.VerifyTwoFactorPayload{Token: "pending", Code: "654321"},
+		mock.Anything,
+	).Return(nil, service.ErrInvalidTwoFactorCode)
+	twoFactorService.On(
+		"CompleteLogin",
+		mock.Anything,
+		&payloads.VerifyTwoFactorPayload{Token: "pending", Code: "000000"},
+		mock.Anything,
+	).Return(nil, &service.LoginThrottledError{RetryAfter: 90 * time.Second})
+	twoFactorService.On("Setup", mock.Anything, hutao).Return(nil, service.ErrTwoFactorUnavailable)
+
+	t.Run("signs in without two-factor", func(t *testing.T) {
+		body := strings.NewReader(`{"email": "hutao@sapphire.com", "password": "password"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/signin", body)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.NotEmpty(t, rr.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("asks for a code when two-factor is on", func(t *testing.T) {
+		body := strings.NewReader(`{"email": "chaee@sapphire.com", "password": "password"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/signin", body)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusAccepted, rr.Code)
+		assert.Empty(t, rr.Header().Get("Set-Cookie"))
+
+		var response struct {
+			Data responses.SigninChallengeResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Data.TwoFactorRequired)
+		assert.Equal(t, "pending", response.Data.Token)
+	})
+
+	t.Run("verifies the code", func(t *testing.T) {
+		tests := []struct {
+			code   string
+			status int
+		}{
+			{"123456", http.StatusNoContent},
+			{"654321", http.StatusUnauthorized},
+			{"000000", http.StatusTooManyRequests},
+		}
+		for _, test := range tests {
+			body := strings.NewReader(`{"token": "pending", "code": "` + test.code + `"}`)
+			req, err := http.NewRequest(http.MethodPost, "/v1/auth/2fa/verify", body)
+			require.NoError(t, err)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, test.status, rr.Code, test.code)
+		}
+	})
+
+	t.Run("returns status 401 on setup without a session", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/2fa/setup", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("returns status 503 on setup without an encryption key", func(t *testing.T) {
+		sessionCookie := withTestSession(t, app, hutao, &models.Session{ID: "hutao", UserID: hutao.ID})
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/2fa/setup", nil)
+		require.NoError(t, err)
+		req.AddCookie(sessionCookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+}
+
+func TestAccessTokenAuthentication(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "prod"
+	app.Config.FrontedURL = "https://sapphire.com"
+	mux := app.Mount()
+
+	user := &models.User{ID: 1, Username: "hutao", Email: "hutao@sapphire.com"}
+	withTestSession(t, app, user, &models.Session{ID: "current", UserID: user.ID})
+
+	accessTokenService := app.Service.AccessToken.(*mocks.MockAccessTokenService)
+	accessTokenService.On("Validate", mock.Anything, "sph_write").Return(&models.AccessToken{
+		ID:     1,
+		UserID: user.ID,
+		Scopes: []string{models.ScopeRead, models.ScopeWrite},
+	}, nil)
+	accessTokenService.On("Validate", mock.Anything, "sph_read").Return(&models.AccessToken{
+		ID:     2,
+		UserID: user.ID,
+		Scopes: []string{models.ScopeRead},
+	}, nil)
+	accessTokenService.On("Validate", mock.Anything, "sph_revoked").Return(nil, service.ErrInvalidAccessToken)
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		code          int
+	}{
+		{"writes with the write scope", "/v1/auth/me", "Bearer sph_write", http.StatusOK},
+		{"does not write with the read scope", "/v1/auth/me", "Bearer sph_read", http.StatusForbidden},
+		{"refuses a revoked token", "/v1/auth/me", "Bearer sph_revoked", http.StatusUnauthorized},
+		{"refuses an empty token", "/v1/auth/me", "Bearer ", http.StatusUnauthorized},
+		{"keeps credentials for sessions", "/v1/auth/tokens", "Bearer sph_write", http.StatusForbidden},
+		{"keeps csrf for cookies", "/v1/auth/me", "", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, test.path, nil)
+			require.NoError(t, err)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			req.AddCookie(&http.Cookie{Name: service.AuthTokenKey, Value: "token-current"})
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, test.code, rr.Code)
+		})
+	}
+}
+
+func TestAccessTokenHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	user := &models.User{ID: 1, Username: "hutao"}
+	cookie := withTestSession(t, app, user, &models.Session{ID: "current", UserID: user.ID})
+
+	days := 30
+	accessTokenService := app.Service.AccessToken.(*mocks.MockAccessTokenService)
+	accessTokenService.On("Create", mock.Anything, user, &payloads.CreateAccessTokenPayload{
+		Name:          "bot",
+		Scopes:        []string{"read"},
+		ExpiresInDays: &days,
+	}).Return(&models.AccessToken{ID: 3, Name: "bot", Token: "sph_secret", Scopes: []string{"read"}}, nil)
+	accessTokenService.On("GetFromUser", mock.Anything, user.ID).Return([]*models.AccessToken{
+		{ID: 3, Name: "bot", Scopes: []string{"read"}},
+	}, nil)
+	accessTokenService.On("Revoke", mock.Anything, user.ID, int64(3)).Return(nil)
+
+	t.Run("shows the token only when it is created", func(t *testing.T) {
+		body := strings.NewReader(`{"name": "bot", "scopes": ["read"], "expires_in_days": 30}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/tokens", body)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"token":"sph_secret"`)
+
+		req, err = http.NewRequest(http.MethodGet, "/v1/auth/tokens", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr = testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), `"token"`)
+	})
+
+	t.Run("revokes a token", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodDelete, "/v1/auth/tokens/3", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+}
+
+func TestLoginLockout(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	admin := &models.User{ID: 1, Username: "hutao", Role: models.Role{Level: config.Roles["admin"].Level}}
+	user := &models.User{ID: 2, Username: "chaee", Role: models.Role{Level: config.Roles["user"].Level}}
+	adminCookie := withTestSession(t, app, admin, &models.Session{ID: "admin", UserID: admin.ID})
+	userCookie := withTestSession(t, app, user, &models.Session{ID: "user", UserID: user.ID})
+
+	authService := app.Service.Auth.(*mocks.MockAuthService)
+	authService.On(
+		"Authenticate",
+		mock.Anything,
+		&payloads.SigninPayload{Email: "chaee@sapphire.com", Password: "password"},
+		"192.0.2.1",
+	).Return(nil, &service.LoginThrottledError{RetryAfter: 1500 * time.Millisecond})
+	authService.On("Unlock", mock.Anything, admin, user.ID).Return(nil)
+	authService.On("Unlock", mock.Anything, admin, int64(3)).Return(store.ErrNotFound)
+
+	t.Run("returns status 429 while the sign in is throttled", func(t *testing.T) {
+		body := strings.NewReader(`{"email": "chaee@sapphire.com", "password": "password"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/signin", body)
+		require.NoError(t, err)
+		req.RemoteAddr = "192.0.2.1:4000"
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+		assert.Empty(t, rr.Header().Get("Set-Cookie"))
+	})
+
+	t.Run("ignores forwarded addresses without a proxy", func(t *testing.T) {
+		body := strings.NewReader(`{"email": "chaee@sapphire.com", "password": "password"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/signin", body)
+		require.NoError(t, err)
+		req.RemoteAddr = "192.0.2.1:4000"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		req.Header.Set("X-Real-Ip", "203.0.113.7")
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	})
+
+	t.Run("reads the address from the proxy header", func(t *testing.T) {
+		app.Config.ProxyIPHeader = "X-Real-Ip"
+		defer func() { app.Config.ProxyIPHeader = "" }()
+		proxied := app.Mount()
+
+		body := strings.NewReader(`{"email": "chaee@sapphire.com", "password": "password"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/signin", body)
+		require.NoError(t, err)
+		req.RemoteAddr = "10.0.0.2:4000"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		req.Header.Set("X-Real-Ip", "192.0.2.1")
+
+		rr := testutils.ExecuteRequest(req, proxied)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	})
+
+	t.Run("lets admins unlock an account", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodDelete, "/v1/admin/users/2/lockout", nil)
+		require.NoError(t, err)
+		req.AddCookie(adminCookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		authService.AssertCalled(t, "Unlock", mock.Anything, admin, user.ID)
+	})
+
+	t.Run("returns status 404 when unlocking an unknown account", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodDelete, "/v1/admin/users/3/lockout", nil)
+		require.NoError(t, err)
+		req.AddCookie(adminCookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("returns status 403 when a user tries to unlock", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodDelete, "/v1/admin/users/2/lockout", nil)
+		require.NoError(t, err)
+		req.AddCookie(userCookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
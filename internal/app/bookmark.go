@@ -0,0 +1,127 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+// BookmarkPost godoc
+//
+//	@Summary		Bookmarks a post
+//	@Description	Saves a post in the user's private bookmarks, bookmarking it again does nothing
+//	@Tags			post
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path	int	true	"Post ID"
+//	@Success		204		"Post bookmarked"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/post/{postID}/bookmark [put]
+func (app *Application) bookmarkPostHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	post := getPostFromCtx(r)
+	if _, err := app.Service.Bookmark.Create(r.Context(), user, post); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound), errors.Is(err, store.ErrForeignKeyViolation):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
+// RemoveBookmark godoc
+//
+//	@Summary		Removes a bookmark
+//	@Description	Removes a post from the user's bookmarks
+//	@Tags			post
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path	int	true	"Post ID"
+//	@Success		204		"Bookmark removed"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/post/{postID}/bookmark [delete]
+func (app *Application) removeBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	post := getPostFromCtx(r)
+	if err := app.Service.Bookmark.Delete(r.Context(), user, post); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
+// GetUserBookmarks godoc
+//
+//	@Summary		Gets the user bookmarks
+//	@Description	Gets the posts the authenticated user bookmarked, the most recently saved first
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		string	false	"Limit"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Success		200		{object}	responses.GetBookmarksResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/user/bookmarks [get]
+func (app *Application) getUserBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	userBookmarks := pagination.UserBookmarks{UserID: user.ID}
+	query := r.URL.Query()
+	if err := userBookmarks.Parse(query.Get("limit"), query.Get("cursor")); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	bookmarks, err := app.Service.Bookmark.GetFromUser(r.Context(), &userBookmarks)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	var response responses.GetBookmarksResponse
+	response.Bookmarks = make([]responses.BookmarkResponse, len(bookmarks))
+	response.NextCursor = userBookmarks.NextCursor
+	for idx, bookmark := range bookmarks {
+		post := bookmark.Post
+		response.Bookmarks[idx] = responses.BookmarkResponse{
+			Post: responses.PostResponse{
+				ID:        post.ID,
+				Tittle:    post.Tittle,
+				Content:   post.Content,
+				Tags:      post.Tags,
+				MediaURL:  post.Media.String,
+				CreatedAt: post.CreatedAt,
+				UpdatedAt: post.UpdatedAt,
+				User: &responses.UserResponse{
+					ID:        post.User.ID,
+					Username:  post.User.Username,
+					FirstName: post.User.FirstName,
+					LastName:  post.User.LastName,
+				},
+			},
+			CreatedAt: bookmark.CreatedAt,
+		}
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
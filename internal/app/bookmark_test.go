@@ -0,0 +1,104 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUserBookmarksHandler(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.Mount()
+
+	t.Run("returns status 401 without a session", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/user/bookmarks", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestBookmarkHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	user := &models.User{ID: 1, Username: "hutao"}
+	author := &models.User{ID: 2, Username: "chaee"}
+	cookie := withTestSession(t, app, user, &models.Session{ID: "hutao", UserID: user.ID})
+	post := &models.Post{ID: 101, Content: "wangsheng", User: author}
+	unsaved := &models.Post{ID: 102, Content: "liyue", User: author}
+	savedAt := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+
+	postService := app.Service.Post.(*mocks.MockPostService)
+	postService.On("GetWithUser", mock.Anything, post.ID).Return(post, nil)
+	postService.On("GetWithUser", mock.Anything, unsaved.ID).Return(unsaved, nil)
+	bookmarkService := app.Service.Bookmark.(*mocks.MockBookmarkService)
+	bookmarkService.On("Create", mock.Anything, user, post).Return(
+		&models.Bookmark{UserID: user.ID, Post: post, CreatedAt: savedAt}, nil,
+	)
+	bookmarkService.On("Delete", mock.Anything, user, post).Return(nil)
+	bookmarkService.On("Delete", mock.Anything, user, unsaved).Return(store.ErrNotFound)
+	bookmarkService.On("GetFromUser", mock.Anything, mock.MatchedBy(func(q *pagination.UserBookmarks) bool {
+		return q.UserID == user.ID
+	})).Return([]*models.Bookmark{{UserID: user.ID, Post: post, CreatedAt: savedAt}}, nil)
+
+	t.Run("bookmarks a post more than once", func(t *testing.T) {
+		for range 2 {
+			req, err := http.NewRequest(http.MethodPut, "/v1/post/101/bookmark", nil)
+			require.NoError(t, err)
+			req.AddCookie(cookie)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+		}
+	})
+
+	t.Run("lists the bookmarked posts", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/user/bookmarks", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetBookmarksResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data.Bookmarks, 1)
+		assert.Equal(t, post.ID, response.Data.Bookmarks[0].Post.ID)
+		assert.Equal(t, "chaee", response.Data.Bookmarks[0].Post.User.Username)
+		assert.True(t, savedAt.Equal(response.Data.Bookmarks[0].CreatedAt))
+	})
+
+	t.Run("removes a bookmark", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodDelete, "/v1/post/101/bookmark", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("returns status 404 when removing a post that is not bookmarked", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodDelete, "/v1/post/102/bookmark", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
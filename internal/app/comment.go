@@ -0,0 +1,224 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+func getCommentFromCtx(r *http.Request) *models.Comment {
+	comment, _ := r.Context().Value(commentCtx).(*models.Comment)
+	return comment
+}
+
+func newCommentResponse(comment *models.Comment) responses.CommentResponse {
+	response := responses.CommentResponse{
+		ID:         comment.ID,
+		PostID:     comment.PostId,
+		Content:    comment.Content,
+		ReplyCount: comment.ReplyCount,
+		CreatedAt:  comment.CreatedAt,
+		UpdatedAt:  comment.UpdatedAt,
+		User: &responses.UserResponse{
+			ID:        comment.UserId,
+			Username:  comment.User.Username,
+			FirstName: comment.User.FirstName,
+			LastName:  comment.User.LastName,
+		},
+		Mentions: newMentionsResponse(comment.Mentions),
+	}
+	if comment.ParentID.Valid {
+		response.ParentID = &comment.ParentID.Int64
+	}
+	return response
+}
+
+// CreateComment godoc
+//
+//	@Summary		Comments on a post
+//	@Description	A authenticated user can comment on a post or reply to another comment
+//	@Tags			comment
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int								true	"Post ID"
+//	@Param			payload	body		payloads.CreateCommentPayload	true	"Comment payload"
+//	@Success		201		{object}	responses.CommentResponse
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/post/{postID}/comments [post]
+func (app *Application) createCommentHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.CreateCommentPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	post := getPostFromCtx(r)
+	comment, err := app.Service.Comment.Create(r.Context(), user, post, &payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPayload):
+			app.BadRequestResponse(w, r, err)
+		case errors.Is(err, store.ErrNotFound), errors.Is(err, store.ErrForeignKeyViolation):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusCreated, newCommentResponse(comment)); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetPostComments godoc
+//
+//	@Summary		Gets the comments of a post
+//	@Description	Gets the top-level comments of a post, each one with its number of replies
+//	@Tags			comment
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int		true	"Post ID"
+//	@Param			limit	query		string	false	"Limit"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Success		200		{object}	responses.GetCommentsResponse
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/post/{postID}/comments [get]
+func (app *Application) getPostCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	post := getPostFromCtx(r)
+	postComments := pagination.PostComments{PostID: post.ID}
+	app.writeCommentThread(w, r, &postComments)
+}
+
+// GetCommentReplies godoc
+//
+//	@Summary		Gets the replies of a comment
+//	@Description	Gets the direct replies of a comment, each one with its number of replies
+//	@Tags			comment
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID		path		int		true	"Post ID"
+//	@Param			commentID	path		int		true	"Comment ID"
+//	@Param			limit		query		string	false	"Limit"
+//	@Param			cursor		query		string	false	"Cursor"
+//	@Success		200			{object}	responses.GetCommentsResponse
+//	@Failure		400			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Router			/post/{postID}/comments/{commentID}/replies [get]
+func (app *Application) getCommentRepliesHandler(w http.ResponseWriter, r *http.Request) {
+	comment := getCommentFromCtx(r)
+	postComments := pagination.PostComments{PostID: comment.PostId}
+	postComments.ParentID.Int64 = comment.ID
+	postComments.ParentID.Valid = true
+	app.writeCommentThread(w, r, &postComments)
+}
+
+func (app *Application) writeCommentThread(w http.ResponseWriter, r *http.Request, postComments *pagination.PostComments) {
+	query := r.URL.Query()
+	if err := postComments.Parse(query.Get("limit"), query.Get("cursor")); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	comments, err := app.Service.Comment.GetThread(r.Context(), postComments)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	var response responses.GetCommentsResponse
+	response.Comments = make([]responses.CommentResponse, len(comments))
+	response.NextCursor = postComments.NextCursor
+	for idx, comment := range comments {
+		response.Comments[idx] = newCommentResponse(comment)
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// UpdateComment godoc
+//
+//	@Summary		Updates a comment
+//	@Description	Allows a user to update their own comment
+//	@Tags			comment
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID		path		int								true	"Post ID"
+//	@Param			commentID	path		int								true	"Comment ID"
+//	@Param			payload		body		payloads.UpdateCommentPayload	true	"Update comment payload"
+//	@Success		200			{object}	responses.CommentResponse
+//	@Failure		400			{object}	error
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/post/{postID}/comments/{commentID} [patch]
+func (app *Application) updateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment := getCommentFromCtx(r)
+	var payload payloads.UpdateCommentPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.Service.Comment.Update(r.Context(), getUserFromContext(r), comment, &payload); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPayload):
+			app.BadRequestResponse(w, r, err)
+		case errors.Is(err, store.ErrNotFound):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, newCommentResponse(comment)); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// DeleteComment godoc
+//
+//	@Summary		Deletes a comment
+//	@Description	Deletes a comment and all of its replies
+//	@Tags			comment
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID		path	int	true	"Post ID"
+//	@Param			commentID	path	int	true	"Comment ID"
+//	@Success		204			"Comment deleted"
+//	@Failure		403			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/post/{postID}/comments/{commentID} [delete]
+func (app *Application) deleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	comment := getCommentFromCtx(r)
+	if err := app.Service.Comment.Delete(r.Context(), getUserFromContext(r), comment); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
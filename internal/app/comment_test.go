@@ -0,0 +1,320 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPostCommentsHandler(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.Mount()
+
+	post := &models.Post{
+		ID:   101,
+		User: &models.User{ID: 1, Username: "testuser"},
+	}
+	createdAt := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	comments := []*models.Comment{
+		{
+			ID:         7,
+			PostId:     post.ID,
+			UserId:     2,
+			Content:    "first!",
+			ReplyCount: 2,
+			CreatedAt:  createdAt,
+			User:       models.UserComment{Username: "chaee"},
+		},
+	}
+	replies := []*models.Comment{
+		{
+			ID:        8,
+			PostId:    post.ID,
+			UserId:    1,
+			ParentID:  sql.NullInt64{Int64: 7, Valid: true},
+			Content:   "welcome",
+			CreatedAt: createdAt.Add(time.Minute),
+			User:      models.UserComment{Username: "testuser"},
+		},
+	}
+
+	postService := app.Service.Post.(*mocks.MockPostService)
+	postService.On("GetWithUser", mock.Anything, int64(101)).Return(post, nil)
+	postService.On("GetWithUser", mock.Anything, int64(1)).Return(nil, store.ErrNotFound)
+
+	commentService := app.Service.Comment.(*mocks.MockCommentService)
+	commentService.On("GetByID", mock.Anything, int64(7)).Return(comments[0], nil)
+	commentService.On("GetByID", mock.Anything, int64(99)).Return(
+		&models.Comment{ID: 99, PostId: 55}, nil,
+	)
+	commentService.On("GetThread", mock.Anything, mock.MatchedBy(func(q *pagination.PostComments) bool {
+		return q.PostID == post.ID && !q.ParentID.Valid
+	})).Return(comments, nil)
+	commentService.On("GetThread", mock.Anything, mock.MatchedBy(func(q *pagination.PostComments) bool {
+		return q.PostID == post.ID && q.ParentID.Valid && q.ParentID.Int64 == 7
+	})).Return(replies, nil)
+
+	t.Run("returns the top-level comments of a post", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/post/101/comments", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetCommentsResponse `json:"data"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&response)
+		require.NoError(t, err, "failed to decode response: %v", err)
+
+		require.Len(t, response.Data.Comments, 1)
+		assert.Equal(t, "first!", response.Data.Comments[0].Content)
+		assert.Equal(t, 2, response.Data.Comments[0].ReplyCount)
+		assert.Nil(t, response.Data.Comments[0].ParentID)
+		assert.Equal(t, "chaee", response.Data.Comments[0].User.Username)
+	})
+
+	t.Run("returns the replies of a comment", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/post/101/comments/7/replies", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetCommentsResponse `json:"data"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&response)
+		require.NoError(t, err, "failed to decode response: %v", err)
+
+		require.Len(t, response.Data.Comments, 1)
+		require.NotNil(t, response.Data.Comments[0].ParentID)
+		assert.Equal(t, int64(7), *response.Data.Comments[0].ParentID)
+	})
+
+	t.Run("returns status 404 for a comment of another post", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/post/101/comments/99/replies", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("returns status 404 for a non-existent post", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/post/1/comments", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("returns status 400 for an invalid cursor", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/post/101/comments?cursor=yesterday", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestCreateCommentHandler(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	user := &models.User{ID: 2, Username: "chaee"}
+	cookie := withTestSession(t, app, user, &models.Session{ID: "chaee", UserID: user.ID})
+	post := &models.Post{ID: 101, User: &models.User{ID: 1, Username: "testuser"}}
+
+	postService := app.Service.Post.(*mocks.MockPostService)
+	postService.On("GetWithUser", mock.Anything, post.ID).Return(post, nil)
+
+	commentService := app.Service.Comment.(*mocks.MockCommentService)
+	withContent := func(content string) any {
+		return mock.MatchedBy(func(payload *payloads.CreateCommentPayload) bool {
+			return payload.Content == content
+		})
+	}
+	commentService.On("Create", mock.Anything, user, post, withContent("first!")).Return(&models.Comment{
+		ID:      7,
+		PostId:  post.ID,
+		UserId:  user.ID,
+		Content: "first!",
+		User:    models.UserComment{Username: user.Username},
+	}, nil)
+	commentService.On("Create", mock.Anything, user, post, withContent("")).Return(nil, service.ErrInvalidPayload)
+	commentService.On("Create", mock.Anything, user, post, withContent("late reply")).Return(nil, store.ErrForeignKeyViolation)
+
+	tests := []struct {
+		name         string
+		body         string
+		withSession  bool
+		expectedCode int
+	}{
+		{"creates the comment", `{"content":"first!"}`, true, http.StatusCreated},
+		{"invalid payload", `{"content":""}`, true, http.StatusBadRequest},
+		{"malformed body", `{"content":`, true, http.StatusBadRequest},
+		{"post removed meanwhile", `{"content":"late reply"}`, true, http.StatusNotFound},
+		{"without session", `{"content":"first!"}`, false, http.StatusUnauthorized},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "/v1/post/101/comments", strings.NewReader(test.body))
+			require.NoError(t, err)
+			if test.withSession {
+				req.AddCookie(cookie)
+			}
+
+			rr := testutils.ExecuteRequest(req, mux)
+			require.Equal(t, test.expectedCode, rr.Code)
+			if rr.Code != http.StatusCreated {
+				return
+			}
+
+			var response struct {
+				Data responses.CommentResponse `json:"data"`
+			}
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			assert.Equal(t, int64(7), response.Data.ID)
+			assert.Equal(t, "first!", response.Data.Content)
+			assert.Equal(t, "chaee", response.Data.User.Username)
+		})
+	}
+}
+
+// newCommentOwnershipTest mounts the application with a post holding the
+// comment 7 of chaee, and returns the cookies of the author, of another user,
+// of a moderator and of an admin
+func newCommentOwnershipTest(t *testing.T) (*Application, http.Handler, map[string]*http.Cookie) {
+	t.Helper()
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	users := map[string]*models.User{
+		"author":    {ID: 2, Username: "chaee", Role: models.Role{Level: config.Roles["user"].Level}},
+		"other":     {ID: 3, Username: "yelan", Role: models.Role{Level: config.Roles["user"].Level}},
+		"moderator": {ID: 4, Username: "xiangling", Role: models.Role{Level: config.Roles["moderator"].Level}},
+		"admin":     {ID: 5, Username: "hutao", Role: models.Role{Level: config.Roles["admin"].Level}},
+	}
+	cookies := make(map[string]*http.Cookie, len(users))
+	for name, user := range users {
+		cookies[name] = withTestSession(t, app, user, &models.Session{ID: name, UserID: user.ID})
+	}
+
+	post := &models.Post{ID: 101, User: &models.User{ID: 1, Username: "testuser"}}
+	app.Service.Post.(*mocks.MockPostService).On("GetWithUser", mock.Anything, post.ID).Return(post, nil)
+	commentService := app.Service.Comment.(*mocks.MockCommentService)
+	commentService.On("GetByID", mock.Anything, int64(7)).Return(&models.Comment{
+		ID:      7,
+		PostId:  post.ID,
+		UserId:  users["author"].ID,
+		Content: "first!",
+		User:    models.UserComment{Username: users["author"].Username},
+	}, nil)
+	commentService.On("GetByID", mock.Anything, int64(99)).Return(nil, store.ErrNotFound)
+
+	return app, mux, cookies
+}
+
+func TestUpdateCommentHandler(t *testing.T) {
+	app, mux, cookies := newCommentOwnershipTest(t)
+
+	commentService := app.Service.Comment.(*mocks.MockCommentService)
+	withContent := func(content string) any {
+		return mock.MatchedBy(func(payload *payloads.UpdateCommentPayload) bool {
+			return payload.Content == content
+		})
+	}
+	commentService.On("Update", mock.Anything, mock.Anything, mock.Anything, withContent("edited")).Run(func(args mock.Arguments) {
+		args.Get(2).(*models.Comment).Content = args.Get(3).(*payloads.UpdateCommentPayload).Content
+	}).Return(nil)
+	commentService.On("Update", mock.Anything, mock.Anything, mock.Anything, withContent("")).Return(service.ErrInvalidPayload)
+	commentService.On("Update", mock.Anything, mock.Anything, mock.Anything, withContent("too late")).Return(store.ErrNotFound)
+
+	tests := []struct {
+		name         string
+		path         string
+		user         string
+		body         string
+		expectedCode int
+	}{
+		{"the author edits", "/v1/post/101/comments/7", "author", `{"content":"edited"}`, http.StatusOK},
+		{"a moderator edits", "/v1/post/101/comments/7", "moderator", `{"content":"edited"}`, http.StatusOK},
+		{"another user cannot edit", "/v1/post/101/comments/7", "other", `{"content":"edited"}`, http.StatusForbidden},
+		{"invalid payload", "/v1/post/101/comments/7", "author", `{"content":""}`, http.StatusBadRequest},
+		{"malformed body", "/v1/post/101/comments/7", "author", `{"content":`, http.StatusBadRequest},
+		{"comment removed meanwhile", "/v1/post/101/comments/7", "author", `{"content":"too late"}`, http.StatusNotFound},
+		{"non-existent comment", "/v1/post/101/comments/99", "author", `{"content":"edited"}`, http.StatusNotFound},
+		{"invalid comment id", "/v1/post/101/comments/seven", "author", `{"content":"edited"}`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPatch, test.path, strings.NewReader(test.body))
+			require.NoError(t, err)
+			req.AddCookie(cookies[test.user])
+
+			rr := testutils.ExecuteRequest(req, mux)
+			require.Equal(t, test.expectedCode, rr.Code)
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Data responses.CommentResponse `json:"data"`
+			}
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			assert.Equal(t, "edited", response.Data.Content)
+		})
+	}
+}
+
+func TestDeleteCommentHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		user         string
+		err          error
+		expectedCode int
+	}{
+		{"the author deletes", "/v1/post/101/comments/7", "author", nil, http.StatusNoContent},
+		{"an admin deletes", "/v1/post/101/comments/7", "admin", nil, http.StatusNoContent},
+		{"a moderator cannot delete", "/v1/post/101/comments/7", "moderator", nil, http.StatusForbidden},
+		{"another user cannot delete", "/v1/post/101/comments/7", "other", nil, http.StatusForbidden},
+		{"comment removed meanwhile", "/v1/post/101/comments/7", "author", store.ErrNotFound, http.StatusNotFound},
+		{"non-existent comment", "/v1/post/101/comments/99", "author", nil, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			app, mux, cookies := newCommentOwnershipTest(t)
+			commentService := app.Service.Comment.(*mocks.MockCommentService)
+			commentService.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(test.err)
+
+			req, err := http.NewRequest(http.MethodDelete, test.path, nil)
+			require.NoError(t, err)
+			req.AddCookie(cookies[test.user])
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, test.expectedCode, rr.Code)
+			if test.expectedCode == http.StatusForbidden {
+				commentService.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -14,4 +14,11 @@ var (
 	ErrSessionContextNotFound  = errors.New("session was not found on context")
 	ErrUserContextNotFound     = errors.New("user was not found on context")
 	ErrPostContextNotFound     = errors.New("post was not found on context")
+
+	ErrInsufficientScope = errors.New("access token scopes do not allow this request")
+	ErrSessionRequired   = errors.New("this request needs a signed in session, not an access token")
+
+	ErrUnknownOAuthProvider = errors.New("oauth provider is not enabled")
+	ErrInsufficientRole     = errors.New("role does not allow this request")
+	ErrMagicLinkDisabled    = errors.New("magic link sign in is not enabled")
 )
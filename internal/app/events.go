@@ -0,0 +1,95 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/events"
+)
+
+// eventStreamKeepAlive is how often a comment is sent on an idle stream, so
+// proxies do not close it
+const eventStreamKeepAlive = 25 * time.Second
+
+// EventStream godoc
+//
+//	@Summary		Streams real-time events
+//	@Description	Pushes new feed posts, notifications, follows and private messages to the authenticated user as Server-Sent Events
+//	@Tags			events
+//	@Produce		text/event-stream
+//	@Success		200	"Event stream"
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/events [get]
+func (app *Application) eventStreamHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	controller := http.NewResponseController(w)
+	// the server write timeout is meant for regular requests
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	subscription, err := app.Events.Subscribe(
+		events.FeedTopic(user.ID),
+		events.NotificationsTopic(user.ID),
+		events.MessagesTopic(user.ID),
+	)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", (5 * time.Second).Milliseconds())
+	if err := controller.Flush(); err != nil {
+		app.Logger.Warnw("event stream does not support flushing", "error", err)
+		return
+	}
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				if err := subscription.Err(); errors.Is(err, events.ErrSlowConsumer) {
+					app.Logger.Warnw("event stream dropped", "user", user.ID, "error", err)
+				}
+				return
+			}
+			if err := writeServerSentEvent(w, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeServerSentEvent(w http.ResponseWriter, event *events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Kind, data)
+	return err
+}
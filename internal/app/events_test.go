@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mochaeng/sapphire-backend/internal/events"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventStreamHandler(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.Mount()
+
+	t.Run("returns status 401 without a session", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/events", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestWebSocketHandler(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	user := &models.User{ID: 1, Username: "hutao"}
+	cookie := withTestSession(t, app, user, &models.Session{ID: "hutao", UserID: user.ID})
+	app.Service.Post.(*mocks.MockPostService).On("GetWithUser", mock.Anything, int64(404)).Return(nil, store.ErrNotFound)
+
+	dial := func(t *testing.T) *websocket.Conn {
+		t.Helper()
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/v1/ws"
+		header := http.Header{"Cookie": {cookie.String()}}
+		conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+		require.NoError(t, err, "could not dial the websocket")
+		resp.Body.Close()
+		t.Cleanup(func() { conn.Close() })
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		return conn
+	}
+	readMessage := func(t *testing.T, conn *websocket.Conn) responses.SocketMessageResponse {
+		t.Helper()
+		var message responses.SocketMessageResponse
+		require.NoError(t, conn.ReadJSON(&message))
+		return message
+	}
+
+	t.Run("returns status 401 without a session", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/ws", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("receives the events of the subscribed channels", func(t *testing.T) {
+		conn := dial(t)
+		require.NoError(t, conn.WriteJSON(map[string]string{"action": "subscribe", "channel": "feed"}))
+		reply := readMessage(t, conn)
+		require.Equal(t, "subscribe", reply.Type)
+		assert.Equal(t, "feed", reply.Channel)
+		assert.Empty(t, reply.Error)
+
+		event, err := events.New(events.KindPost, events.PostData{ID: 101, Content: "wangsheng"})
+		require.NoError(t, err)
+		require.NoError(t, app.Events.Publish(context.Background(), []string{events.FeedTopic(user.ID)}, event))
+
+		message := readMessage(t, conn)
+		require.Equal(t, "event", message.Type)
+		require.NotNil(t, message.Event)
+		assert.Equal(t, events.KindPost, message.Event.Kind)
+		var data events.PostData
+		require.NoError(t, json.Unmarshal(message.Event.Data, &data))
+		assert.Equal(t, int64(101), data.ID)
+	})
+
+	t.Run("answers an error for the comments of unknown posts", func(t *testing.T) {
+		conn := dial(t)
+		require.NoError(t, conn.WriteJSON(map[string]any{"action": "subscribe", "channel": "comments", "post_id": 404}))
+		reply := readMessage(t, conn)
+		assert.Equal(t, "subscribe", reply.Type)
+		assert.Equal(t, "post not found", reply.Error)
+	})
+}
@@ -12,7 +12,7 @@ import (
 // GetUserFeed godoc
 //
 //	@Summary		Gets the user feed
-//	@Description	A feed contains the user own's posts and the ones their follow
+//	@Description	A feed contains the user own's posts, the ones their follow and the posts reposted by them
 //	@Tags			user
 //	@Accept			json
 //	@Produce		json
@@ -53,7 +53,7 @@ func (app *Application) GetUserFeedHandler(w http.ResponseWriter, r *http.Reques
 	response.NextCursor = feedQuery.NextCursor
 
 	for idx, post := range posts {
-		response.Posts[idx] = responses.PostResponse{
+		postResponse := responses.PostResponse{
 			ID:        post.ID,
 			Content:   post.Content,
 			MediaURL:  post.Media.String,
@@ -64,7 +64,33 @@ func (app *Application) GetUserFeedHandler(w http.ResponseWriter, r *http.Reques
 				FirstName: post.User.FirstName,
 				LastName:  post.User.LastName,
 			},
+			CommentCount: post.CommentCount,
+			Reactions:    newReactionsResponse(&post.Reactions),
 		}
+		if post.RepostedBy != nil {
+			postResponse.RepostedBy = &responses.UserResponse{
+				ID:        post.RepostedBy.ID,
+				Username:  post.RepostedBy.Username,
+				FirstName: post.RepostedBy.FirstName,
+				LastName:  post.RepostedBy.LastName,
+			}
+			postResponse.RepostedAt = &post.TimelineAt
+		}
+		if post.Quoted != nil {
+			postResponse.Quote = &responses.PostResponse{
+				ID:        post.Quoted.ID,
+				Content:   post.Quoted.Content,
+				MediaURL:  post.Quoted.Media.String,
+				CreatedAt: post.Quoted.CreatedAt,
+				User: &responses.UserResponse{
+					ID:        post.Quoted.User.ID,
+					Username:  post.Quoted.User.Username,
+					FirstName: post.Quoted.User.FirstName,
+					LastName:  post.Quoted.User.LastName,
+				},
+			}
+		}
+		response.Posts[idx] = postResponse
 	}
 
 	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
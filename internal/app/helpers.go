@@ -1,7 +1,9 @@
 package app
 
 import (
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/mochaeng/sapphire-backend/internal/models"
@@ -24,6 +26,38 @@ func getSessionFromContext(r *http.Request) *models.Session {
 	return session
 }
 
+// getBearerToken reads an "Authorization: Bearer" header. Other schemes are
+// left alone, as if no header was sent
+func getBearerToken(r *http.Request) (string, bool, error) {
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", false, nil
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", true, ErrAuthorizationHeaderMalformed
+	}
+	return token, true, nil
+}
+
+// maxUserAgentSize keeps clients from filling the sessions table with huge
+// headers
+const maxUserAgentSize = 512
+
+// getSessionClient describes the device of the request. The IP comes from
+// RemoteAddr, which realIPMiddleware already replaced when proxied
+func getSessionClient(r *http.Request) models.SessionClient {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentSize {
+		userAgent = userAgent[:maxUserAgentSize]
+	}
+	return models.SessionClient{IP: ip, UserAgent: userAgent}
+}
+
 func deleteCookie(w http.ResponseWriter, cookieName string) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     cookieName,
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+// magicLinkBrowserKey is the cookie of the browser that asked for a magic
+// link, the link does not work anywhere else
+const magicLinkBrowserKey = "magic-link-browser"
+
+// RequestMagicLinkHandler godoc
+//
+//	@Summary		Asks for a sign in link
+//	@Description	E-mails a single-use sign in link when the address belongs to an active user. Only the browser that asked can use it. The answer is the same for unknown addresses
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	payloads.MagicLinkPayload	true	"User e-mail"
+//	@Success		204		"sign in e-mail sent if the user exists"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/magic-link [post]
+func (app *Application) requestMagicLinkHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.Config.Auth.MagicLink.Enabled {
+		app.NotFoundResponse(w, r, ErrMagicLinkDisabled)
+		return
+	}
+
+	var payload payloads.MagicLinkPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	browserToken, err := app.Service.Auth.RequestMagicLink(r.Context(), &payload)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     magicLinkBrowserKey,
+		Value:    browserToken,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		MaxAge:   int(app.Config.Auth.MagicLink.Expired.Seconds()),
+		SameSite: http.SameSiteLaxMode,
+	})
+	httpio.NoContentResponse(w)
+}
+
+// MagicLinkSigninHandler godoc
+//
+//	@Summary		Signs in with a magic link
+//	@Description	Uses up the token of the link e-mailed to the user and creates the session. It has to be sent from the browser that asked for the link
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	payloads.MagicLinkSigninPayload	true	"Token of the link"
+//	@Success		204		"user has signin"
+//	@Success		202		{object}	responses.SigninChallengeResponse	"two-factor code required"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/magic-link/signin [post]
+func (app *Application) magicLinkSigninHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.Config.Auth.MagicLink.Enabled {
+		app.NotFoundResponse(w, r, ErrMagicLinkDisabled)
+		return
+	}
+
+	var payload payloads.MagicLinkSigninPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var browserToken string
+	if cookie, err := r.Cookie(magicLinkBrowserKey); err == nil {
+		browserToken = cookie.Value
+	}
+
+	user, err := app.Service.Auth.SigninWithMagicLink(r.Context(), &payload, browserToken)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		case store.ErrNotFound:
+			app.UnauthorizedErrorResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	deleteCookie(w, magicLinkBrowserKey)
+	app.completeSignin(w, r, user, "")
+}
+
+// GetMagicLinkPreferenceHandler godoc
+//
+//	@Summary		Tells if the user accepts magic links
+//	@Description	Tells if sign in links can be e-mailed to the authenticated user
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	responses.MagicLinkPreferenceResponse
+//	@Failure		401	{object}	error
+//	@Failure		404	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/magic-link/preference [get]
+func (app *Application) getMagicLinkPreferenceHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.Config.Auth.MagicLink.Enabled {
+		app.NotFoundResponse(w, r, ErrMagicLinkDisabled)
+		return
+	}
+
+	user := getUserFromContext(r)
+	isEnabled, err := app.Service.Auth.GetMagicLinkEnabled(r.Context(), user.ID)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+	response := responses.MagicLinkPreferenceResponse{Enabled: isEnabled}
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// SetMagicLinkPreferenceHandler godoc
+//
+//	@Summary		Turns magic links on or off
+//	@Description	Lets the authenticated user refuse sign in links. Turning them off makes the links already sent stop working
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	payloads.MagicLinkPreferencePayload	true	"Whether links are accepted"
+//	@Success		204		"preference saved"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/magic-link/preference [put]
+func (app *Application) setMagicLinkPreferenceHandler(w http.ResponseWriter, r *http.Request) {
+	if !app.Config.Auth.MagicLink.Enabled {
+		app.NotFoundResponse(w, r, ErrMagicLinkDisabled)
+		return
+	}
+
+	var payload payloads.MagicLinkPreferencePayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	if err := app.Service.Auth.SetMagicLinkEnabled(r.Context(), user.ID, &payload); err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
@@ -0,0 +1,172 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMagicLinkHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	app.Config.Auth.MagicLink.Enabled = true
+	app.Config.Auth.MagicLink.Expired = 15 * time.Minute
+	mux := app.Mount()
+
+	hutao := &models.User{ID: 1, Username: "hutao"}
+	authService := app.Service.Auth.(*mocks.MockAuthService)
+	authService.On(
+		"RequestMagicLink",
+		mock.Anything,
+		&payloads.MagicLinkPayload{Email: "hutao@sapphire.com"},
+	).Return("browser", nil)
+	authService.On(
+		"SigninWithMagicLink",
+		mock.Anything,
+		&payloads.MagicLinkSigninPayload{Token: "valid"},
+		"browser",
+	).Return(hutao, nil)
+	authService.On(
+		"SigninWithMagicLink",
+		mock.Anything,
+		&payloads.MagicLinkSigninPayload{Token: "valid"},
+		mock.Anything,
+	).Return(nil, store.ErrNotFound)
+	authService.On("GetCookieSession", hutao.ID, mock.Anything).Return(
+		&http.Cookie{Name: service.AuthTokenKey, Value: "new-session"},
+		nil,
+	)
+	app.Service.TwoFactor.(*mocks.MockTwoFactorService).On("BeginLogin", mock.Anything, hutao).Return(nil, nil)
+
+	cookies := func(rr *httptest.ResponseRecorder) map[string]*http.Cookie {
+		named := make(map[string]*http.Cookie)
+		for _, cookie := range rr.Result().Cookies() {
+			named[cookie.Name] = cookie
+		}
+		return named
+	}
+
+	t.Run("binds the link to the browser that asks for it", func(t *testing.T) {
+		body := strings.NewReader(`{"email": "hutao@sapphire.com"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/magic-link", body)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		browser := cookies(rr)[magicLinkBrowserKey]
+		require.NotNil(t, browser)
+		assert.Equal(t, "browser", browser.Value)
+		assert.True(t, browser.HttpOnly)
+		assert.Equal(t, 15*60, browser.MaxAge)
+	})
+
+	t.Run("signs in from the same browser", func(t *testing.T) {
+		body := strings.NewReader(`{"token": "valid"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/magic-link/signin", body)
+		require.NoError(t, err)
+		req.AddCookie(&http.Cookie{Name: magicLinkBrowserKey, Value: "browser"})
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusNoContent, rr.Code)
+		named := cookies(rr)
+		require.NotNil(t, named[service.AuthTokenKey])
+		assert.Equal(t, "new-session", named[service.AuthTokenKey].Value)
+		require.NotNil(t, named[magicLinkBrowserKey])
+		assert.Equal(t, -1, named[magicLinkBrowserKey].MaxAge)
+	})
+
+	t.Run("returns status 401 from another browser", func(t *testing.T) {
+		for _, browser := range []string{"", "other-browser"} {
+			body := strings.NewReader(`{"token": "valid"}`)
+			req, err := http.NewRequest(http.MethodPost, "/v1/auth/magic-link/signin", body)
+			require.NoError(t, err)
+			if browser != "" {
+				req.AddCookie(&http.Cookie{Name: magicLinkBrowserKey, Value: browser})
+			}
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code, browser)
+			assert.Nil(t, cookies(rr)[service.AuthTokenKey], browser)
+		}
+	})
+
+	t.Run("reads and saves the preference of the user", func(t *testing.T) {
+		cookie := withTestSession(t, app, hutao, &models.Session{ID: "hutao", UserID: hutao.ID})
+		isEnabled := false
+		authService.On("GetMagicLinkEnabled", mock.Anything, hutao.ID).Return(true, nil)
+		authService.On(
+			"SetMagicLinkEnabled",
+			mock.Anything,
+			hutao.ID,
+			&payloads.MagicLinkPreferencePayload{Enabled: &isEnabled},
+		).Return(nil)
+		authService.On(
+			"SetMagicLinkEnabled",
+			mock.Anything,
+			hutao.ID,
+			&payloads.MagicLinkPreferencePayload{},
+		).Return(service.ErrInvalidPayload)
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/auth/magic-link/preference", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var response struct {
+			Data responses.MagicLinkPreferenceResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Data.Enabled)
+
+		tests := []struct {
+			body string
+			code int
+		}{
+			{`{"enabled": false}`, http.StatusNoContent},
+			{`{}`, http.StatusBadRequest},
+		}
+		for _, test := range tests {
+			req, err := http.NewRequest(http.MethodPut, "/v1/auth/magic-link/preference", strings.NewReader(test.body))
+			require.NoError(t, err)
+			req.AddCookie(cookie)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, test.code, rr.Code, test.body)
+		}
+	})
+
+	t.Run("returns status 401 on the preference without a session", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/auth/magic-link/preference", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("returns status 404 when magic links are off", func(t *testing.T) {
+		app.Config.Auth.MagicLink.Enabled = false
+		defer func() { app.Config.Auth.MagicLink.Enabled = true }()
+
+		for _, path := range []string{"/v1/auth/magic-link", "/v1/auth/magic-link/signin"} {
+			req, err := http.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+			require.NoError(t, err)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, http.StatusNotFound, rr.Code, path)
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+func getConversationFromCtx(r *http.Request) *models.Conversation {
+	conversation, _ := r.Context().Value(conversationCtx).(*models.Conversation)
+	return conversation
+}
+
+func newMessageResponse(message *models.Message) responses.MessageResponse {
+	return responses.MessageResponse{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		Sender: responses.UserResponse{
+			ID:        message.Sender.ID,
+			Username:  message.Sender.Username,
+			FirstName: message.Sender.FirstName,
+			LastName:  message.Sender.LastName,
+		},
+		Content:   message.Content,
+		CreatedAt: message.CreatedAt,
+	}
+}
+
+func newConversationResponse(conversation *models.Conversation) responses.ConversationResponse {
+	response := responses.ConversationResponse{
+		ID:           conversation.ID,
+		IsGroup:      conversation.IsGroup,
+		Title:        conversation.Title,
+		Participants: make([]responses.ConversationParticipantResponse, len(conversation.Participants)),
+		UnreadCount:  conversation.UnreadCount,
+		CreatedAt:    conversation.CreatedAt,
+		UpdatedAt:    conversation.UpdatedAt,
+	}
+	for idx, participant := range conversation.Participants {
+		participantResponse := responses.ConversationParticipantResponse{
+			User: responses.UserResponse{
+				ID:        participant.User.ID,
+				Username:  participant.User.Username,
+				FirstName: participant.User.FirstName,
+				LastName:  participant.User.LastName,
+			},
+		}
+		if participant.LastReadMessageID.Valid {
+			participantResponse.LastReadMessageID = &participant.LastReadMessageID.Int64
+		}
+		if participant.LastReadAt.Valid {
+			participantResponse.LastReadAt = &participant.LastReadAt.Time
+		}
+		response.Participants[idx] = participantResponse
+	}
+	if conversation.LastMessage != nil {
+		lastMessage := newMessageResponse(conversation.LastMessage)
+		response.LastMessage = &lastMessage
+	}
+	return response
+}
+
+// CreateConversation godoc
+//
+//	@Summary		Starts a conversation
+//	@Description	Starts a private conversation with one user, reusing the existing one, or a group with several users
+//	@Tags			message
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		payloads.CreateConversationPayload	true	"Conversation payload"
+//	@Success		201		{object}	responses.ConversationResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		403		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/conversations [post]
+func (app *Application) createConversationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.CreateConversationPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	conversation, err := app.Service.Message.CreateConversation(r.Context(), user, &payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPayload):
+			app.BadRequestResponse(w, r, err)
+		case errors.Is(err, service.ErrOperationNotAllowed):
+			app.ForbiddenErrorResponse(w, r, err)
+		case errors.Is(err, store.ErrForeignKeyViolation):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusCreated, newConversationResponse(conversation)); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetConversations godoc
+//
+//	@Summary		Gets the user conversations
+//	@Description	Gets the conversations of the authenticated user with their last message, the most recently active first
+//	@Tags			message
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		string	false	"Limit"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Success		200		{object}	responses.GetConversationsResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/conversations [get]
+func (app *Application) getConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	userConversations := pagination.UserConversations{UserID: user.ID}
+	query := r.URL.Query()
+	if err := userConversations.Parse(query.Get("limit"), query.Get("cursor")); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	conversations, err := app.Service.Message.GetConversations(r.Context(), &userConversations)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	var response responses.GetConversationsResponse
+	response.Conversations = make([]responses.ConversationResponse, len(conversations))
+	response.NextCursor = userConversations.NextCursor
+	for idx, conversation := range conversations {
+		response.Conversations[idx] = newConversationResponse(conversation)
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetConversationMessages godoc
+//
+//	@Summary		Gets the messages of a conversation
+//	@Description	Gets the message history of a conversation the user takes part in, the most recent first
+//	@Tags			message
+//	@Accept			json
+//	@Produce		json
+//	@Param			conversationID	path		int		true	"Conversation ID"
+//	@Param			limit			query		string	false	"Limit"
+//	@Param			cursor			query		string	false	"Cursor"
+//	@Success		200				{object}	responses.GetMessagesResponse
+//	@Failure		400				{object}	error
+//	@Failure		401				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/conversations/{conversationID}/messages [get]
+func (app *Application) getConversationMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	conversation := getConversationFromCtx(r)
+	conversationMessages := pagination.ConversationMessages{ConversationID: conversation.ID}
+	query := r.URL.Query()
+	if err := conversationMessages.Parse(query.Get("limit"), query.Get("cursor")); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	messages, err := app.Service.Message.GetMessages(r.Context(), &conversationMessages)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	var response responses.GetMessagesResponse
+	response.Messages = make([]responses.MessageResponse, len(messages))
+	response.NextCursor = conversationMessages.NextCursor
+	for idx, message := range messages {
+		response.Messages[idx] = newMessageResponse(message)
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// SendMessage godoc
+//
+//	@Summary		Sends a message
+//	@Description	Sends a message to a conversation the user takes part in
+//	@Tags			message
+//	@Accept			json
+//	@Produce		json
+//	@Param			conversationID	path		int							true	"Conversation ID"
+//	@Param			payload			body		payloads.SendMessagePayload	true	"Message payload"
+//	@Success		201				{object}	responses.MessageResponse
+//	@Failure		400				{object}	error
+//	@Failure		401				{object}	error
+//	@Failure		403				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/conversations/{conversationID}/messages [post]
+func (app *Application) sendMessageHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.SendMessagePayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	conversation := getConversationFromCtx(r)
+	message, err := app.Service.Message.Send(r.Context(), user, conversation, &payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPayload):
+			app.BadRequestResponse(w, r, err)
+		case errors.Is(err, service.ErrOperationNotAllowed):
+			app.ForbiddenErrorResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusCreated, newMessageResponse(message)); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// MarkConversationRead godoc
+//
+//	@Summary		Marks a conversation as read
+//	@Description	Moves the user read receipt to the latest message of the conversation
+//	@Tags			message
+//	@Accept			json
+//	@Produce		json
+//	@Param			conversationID	path	int	true	"Conversation ID"
+//	@Success		204				"Conversation marked as read"
+//	@Failure		401				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/conversations/{conversationID}/read [put]
+func (app *Application) markConversationReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	conversation := getConversationFromCtx(r)
+	if err := app.Service.Message.MarkRead(r.Context(), user, conversation); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
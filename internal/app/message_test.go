@@ -0,0 +1,185 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	hutao := &models.User{ID: 1, Username: "hutao"}
+	chaee := &models.User{ID: 2, Username: "chaee"}
+	momo := &models.User{ID: 3, Username: "momo"}
+	cookie := withTestSession(t, app, hutao, &models.Session{ID: "hutao", UserID: hutao.ID})
+
+	group := &models.Conversation{
+		ID:      1,
+		IsGroup: true,
+		Title:   "wangsheng",
+		Participants: []*models.ConversationParticipant{
+			{User: hutao}, {User: chaee}, {User: momo},
+		},
+	}
+	messageService := app.Service.Message.(*mocks.MockMessageService)
+	messageService.On("GetConversation", mock.Anything, group.ID, hutao.ID).Return(group, nil)
+	messageService.On("GetConversation", mock.Anything, int64(2), hutao.ID).Return(nil, store.ErrNotFound)
+	messageService.On("CreateConversation", mock.Anything, hutao, &payloads.CreateConversationPayload{
+		ParticipantIDs: []int64{2, 3},
+		Title:          "wangsheng",
+	}).Return(group, nil)
+	messageService.On("CreateConversation", mock.Anything, hutao, &payloads.CreateConversationPayload{
+		ParticipantIDs: []int64{4},
+	}).Return(nil, service.ErrOperationNotAllowed)
+	messageService.On("GetConversations", mock.Anything, mock.MatchedBy(func(q *pagination.UserConversations) bool {
+		return q.UserID == hutao.ID
+	})).Return([]*models.Conversation{group}, nil)
+	messageService.On("GetMessages", mock.Anything, mock.MatchedBy(func(q *pagination.ConversationMessages) bool {
+		return q.ConversationID == group.ID
+	})).Return([]*models.Message{
+		{ID: 2, ConversationID: group.ID, Sender: chaee, Content: "bye"},
+		{ID: 1, ConversationID: group.ID, Sender: hutao, Content: "hello"},
+	}, nil)
+	messageService.On("Send", mock.Anything, hutao, group, &payloads.SendMessagePayload{Content: "hello"}).Return(
+		&models.Message{ID: 3, ConversationID: group.ID, Sender: hutao, Content: "hello"}, nil,
+	)
+	messageService.On("Send", mock.Anything, hutao, group, &payloads.SendMessagePayload{Content: "blocked"}).Return(
+		nil, service.ErrOperationNotAllowed,
+	)
+	messageService.On("MarkRead", mock.Anything, hutao, group).Return(nil)
+
+	t.Run("returns status 401 without a session", func(t *testing.T) {
+		paths := []string{
+			"/v1/conversations",
+			"/v1/conversations/1/messages",
+		}
+		for _, path := range paths {
+			req, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code, path)
+		}
+	})
+
+	t.Run("creates a group", func(t *testing.T) {
+		body := strings.NewReader(`{"participant_ids": [2, 3], "title": "wangsheng"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/conversations", body)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var response struct {
+			Data responses.ConversationResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.True(t, response.Data.IsGroup)
+		assert.Len(t, response.Data.Participants, 3)
+	})
+
+	t.Run("returns status 403 when a participant is blocked", func(t *testing.T) {
+		body := strings.NewReader(`{"participant_ids": [4]}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/conversations", body)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("lists the user conversations", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/conversations", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetConversationsResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data.Conversations, 1)
+		assert.Equal(t, "wangsheng", response.Data.Conversations[0].Title)
+	})
+
+	t.Run("lists the messages, the most recent first", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/conversations/1/messages", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetMessagesResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data.Messages, 2)
+		assert.Equal(t, "bye", response.Data.Messages[0].Content)
+	})
+
+	t.Run("returns status 404 for conversations of other users", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/conversations/2/messages", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("sends a message", func(t *testing.T) {
+		body := strings.NewReader(`{"content": "hello"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/conversations/1/messages", body)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusCreated, rr.Code)
+
+		var response struct {
+			Data responses.MessageResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, int64(3), response.Data.ID)
+		assert.Equal(t, "hutao", response.Data.Sender.Username)
+	})
+
+	t.Run("returns status 403 when sending to a member with a block", func(t *testing.T) {
+		body := strings.NewReader(`{"content": "blocked"}`)
+		req, err := http.NewRequest(http.MethodPost, "/v1/conversations/1/messages", body)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("marks the conversation as read", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, "/v1/conversations/1/read", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		messageService.AssertCalled(t, "MarkRead", mock.Anything, hutao, group)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -16,11 +17,18 @@ import (
 type postKey string
 type userKey string
 type sessionKey string
+type commentKey string
+type conversationKey string
+type accessTokenKey string
 
 const (
 	postCtx    postKey    = "post"
 	userCtx    userKey    = "user"
 	sessionCtx sessionKey = "session"
+	commentCtx commentKey = "comment"
+
+	conversationCtx conversationKey = "conversation"
+	accessTokenCtx  accessTokenKey  = "accessToken"
 )
 
 func (app *Application) postContextMiddleware(next http.Handler) http.Handler {
@@ -47,6 +55,60 @@ func (app *Application) postContextMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+func (app *Application) commentContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		commentID, err := strconv.ParseInt(chi.URLParam(r, "commentID"), 10, 64)
+		if err != nil || commentID < 1 {
+			app.BadRequestResponse(w, r, err)
+			return
+		}
+		ctx := r.Context()
+		comment, err := app.Service.Comment.GetByID(ctx, commentID)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.NotFoundResponse(w, r, err)
+			default:
+				app.InternalServerErrorResponse(w, r, err)
+			}
+			return
+		}
+		post := getPostFromCtx(r)
+		if post == nil || comment.PostId != post.ID {
+			app.NotFoundResponse(w, r, store.ErrNotFound)
+			return
+		}
+		ctx = context.WithValue(ctx, commentCtx, comment)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// conversationContextMiddleware loads a conversation of the authenticated
+// user, the ones they do not take part in are not found
+func (app *Application) conversationContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conversationID, err := strconv.ParseInt(chi.URLParam(r, "conversationID"), 10, 64)
+		if err != nil || conversationID < 1 {
+			app.BadRequestResponse(w, r, err)
+			return
+		}
+		user := getUserFromContext(r)
+		ctx := r.Context()
+		conversation, err := app.Service.Message.GetConversation(ctx, conversationID, user.ID)
+		if err != nil {
+			switch err {
+			case store.ErrNotFound:
+				app.NotFoundResponse(w, r, err)
+			default:
+				app.InternalServerErrorResponse(w, r, err)
+			}
+			return
+		}
+		ctx = context.WithValue(ctx, conversationCtx, conversation)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 func (app *Application) userContextMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
@@ -97,15 +159,27 @@ func (app *Application) basicAuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// authTokenMiddleware authenticates the request with the session cookie, or
+// with a personal access token sent as "Authorization: Bearer". When a bearer
+// token is sent the cookie is ignored
 func (app *Application) authTokenMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok, err := getBearerToken(r); ok {
+			if err != nil {
+				app.UnauthorizedErrorResponse(w, r, err)
+				return
+			}
+			app.authenticateAccessToken(w, r, token, next)
+			return
+		}
+
 		cookie, err := r.Cookie(services.AuthTokenKey)
 		if err != nil || len(cookie.Value) == 0 {
 			app.UnauthorizedErrorResponse(w, r, ErrMissingOrEmptyAuthToken)
 			return
 		}
 
-		session, err := app.Service.Auth.ValidateSessionToken(cookie.Value)
+		session, err := app.Service.Auth.ValidateSessionToken(cookie.Value, getSessionClient(r))
 		if err != nil {
 			app.UnauthorizedErrorResponse(w, r, ErrInvalidUserSession)
 			app.deleteUserSessionCookie(w)
@@ -125,6 +199,125 @@ func (app *Application) authTokenMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// optionalAuthTokenMiddleware identifies the user when a valid session is sent,
+// but lets anonymous requests through
+func (app *Application) optionalAuthTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok, err := getBearerToken(r); ok {
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			app.authenticateAccessToken(w, r, token, next)
+			return
+		}
+
+		cookie, err := r.Cookie(services.AuthTokenKey)
+		if err != nil || len(cookie.Value) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		session, err := app.Service.Auth.ValidateSessionToken(cookie.Value, getSessionClient(r))
+		if err != nil || session == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		user, err := app.Service.User.GetCached(ctx, session.UserID)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx = context.WithValue(ctx, userCtx, user)
+		ctx = context.WithValue(ctx, sessionCtx, session)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticateAccessToken serves the request as the owner of the personal
+// access token, when the token scopes allow the request method
+func (app *Application) authenticateAccessToken(w http.ResponseWriter, r *http.Request, token string, next http.Handler) {
+	ctx := r.Context()
+	accessToken, err := app.Service.AccessToken.Validate(ctx, token)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidAccessToken:
+			app.UnauthorizedErrorResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	if !accessToken.Allows(r.Method) {
+		app.ForbiddenErrorResponse(w, r, ErrInsufficientScope)
+		return
+	}
+
+	user, err := app.Service.User.GetCached(ctx, accessToken.UserID)
+	if err != nil {
+		app.UnauthorizedErrorResponse(w, r, err)
+		return
+	}
+
+	ctx = context.WithValue(ctx, userCtx, user)
+	ctx = context.WithValue(ctx, accessTokenCtx, accessToken)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// sessionOnlyMiddleware keeps access tokens away from the routes that manage
+// how the user signs in, those need a browser session
+func (app *Application) sessionOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if getSessionFromContext(r) == nil {
+			app.ForbiddenErrorResponse(w, r, ErrSessionRequired)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// realIPMiddleware replaces RemoteAddr with the client address the reverse
+// proxy wrote in the configured header. Only that header is read, since the
+// proxy overwrites it while clients can forge every other one, and none at all
+// when the api is not behind a proxy
+func (app *Application) realIPMiddleware(next http.Handler) http.Handler {
+	header := app.Config.ProxyIPHeader
+	if header == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(header))); ip != nil {
+			r.RemoteAddr = ip.String()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// auditIPMiddleware lets the services record the address the request came
+// from in the audit log. It has to run after realIPMiddleware
+func (app *Application) auditIPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := services.WithAuditIP(r.Context(), getSessionClient(r).IP)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// checkRoleLevel only lets users whose role is at least the required level
+// through
+func (app *Application) checkRoleLevel(requiredLevel int, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromContext(r)
+		if user.Role.Level < requiredLevel {
+			app.ForbiddenErrorResponse(w, r, ErrInsufficientRole)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (app *Application) checkPostOwnership(requiredLevel int, next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := getUserFromContext(r)
@@ -141,6 +334,22 @@ func (app *Application) checkPostOwnership(requiredLevel int, next http.HandlerF
 	})
 }
 
+func (app *Application) checkCommentOwnership(requiredLevel int, next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := getUserFromContext(r)
+		comment := getCommentFromCtx(r)
+		if comment.UserId == user.ID {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if user.Role.Level >= requiredLevel {
+			next.ServeHTTP(w, r)
+			return
+		}
+		app.ForbiddenErrorResponse(w, r, fmt.Errorf("no required level to own a comment"))
+	})
+}
+
 func (app *Application) rateLimiterMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if app.Config.RateLimiter.IsEnable {
@@ -159,6 +368,12 @@ func (app *Application) csrfMiddleware(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
+		// browsers do not attach bearer tokens by themselves, and the cookie
+		// is ignored when one is sent
+		if _, ok, _ := getBearerToken(r); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
 		if r.Method != http.MethodGet {
 			origin := r.Header.Get("Origin")
 			if origin == "" || origin != app.Config.FrontedURL {
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealIPMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		proxyHeader string
+		headers     map[string]string
+		expected    string
+	}{
+		{
+			name:     "keeps the peer address without a proxy",
+			headers:  map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Real-Ip": "203.0.113.7"},
+			expected: "192.0.2.1",
+		},
+		{
+			name:        "reads the proxy header",
+			proxyHeader: "X-Real-Ip",
+			headers:     map[string]string{"X-Real-Ip": " 198.51.100.4 "},
+			expected:    "198.51.100.4",
+		},
+		{
+			name:        "ignores the headers the proxy does not set",
+			proxyHeader: "X-Real-Ip",
+			headers:     map[string]string{"X-Forwarded-For": "203.0.113.7", "True-Client-Ip": "203.0.113.7"},
+			expected:    "192.0.2.1",
+		},
+		{
+			name:        "ignores a header that is not an address",
+			proxyHeader: "X-Real-Ip",
+			headers:     map[string]string{"X-Real-Ip": "localhost"},
+			expected:    "192.0.2.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			app.Config.ProxyIPHeader = tt.proxyHeader
+
+			var ip string
+			handler := app.realIPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ip = getSessionClient(r).IP
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:4000"
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.expected, ip)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+// GetNotifications godoc
+//
+//	@Summary		Gets the user notifications
+//	@Description	Gets the authenticated user notifications grouped by kind and target, the most recent first
+//	@Tags			notification
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		string	false	"Limit"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Success		200		{object}	responses.GetNotificationsResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/notifications [get]
+func (app *Application) getNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	userNotifications := pagination.UserNotifications{UserID: user.ID}
+	query := r.URL.Query()
+	if err := userNotifications.Parse(query.Get("limit"), query.Get("cursor")); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	groups, err := app.Service.Notification.GetFromUser(r.Context(), &userNotifications)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	var response responses.GetNotificationsResponse
+	response.Notifications = make([]responses.NotificationResponse, len(groups))
+	response.NextCursor = userNotifications.NextCursor
+	for idx, group := range groups {
+		notification := responses.NotificationResponse{
+			ID:          group.ID,
+			Kind:        group.Kind,
+			Message:     group.Message(),
+			Actors:      make([]responses.UserResponse, len(group.Actors)),
+			ActorsCount: group.ActorsCount,
+			IsRead:      group.IsRead,
+			CreatedAt:   group.CreatedAt,
+		}
+		if group.PostID.Valid {
+			notification.PostID = &group.PostID.Int64
+		}
+		if group.CommentID.Valid {
+			notification.CommentID = &group.CommentID.Int64
+		}
+		for actorIdx, actor := range group.Actors {
+			notification.Actors[actorIdx] = responses.UserResponse{
+				ID:       actor.ID,
+				Username: actor.Username,
+			}
+		}
+		response.Notifications[idx] = notification
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetUnreadNotificationsCount godoc
+//
+//	@Summary		Counts the unread notifications
+//	@Description	Counts the notification groups the authenticated user has not read yet
+//	@Tags			notification
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	responses.UnreadNotificationsResponse
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/notifications/unread-count [get]
+func (app *Application) getUnreadNotificationsCountHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	count, err := app.Service.Notification.CountUnread(r.Context(), user.ID)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+	response := responses.UnreadNotificationsResponse{Count: count}
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// MarkNotificationRead godoc
+//
+//	@Summary		Marks a notification as read
+//	@Description	Marks as read the notification and the others grouped with it
+//	@Tags			notification
+//	@Accept			json
+//	@Produce		json
+//	@Param			notificationID	path	int	true	"Notification ID"
+//	@Success		204				"Notification marked as read"
+//	@Failure		400				{object}	error
+//	@Failure		401				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/notifications/{notificationID}/read [put]
+func (app *Application) markNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	notificationID, err := strconv.ParseInt(chi.URLParam(r, "notificationID"), 10, 64)
+	if err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+	if err := app.Service.Notification.MarkRead(r.Context(), user.ID, notificationID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
+// MarkAllNotificationsRead godoc
+//
+//	@Summary		Marks all notifications as read
+//	@Description	Marks every notification of the authenticated user as read
+//	@Tags			notification
+//	@Accept			json
+//	@Produce		json
+//	@Success		204	"Notifications marked as read"
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/notifications/read [put]
+func (app *Application) markAllNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	if err := app.Service.Notification.MarkAllRead(r.Context(), user.ID); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+	httpio.NoContentResponse(w)
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	user := &models.User{ID: 1, Username: "momo"}
+	cookie := withTestSession(t, app, user, &models.Session{ID: "momo", UserID: user.ID})
+
+	notificationService := app.Service.Notification.(*mocks.MockNotificationService)
+	notificationService.On("GetFromUser", mock.Anything, mock.MatchedBy(func(q *pagination.UserNotifications) bool {
+		return q.UserID == user.ID
+	})).Return([]*models.NotificationGroup{{
+		ID:          7,
+		Kind:        models.NotificationReaction,
+		PostID:      sql.NullInt64{Int64: 101, Valid: true},
+		Actors:      []*models.User{{ID: 2, Username: "chaee"}, {ID: 3, Username: "hutao"}},
+		ActorsCount: 2,
+		CreatedAt:   time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC),
+	}}, nil)
+	notificationService.On("CountUnread", mock.Anything, user.ID).Return(4, nil)
+	notificationService.On("MarkRead", mock.Anything, user.ID, int64(7)).Return(nil)
+	notificationService.On("MarkRead", mock.Anything, user.ID, int64(8)).Return(store.ErrNotFound)
+	notificationService.On("MarkAllRead", mock.Anything, user.ID).Return(nil)
+
+	request := func(method string, path string) *http.Request {
+		req, err := http.NewRequest(method, path, nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+		return req
+	}
+
+	t.Run("lists the grouped notifications", func(t *testing.T) {
+		rr := testutils.ExecuteRequest(request(http.MethodGet, "/v1/notifications"), mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetNotificationsResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data.Notifications, 1)
+		notification := response.Data.Notifications[0]
+		assert.Equal(t, "chaee and hutao reacted to your post", notification.Message)
+		require.NotNil(t, notification.PostID)
+		assert.Equal(t, int64(101), *notification.PostID)
+		assert.Nil(t, notification.CommentID)
+		assert.Len(t, notification.Actors, 2)
+	})
+
+	t.Run("counts the unread groups", func(t *testing.T) {
+		rr := testutils.ExecuteRequest(request(http.MethodGet, "/v1/notifications/unread-count"), mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"data":{"count":4}}`, rr.Body.String())
+	})
+
+	t.Run("marks a group as read", func(t *testing.T) {
+		rr := testutils.ExecuteRequest(request(http.MethodPut, "/v1/notifications/7/read"), mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("returns status 404 for a notification of someone else", func(t *testing.T) {
+		rr := testutils.ExecuteRequest(request(http.MethodPut, "/v1/notifications/8/read"), mux)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("returns status 400 for an invalid id", func(t *testing.T) {
+		rr := testutils.ExecuteRequest(request(http.MethodPut, "/v1/notifications/seven/read"), mux)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("marks every notification as read", func(t *testing.T) {
+		rr := testutils.ExecuteRequest(request(http.MethodPut, "/v1/notifications/read"), mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		notificationService.AssertCalled(t, "MarkAllRead", mock.Anything, user.ID)
+	})
+
+	t.Run("returns status 401 without a session", func(t *testing.T) {
+		routes := []struct {
+			method string
+			path   string
+		}{
+			{http.MethodGet, "/v1/notifications"},
+			{http.MethodGet, "/v1/notifications/unread-count"},
+			{http.MethodPut, "/v1/notifications/read"},
+			{http.MethodPut, "/v1/notifications/7/read"},
+		}
+		for _, route := range routes {
+			req, err := http.NewRequest(route.method, route.path, nil)
+			require.NoError(t, err)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, http.StatusUnauthorized, rr.Code, route.path)
+		}
+	})
+}
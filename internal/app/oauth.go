@@ -7,10 +7,55 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/markbates/goth/gothic"
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
 )
 
+// oauthLinkSession is the cookie that remembers which user asked to link a
+// provider while the browser is away at the provider
+const oauthLinkSession = "_sapphire_oauth_link"
+
 func (app *Application) OAuthLoginHandler(w http.ResponseWriter, r *http.Request) {
 	provider := chi.URLParam(r, "provider")
+	if !app.Config.OAuth.IsEnabled(provider) {
+		app.NotFoundResponse(w, r, ErrUnknownOAuthProvider)
+		return
+	}
+
+	r = setProviderInContext(r, provider)
+
+	gothic.BeginAuthHandler(w, r)
+}
+
+// OAuthLinkHandler godoc
+//
+//	@Summary		Links a provider account
+//	@Description	Sends the signed in user to the provider, the account they sign in with there is linked on the callback
+//	@Tags			auth
+//	@Param			provider	path	string	true	"Provider name"
+//	@Success		307			"redirect to the provider"
+//	@Failure		401			{object}	error
+//	@Failure		404			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/{provider}/link [get]
+func (app *Application) OAuthLinkHandler(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+	if !app.Config.OAuth.IsEnabled(provider) {
+		app.NotFoundResponse(w, r, ErrUnknownOAuthProvider)
+		return
+	}
+
+	user := getUserFromContext(r)
+	session, _ := gothic.Store.New(r, oauthLinkSession)
+	session.Values["user_id"] = user.ID
+	session.Values["provider"] = provider
+	if err := session.Save(r, w); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
 
 	r = setProviderInContext(r, provider)
 
@@ -19,47 +64,151 @@ func (app *Application) OAuthLoginHandler(w http.ResponseWriter, r *http.Request
 
 func (app *Application) OAuthCallbackHandler(w http.ResponseWriter, r *http.Request) {
 	provider := chi.URLParam(r, "provider")
+	if !app.Config.OAuth.IsEnabled(provider) {
+		app.NotFoundResponse(w, r, ErrUnknownOAuthProvider)
+		return
+	}
 
 	r = setProviderInContext(r, provider)
 
+	linkUserID, isLink := app.popOAuthLink(w, r, provider)
+
 	gothUser, err := gothic.CompleteUserAuth(w, r)
 	if err != nil {
 		app.BadRequestResponse(w, r, fmt.Errorf("oauth failed. Error: %w", err))
 		return
 	}
 
-	// 1. check if email exists and it's verified by the provider
-	// 2. check if there's an already user in the database with that email
-	// 3. if the user exists:
-	// 		  if the user already has oauth account:
-	// 			- create session
-	// 		  else:
-	// 			- create oauth record (provider, providerUserID, userID)
-	// 			- activated user if it's not yet
-	// 			- create session
-	// 	  else:
-	// 		- create user record
-	// 		- create oauth record
-	// 		- create session
-	ctx := context.Background()
-	user, err := app.Service.User.LinkOrCreateUserFromOAuth(ctx, &gothUser)
+	if isLink {
+		user := getUserFromContext(r)
+		if user == nil || getSessionFromContext(r) == nil || user.ID != linkUserID {
+			app.UnauthorizedErrorResponse(w, r, ErrInvalidUserSession)
+			return
+		}
+		if _, err := app.Service.User.LinkOAuth(r.Context(), user.ID, &gothUser); err != nil {
+			switch err {
+			case service.ErrInvalidOAuthUser:
+				app.BadRequestResponse(w, r, err)
+			case store.ErrConflict:
+				app.ConflictResponse(w, r, err)
+			default:
+				app.InternalServerErrorResponse(w, r, err)
+			}
+			return
+		}
+		redirectURL := app.Config.FrontedURL + app.Config.OAuth.LinkRedirectPath
+		http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
+		return
+	}
+
+	// provider accounts sign in the user they are linked to. One that is not
+	// linked creates a new user, unless its e-mail is taken, in which case the
+	// owner has to sign in and link it from the settings
+	user, err := app.Service.User.GetOrCreateUserFromOAuth(r.Context(), &gothUser)
 	if err != nil {
-		app.BadRequestResponse(w, r, fmt.Errorf("oauth creation failed. Error: %w", err))
+		switch err {
+		case service.ErrInvalidOAuthUser:
+			app.BadRequestResponse(w, r, err)
+		case service.ErrOAuthEmailTaken:
+			app.ConflictResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
 		return
 	}
 
-	cookie, err := app.Service.Auth.GetCookieSession(user.ID)
+	// a provider account stands in for the password, users with two-factor
+	// still get the challenge
+	redirectURL := app.Config.FrontedURL + app.Config.OAuth.RedirectPath
+	app.completeSignin(w, r, user, redirectURL)
+}
+
+// popOAuthLink reads and clears the link request of the callback, if the
+// user started one for this provider
+func (app *Application) popOAuthLink(w http.ResponseWriter, r *http.Request, provider string) (int64, bool) {
+	session, err := gothic.Store.Get(r, oauthLinkSession)
+	if err != nil || session.IsNew {
+		return 0, false
+	}
+	userID, ok := session.Values["user_id"].(int64)
+	linkProvider, _ := session.Values["provider"].(string)
+
+	session.Options.MaxAge = -1
+	session.Values = make(map[any]any)
+	if err := session.Save(r, w); err != nil {
+		app.Logger.Warnw("could not clear the oauth link cookie", "error", err)
+	}
+	return userID, ok && linkProvider == provider
+}
+
+func newOAuthAccountResponse(oauthAccount *models.OAuthAccount) responses.OAuthAccountResponse {
+	return responses.OAuthAccountResponse{
+		Provider:       oauthAccount.ProviderID,
+		ProviderUserID: oauthAccount.ProviderUserID,
+		CreatedAt:      oauthAccount.CreatedAt,
+	}
+}
+
+// GetOAuthAccountsHandler godoc
+//
+//	@Summary		Lists the linked provider accounts
+//	@Description	Lists the provider accounts the authenticated user can sign in with
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	responses.GetOAuthAccountsResponse
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/identities [get]
+func (app *Application) getOAuthAccountsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	oauthAccounts, err := app.Service.User.GetOAuthAccounts(r.Context(), user.ID)
 	if err != nil {
 		app.InternalServerErrorResponse(w, r, err)
 		return
 	}
 
-	redirectURL := fmt.Sprintf("%s/auth/oauth-success", app.Config.FrontedURL)
-	http.SetCookie(w, cookie)
-	http.Redirect(w, r, redirectURL, http.StatusPermanentRedirect)
+	response := responses.GetOAuthAccountsResponse{Accounts: make([]responses.OAuthAccountResponse, len(oauthAccounts))}
+	for idx, oauthAccount := range oauthAccounts {
+		response.Accounts[idx] = newOAuthAccountResponse(oauthAccount)
+	}
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// UnlinkOAuthAccountHandler godoc
+//
+//	@Summary		Unlinks a provider account
+//	@Description	Unlinks a provider account of the authenticated user, as long as a password, passkey or another provider account is left to sign in with
+//	@Tags			auth
+//	@Produce		json
+//	@Param			provider		path	string	true	"Provider name"
+//	@Param			providerUserID	path	string	true	"Account id at the provider"
+//	@Success		204				"account unlinked"
+//	@Failure		401				{object}	error
+//	@Failure		404				{object}	error
+//	@Failure		409				{object}	error
+//	@Failure		500				{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/identities/{provider}/{providerUserID} [delete]
+func (app *Application) unlinkOAuthAccountHandler(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+	providerUserID := chi.URLParam(r, "providerUserID")
 
-	// app.Logger.Infow("provider", provider)
-	// app.Logger.Infow("gothUser", gothUser)
+	user := getUserFromContext(r)
+	if err := app.Service.User.UnlinkOAuth(r.Context(), user.ID, provider, providerUserID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		case service.ErrLastLoginMethod:
+			app.ConflictResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
 }
 
 func setProviderInContext(r *http.Request, provider string) *http.Request {
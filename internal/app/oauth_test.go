@@ -0,0 +1,245 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/gothic"
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/oauth"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOAuthHandlers(t *testing.T) {
+	issuer := testutils.NewOIDCIssuer("sapphire")
+	defer issuer.Close()
+
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	app.Config.FrontedURL = "http://sapphire.com"
+	app.Config.OAuth = config.OAuthConfig{
+		Providers: []config.OAuthProviderCfg{{
+			Name:         "sso",
+			Kind:         config.OAuthOIDC,
+			Key:          "sapphire",
+			Secret:       "secret",
+			CallbackURI:  "http://api.sapphire.com/v1/auth/sso/callback",
+			DiscoveryURL: issuer.DiscoveryURL(),
+		}},
+		RedirectPath:     "/welcome",
+		LinkRedirectPath: "/settings/accounts",
+	}
+	mux := app.Mount()
+
+	providers, err := oauth.NewProviders(&app.Config.OAuth)
+	require.NoError(t, err)
+	goth.UseProviders(providers...)
+	defer goth.ClearProviders()
+	gothic.Store = sessions.NewCookieStore([]byte("oauth-test-secret"))
+
+	user := &models.User{ID: 1, Username: "hutao"}
+	twoFactorUser := &models.User{ID: 2, Username: "chaee"}
+	sessionCookie := withTestSession(t, app, user, &models.Session{ID: "current", UserID: user.ID})
+
+	userService := app.Service.User.(*mocks.MockUserService)
+	withSubject := func(subject string) any {
+		return mock.MatchedBy(func(gothUser *goth.User) bool {
+			return gothUser.Provider == "sso" && gothUser.UserID == subject && gothUser.Email == issuer.Email
+		})
+	}
+	userService.On("GetOrCreateUserFromOAuth", mock.Anything, withSubject("issuer-user-1")).Return(user, nil)
+	userService.On("GetOrCreateUserFromOAuth", mock.Anything, withSubject("issuer-user-2")).Return(nil, service.ErrOAuthEmailTaken)
+	userService.On("GetOrCreateUserFromOAuth", mock.Anything, withSubject("issuer-user-5")).Return(twoFactorUser, nil)
+	userService.On("LinkOAuth", mock.Anything, user.ID, withSubject("issuer-user-1")).Return(&models.OAuthAccount{
+		ProviderID:     "sso",
+		ProviderUserID: "issuer-user-1",
+		UserID:         user.ID,
+	}, nil)
+	userService.On("LinkOAuth", mock.Anything, user.ID, withSubject("issuer-user-2")).Return(nil, store.ErrConflict)
+	userService.On("GetOAuthAccounts", mock.Anything, user.ID).Return([]*models.OAuthAccount{
+		{ProviderID: "sso", ProviderUserID: "issuer-user-1", UserID: user.ID, CreatedAt: "2024-01-01T00:00:00Z"},
+	}, nil)
+	userService.On("UnlinkOAuth", mock.Anything, user.ID, "sso", "issuer-user-1").Return(service.ErrLastLoginMethod)
+	userService.On("UnlinkOAuth", mock.Anything, user.ID, "sso", "issuer-user-3").Return(store.ErrNotFound)
+	userService.On("UnlinkOAuth", mock.Anything, user.ID, "sso", "issuer-user-4").Return(nil)
+	app.Service.Auth.(*mocks.MockAuthService).On(
+		"GetCookieSession",
+		user.ID,
+		mock.Anything,
+	).Return(&http.Cookie{Name: service.AuthTokenKey, Value: "new-session"}, nil)
+	twoFactorService := app.Service.TwoFactor.(*mocks.MockTwoFactorService)
+	twoFactorService.On("BeginLogin", mock.Anything, user).Return(nil, nil)
+	twoFactorService.On("BeginLogin", mock.Anything, twoFactorUser).Return(&models.PendingLogin{
+		Token:     "pending",
+		ExpiresAt: time.Now().Add(5 * time.Minute),
+	}, nil)
+
+	// goToIssuer starts the flow at path and comes back to the callback the
+	// way the browser would, with the cookies of the first response
+	goToIssuer := func(t *testing.T, path string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+		authURL, err := url.Parse(rr.Header().Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, issuer.URL()+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+
+		callback := url.Values{"code": {issuer.Code}, "state": {authURL.Query().Get("state")}}
+		req, err = http.NewRequest(http.MethodGet, "/v1/auth/sso/callback?"+callback.Encode(), nil)
+		require.NoError(t, err)
+		for _, cookie := range append(rr.Result().Cookies(), cookies...) {
+			req.AddCookie(cookie)
+		}
+		return testutils.ExecuteRequest(req, mux)
+	}
+
+	t.Run("returns status 404 for a provider that is not enabled", func(t *testing.T) {
+		for _, path := range []string{"/v1/auth/google/login", "/v1/auth/google/callback"} {
+			req, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, http.StatusNotFound, rr.Code, path)
+		}
+	})
+
+	t.Run("signs in with the oidc issuer and redirects to the frontend", func(t *testing.T) {
+		issuer.Subject = "issuer-user-1"
+
+		rr := goToIssuer(t, "/v1/auth/sso/login")
+		require.Equal(t, http.StatusPermanentRedirect, rr.Code)
+		assert.Equal(t, "http://sapphire.com/welcome", rr.Header().Get("Location"))
+		var newSession *http.Cookie
+		for _, cookie := range rr.Result().Cookies() {
+			if cookie.Name == service.AuthTokenKey {
+				newSession = cookie
+			}
+		}
+		require.NotNil(t, newSession)
+		assert.Equal(t, "new-session", newSession.Value)
+	})
+
+	t.Run("asks for a code when two-factor is on", func(t *testing.T) {
+		issuer.Subject = "issuer-user-5"
+
+		rr := goToIssuer(t, "/v1/auth/sso/login")
+		require.Equal(t, http.StatusAccepted, rr.Code)
+		for _, cookie := range rr.Result().Cookies() {
+			assert.NotEqual(t, service.AuthTokenKey, cookie.Name)
+		}
+
+		var response struct {
+			Data responses.SigninChallengeResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.True(t, response.Data.TwoFactorRequired)
+		assert.Equal(t, "pending", response.Data.Token)
+		app.Service.Auth.(*mocks.MockAuthService).AssertNotCalled(t, "GetCookieSession", twoFactorUser.ID, mock.Anything)
+	})
+
+	t.Run("returns status 409 when the e-mail belongs to another account", func(t *testing.T) {
+		issuer.Subject = "issuer-user-2"
+
+		rr := goToIssuer(t, "/v1/auth/sso/login")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		userService.AssertNotCalled(t, "LinkOAuth", mock.Anything, mock.Anything, withSubject("issuer-user-2"))
+	})
+
+	t.Run("returns status 401 when linking without a session", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/auth/sso/link", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("links the provider account to the signed in user", func(t *testing.T) {
+		issuer.Subject = "issuer-user-1"
+
+		rr := goToIssuer(t, "/v1/auth/sso/link", sessionCookie)
+		require.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+		assert.Equal(t, "http://sapphire.com/settings/accounts", rr.Header().Get("Location"))
+		userService.AssertCalled(t, "LinkOAuth", mock.Anything, user.ID, withSubject("issuer-user-1"))
+	})
+
+	t.Run("returns status 409 when the provider account is linked already", func(t *testing.T) {
+		issuer.Subject = "issuer-user-2"
+
+		rr := goToIssuer(t, "/v1/auth/sso/link", sessionCookie)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("does not link when the session is gone at the callback", func(t *testing.T) {
+		issuer.Subject = "issuer-user-1"
+
+		req, err := http.NewRequest(http.MethodGet, "/v1/auth/sso/link", nil)
+		require.NoError(t, err)
+		req.AddCookie(sessionCookie)
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusTemporaryRedirect, rr.Code)
+		authURL, err := url.Parse(rr.Header().Get("Location"))
+		require.NoError(t, err)
+
+		callback := url.Values{"code": {issuer.Code}, "state": {authURL.Query().Get("state")}}
+		req, err = http.NewRequest(http.MethodGet, "/v1/auth/sso/callback?"+callback.Encode(), nil)
+		require.NoError(t, err)
+		for _, cookie := range rr.Result().Cookies() {
+			req.AddCookie(cookie)
+		}
+		rr = testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("lists the linked accounts", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/auth/identities", nil)
+		require.NoError(t, err)
+		req.AddCookie(sessionCookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetOAuthAccountsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Data.Accounts, 1)
+		assert.Equal(t, "sso", response.Data.Accounts[0].Provider)
+		assert.Equal(t, "issuer-user-1", response.Data.Accounts[0].ProviderUserID)
+	})
+
+	t.Run("unlinks an account only when another way to sign in is left", func(t *testing.T) {
+		codes := map[string]int{
+			"issuer-user-1": http.StatusConflict,
+			"issuer-user-3": http.StatusNotFound,
+			"issuer-user-4": http.StatusNoContent,
+		}
+		for providerUserID, code := range codes {
+			req, err := http.NewRequest(http.MethodDelete, "/v1/auth/identities/sso/"+providerUserID, nil)
+			require.NoError(t, err)
+			req.AddCookie(sessionCookie)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, code, rr.Code, providerUserID)
+		}
+	})
+}
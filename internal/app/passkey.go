@@ -0,0 +1,306 @@
+package app
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/webauthn"
+)
+
+func newPasskeyResponse(passkey *models.Passkey) responses.PasskeyResponse {
+	response := responses.PasskeyResponse{
+		ID:        passkey.ID,
+		Name:      passkey.Name,
+		CreatedAt: passkey.CreatedAt,
+	}
+	if passkey.LastUsedAt.Valid {
+		response.LastUsedAt = &passkey.LastUsedAt.Time
+	}
+	return response
+}
+
+func passkeyTimeout(challenge *models.PasskeyChallenge) int64 {
+	return time.Until(challenge.ExpiresAt).Milliseconds()
+}
+
+// BeginPasskeyRegistrationHandler godoc
+//
+//	@Summary		Starts adding a passkey
+//	@Description	Answers with the options for navigator.credentials.create
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	responses.PasskeyCreationOptionsResponse
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/passkeys/register/begin [post]
+func (app *Application) beginPasskeyRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	creation, err := app.Service.Passkey.BeginRegistration(r.Context(), user)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	response := responses.PasskeyCreationOptionsResponse{
+		Challenge: base64.RawURLEncoding.EncodeToString(creation.Challenge.Challenge),
+		RP: responses.PasskeyRelyingPartyResponse{
+			ID:   app.Config.Auth.Passkey.RPID,
+			Name: app.Config.Auth.Passkey.RPName,
+		},
+		User: responses.PasskeyUserResponse{
+			ID:          base64.RawURLEncoding.EncodeToString(creation.UserHandle),
+			Name:        user.Username,
+			DisplayName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		},
+		PubKeyCredParams:   make([]responses.PasskeyCredentialParameter, len(webauthn.Algorithms)),
+		Timeout:            passkeyTimeout(creation.Challenge),
+		ExcludeCredentials: make([]responses.PasskeyCredentialDescriptor, len(creation.Passkeys)),
+		AuthenticatorSelection: responses.PasskeyAuthenticatorSelection{
+			ResidentKey:      "required",
+			UserVerification: "preferred",
+		},
+		Attestation: "none",
+	}
+	for idx, algorithm := range webauthn.Algorithms {
+		response.PubKeyCredParams[idx] = responses.PasskeyCredentialParameter{Type: "public-key", Alg: algorithm}
+	}
+	for idx, passkey := range creation.Passkeys {
+		response.ExcludeCredentials[idx] = responses.PasskeyCredentialDescriptor{
+			Type: "public-key",
+			ID:   base64.RawURLEncoding.EncodeToString(passkey.CredentialID),
+		}
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// FinishPasskeyRegistrationHandler godoc
+//
+//	@Summary		Adds a passkey
+//	@Description	Saves the credential returned by navigator.credentials.create
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		payloads.RegisterPasskeyPayload	true	"Passkey name and credential"
+//	@Success		201		{object}	responses.PasskeyResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/passkeys/register/finish [post]
+func (app *Application) finishPasskeyRegistrationHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.RegisterPasskeyPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	passkey, err := app.Service.Passkey.FinishRegistration(r.Context(), user, &payload)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidPayload, service.ErrInvalidPasskey:
+			app.BadRequestResponse(w, r, err)
+		case store.ErrConflict:
+			app.ConflictResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusCreated, newPasskeyResponse(passkey)); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// BeginPasskeyLoginHandler godoc
+//
+//	@Summary		Starts a passkey sign in
+//	@Description	Answers with the options for navigator.credentials.get
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	responses.PasskeyRequestOptionsResponse
+//	@Failure		500	{object}	error
+//	@Router			/auth/passkeys/login/begin [post]
+func (app *Application) beginPasskeyLoginHandler(w http.ResponseWriter, r *http.Request) {
+	challenge, err := app.Service.Passkey.BeginLogin(r.Context())
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	response := responses.PasskeyRequestOptionsResponse{
+		Challenge:        base64.RawURLEncoding.EncodeToString(challenge.Challenge),
+		RPID:             app.Config.Auth.Passkey.RPID,
+		Timeout:          passkeyTimeout(challenge),
+		UserVerification: "required",
+	}
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// FinishPasskeyLoginHandler godoc
+//
+//	@Summary		Signs in with a passkey
+//	@Description	Checks the assertion returned by navigator.credentials.get and creates the session
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	payloads.PasskeyAssertionPayload	true	"Passkey assertion"
+//	@Success		204		"user has signin"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/auth/passkeys/login/finish [post]
+func (app *Application) finishPasskeyLoginHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.PasskeyAssertionPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user, err := app.Service.Passkey.FinishLogin(r.Context(), &payload)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		case service.ErrInvalidPasskey:
+			app.UnauthorizedErrorResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	cookie, err := app.Service.Auth.GetCookieSession(user.ID, getSessionClient(r))
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	http.SetCookie(w, cookie)
+	httpio.NoContentResponse(w)
+}
+
+// GetPasskeysHandler godoc
+//
+//	@Summary		Lists the user passkeys
+//	@Description	Lists the passkeys of the authenticated user, the oldest first
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	responses.GetPasskeysResponse
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/passkeys [get]
+func (app *Application) getPasskeysHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	passkeys, err := app.Service.Passkey.GetFromUser(r.Context(), user.ID)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	response := responses.GetPasskeysResponse{Passkeys: make([]responses.PasskeyResponse, len(passkeys))}
+	for idx, passkey := range passkeys {
+		response.Passkeys[idx] = newPasskeyResponse(passkey)
+	}
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// RenamePasskeyHandler godoc
+//
+//	@Summary		Renames a passkey
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			passkeyID	path	int								true	"Passkey ID"
+//	@Param			payload		body	payloads.RenamePasskeyPayload	true	"New name"
+//	@Success		204			"passkey renamed"
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/passkeys/{passkeyID} [patch]
+func (app *Application) renamePasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	passkeyID, err := strconv.ParseInt(chi.URLParam(r, "passkeyID"), 10, 64)
+	if err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+	var payload payloads.RenamePasskeyPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	if err := app.Service.Passkey.Rename(r.Context(), user.ID, passkeyID, &payload); err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
+// DeletePasskeyHandler godoc
+//
+//	@Summary		Removes a passkey
+//	@Description	Removes a passkey of the authenticated user, it can no longer be used to sign in. A password, provider account or another passkey has to be left to sign in with
+//	@Tags			auth
+//	@Produce		json
+//	@Param			passkeyID	path	int	true	"Passkey ID"
+//	@Success		204			"passkey removed"
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		409			{object}	error	"last way to sign in"
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/passkeys/{passkeyID} [delete]
+func (app *Application) deletePasskeyHandler(w http.ResponseWriter, r *http.Request) {
+	passkeyID, err := strconv.ParseInt(chi.URLParam(r, "passkeyID"), 10, 64)
+	if err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	if err := app.Service.Passkey.Delete(r.Context(), user.ID, passkeyID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		case service.ErrLastLoginMethod:
+			app.ConflictResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasskeyHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	app.Config.Auth.Passkey.RPID = "sapphire.com"
+	app.Config.Auth.Passkey.Origin = "https://sapphire.com"
+	mux := app.Mount()
+
+	user := &models.User{ID: 1, Username: "hutao", FirstName: "Hu", LastName: "Tao"}
+	cookie := withTestSession(t, app, user, &models.Session{ID: "current", UserID: user.ID})
+
+	authenticator, err := testutils.NewAuthenticator(app.Config.Auth.Passkey.RPID, app.Config.Auth.Passkey.Origin)
+	require.NoError(t, err)
+	challenge := &models.PasskeyChallenge{
+		Challenge: []byte("challenge"),
+		Kind:      models.PasskeyLogin,
+		ExpiresAt: time.Now().Add(5 * time.Minute),
+	}
+	valid := authenticator.Login(challenge.Challenge)
+	forged := authenticator.Login(challenge.Challenge)
+	forged.Response.Signature = valid.Response.Signature
+
+	passkeyService := app.Service.Passkey.(*mocks.MockPasskeyService)
+	passkeyService.On("BeginLogin", mock.Anything).Return(challenge, nil)
+	passkeyService.On("FinishLogin", mock.Anything, &valid).Return(user, nil)
+	passkeyService.On("FinishLogin", mock.Anything, &forged).Return(nil, service.ErrInvalidPasskey)
+	passkeyService.On("BeginRegistration", mock.Anything, user).Return(&models.PasskeyCreation{
+		Challenge:  challenge,
+		User:       user,
+		UserHandle: []byte{0, 0, 0, 0, 0, 0, 0, 1},
+		Passkeys:   []*models.Passkey{{ID: 7, CredentialID: authenticator.CredentialID}},
+	}, nil)
+	passkeyService.On("GetFromUser", mock.Anything, user.ID).Return([]*models.Passkey{{ID: 7, Name: "Laptop"}}, nil)
+	passkeyService.On("Delete", mock.Anything, user.ID, int64(7)).Return(nil)
+	passkeyService.On("Delete", mock.Anything, user.ID, int64(8)).Return(store.ErrNotFound)
+	passkeyService.On("Delete", mock.Anything, user.ID, int64(9)).Return(service.ErrLastLoginMethod)
+
+	authService := app.Service.Auth.(*mocks.MockAuthService)
+	authService.On("GetCookieSession", user.ID, mock.Anything).Return(&http.Cookie{Name: service.AuthTokenKey, Value: "new-session"}, nil)
+
+	t.Run("returns status 401 on registration without a session", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/passkeys/register/begin", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("answers the creation options", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "/v1/auth/passkeys/register/begin", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.PasskeyCreationOptionsResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(challenge.Challenge), response.Data.Challenge)
+		assert.Equal(t, "sapphire.com", response.Data.RP.ID)
+		assert.Equal(t, "Hu Tao", response.Data.User.DisplayName)
+		require.Len(t, response.Data.ExcludeCredentials, 1)
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(authenticator.CredentialID), response.Data.ExcludeCredentials[0].ID)
+	})
+
+	t.Run("signs in with a passkey", func(t *testing.T) {
+		tests := []struct {
+			name      string
+			assertion payloads.PasskeyAssertionPayload
+			code      int
+		}{
+			{"valid", valid, http.StatusNoContent},
+			{"forged", forged, http.StatusUnauthorized},
+		}
+		for _, test := range tests {
+			body, err := json.Marshal(test.assertion)
+			require.NoError(t, err)
+			req, err := http.NewRequest(http.MethodPost, "/v1/auth/passkeys/login/finish", bytes.NewReader(body))
+			require.NoError(t, err)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, test.code, rr.Code, test.name)
+		}
+	})
+
+	t.Run("lists and removes passkeys", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/auth/passkeys", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+		var response struct {
+			Data responses.GetPasskeysResponse `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.Len(t, response.Data.Passkeys, 1)
+		assert.Equal(t, "Laptop", response.Data.Passkeys[0].Name)
+
+		tests := []struct {
+			path string
+			code int
+		}{
+			{"/v1/auth/passkeys/7", http.StatusNoContent},
+			{"/v1/auth/passkeys/8", http.StatusNotFound},
+			{"/v1/auth/passkeys/9", http.StatusConflict},
+			{"/v1/auth/passkeys/abc", http.StatusBadRequest},
+		}
+		for _, test := range tests {
+			req, err := http.NewRequest(http.MethodDelete, test.path, nil)
+			require.NoError(t, err)
+			req.AddCookie(cookie)
+
+			rr := testutils.ExecuteRequest(req, mux)
+			assert.Equal(t, test.code, rr.Code, test.path)
+		}
+	})
+}
@@ -18,6 +18,22 @@ func getPostFromCtx(r *http.Request) *models.Post {
 	return post
 }
 
+func newMentionsResponse(mentions []models.Mention) []responses.MentionResponse {
+	if len(mentions) == 0 {
+		return nil
+	}
+	response := make([]responses.MentionResponse, len(mentions))
+	for idx, mention := range mentions {
+		response[idx] = responses.MentionResponse{
+			UserID:   mention.UserID,
+			Username: mention.Username,
+			Start:    mention.Start,
+			End:      mention.End,
+		}
+	}
+	return response
+}
+
 // CreatePost godoc
 //
 //	@Summary		Creates a post
@@ -75,6 +91,7 @@ func (app *Application) createPostHandler(w http.ResponseWriter, r *http.Request
 		MediaURL:  post.Media.String,
 		CreatedAt: post.CreatedAt,
 		UserID:    user.ID,
+		Mentions:  newMentionsResponse(post.Mentions),
 	}
 	if err := httpio.JsonResponse(w, http.StatusCreated, response); err != nil {
 		app.InternalServerErrorResponse(w, r, err)
@@ -97,6 +114,22 @@ func (app *Application) createPostHandler(w http.ResponseWriter, r *http.Request
 //	@Router			/post/{postID} [get]
 func (app *Application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 	post := getPostFromCtx(r)
+
+	var viewerID int64
+	if viewer := getUserFromContext(r); viewer != nil {
+		viewerID = viewer.ID
+	}
+	reactions, err := app.Service.Reaction.GetSummary(r.Context(), post.ID, viewerID)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+	mentions, err := app.Service.Post.GetMentions(r.Context(), post)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
 	response := &responses.GetPostResponse{
 		Tittle:    post.Tittle,
 		Content:   post.Content,
@@ -110,6 +143,14 @@ func (app *Application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 			FirstName: post.User.FirstName,
 			LastName:  post.User.LastName,
 		},
+		Reactions: newReactionsResponse(reactions),
+		Mentions:  newMentionsResponse(mentions),
+	}
+	if post.RepostOfID.Valid {
+		response.RepostOf = &post.RepostOfID.Int64
+	}
+	if post.QuoteOfID.Valid {
+		response.QuoteOf = &post.QuoteOfID.Int64
 	}
 	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
 		app.InternalServerErrorResponse(w, r, err)
@@ -132,7 +173,7 @@ func (app *Application) getPostHandler(w http.ResponseWriter, r *http.Request) {
 //	@Router			/post/{postID} [delete]
 func (app *Application) deletePostHandler(w http.ResponseWriter, r *http.Request) {
 	post := getPostFromCtx(r)
-	if err := app.Service.Post.Delete(r.Context(), post.ID); err != nil {
+	if err := app.Service.Post.Delete(r.Context(), getUserFromContext(r), post); err != nil {
 		switch {
 		case errors.Is(err, store.ErrNotFound):
 			app.NotFoundResponse(w, r, err)
@@ -155,6 +196,7 @@ func (app *Application) deletePostHandler(w http.ResponseWriter, r *http.Request
 //	@Param			payload	body		models.UpdatePostPayload	true	"Update post payload"
 //	@Success		200		{object}	models.UpdatePostResponse
 //	@Failure		400		{object}	error
+//	@Failure		403		{object}	error
 //	@Failure		404		{object}	error
 //	@Failure		500		{object}	error
 //	@Security		ApiKeyAuth
@@ -167,10 +209,12 @@ func (app *Application) updatePostHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if err := app.Service.Post.Update(r.Context(), post, &payload); err != nil {
+	if err := app.Service.Post.Update(r.Context(), getUserFromContext(r), post, &payload); err != nil {
 		switch err {
 		case store.ErrNotFound:
 			app.NotFoundResponse(w, r, err)
+		case service.ErrOperationNotAllowed:
+			app.ForbiddenErrorResponse(w, r, err)
 		default:
 			app.InternalServerErrorResponse(w, r, err)
 		}
@@ -181,9 +225,130 @@ func (app *Application) updatePostHandler(w http.ResponseWriter, r *http.Request
 		Tittle:    post.Tittle,
 		Content:   post.Content,
 		UpdatedAt: post.UpdatedAt,
+		Mentions:  newMentionsResponse(post.Mentions),
 	}
 	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
 		app.InternalServerErrorResponse(w, r, err)
 		return
 	}
 }
+
+// RepostPost godoc
+//
+//	@Summary		Reposts a post
+//	@Description	Boosts a post into the feed of the user's followers
+//	@Tags			post
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int	true	"Post ID"
+//	@Success		201		{object}	responses.CreatePostResponse
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/post/{postID}/repost [post]
+func (app *Application) repostPostHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	post := getPostFromCtx(r)
+	repost, err := app.Service.Post.Repost(r.Context(), user, post)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrConflict):
+			app.ConflictResponse(w, r, err)
+		case errors.Is(err, store.ErrNotFound), errors.Is(err, store.ErrForeignKeyViolation):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	response := &responses.CreatePostResponse{
+		ID:        repost.ID,
+		CreatedAt: repost.CreatedAt,
+		UserID:    user.ID,
+		RepostOf:  &repost.RepostOfID.Int64,
+	}
+	if err := httpio.JsonResponse(w, http.StatusCreated, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// UndoRepost godoc
+//
+//	@Summary		Undoes a repost
+//	@Description	Removes the repost the user made of a post
+//	@Tags			post
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path	int	true	"Post ID"
+//	@Success		204		"Repost removed"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/post/{postID}/repost [delete]
+func (app *Application) undoRepostHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	post := getPostFromCtx(r)
+	if err := app.Service.Post.Unrepost(r.Context(), user, post); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
+// QuotePost godoc
+//
+//	@Summary		Quotes a post
+//	@Description	Creates a new post with its own content that references another one
+//	@Tags			post
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int							true	"Post ID"
+//	@Param			payload	body		payloads.QuotePostPayload	true	"Quote payload"
+//	@Success		201		{object}	responses.CreatePostResponse
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/post/{postID}/quote [post]
+func (app *Application) quotePostHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.QuotePostPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	post := getPostFromCtx(r)
+	quote, err := app.Service.Post.Quote(r.Context(), user, post, &payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPayload):
+			app.BadRequestResponse(w, r, err)
+		case errors.Is(err, store.ErrNotFound), errors.Is(err, store.ErrForeignKeyViolation):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	response := &responses.CreatePostResponse{
+		ID:        quote.ID,
+		Tittle:    quote.Tittle,
+		Content:   quote.Content,
+		CreatedAt: quote.CreatedAt,
+		UserID:    user.ID,
+		QuoteOf:   &quote.QuoteOfID.Int64,
+		Mentions:  newMentionsResponse(quote.Mentions),
+	}
+	if err := httpio.JsonResponse(w, http.StatusCreated, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
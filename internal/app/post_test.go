@@ -1,13 +1,16 @@
 package app
 
 import (
+	"database/sql"
 	"encoding/json"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/mochaeng/sapphire-backend/internal/mocks"
 	"github.com/mochaeng/sapphire-backend/internal/models"
 	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
 	"github.com/mochaeng/sapphire-backend/internal/store"
 	"github.com/mochaeng/sapphire-backend/internal/testutils"
 	"github.com/stretchr/testify/assert"
@@ -25,17 +28,52 @@ func TestGetPostHandler(t *testing.T) {
 		Content: "This is a sample post content",
 		User:    &models.User{ID: 1, Username: "testuser"},
 	}
+	quotePost := &models.Post{
+		ID:        102,
+		Content:   "look at this",
+		User:      &models.User{ID: 2, Username: "chaee"},
+		QuoteOfID: sql.NullInt64{Int64: 101, Valid: true},
+	}
 	nonExistentID := 1
 	app.Service.Post.(*mocks.MockPostService).On(
 		"GetWithUser",
 		mock.Anything,
 		int64(101),
 	).Return(expectedPost, nil)
+	app.Service.Post.(*mocks.MockPostService).On(
+		"GetWithUser",
+		mock.Anything,
+		int64(102),
+	).Return(quotePost, nil)
+	app.Service.Post.(*mocks.MockPostService).On(
+		"GetMentions",
+		mock.Anything,
+		expectedPost,
+	).Return([]models.Mention{{UserID: 2, Username: "chaee", Start: 0, End: 6}}, nil)
+	app.Service.Post.(*mocks.MockPostService).On(
+		"GetMentions",
+		mock.Anything,
+		quotePost,
+	).Return(nil, nil)
 	app.Service.Post.(*mocks.MockPostService).On(
 		"GetWithUser",
 		mock.Anything,
 		int64(nonExistentID),
 	).Return(nil, store.ErrNotFound)
+	app.Service.Reaction.(*mocks.MockReactionService).On(
+		"GetSummary",
+		mock.Anything,
+		int64(101),
+		int64(0),
+	).Return(&models.ReactionSummary{
+		Counts: map[string]int{"like": 3, "love": 1},
+	}, nil)
+	app.Service.Reaction.(*mocks.MockReactionService).On(
+		"GetSummary",
+		mock.Anything,
+		int64(102),
+		int64(0),
+	).Return(&models.ReactionSummary{}, nil)
 
 	t.Run("returns status 200 for a existent post", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodGet, "/v1/post/101", nil)
@@ -54,6 +92,30 @@ func TestGetPostHandler(t *testing.T) {
 		assert.Equal(t, expectedPost.Tittle, response.Data.Tittle)
 		assert.Equal(t, expectedPost.Content, response.Data.Content)
 		assert.Equal(t, expectedPost.User.Username, response.Data.User.Username)
+		require.NotNil(t, response.Data.Reactions)
+		assert.Equal(t, 3, response.Data.Reactions.Counts["like"])
+		assert.Empty(t, response.Data.Reactions.ViewerReaction)
+		require.Len(t, response.Data.Mentions, 1)
+		assert.Equal(t, "chaee", response.Data.Mentions[0].Username)
+		assert.Equal(t, 6, response.Data.Mentions[0].End)
+	})
+
+	t.Run("returns the quoted post id of a quote post", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/post/102", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetPostResponse `json:"data"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&response)
+		require.NoError(t, err, "failed to decode response: %v", err)
+
+		require.NotNil(t, response.Data.QuoteOf)
+		assert.Equal(t, int64(101), *response.Data.QuoteOf)
+		assert.Nil(t, response.Data.RepostOf)
 	})
 
 	t.Run("returns status 404 for a non-existent post", func(t *testing.T) {
@@ -65,3 +127,26 @@ func TestGetPostHandler(t *testing.T) {
 		assert.JSONEq(t, `{"error":"not found"}`, rr.Body.String())
 	})
 }
+
+func TestUpdatePostHandler(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	user := &models.User{ID: 1, Username: "hutao"}
+	cookie := withTestSession(t, app, user, &models.Session{ID: "hutao", UserID: user.ID})
+	repost := &models.Post{ID: 103, User: user, RepostOfID: sql.NullInt64{Int64: 101, Valid: true}}
+	postService := app.Service.Post.(*mocks.MockPostService)
+	postService.On("GetWithUser", mock.Anything, repost.ID).Return(repost, nil)
+	postService.On("Update", mock.Anything, user, repost, mock.Anything).Return(service.ErrOperationNotAllowed)
+
+	t.Run("returns status 403 when editing a repost", func(t *testing.T) {
+		body := strings.NewReader(`{"content": "edited"}`)
+		req, err := http.NewRequest(http.MethodPatch, "/v1/post/103", body)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+func newReactionsResponse(summary *models.ReactionSummary) *responses.ReactionsResponse {
+	counts := summary.Counts
+	if counts == nil {
+		counts = map[string]int{}
+	}
+	return &responses.ReactionsResponse{
+		Counts:         counts,
+		ViewerReaction: summary.ViewerReaction,
+	}
+}
+
+// ReactToPost godoc
+//
+//	@Summary		Reacts to a post
+//	@Description	Leaves a reaction on a post, replacing the previous one the user had left
+//	@Tags			post
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path		int						true	"Post ID"
+//	@Param			payload	body		payloads.ReactPayload	true	"Reaction kind"
+//	@Success		200		{object}	responses.ReactionsResponse
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/post/{postID}/reactions [put]
+func (app *Application) reactToPostHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.ReactPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	post := getPostFromCtx(r)
+	summary, err := app.Service.Reaction.React(r.Context(), user.ID, post.ID, payload.Kind)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPayload):
+			app.BadRequestResponse(w, r, err)
+		case errors.Is(err, store.ErrNotFound), errors.Is(err, store.ErrForeignKeyViolation):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, newReactionsResponse(summary)); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// RemovePostReaction godoc
+//
+//	@Summary		Removes a reaction from a post
+//	@Description	Removes the reaction the user had left on a post
+//	@Tags			post
+//	@Accept			json
+//	@Produce		json
+//	@Param			postID	path	int	true	"Post ID"
+//	@Success		204		"Reaction removed"
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/post/{postID}/reactions [delete]
+func (app *Application) removePostReactionHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	post := getPostFromCtx(r)
+	if err := app.Service.Reaction.Unreact(r.Context(), user.ID, post.ID); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReactionHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	user := &models.User{ID: 2, Username: "chaee"}
+	cookie := withTestSession(t, app, user, &models.Session{ID: "chaee", UserID: user.ID})
+	post := &models.Post{ID: 101, User: &models.User{ID: 1, Username: "momo"}}
+
+	app.Service.Post.(*mocks.MockPostService).On("GetWithUser", mock.Anything, post.ID).Return(post, nil)
+	reactionService := app.Service.Reaction.(*mocks.MockReactionService)
+	reactionService.On("React", mock.Anything, user.ID, post.ID, "love").Return(
+		&models.ReactionSummary{Counts: map[string]int{"love": 2}, ViewerReaction: "love"}, nil,
+	)
+	reactionService.On("React", mock.Anything, user.ID, post.ID, "angry").Return(nil, service.ErrInvalidPayload)
+
+	react := func(kind string) *http.Request {
+		req, err := http.NewRequest(http.MethodPut, "/v1/post/101/reactions", bytes.NewBufferString(`{"kind":"`+kind+`"}`))
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+		return req
+	}
+
+	t.Run("returns the summary after reacting", func(t *testing.T) {
+		rr := testutils.ExecuteRequest(react("love"), mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.ReactionsResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, map[string]int{"love": 2}, response.Data.Counts)
+		assert.Equal(t, "love", response.Data.ViewerReaction)
+	})
+
+	t.Run("returns status 400 for an unknown kind", func(t *testing.T) {
+		rr := testutils.ExecuteRequest(react("angry"), mux)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns status 204 after removing the reaction", func(t *testing.T) {
+		reactionService.On("Unreact", mock.Anything, user.ID, post.ID).Return(nil).Once()
+		req, err := http.NewRequest(http.MethodDelete, "/v1/post/101/reactions", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	})
+
+	t.Run("returns status 404 when there is no reaction to remove", func(t *testing.T) {
+		reactionService.On("Unreact", mock.Anything, user.ID, post.ID).Return(store.ErrNotFound).Once()
+		req, err := http.NewRequest(http.MethodDelete, "/v1/post/101/reactions", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("returns status 401 without a session", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, "/v1/post/101/reactions", bytes.NewBufferString(`{"kind":"love"}`))
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
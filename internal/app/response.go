@@ -42,6 +42,11 @@ func (app *Application) ForbiddenErrorResponse(w http.ResponseWriter, r *http.Re
 	httpio.WriteJSONWithError(w, http.StatusForbidden, "forbidden")
 }
 
+func (app *Application) ServiceUnavailableResponse(w http.ResponseWriter, r *http.Request, err error) {
+	app.Logger.Warnw("service unavailable error", "path", r.URL.Path, "method", r.Method, "error", err)
+	httpio.WriteJSONWithError(w, http.StatusServiceUnavailable, err.Error())
+}
+
 func (app *Application) RateLimitExceededResponse(w http.ResponseWriter, r *http.Request, retryAfter string) {
 	app.Logger.Warn("rate limit exceed", "method", r.Method, "path", r.URL.Path)
 	w.Header().Set("Retry-After", retryAfter)
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+)
+
+// Search godoc
+//
+//	@Summary		Searches posts or users
+//	@Description	Ranks posts by title, content and tags, or users by username and name
+//	@Tags			search
+//	@Accept			json
+//	@Produce		json
+//	@Param			q			query		string	true	"Search terms"
+//	@Param			type		query		string	false	"posts (default) or users"
+//	@Param			author		query		string	false	"Only posts from this username"
+//	@Param			tag			query		string	false	"Only posts with this tag"
+//	@Param			since		query		string	false	"Only posts created from this date"
+//	@Param			until		query		string	false	"Only posts created before this date"
+//	@Param			has_media	query		bool	false	"Only posts with or without media"
+//	@Param			limit		query		string	false	"Limit"
+//	@Param			cursor		query		string	false	"Cursor"
+//	@Success		200			{object}	responses.SearchResponse
+//	@Failure		400			{object}	error
+//	@Failure		500			{object}	error
+//	@Router			/search [get]
+func (app *Application) searchHandler(w http.ResponseWriter, r *http.Request) {
+	var search pagination.Search
+	if err := search.Parse(r.URL.Query()); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var response responses.SearchResponse
+	switch search.Type {
+	case pagination.SearchTypeUsers:
+		users, err := app.Service.Search.Users(r.Context(), &search)
+		if err != nil {
+			app.InternalServerErrorResponse(w, r, err)
+			return
+		}
+		response.Users = make([]responses.UserResponse, len(users))
+		for idx, user := range users {
+			response.Users[idx] = responses.UserResponse{
+				ID:        user.ID,
+				Username:  user.Username,
+				FirstName: user.FirstName,
+				LastName:  user.LastName,
+			}
+		}
+	default:
+		posts, err := app.Service.Search.Posts(r.Context(), &search)
+		if err != nil {
+			switch err {
+			case service.ErrInvalidPayload:
+				app.BadRequestResponse(w, r, err)
+			default:
+				app.InternalServerErrorResponse(w, r, err)
+			}
+			return
+		}
+		response.Posts = make([]responses.PostResponse, len(posts))
+		for idx, post := range posts {
+			response.Posts[idx] = responses.PostResponse{
+				ID:        post.ID,
+				Tittle:    post.Tittle,
+				Content:   post.Content,
+				Tags:      post.Tags,
+				MediaURL:  post.Media.String,
+				CreatedAt: post.CreatedAt,
+				UpdatedAt: post.UpdatedAt,
+				User: &responses.UserResponse{
+					ID:        post.User.ID,
+					Username:  post.User.Username,
+					FirstName: post.User.FirstName,
+					LastName:  post.User.LastName,
+				},
+			}
+		}
+	}
+	response.NextCursor = search.NextCursor
+
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchHandler(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.Mount()
+
+	posts := []*models.Post{
+		{
+			ID:      5,
+			Tittle:  "chaeyoung <3",
+			Content: "chaeyoung > lalisa",
+			Tags:    []string{"twice"},
+			User:    &models.User{ID: 1, Username: "testuser"},
+		},
+	}
+	searchService := app.Service.Search.(*mocks.MockSearchService)
+	searchService.On("Posts", mock.Anything, mock.MatchedBy(func(s *pagination.Search) bool {
+		return s.Query == "chaeyoung" && s.Tag.Valid && s.Tag.String == "twice" && !s.HasMedia.Valid
+	})).Return(posts, nil)
+	searchService.On("Users", mock.Anything, mock.MatchedBy(func(s *pagination.Search) bool {
+		return s.Query == "chae"
+	})).Return([]*models.User{{ID: 2, Username: "chaee"}}, nil)
+
+	t.Run("returns the ranked posts", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/search?q=chaeyoung&tag=twice", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.SearchResponse `json:"data"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&response)
+		require.NoError(t, err, "failed to decode response: %v", err)
+
+		require.Len(t, response.Data.Posts, 1)
+		assert.Equal(t, int64(5), response.Data.Posts[0].ID)
+		assert.Empty(t, response.Data.Users)
+	})
+
+	t.Run("returns the ranked users", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/search?q=chae&type=users", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.SearchResponse `json:"data"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&response)
+		require.NoError(t, err, "failed to decode response: %v", err)
+
+		require.Len(t, response.Data.Users, 1)
+		assert.Equal(t, "chaee", response.Data.Users[0].Username)
+	})
+
+	t.Run("returns status 400 without search terms", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/search", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns status 400 for a malformed cursor", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/search?q=chaeyoung&cursor=yesterday", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+)
+
+// GetTagPosts godoc
+//
+//	@Summary		Gets the posts of a tag
+//	@Description	Gets the posts using a tag, the most recent first
+//	@Tags			tag
+//	@Accept			json
+//	@Produce		json
+//	@Param			tag		path		string	true	"Tag"
+//	@Param			limit	query		string	false	"Limit"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Success		200		{object}	responses.TagPostsResponse
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/tags/{tag}/posts [get]
+func (app *Application) getTagPostsHandler(w http.ResponseWriter, r *http.Request) {
+	tagPosts := pagination.TagPosts{Tag: chi.URLParam(r, "tag")}
+	query := r.URL.Query()
+	if err := tagPosts.Parse(query.Get("limit"), query.Get("cursor")); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	posts, err := app.Service.Tag.GetPosts(r.Context(), &tagPosts)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	var response responses.TagPostsResponse
+	response.Tag = tagPosts.Tag
+	response.Posts = make([]responses.PostResponse, len(posts))
+	response.NextCursor = tagPosts.NextCursor
+	for idx, post := range posts {
+		response.Posts[idx] = responses.PostResponse{
+			ID:        post.ID,
+			Tittle:    post.Tittle,
+			Content:   post.Content,
+			Tags:      post.Tags,
+			MediaURL:  post.Media.String,
+			CreatedAt: post.CreatedAt,
+			UpdatedAt: post.UpdatedAt,
+			User: &responses.UserResponse{
+				ID:        post.User.ID,
+				Username:  post.User.Username,
+				FirstName: post.User.FirstName,
+				LastName:  post.User.LastName,
+			},
+		}
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// GetTrendingTags godoc
+//
+//	@Summary		Gets the trending tags
+//	@Description	Gets the most used tags inside the trending window, refreshed periodically
+//	@Tags			tag
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		string	false	"Limit"
+//	@Success		200		{object}	responses.TrendingTagsResponse
+//	@Failure		400		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/tags/trending [get]
+func (app *Application) getTrendingTagsHandler(w http.ResponseWriter, r *http.Request) {
+	limit, err := pagination.ParseTrendingLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	tags, err := app.Service.Tag.GetTrending(r.Context(), limit)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	var response responses.TrendingTagsResponse
+	response.Tags = make([]responses.TrendingTagResponse, len(tags))
+	for idx, tag := range tags {
+		response.Tags[idx] = responses.TrendingTagResponse{
+			Tag:       tag.Tag,
+			PostCount: tag.PostCount,
+			UserCount: tag.UserCount,
+		}
+	}
+	if len(tags) > 0 {
+		response.ComputedAt = &tags[0].ComputedAt
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagHandlers(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.Mount()
+
+	computedAt := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+	tagService := app.Service.Tag.(*mocks.MockTagService)
+	tagService.On("GetTrending", mock.Anything, pagination.TrendingTagsLimitDefault).Return(
+		[]*models.TrendingTag{
+			{Tag: "twice", PostCount: 12, UserCount: 7, ComputedAt: computedAt},
+			{Tag: "kpop", PostCount: 9, UserCount: 4, ComputedAt: computedAt},
+		}, nil,
+	)
+	tagService.On("GetPosts", mock.Anything, mock.MatchedBy(func(q *pagination.TagPosts) bool {
+		return q.Tag == "twice"
+	})).Return([]*models.Post{
+		{ID: 3, Content: "fancy", Tags: []string{"twice"}, User: &models.User{ID: 1, Username: "testuser"}},
+	}, nil)
+
+	t.Run("returns the trending tags in order", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/tags/trending", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.TrendingTagsResponse `json:"data"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&response)
+		require.NoError(t, err, "failed to decode response: %v", err)
+
+		require.Len(t, response.Data.Tags, 2)
+		assert.Equal(t, "twice", response.Data.Tags[0].Tag)
+		require.NotNil(t, response.Data.ComputedAt)
+		assert.True(t, computedAt.Equal(*response.Data.ComputedAt))
+	})
+
+	t.Run("returns the posts of a tag", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/tags/twice/posts", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.TagPostsResponse `json:"data"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&response)
+		require.NoError(t, err, "failed to decode response: %v", err)
+
+		assert.Equal(t, "twice", response.Data.Tag)
+		require.Len(t, response.Data.Posts, 1)
+		assert.Equal(t, int64(3), response.Data.Posts[0].ID)
+	})
+}
@@ -1,10 +1,15 @@
 package app
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/events"
 	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 )
 
@@ -21,5 +26,26 @@ func newTestApplication(t *testing.T) *Application {
 		Config:  &config.Cfg{},
 		Service: &mockService,
 		Logger:  logger,
+		Events:  events.NewLocalBroker(),
 	}
 }
+
+// withTestSession makes the mocked services accept a session for the user and
+// returns the cookie to send with the requests
+func withTestSession(t *testing.T, app *Application, user *models.User, session *models.Session) *http.Cookie {
+	t.Helper()
+
+	token := "token-" + session.ID
+	app.Service.Auth.(*mocks.MockAuthService).On(
+		"ValidateSessionToken",
+		token,
+		mock.Anything,
+	).Return(session, nil)
+	app.Service.User.(*mocks.MockUserService).On(
+		"GetCached",
+		mock.Anything,
+		user.ID,
+	).Return(user, nil)
+
+	return &http.Cookie{Name: services.AuthTokenKey, Value: token}
+}
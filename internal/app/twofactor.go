@@ -0,0 +1,183 @@
+package app
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+// SetupTwoFactorHandler godoc
+//
+//	@Summary		Starts two-factor setup
+//	@Description	Creates a new TOTP secret and its provisioning URI for an authenticator app. Two-factor is only turned on after a code is confirmed
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	responses.TwoFactorSetupResponse
+//	@Failure		401	{object}	error
+//	@Failure		409	{object}	error
+//	@Failure		500	{object}	error
+//	@Failure		503	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/2fa/setup [post]
+func (app *Application) setupTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	setup, err := app.Service.TwoFactor.Setup(r.Context(), user)
+	if err != nil {
+		switch err {
+		case store.ErrConflict:
+			app.ConflictResponse(w, r, err)
+		case service.ErrTwoFactorUnavailable:
+			app.ServiceUnavailableResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	response := responses.TwoFactorSetupResponse{Secret: setup.Secret, URI: setup.URI}
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// EnableTwoFactorHandler godoc
+//
+//	@Summary		Turns two-factor on
+//	@Description	Confirms a code from the authenticator app and answers with the recovery codes, which are only shown once
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body		payloads.EnableTwoFactorPayload	true	"Authenticator code"
+//	@Success		200		{object}	responses.RecoveryCodesResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Failure		503		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/2fa/enable [post]
+func (app *Application) enableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.EnableTwoFactorPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	codes, err := app.Service.TwoFactor.Enable(r.Context(), user, &payload)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidPayload, service.ErrInvalidTwoFactorCode:
+			app.BadRequestResponse(w, r, err)
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		case store.ErrConflict:
+			app.ConflictResponse(w, r, err)
+		case service.ErrTwoFactorUnavailable:
+			app.ServiceUnavailableResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, responses.RecoveryCodesResponse{RecoveryCodes: codes}); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+// DisableTwoFactorHandler godoc
+//
+//	@Summary		Turns two-factor off
+//	@Description	Removes the TOTP secret and the recovery codes after checking the user password
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	payloads.DisableTwoFactorPayload	true	"User password"
+//	@Success		204		"two-factor turned off"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/auth/2fa/disable [post]
+func (app *Application) disableTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.DisableTwoFactorPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	if err := app.Service.TwoFactor.Disable(r.Context(), user, &payload); err != nil {
+		switch err {
+		case service.ErrInvalidPayload, service.ErrInvalidCredentials:
+			app.BadRequestResponse(w, r, err)
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
+// VerifyTwoFactorHandler godoc
+//
+//	@Summary		Finishes a two-factor sign in
+//	@Description	Checks the authenticator or recovery code of a sign in that asked for two-factor and creates the session
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	payloads.VerifyTwoFactorPayload	true	"Sign in token and code"
+//	@Success		204		"user has signin"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		429		{object}	error	"too many failed sign ins"
+//	@Failure		500		{object}	error
+//	@Failure		503		{object}	error
+//	@Router			/auth/2fa/verify [post]
+func (app *Application) verifyTwoFactorHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.VerifyTwoFactorPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user, err := app.Service.TwoFactor.CompleteLogin(r.Context(), &payload, getSessionClient(r).IP)
+	if err != nil {
+		var throttled *service.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			retryAfter := int64(math.Ceil(throttled.RetryAfter.Seconds()))
+			app.RateLimitExceededResponse(w, r, strconv.FormatInt(retryAfter, 10))
+		case err == service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		case err == service.ErrInvalidTwoFactorCode, err == service.ErrInvalidPendingLogin:
+			app.UnauthorizedErrorResponse(w, r, err)
+		case err == service.ErrTwoFactorUnavailable:
+			app.ServiceUnavailableResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	cookie, err := app.Service.Auth.GetCookieSession(user.ID, getSessionClient(r))
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	http.SetCookie(w, cookie)
+	httpio.NoContentResponse(w)
+}
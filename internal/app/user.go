@@ -6,10 +6,13 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/mochaeng/sapphire-backend/internal/config"
 	"github.com/mochaeng/sapphire-backend/internal/httpio"
+	"github.com/mochaeng/sapphire-backend/internal/models"
 	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
 	"github.com/mochaeng/sapphire-backend/internal/models/responses"
-	"github.com/mochaeng/sapphire-backend/internal/services"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
 	"github.com/mochaeng/sapphire-backend/internal/store"
 )
 
@@ -160,6 +163,81 @@ func (app *Application) unfollowUserHandler(w http.ResponseWriter, r *http.Reque
 	httpio.NoContentResponse(w)
 }
 
+// BlockUser godoc
+//
+//	@Summary		Blocks a user
+//	@Description	Blocks a user, removing the follows between both and stopping their private messages
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path	int	true	"User ID"
+//	@Success		204		"User blocked"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/user/{userID}/block [put]
+func (app *Application) blockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	blockedID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.Service.User.Block(r.Context(), user.ID, blockedID); err != nil {
+		switch err {
+		case service.ErrOperationNotAllowed:
+			app.BadRequestResponse(w, r, err)
+		case store.ErrConflict:
+			app.ConflictResponse(w, r, err)
+		case store.ErrForeignKeyViolation:
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	httpio.NoContentResponse(w)
+}
+
+// UnblockUser godoc
+//
+//	@Summary		Unblocks a user
+//	@Description	Removes a block the user made
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			userID	path	int	true	"User ID"
+//	@Success		204		"User unblocked"
+//	@Failure		400		{object}	error
+//	@Failure		404		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/user/{userID}/unblock [put]
+func (app *Application) unblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	blockedID, err := strconv.ParseInt(chi.URLParam(r, "userID"), 10, 64)
+	if err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	if err := app.Service.User.Unblock(r.Context(), user.ID, blockedID); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	httpio.NoContentResponse(w)
+}
+
 // ActiveUser godoc
 //
 //	@Summary		Activates a user in the application
@@ -190,6 +268,75 @@ func (app *Application) activateUserHandler(w http.ResponseWriter, r *http.Reque
 	httpio.NoContentResponse(w)
 }
 
+// ChangeUserEmail godoc
+//
+//	@Summary		Changes the user e-mail
+//	@Description	Sends a confirmation link to the new address and a notice to the current one. The account keeps the current address until the link is used
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			payload	body	payloads.ChangeEmailPayload	true	"New e-mail"
+//	@Success		204		"confirmation e-mail sent"
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/user/email [put]
+func (app *Application) changeUserEmailHandler(w http.ResponseWriter, r *http.Request) {
+	var payload payloads.ChangeEmailPayload
+	if err := httpio.ReadJSON(w, r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	if err := app.Service.Auth.RequestEmailChange(r.Context(), user, &payload); err != nil {
+		switch err {
+		case service.ErrInvalidPayload:
+			app.BadRequestResponse(w, r, err)
+		case store.ErrDuplicateEmail:
+			app.ConflictResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
+// ConfirmUserEmail godoc
+//
+//	@Summary		Confirms a new e-mail
+//	@Description	Moves the account to the new address using the token sent to it
+//	@Tags			user
+//	@Produce		json
+//	@Param			token	path		string	true	"E-mail change token"
+//	@Success		204		{string}	string	"E-mail changed"
+//	@Failure		404		{object}	error
+//	@Failure		409		{object}	error
+//	@Failure		500		{object}	error
+//	@Router			/verify-email/change/{token} [put]
+func (app *Application) confirmUserEmailHandler(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	if token == "" {
+		app.BadRequestResponse(w, r, httpio.ErrEmptyParam)
+		return
+	}
+	if err := app.Service.Auth.ConfirmEmailChange(r.Context(), token); err != nil {
+		switch err {
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		case store.ErrDuplicateEmail:
+			app.ConflictResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+	httpio.NoContentResponse(w)
+}
+
 // GetUserProfileByUsername godoc
 //
 //	@Summary		Fetches a user profile
@@ -214,14 +361,83 @@ func (app *Application) getUserProfile(w http.ResponseWriter, r *http.Request) {
 		switch err {
 		case store.ErrNotFound:
 			app.NotFoundResponse(w, r, err)
-		case services.ErrInvalidPayload:
+		case service.ErrInvalidPayload:
 			app.BadRequestResponse(w, r, err)
 		default:
 			app.InternalServerErrorResponse(w, r, err)
 		}
 		return
 	}
-	response := &responses.GetUserProfileResponse{
+	response := newUserProfileResponse(userProfile)
+	if err := httpio.JsonResponse(w, http.StatusCreated, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+}
+
+// UpdateUserProfile godoc
+//
+//	@Summary		Updates the user profile
+//	@Description	Updates the profile of the authenticated user. Only the fields sent are changed, and an empty value clears the field
+//	@Tags			user
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			description	formData	string	false	"Description"
+//	@Param			location	formData	string	false	"Location"
+//	@Param			user_link	formData	string	false	"Link to an external page"
+//	@Param			avatar		formData	file	false	"Avatar image"
+//	@Param			banner		formData	file	false	"Banner image"
+//	@Success		200			{object}	responses.GetUserProfileResponse
+//	@Failure		400			{object}	error
+//	@Failure		401			{object}	error
+//	@Failure		404			{object}	error
+//	@Failure		500			{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/user/profile [patch]
+func (app *Application) updateUserProfileHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(config.MaxMediaUploadSize); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	var payload payloads.UpdateUserPayload
+	if err := httpio.ReadFormDataValues(r, &payload); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	avatar, err := httpio.ReadFormFile(r, "avatar", config.MaxMediaUploadSize)
+	if err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+	banner, err := httpio.ReadFormFile(r, "banner", config.MaxMediaUploadSize)
+	if err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	user := getUserFromContext(r)
+	userProfile, err := app.Service.User.UpdateProfile(r.Context(), user, &payload, avatar, banner)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidPayload, service.ErrSaveFile:
+			app.BadRequestResponse(w, r, err)
+		case store.ErrNotFound:
+			app.NotFoundResponse(w, r, err)
+		default:
+			app.InternalServerErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, newUserProfileResponse(userProfile)); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
+
+func newUserProfileResponse(userProfile *models.UserProfile) *responses.GetUserProfileResponse {
+	return &responses.GetUserProfileResponse{
 		Username:      userProfile.User.Username,
 		FirstName:     userProfile.User.FirstName,
 		LastName:      userProfile.User.LastName,
@@ -237,10 +453,6 @@ func (app *Application) getUserProfile(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:     userProfile.CreatedAt,
 		UpdatedAt:     userProfile.UpdatedAt,
 	}
-	if err := httpio.JsonResponse(w, http.StatusCreated, response); err != nil {
-		app.InternalServerErrorResponse(w, r, err)
-		return
-	}
 }
 
 func (app *Application) getUserPosts(w http.ResponseWriter, r *http.Request) {
@@ -256,6 +468,9 @@ func (app *Application) getUserPosts(w http.ResponseWriter, r *http.Request) {
 
 	userPosts := pagination.UserPosts{}
 	userPosts.Parser(limitParam, cursorParam)
+	if user := getUserFromContext(r); user != nil {
+		userPosts.ViewerID = user.ID
+	}
 
 	posts, err := app.Service.User.GetPostsFromUsername(r.Context(), username, &userPosts)
 	if err != nil {
@@ -274,13 +489,15 @@ func (app *Application) getUserPosts(w http.ResponseWriter, r *http.Request) {
 
 	for idx, post := range posts {
 		response.Posts[idx] = responses.PostResponse{
-			ID:        post.ID,
-			Tittle:    post.Tittle,
-			Content:   post.Content,
-			MediaURL:  post.Media.String,
-			Tags:      post.Tags,
-			CreatedAt: post.CreatedAt,
-			UpdatedAt: post.UpdatedAt,
+			ID:           post.ID,
+			Tittle:       post.Tittle,
+			Content:      post.Content,
+			MediaURL:     post.Media.String,
+			Tags:         post.Tags,
+			CreatedAt:    post.CreatedAt,
+			UpdatedAt:    post.UpdatedAt,
+			CommentCount: post.CommentCount,
+			Reactions:    newReactionsResponse(&post.Reactions),
 		}
 	}
 
@@ -289,3 +506,67 @@ func (app *Application) getUserPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// GetUserMentions godoc
+//
+//	@Summary		Gets the mentions of the user
+//	@Description	Gets the posts and comments where the authenticated user was mentioned, the most recent first
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Param			limit	query		string	false	"Limit"
+//	@Param			cursor	query		string	false	"Cursor"
+//	@Success		200		{object}	responses.GetUserMentionsResponse
+//	@Failure		400		{object}	error
+//	@Failure		401		{object}	error
+//	@Failure		500		{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/user/mentions [get]
+func (app *Application) getUserMentionsHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+	userMentions := pagination.UserMentions{UserID: user.ID}
+	query := r.URL.Query()
+	if err := userMentions.Parse(query.Get("limit"), query.Get("cursor")); err != nil {
+		app.BadRequestResponse(w, r, err)
+		return
+	}
+
+	mentions, err := app.Service.User.GetMentions(r.Context(), &userMentions)
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+
+	var response responses.GetUserMentionsResponse
+	response.Mentions = make([]responses.UserMentionResponse, len(mentions))
+	response.NextCursor = userMentions.NextCursor
+	for idx, mention := range mentions {
+		post := mention.Post
+		mentionResponse := responses.UserMentionResponse{
+			Post: responses.PostResponse{
+				ID:        post.ID,
+				Tittle:    post.Tittle,
+				Content:   post.Content,
+				MediaURL:  post.Media.String,
+				CreatedAt: post.CreatedAt,
+				UpdatedAt: post.UpdatedAt,
+				User: &responses.UserResponse{
+					ID:        post.User.ID,
+					Username:  post.User.Username,
+					FirstName: post.User.FirstName,
+					LastName:  post.User.LastName,
+				},
+			},
+			CreatedAt: mention.CreatedAt,
+		}
+		if mention.Comment != nil {
+			commentResponse := newCommentResponse(mention.Comment)
+			mentionResponse.Comment = &commentResponse
+		}
+		response.Mentions[idx] = mentionResponse
+	}
+
+	if err := httpio.JsonResponse(w, http.StatusOK, response); err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,150 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	service "github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newProfileForm builds the multipart body of a profile update
+func newProfileForm(t *testing.T, fields map[string]string, files map[string][]byte) (*bytes.Buffer, string) {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		require.NoError(t, writer.WriteField(name, value))
+	}
+	for name, content := range files {
+		part, err := writer.CreateFormFile(name, name+".png")
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return body, writer.FormDataContentType()
+}
+
+func TestUpdateUserProfileHandler(t *testing.T) {
+	app := newTestApplication(t)
+	app.Config.Env = "dev"
+	mux := app.Mount()
+
+	user := &models.User{ID: 1, Username: "hutao"}
+	cookie := withTestSession(t, app, user, &models.Session{ID: "hutao", UserID: user.ID})
+	avatar := []byte("\x89PNG\r\n\x1a\n")
+
+	userService := app.Service.User.(*mocks.MockUserService)
+	onlyDescription := mock.MatchedBy(func(payload *payloads.UpdateUserPayload) bool {
+		return payload.Description != nil && *payload.Description == "77th director" &&
+			payload.Location == nil && payload.UserLink == nil
+	})
+	userService.On("UpdateProfile", mock.Anything, user, onlyDescription, []byte(nil), []byte(nil)).Return(
+		&models.UserProfile{User: user, Description: "77th director", Location: "liyue"}, nil,
+	)
+	userService.On("UpdateProfile", mock.Anything, user, mock.Anything, avatar, []byte(nil)).Return(
+		nil, service.ErrSaveFile,
+	)
+
+	t.Run("updates only the fields sent", func(t *testing.T) {
+		body, contentType := newProfileForm(t, map[string]string{"description": "77th director"}, nil)
+		req, err := http.NewRequest(http.MethodPatch, "/v1/user/profile", body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetUserProfileResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		assert.Equal(t, "77th director", response.Data.Description)
+		assert.Equal(t, "liyue", response.Data.Location)
+	})
+
+	t.Run("returns status 400 when the avatar is not saved", func(t *testing.T) {
+		body, contentType := newProfileForm(t, nil, map[string][]byte{"avatar": avatar})
+		req, err := http.NewRequest(http.MethodPatch, "/v1/user/profile", body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("returns status 401 without a session", func(t *testing.T) {
+		body, contentType := newProfileForm(t, map[string]string{"description": "77th director"}, nil)
+		req, err := http.NewRequest(http.MethodPatch, "/v1/user/profile", body)
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", contentType)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestGetUserPostsHandler(t *testing.T) {
+	app := newTestApplication(t)
+	mux := app.Mount()
+
+	viewer := &models.User{ID: 2, Username: "chaee"}
+	cookie := withTestSession(t, app, viewer, &models.Session{ID: "chaee", UserID: viewer.ID})
+	post := &models.PostWithMetadata{
+		Post:         models.Post{ID: 1, Content: "hello"},
+		CommentCount: 3,
+		Reactions:    models.ReactionSummary{Counts: map[string]int{"love": 2}, ViewerReaction: "love"},
+	}
+
+	userService := app.Service.User.(*mocks.MockUserService)
+	forViewer := func(viewerID int64) interface{} {
+		return mock.MatchedBy(func(userPosts *pagination.UserPosts) bool { return userPosts.ViewerID == viewerID })
+	}
+	userService.On("GetPostsFromUsername", mock.Anything, "momo", forViewer(viewer.ID)).Return(
+		[]*models.PostWithMetadata{post}, nil,
+	)
+	userService.On("GetPostsFromUsername", mock.Anything, "momo", forViewer(0)).Return(
+		[]*models.PostWithMetadata{}, nil,
+	)
+
+	t.Run("returns the reaction summaries of the viewer", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/user/posts/momo", nil)
+		require.NoError(t, err)
+		req.AddCookie(cookie)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var response struct {
+			Data responses.GetUserPostsResponse `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		require.Len(t, response.Data.Posts, 1)
+		assert.Equal(t, 3, response.Data.Posts[0].CommentCount)
+		require.NotNil(t, response.Data.Posts[0].Reactions)
+		assert.Equal(t, map[string]int{"love": 2}, response.Data.Posts[0].Reactions.Counts)
+		assert.Equal(t, "love", response.Data.Posts[0].Reactions.ViewerReaction)
+	})
+
+	t.Run("lets anonymous users through", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/v1/user/posts/momo", nil)
+		require.NoError(t, err)
+
+		rr := testutils.ExecuteRequest(req, mux)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
@@ -0,0 +1,198 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mochaeng/sapphire-backend/internal/events"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/models/responses"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+const (
+	socketWriteWait      = 10 * time.Second
+	socketPongWait       = 60 * time.Second
+	socketPingPeriod     = (socketPongWait * 9) / 10
+	socketMaxMessageSize = 1024
+	// socketRepliesSize is how many answers to client messages can wait to be
+	// written before the client is considered too slow
+	socketRepliesSize = 16
+)
+
+// WebSocket godoc
+//
+//	@Summary		Opens a WebSocket for real-time events
+//	@Description	Upgrades the connection to a WebSocket. Clients subscribe and unsubscribe to the feed, notifications, messages and comments channels, the last one needing a post_id
+//	@Tags			events
+//	@Success		101	"Switching protocols"
+//	@Failure		401	{object}	error
+//	@Failure		500	{object}	error
+//	@Security		ApiKeyAuth
+//	@Router			/ws [get]
+func (app *Application) webSocketHandler(w http.ResponseWriter, r *http.Request) {
+	user := getUserFromContext(r)
+
+	subscription, err := app.Events.Subscribe()
+	if err != nil {
+		app.InternalServerErrorResponse(w, r, err)
+		return
+	}
+	defer subscription.Close()
+
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     app.checkSocketOrigin,
+	}
+	// on failure the upgrader already replied to the client
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		app.Logger.Warnw("websocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	app.connections.Add(1)
+	defer app.connections.Done()
+
+	replies := make(chan responses.SocketMessageResponse, socketRepliesSize)
+	done := make(chan struct{})
+	go app.readSocket(conn, user, subscription, replies, done)
+
+	ticker := time.NewTicker(socketPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				app.closeSocket(conn, user, subscription.Err())
+				return
+			}
+			message := responses.SocketMessageResponse{Type: "event", Event: event}
+			if err := writeSocket(conn, message); err != nil {
+				return
+			}
+		case reply := <-replies:
+			if err := writeSocket(conn, reply); err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// readSocket handles the client messages until the connection fails or the
+// client stops answering the pings
+func (app *Application) readSocket(conn *websocket.Conn, user *models.User, subscription *events.Subscription, replies chan<- responses.SocketMessageResponse, done chan<- struct{}) {
+	defer close(done)
+
+	conn.SetReadLimit(socketMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(socketPongWait))
+	})
+
+	for {
+		var payload payloads.SocketMessagePayload
+		if err := conn.ReadJSON(&payload); err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				app.Logger.Infow("websocket read failed", "user", user.ID, "error", err)
+			}
+			return
+		}
+
+		reply := app.handleSocketMessage(user, subscription, &payload)
+		select {
+		case replies <- reply:
+		default:
+			app.Logger.Warnw("websocket client is not reading its replies", "user", user.ID)
+			return
+		}
+	}
+}
+
+func (app *Application) handleSocketMessage(user *models.User, subscription *events.Subscription, payload *payloads.SocketMessagePayload) responses.SocketMessageResponse {
+	reply := responses.SocketMessageResponse{
+		Type:    payload.Action,
+		Channel: payload.Channel,
+		PostID:  payload.PostID,
+	}
+	if err := models.Validate.Struct(payload); err != nil {
+		reply.Type = "error"
+		reply.Error = "invalid message"
+		return reply
+	}
+
+	var topic string
+	switch payload.Channel {
+	case "feed":
+		topic = events.FeedTopic(user.ID)
+	case "notifications":
+		topic = events.NotificationsTopic(user.ID)
+	case "messages":
+		topic = events.MessagesTopic(user.ID)
+	case "comments":
+		ctx, cancel := context.WithTimeout(context.Background(), store.QueryTimeoutDuration)
+		defer cancel()
+		if _, err := app.Service.Post.GetWithUser(ctx, payload.PostID); err != nil {
+			reply.Error = "post not found"
+			if !errors.Is(err, store.ErrNotFound) {
+				app.Logger.Errorw("could not find post for websocket channel", "post", payload.PostID, "error", err)
+				reply.Error = "could not subscribe to the post comments"
+			}
+			return reply
+		}
+		topic = events.PostCommentsTopic(payload.PostID)
+	}
+
+	if payload.Action == "subscribe" {
+		subscription.Add(topic)
+	} else {
+		subscription.Remove(topic)
+	}
+	return reply
+}
+
+// closeSocket tells the client why its subscription ended: the server going
+// away, or the client not keeping up with its events
+func (app *Application) closeSocket(conn *websocket.Conn, user *models.User, reason error) {
+	code, text := websocket.CloseNormalClosure, ""
+	switch {
+	case errors.Is(reason, events.ErrBrokerClosed):
+		code, text = websocket.CloseGoingAway, "server shutting down"
+	case errors.Is(reason, events.ErrSlowConsumer):
+		app.Logger.Warnw("websocket dropped", "user", user.ID, "error", reason)
+		code, text = websocket.CloseTryAgainLater, "too many pending events"
+	}
+	message := websocket.FormatCloseMessage(code, text)
+	conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(socketWriteWait))
+}
+
+// checkSocketOrigin follows the csrf middleware rules. Requests without an
+// Origin header come from non-browser clients, which do not send cookies on
+// someone else's behalf
+func (app *Application) checkSocketOrigin(r *http.Request) bool {
+	if app.Config.Env == "dev" {
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	return origin == "" || origin == app.Config.FrontedURL
+}
+
+func writeSocket(conn *websocket.Conn, message responses.SocketMessageResponse) error {
+	conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+	return conn.WriteJSON(message)
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
+	"fmt"
 	"time"
 
+	"github.com/mochaeng/sapphire-backend/internal/events"
 	"github.com/mochaeng/sapphire-backend/internal/mailer"
 	"github.com/mochaeng/sapphire-backend/internal/store"
 	"github.com/mochaeng/sapphire-backend/internal/store/cache"
@@ -48,6 +50,12 @@ type Cfg struct {
 	FrontedURL  string
 	ApiBasePath string
 	OAuth       OAuthConfig
+	Reactions   ReactionCfg
+	Trending    TrendingCfg
+	Purge       PurgeCfg
+	// ProxyIPHeader is the header the reverse proxy overwrites with the client
+	// address. Empty when there is no proxy, clients can send any header
+	ProxyIPHeader string
 }
 
 type DbCfg struct {
@@ -63,6 +71,7 @@ type ServiceCfg struct {
 	CacheStore *cache.Store
 	Cfg        *Cfg
 	Mailer     mailer.Client
+	Events     events.Broker
 }
 
 type CacheCfg struct {
@@ -77,8 +86,75 @@ type RedisCfg struct {
 }
 
 type AuthCfg struct {
-	Basic BasicAuthCfg
-	Token TokenCfg
+	Basic     BasicAuthCfg
+	Token     TokenCfg
+	TwoFactor TwoFactorCfg
+	Passkey   PasskeyCfg
+	Lockout   LockoutCfg
+	MagicLink MagicLinkCfg
+}
+
+// MagicLinkCfg is the sign in with a single-use link sent by e-mail, off
+// unless Enabled
+type MagicLinkCfg struct {
+	Enabled bool
+	// Expired is how long a link can be used
+	Expired time.Duration
+}
+
+// LockoutCfg slows down password guessing. Every account and address gets a
+// few free failed sign ins, then waits BaseDelay, doubled on each failure up
+// to MaxDelay. Reaching the maximum failures locks it for Duration
+type LockoutCfg struct {
+	AccountFreeAttempts int
+	IPFreeAttempts      int
+	BaseDelay           time.Duration
+	MaxDelay            time.Duration
+	// MaxAccountFailures locks the account and e-mails its owner
+	MaxAccountFailures int
+	MaxIPFailures      int
+	// Duration is how long a lockout lasts, and how long failures are
+	// remembered after the last one
+	Duration time.Duration
+}
+
+// Check tells if the lockout can work with the configuration. A maximum of
+// zero failures would lock every account and address for good
+func (c *LockoutCfg) Check() error {
+	if c.Duration <= 0 {
+		return fmt.Errorf("lockout duration must be positive, got %s", c.Duration)
+	}
+	if c.BaseDelay <= 0 || c.MaxDelay < c.BaseDelay {
+		return fmt.Errorf("lockout base delay must be positive and at most the max delay")
+	}
+	if c.AccountFreeAttempts < 0 || c.MaxAccountFailures <= c.AccountFreeAttempts {
+		return fmt.Errorf("max account failures must be more than the %d free attempts", c.AccountFreeAttempts)
+	}
+	if c.IPFreeAttempts < 0 || c.MaxIPFailures <= c.IPFreeAttempts {
+		return fmt.Errorf("max address failures must be more than the %d free attempts", c.IPFreeAttempts)
+	}
+	return nil
+}
+
+type TwoFactorCfg struct {
+	// EncryptionKey is the AES key the TOTP secrets are encrypted with. Two
+	// factor authentication cannot be set up without it
+	EncryptionKey []byte
+	// Issuer is the name authenticator apps show next to the codes
+	Issuer string
+	// PendingLoginExpired is how long a user has to give the code after the
+	// password was accepted
+	PendingLoginExpired time.Duration
+}
+
+type PasskeyCfg struct {
+	// RPID is the domain passkeys are bound to, they stop working if it changes
+	RPID   string
+	RPName string
+	// Origin is the address of the frontend running the WebAuthn ceremonies
+	Origin string
+	// ChallengeExpired is how long a ceremony can take
+	ChallengeExpired time.Duration
 }
 
 type TokenCfg struct {
@@ -93,8 +169,16 @@ type BasicAuthCfg struct {
 }
 
 type MailCfg struct {
-	Expired   time.Duration
-	FromEmail string
+	// Expired is how long an activation link can be used
+	Expired time.Duration
+	// ResendInterval is how long a user waits before asking for another
+	// activation e-mail
+	ResendInterval time.Duration
+	// ResetExpired is how long a password reset link can be used
+	ResetExpired time.Duration
+	// EmailChangeExpired is how long a new address has to be confirmed
+	EmailChangeExpired time.Duration
+	FromEmail          string
 }
 
 type RateLimiterConfig struct {
@@ -104,11 +188,85 @@ type RateLimiterConfig struct {
 }
 
 type OAuthConfig struct {
-	Google GoogleOAuth
+	// Providers are the providers users can sign in with
+	Providers []OAuthProviderCfg
+	// RedirectPath is the frontend page users land on after signing in with a
+	// provider
+	RedirectPath string
+	// LinkRedirectPath is the frontend page users land on after linking a
+	// provider to their account
+	LinkRedirectPath string
 }
 
-type GoogleOAuth struct {
+const (
+	OAuthGoogle = "google"
+	OAuthGitHub = "github"
+	OAuthOIDC   = "oidc"
+)
+
+type OAuthProviderCfg struct {
+	// Name identifies the provider in the URLs, as in /auth/{name}/login
+	Name string
+	// Kind is OAuthGoogle, OAuthGitHub or OAuthOIDC
+	Kind        string
 	Key         string
 	Secret      string
 	CallbackURI string
+	// DiscoveryURL is the OpenID configuration document of an OIDC issuer
+	DiscoveryURL string
+	Scopes       []string
+}
+
+// IsEnabled tells if a provider with the name was configured
+func (c *OAuthConfig) IsEnabled(name string) bool {
+	for _, provider := range c.Providers {
+		if provider.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type ReactionCfg struct {
+	// Kinds are the reactions a user is allowed to leave on a post
+	Kinds []string
+}
+
+type TrendingCfg struct {
+	// Window is how far back posts are considered when ranking tags
+	Window time.Duration
+	// RefreshInterval is how often the trending tags job runs
+	RefreshInterval time.Duration
+	// Size is how many tags are kept in the ranking
+	Size int
+}
+
+// Check tells if the trending tags job can run with the configuration
+func (c *TrendingCfg) Check() error {
+	if c.RefreshInterval <= 0 {
+		return fmt.Errorf("trending refresh interval must be positive, got %s", c.RefreshInterval)
+	}
+	if c.Window <= 0 || c.Size < 1 {
+		return fmt.Errorf("trending window must be positive and the size at least 1")
+	}
+	return nil
+}
+
+type PurgeCfg struct {
+	// Interval is how often accounts that were never activated are deleted
+	Interval time.Duration
+	// GracePeriod is how long after its activation link expires an account is
+	// kept, so the user can still ask for a new link
+	GracePeriod time.Duration
+}
+
+// Check tells if the unconfirmed accounts job can run with the configuration
+func (c *PurgeCfg) Check() error {
+	if c.Interval <= 0 {
+		return fmt.Errorf("purge interval must be positive, got %s", c.Interval)
+	}
+	if c.GracePeriod < 0 {
+		return fmt.Errorf("purge grace period cannot be negative, got %s", c.GracePeriod)
+	}
+	return nil
 }
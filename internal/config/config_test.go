@@ -0,0 +1,62 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrendingCfgCheck(t *testing.T) {
+	valid := config.TrendingCfg{Window: 24 * time.Hour, RefreshInterval: 10 * time.Minute, Size: 50}
+	assert.NoError(t, valid.Check())
+
+	for _, cfg := range []config.TrendingCfg{
+		{Window: 24 * time.Hour, RefreshInterval: 0, Size: 50},
+		{Window: 24 * time.Hour, RefreshInterval: -time.Minute, Size: 50},
+		{Window: 0, RefreshInterval: 10 * time.Minute, Size: 50},
+		{Window: 24 * time.Hour, RefreshInterval: 10 * time.Minute, Size: 0},
+	} {
+		assert.Error(t, cfg.Check(), "%+v", cfg)
+	}
+}
+
+func TestPurgeCfgCheck(t *testing.T) {
+	valid := config.PurgeCfg{Interval: time.Hour, GracePeriod: 72 * time.Hour}
+	assert.NoError(t, valid.Check())
+
+	for _, cfg := range []config.PurgeCfg{
+		{Interval: 0, GracePeriod: time.Hour},
+		{Interval: -time.Hour, GracePeriod: time.Hour},
+		{Interval: time.Hour, GracePeriod: -time.Hour},
+	} {
+		assert.Error(t, cfg.Check(), "%+v", cfg)
+	}
+}
+
+func TestLockoutCfgCheck(t *testing.T) {
+	valid := config.LockoutCfg{
+		AccountFreeAttempts: 3,
+		IPFreeAttempts:      20,
+		BaseDelay:           time.Second,
+		MaxDelay:            time.Minute,
+		MaxAccountFailures:  10,
+		MaxIPFailures:       100,
+		Duration:            15 * time.Minute,
+	}
+	assert.NoError(t, valid.Check())
+
+	for name, change := range map[string]func(cfg *config.LockoutCfg){
+		"no duration":          func(cfg *config.LockoutCfg) { cfg.Duration = 0 },
+		"no base delay":        func(cfg *config.LockoutCfg) { cfg.BaseDelay = 0 },
+		"max below base delay": func(cfg *config.LockoutCfg) { cfg.MaxDelay = time.Millisecond },
+		"no account failures":  func(cfg *config.LockoutCfg) { cfg.MaxAccountFailures = 0 },
+		"no address failures":  func(cfg *config.LockoutCfg) { cfg.MaxIPFailures = 0 },
+		"max under free tries": func(cfg *config.LockoutCfg) { cfg.MaxAccountFailures = 3 },
+	} {
+		cfg := valid
+		change(&cfg)
+		assert.Error(t, cfg.Check(), name)
+	}
+}
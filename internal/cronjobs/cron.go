@@ -4,18 +4,21 @@ import (
 	"context"
 	"time"
 
+	"github.com/mochaeng/sapphire-backend/internal/config"
 	"github.com/mochaeng/sapphire-backend/internal/store"
 	"go.uber.org/zap"
 )
 
-func PurgeUnconfirmedUsers(ctx context.Context, s *store.Store, interval time.Duration, logger *zap.SugaredLogger) {
-	ticker := time.NewTicker(interval)
+// PurgeUnconfirmedUsers deletes the accounts whose activation link expired
+// more than a grace period ago
+func PurgeUnconfirmedUsers(ctx context.Context, s *store.Store, cfg *config.PurgeCfg, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(cfg.Interval)
 	go func() {
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ticker.C:
-				if err := s.User.CleanUpExpiredPendingAccounts(ctx); err != nil {
+				if err := s.User.CleanUpExpiredPendingAccounts(ctx, time.Now().Add(-cfg.GracePeriod)); err != nil {
 					logger.Infow("unconfirmed users clean up failed", "err", err)
 				}
 			case <-ctx.Done():
@@ -24,3 +27,46 @@ func PurgeUnconfirmedUsers(ctx context.Context, s *store.Store, interval time.Du
 		}
 	}()
 }
+
+// RefreshTrendingTags ranks the tags used inside the trending window right away
+// and then on every refresh interval, so requests only read the last ranking
+func RefreshTrendingTags(ctx context.Context, s *store.Store, cfg *config.TrendingCfg, logger *zap.SugaredLogger) {
+	refresh := func() {
+		since := time.Now().Add(-cfg.Window)
+		if err := s.Tag.RefreshTrending(ctx, since, cfg.Size); err != nil {
+			logger.Infow("trending tags refresh failed", "err", err)
+		}
+	}
+	ticker := time.NewTicker(cfg.RefreshInterval)
+	go func() {
+		defer ticker.Stop()
+		refresh()
+		for {
+			select {
+			case <-ticker.C:
+				refresh()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// PurgeLoginFailures deletes the failed sign ins counted in Postgres that are
+// too old to count anymore. Redis expires its own
+func PurgeLoginFailures(ctx context.Context, s *store.Store, cfg *config.LockoutCfg, logger *zap.SugaredLogger) {
+	ticker := time.NewTicker(cfg.Duration)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.LoginFailure.DeleteOlderThan(ctx, time.Now().Add(-cfg.Duration)); err != nil {
+					logger.Infow("login failures clean up failed", "err", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
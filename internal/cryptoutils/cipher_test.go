@@ -0,0 +1,59 @@
+package cryptoutils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	plaintext := []byte("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	t.Run("decrypts what it encrypted", func(t *testing.T) {
+		ciphertext, err := Encrypt(key, plaintext)
+		require.NoError(t, err)
+		assert.NotContains(t, string(ciphertext), string(plaintext))
+
+		decrypted, err := Decrypt(key, ciphertext)
+		require.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("uses a new nonce every time", func(t *testing.T) {
+		first, err := Encrypt(key, plaintext)
+		require.NoError(t, err)
+		second, err := Encrypt(key, plaintext)
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("refuses tampered ciphertexts", func(t *testing.T) {
+		ciphertext, err := Encrypt(key, plaintext)
+		require.NoError(t, err)
+		ciphertext[len(ciphertext)-1] ^= 1
+
+		_, err = Decrypt(key, ciphertext)
+		assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	})
+
+	t.Run("refuses another key", func(t *testing.T) {
+		ciphertext, err := Encrypt(key, plaintext)
+		require.NoError(t, err)
+
+		_, err = Decrypt(bytes.Repeat([]byte{8}, 32), ciphertext)
+		assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	})
+
+	t.Run("refuses ciphertexts shorter than the nonce", func(t *testing.T) {
+		_, err := Decrypt(key, []byte("short"))
+		assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	})
+
+	t.Run("refuses keys of the wrong size", func(t *testing.T) {
+		_, err := Encrypt([]byte("too short"), plaintext)
+		assert.Error(t, err)
+	})
+}
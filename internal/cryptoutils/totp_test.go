@@ -0,0 +1,67 @@
+package cryptoutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA1 key of the RFC 6238 test vectors,
+// "12345678901234567890" in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// the RFC lists 8 digits codes, the last 6 are the same with 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, test := range tests {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(test.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, test.code, code, "at %d", test.unix)
+	}
+
+	_, err := TOTPCode("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	step, ok := ValidateTOTP(rfc6238Secret, "050471", now)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now), step)
+
+	step, ok = ValidateTOTP(rfc6238Secret, "050471", now.Add(TOTPPeriod))
+	assert.True(t, ok, "the previous period is accepted for clock drift")
+	assert.Equal(t, TOTPStep(now), step)
+
+	_, ok = ValidateTOTP(rfc6238Secret, "050471", now.Add(2*TOTPPeriod))
+	assert.False(t, ok, "older periods are refused")
+
+	_, ok = ValidateTOTP(rfc6238Secret, "000000", now)
+	assert.False(t, ok)
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	key, err := totpEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, 20)
+
+	other, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
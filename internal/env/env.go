@@ -3,6 +3,7 @@ package env
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 func GetString(key string, fallback string) string {
@@ -36,3 +37,21 @@ func GetBool(key string, fallback bool) bool {
 	}
 	return valAsBool
 }
+
+// GetStrings reads a comma separated list, ignoring empty items
+func GetStrings(key string, fallback []string) []string {
+	val, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var values []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
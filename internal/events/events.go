@@ -0,0 +1,109 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	KindPost         = "post"
+	KindNotification = "notification"
+	KindFollow       = "follow"
+	KindComment      = "comment"
+	KindMessage      = "message"
+)
+
+var (
+	ErrSlowConsumer = errors.New("subscription dropped for not keeping up with its events")
+	ErrBrokerClosed = errors.New("event broker is closed")
+)
+
+// Event is something pushed to the connected users. Topic is filled by the
+// broker with the topic the event was delivered through
+type Event struct {
+	Kind  string          `json:"kind"`
+	Topic string          `json:"topic,omitempty"`
+	Data  json.RawMessage `json:"data"`
+}
+
+func New(kind string, data any) (*Event, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{Kind: kind, Data: encoded}, nil
+}
+
+// Broker fans events out to the subscriptions of their topics
+type Broker interface {
+	Publish(ctx context.Context, topics []string, event *Event) error
+	Subscribe(topics ...string) (*Subscription, error)
+
+	// Close ends every subscription, it is called when the server shuts down
+	Close()
+}
+
+func FeedTopic(userID int64) string {
+	return fmt.Sprintf("user:%d:feed", userID)
+}
+
+func NotificationsTopic(userID int64) string {
+	return fmt.Sprintf("user:%d:notifications", userID)
+}
+
+func MessagesTopic(userID int64) string {
+	return fmt.Sprintf("user:%d:messages", userID)
+}
+
+func PostCommentsTopic(postID int64) string {
+	return fmt.Sprintf("post:%d:comments", postID)
+}
+
+type PostData struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Tittle    string    `json:"tittle"`
+	Content   string    `json:"content"`
+	Tags      []string  `json:"tags"`
+	MediaURL  string    `json:"media_url,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// RepostedByID and RepostedBy are set when a followed user reposted it
+	RepostedByID int64  `json:"reposted_by_id,omitempty"`
+	RepostedBy   string `json:"reposted_by,omitempty"`
+}
+
+type NotificationData struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`
+	ActorID   int64     `json:"actor_id"`
+	PostID    *int64    `json:"post_id,omitempty"`
+	CommentID *int64    `json:"comment_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FollowData struct {
+	FollowerID int64 `json:"follower_id"`
+}
+
+type CommentData struct {
+	ID        int64     `json:"id"`
+	PostID    int64     `json:"post_id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	ParentID  *int64    `json:"parent_comment_id,omitempty"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type MessageData struct {
+	ID             int64     `json:"id"`
+	ConversationID int64     `json:"conversation_id"`
+	SenderID       int64     `json:"sender_id"`
+	SenderUsername string    `json:"sender_username"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+}
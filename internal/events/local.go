@@ -0,0 +1,164 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriptionBufferSize is how many events a subscription can have pending
+// before it is considered a slow consumer and dropped
+const subscriptionBufferSize = 64
+
+// LocalBroker delivers events only to the subscriptions of this process
+type LocalBroker struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+	closed bool
+}
+
+func NewLocalBroker() *LocalBroker {
+	return &LocalBroker{topics: make(map[string]map[*Subscription]struct{})}
+}
+
+// Publish never blocks on a subscription, the ones whose buffer is full are
+// dropped with ErrSlowConsumer
+func (b *LocalBroker) Publish(ctx context.Context, topics []string, event *Event) error {
+	var slow []*Subscription
+
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrBrokerClosed
+	}
+	for _, topic := range topics {
+		delivered := *event
+		delivered.Topic = topic
+		for sub := range b.topics[topic] {
+			select {
+			case sub.events <- &delivered:
+			default:
+				slow = append(slow, sub)
+			}
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range slow {
+		b.drop(sub, ErrSlowConsumer)
+	}
+	return nil
+}
+
+func (b *LocalBroker) Subscribe(topics ...string) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrBrokerClosed
+	}
+	sub := &Subscription{
+		broker: b,
+		events: make(chan *Event, subscriptionBufferSize),
+		topics: make(map[string]struct{}),
+	}
+	b.add(sub, topics)
+	return sub, nil
+}
+
+func (b *LocalBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, subs := range b.topics {
+		for sub := range subs {
+			if !sub.closed {
+				sub.closed = true
+				sub.err = ErrBrokerClosed
+				close(sub.events)
+			}
+		}
+	}
+	b.topics = make(map[string]map[*Subscription]struct{})
+}
+
+// add must be called with the lock held
+func (b *LocalBroker) add(sub *Subscription, topics []string) {
+	if sub.closed {
+		return
+	}
+	for _, topic := range topics {
+		if b.topics[topic] == nil {
+			b.topics[topic] = make(map[*Subscription]struct{})
+		}
+		b.topics[topic][sub] = struct{}{}
+		sub.topics[topic] = struct{}{}
+	}
+}
+
+// remove must be called with the lock held
+func (b *LocalBroker) remove(sub *Subscription, topics []string) {
+	for _, topic := range topics {
+		delete(b.topics[topic], sub)
+		if len(b.topics[topic]) == 0 {
+			delete(b.topics, topic)
+		}
+		delete(sub.topics, topic)
+	}
+}
+
+func (b *LocalBroker) drop(sub *Subscription, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if sub.closed {
+		return
+	}
+	topics := make([]string, 0, len(sub.topics))
+	for topic := range sub.topics {
+		topics = append(topics, topic)
+	}
+	b.remove(sub, topics)
+	sub.closed = true
+	sub.err = err
+	close(sub.events)
+}
+
+// Subscription receives the events of its topics until it is closed. Its
+// state is guarded by the broker lock
+type Subscription struct {
+	broker *LocalBroker
+	events chan *Event
+	topics map[string]struct{}
+	closed bool
+	err    error
+}
+
+// Events is closed when the subscription ends, Err tells why
+func (s *Subscription) Events() <-chan *Event {
+	return s.events
+}
+
+// Err returns nil while the subscription is open or when it was closed by its
+// owner
+func (s *Subscription) Err() error {
+	s.broker.mu.RLock()
+	defer s.broker.mu.RUnlock()
+	return s.err
+}
+
+func (s *Subscription) Add(topics ...string) {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.add(s, topics)
+}
+
+func (s *Subscription) Remove(topics ...string) {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s, topics)
+}
+
+func (s *Subscription) Close() {
+	s.broker.drop(s, nil)
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const redisEventsChannel = "sapphire:events"
+
+type redisMessage struct {
+	Topics []string `json:"topics"`
+	Event  *Event   `json:"event"`
+}
+
+// RedisBroker publishes events through Redis, so every server instance
+// receives them and delivers to its own subscriptions
+type RedisBroker struct {
+	rdb    *redis.Client
+	local  *LocalBroker
+	logger *zap.SugaredLogger
+}
+
+// NewRedisBroker starts relaying the events published by any instance until
+// the context is done
+func NewRedisBroker(ctx context.Context, rdb *redis.Client, logger *zap.SugaredLogger) *RedisBroker {
+	broker := &RedisBroker{
+		rdb:    rdb,
+		local:  NewLocalBroker(),
+		logger: logger,
+	}
+	pubsub := rdb.Subscribe(ctx, redisEventsChannel)
+	go func() {
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				broker.relay(ctx, msg)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return broker
+}
+
+func (b *RedisBroker) Publish(ctx context.Context, topics []string, event *Event) error {
+	payload, err := json.Marshal(redisMessage{Topics: topics, Event: event})
+	if err != nil {
+		return err
+	}
+	return b.rdb.Publish(ctx, redisEventsChannel, payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(topics ...string) (*Subscription, error) {
+	return b.local.Subscribe(topics...)
+}
+
+func (b *RedisBroker) Close() {
+	b.local.Close()
+}
+
+func (b *RedisBroker) relay(ctx context.Context, msg *redis.Message) {
+	var message redisMessage
+	if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil || message.Event == nil {
+		b.logger.Warnw("invalid event received from redis", "error", err)
+		return
+	}
+	if err := b.local.Publish(ctx, message.Topics, message.Event); err != nil && err != ErrBrokerClosed {
+		b.logger.Errorw("could not relay event", "error", err)
+	}
+}
@@ -22,7 +22,7 @@ func ReadFormDataValues(r *http.Request, data any) error {
 		fieldName := typeOfData.Field(i).Name
 		fieldType := typeOfData.Field(i).Type
 		fieldKind := fieldType.Kind()
-		key := strings.ToLower(fieldName)
+		key := formKey(fieldName, typeOfData.Field(i).Tag.Get("json"))
 		formValues := r.Form[key]
 		if fieldKind == reflect.Slice || fieldKind == reflect.Array {
 			formData[key] = noEmptyTags(formValues)
@@ -58,6 +58,16 @@ func ReadFormFile(r *http.Request, fileField string, maxReadSize int64) ([]byte,
 	return fileBytes, nil
 }
 
+// formKey uses the json name of the field, so multi-word fields are read the
+// same way in forms and in json bodies
+func formKey(fieldName string, jsonTag string) string {
+	name, _, _ := strings.Cut(jsonTag, ",")
+	if name == "" || name == "-" {
+		return strings.ToLower(fieldName)
+	}
+	return name
+}
+
 func noEmptyTags(arr []string) []string {
 	newArr := []string{}
 	for _, value := range arr {
@@ -3,9 +3,14 @@ package mailer
 import "embed"
 
 const (
-	senderName          = "Limerence"
-	maxRetries          = 3
-	UserWelcomeTemplate = "user_invitation.tmpl"
+	senderName                = "Limerence"
+	maxRetries                = 3
+	UserWelcomeTemplate       = "user_invitation.tmpl"
+	PasswordResetTemplate     = "password_reset.tmpl"
+	EmailChangeTemplate       = "email_change.tmpl"
+	EmailChangeNoticeTemplate = "email_change_notice.tmpl"
+	AccountLockedTemplate     = "account_locked.tmpl"
+	MagicLinkTemplate         = "magic_link.tmpl"
 )
 
 //go:embed "templates"
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -37,3 +38,19 @@ func SaveFileToServer(fileBytes []byte, folderPath string) (string, error) {
 	}
 	return newFileName, nil
 }
+
+// IsImage reports whether the file content is an image, whatever its name
+func IsImage(fileBytes []byte) bool {
+	return strings.HasPrefix(http.DetectContentType(fileBytes), "image/")
+}
+
+// RemoveFileFromServer deletes a file saved by SaveFileToServer. A missing
+// file is not an error
+func RemoveFileFromServer(filePath string) error {
+	workDir, _ := os.Getwd()
+	err := os.Remove(filepath.Join(workDir, filePath))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
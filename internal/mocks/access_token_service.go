@@ -0,0 +1,42 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAccessTokenService struct {
+	mock.Mock
+}
+
+func (m *MockAccessTokenService) Create(ctx context.Context, user *models.User, payload *payloads.CreateAccessTokenPayload) (*models.AccessToken, error) {
+	args := m.Called(ctx, user, payload)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.AccessToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAccessTokenService) GetFromUser(ctx context.Context, userID int64) ([]*models.AccessToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.AccessToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAccessTokenService) Revoke(ctx context.Context, userID int64, accessTokenID int64) error {
+	args := m.Called(ctx, userID, accessTokenID)
+	return args.Error(0)
+}
+
+func (m *MockAccessTokenService) Validate(ctx context.Context, token string) (*models.AccessToken, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.AccessToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,43 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAccessTokenStore struct {
+	mock.Mock
+}
+
+func (m *MockAccessTokenStore) Create(ctx context.Context, accessToken *models.AccessToken, tokenHash string) error {
+	args := m.Called(ctx, accessToken, tokenHash)
+	return args.Error(0)
+}
+
+func (m *MockAccessTokenStore) GetByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.AccessToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAccessTokenStore) GetFromUser(ctx context.Context, userID int64) ([]*models.AccessToken, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.AccessToken), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAccessTokenStore) UpdateLastUsed(ctx context.Context, accessTokenID int64) error {
+	args := m.Called(ctx, accessTokenID)
+	return args.Error(0)
+}
+
+func (m *MockAccessTokenStore) Delete(ctx context.Context, userID int64, accessTokenID int64) error {
+	args := m.Called(ctx, userID, accessTokenID)
+	return args.Error(0)
+}
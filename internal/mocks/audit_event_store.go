@@ -0,0 +1,26 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditEventStore struct {
+	mock.Mock
+}
+
+func (m *MockAuditEventStore) Create(ctx context.Context, auditEvent *models.AuditEvent) error {
+	args := m.Called(ctx, auditEvent)
+	return args.Error(0)
+}
+
+func (m *MockAuditEventStore) Get(ctx context.Context, auditEvents *pagination.AuditEvents) ([]*models.AuditEvent, string, error) {
+	args := m.Called(ctx, auditEvents)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.AuditEvent), args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
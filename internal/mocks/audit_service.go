@@ -0,0 +1,21 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) GetEvents(ctx context.Context, auditEvents *pagination.AuditEvents) ([]*models.AuditEvent, error) {
+	args := m.Called(ctx, auditEvents)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.AuditEvent), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,135 @@
+package mocks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) GetCookieSession(userID int64, client models.SessionClient) (*http.Cookie, error) {
+	args := m.Called(userID, client)
+	if args.Get(0) != nil {
+		return args.Get(0).(*http.Cookie), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) RegisterUser(ctx context.Context, payload *payloads.RegisterUserPayload) (*models.UserInvitation, error) {
+	args := m.Called(ctx, payload)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.UserInvitation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) Authenticate(ctx context.Context, payload *payloads.SigninPayload, ip string) (*models.User, error) {
+	args := m.Called(ctx, payload, ip)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) Unlock(ctx context.Context, admin *models.User, userID int64) error {
+	args := m.Called(ctx, admin, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResendActivation(ctx context.Context, payload *payloads.ResendActivationPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ForgotPassword(ctx context.Context, payload *payloads.ForgotPasswordPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ResetPassword(ctx context.Context, payload *payloads.ResetPasswordPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RequestMagicLink(ctx context.Context, payload *payloads.MagicLinkPayload) (string, error) {
+	args := m.Called(ctx, payload)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) SigninWithMagicLink(ctx context.Context, payload *payloads.MagicLinkSigninPayload, browserToken string) (*models.User, error) {
+	args := m.Called(ctx, payload, browserToken)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) GetMagicLinkEnabled(ctx context.Context, userID int64) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthService) SetMagicLinkEnabled(ctx context.Context, userID int64, payload *payloads.MagicLinkPreferencePayload) error {
+	args := m.Called(ctx, userID, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RequestEmailChange(ctx context.Context, user *models.User, payload *payloads.ChangeEmailPayload) error {
+	args := m.Called(ctx, user, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) ConfirmEmailChange(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) GenerateSessionToken() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) CreateSession(token string, userID int64, client models.SessionClient) (*models.Session, error) {
+	args := m.Called(token, userID, client)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Session), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) ValidateSessionToken(token string, client models.SessionClient) (*models.Session, error) {
+	args := m.Called(token, client)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Session), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) InvalidateSession(ctx context.Context, session *models.Session) error {
+	args := m.Called(ctx, session)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) GetSessions(ctx context.Context, userID int64) ([]*models.Session, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Session), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAuthService) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+func (m *MockAuthService) RevokeOtherSessions(ctx context.Context, userID int64, currentSessionID string) error {
+	args := m.Called(ctx, userID, currentSessionID)
+	return args.Error(0)
+}
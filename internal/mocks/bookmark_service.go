@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockBookmarkService struct {
+	mock.Mock
+}
+
+func (m *MockBookmarkService) Create(ctx context.Context, user *models.User, post *models.Post) (*models.Bookmark, error) {
+	args := m.Called(ctx, user, post)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Bookmark), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockBookmarkService) Delete(ctx context.Context, user *models.User, post *models.Post) error {
+	args := m.Called(ctx, user, post)
+	return args.Error(0)
+}
+
+func (m *MockBookmarkService) GetFromUser(ctx context.Context, userBookmarks *pagination.UserBookmarks) ([]*models.Bookmark, error) {
+	args := m.Called(ctx, userBookmarks)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Bookmark), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,48 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCommentService struct {
+	mock.Mock
+}
+
+func (m *MockCommentService) Create(ctx context.Context, user *models.User, post *models.Post, payload *payloads.CreateCommentPayload) (*models.Comment, error) {
+	args := m.Called(ctx, user, post, payload)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Comment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCommentService) GetByID(ctx context.Context, commentID int64) (*models.Comment, error) {
+	args := m.Called(ctx, commentID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Comment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCommentService) GetThread(ctx context.Context, postComments *pagination.PostComments) ([]*models.Comment, error) {
+	args := m.Called(ctx, postComments)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Comment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCommentService) Update(ctx context.Context, user *models.User, comment *models.Comment, payload *payloads.UpdateCommentPayload) error {
+	args := m.Called(ctx, user, comment, payload)
+	return args.Error(0)
+}
+
+func (m *MockCommentService) Delete(ctx context.Context, user *models.User, comment *models.Comment) error {
+	args := m.Called(ctx, user, comment)
+	return args.Error(0)
+}
@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockCommentStore struct {
+	mock.Mock
+}
+
+func (m *MockCommentStore) GetByPostID(ctx context.Context, postID int64) (*[]models.Comment, error) {
+	args := m.Called(ctx, postID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*[]models.Comment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCommentStore) Create(ctx context.Context, comment *models.Comment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+func (m *MockCommentStore) GetByID(ctx context.Context, commentID int64) (*models.Comment, error) {
+	args := m.Called(ctx, commentID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Comment), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockCommentStore) UpdateByID(ctx context.Context, comment *models.Comment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+func (m *MockCommentStore) DeleteByID(ctx context.Context, commentID int64) error {
+	args := m.Called(ctx, commentID)
+	return args.Error(0)
+}
+
+func (m *MockCommentStore) GetThread(ctx context.Context, postComments *pagination.PostComments) ([]*models.Comment, string, error) {
+	args := m.Called(ctx, postComments)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Comment), args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockConversationStore struct {
+	mock.Mock
+}
+
+func (m *MockConversationStore) Create(ctx context.Context, conversation *models.Conversation, participantIDs []int64) error {
+	args := m.Called(ctx, conversation, participantIDs)
+	return args.Error(0)
+}
+
+func (m *MockConversationStore) GetByID(ctx context.Context, conversationID int64) (*models.Conversation, error) {
+	args := m.Called(ctx, conversationID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Conversation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockConversationStore) GetFromUser(ctx context.Context, userConversations *pagination.UserConversations) ([]*models.Conversation, string, error) {
+	args := m.Called(ctx, userConversations)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Conversation), args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
+
+func (m *MockConversationStore) CreateMessage(ctx context.Context, message *models.Message) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+func (m *MockConversationStore) GetMessages(ctx context.Context, conversationMessages *pagination.ConversationMessages) ([]*models.Message, string, error) {
+	args := m.Called(ctx, conversationMessages)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Message), args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
+
+func (m *MockConversationStore) MarkRead(ctx context.Context, conversationID int64, userID int64) error {
+	args := m.Called(ctx, conversationID, userID)
+	return args.Error(0)
+}
@@ -0,0 +1,12 @@
+package mocks
+
+import "github.com/stretchr/testify/mock"
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(templateFile string, username string, email string, data any, isSandbox bool) (int, error) {
+	args := m.Called(templateFile, username, email, data, isSandbox)
+	return args.Int(0), args.Error(1)
+}
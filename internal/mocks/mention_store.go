@@ -0,0 +1,45 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMentionStore struct {
+	mock.Mock
+}
+
+func (m *MockMentionStore) SetForPost(ctx context.Context, postID int64, authorID int64, usernames []string) ([]models.Mention, error) {
+	args := m.Called(ctx, postID, authorID, usernames)
+	if args.Get(0) != nil {
+		return args.Get(0).([]models.Mention), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMentionStore) SetForComment(ctx context.Context, commentID int64, authorID int64, usernames []string) ([]models.Mention, error) {
+	args := m.Called(ctx, commentID, authorID, usernames)
+	if args.Get(0) != nil {
+		return args.Get(0).([]models.Mention), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMentionStore) GetByPost(ctx context.Context, postID int64) ([]models.Mention, error) {
+	args := m.Called(ctx, postID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]models.Mention), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMentionStore) GetMentionsOf(ctx context.Context, userMentions *pagination.UserMentions) ([]*models.UserMention, string, error) {
+	args := m.Called(ctx, userMentions)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.UserMention), args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
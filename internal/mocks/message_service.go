@@ -0,0 +1,59 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockMessageService struct {
+	mock.Mock
+}
+
+func (m *MockMessageService) CreateConversation(ctx context.Context, user *models.User, payload *payloads.CreateConversationPayload) (*models.Conversation, error) {
+	args := m.Called(ctx, user, payload)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Conversation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMessageService) GetConversation(ctx context.Context, conversationID int64, userID int64) (*models.Conversation, error) {
+	args := m.Called(ctx, conversationID, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Conversation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMessageService) GetConversations(ctx context.Context, userConversations *pagination.UserConversations) ([]*models.Conversation, error) {
+	args := m.Called(ctx, userConversations)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Conversation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMessageService) GetMessages(ctx context.Context, conversationMessages *pagination.ConversationMessages) ([]*models.Message, error) {
+	args := m.Called(ctx, conversationMessages)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Message), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMessageService) Send(ctx context.Context, user *models.User, conversation *models.Conversation, payload *payloads.SendMessagePayload) (*models.Message, error) {
+	args := m.Called(ctx, user, conversation, payload)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Message), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockMessageService) MarkRead(ctx context.Context, user *models.User, conversation *models.Conversation) error {
+	args := m.Called(ctx, user, conversation)
+	return args.Error(0)
+}
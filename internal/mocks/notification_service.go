@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNotificationService struct {
+	mock.Mock
+}
+
+func (m *MockNotificationService) Start(ctx context.Context) {
+	m.Called(ctx)
+}
+
+func (m *MockNotificationService) GetFromUser(ctx context.Context, userNotifications *pagination.UserNotifications) ([]*models.NotificationGroup, error) {
+	args := m.Called(ctx, userNotifications)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.NotificationGroup), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockNotificationService) CountUnread(ctx context.Context, userID int64) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockNotificationService) MarkRead(ctx context.Context, userID int64, notificationID int64) error {
+	args := m.Called(ctx, userID, notificationID)
+	return args.Error(0)
+}
+
+func (m *MockNotificationService) MarkAllRead(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
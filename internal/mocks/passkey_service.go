@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPasskeyService struct {
+	mock.Mock
+}
+
+func (m *MockPasskeyService) BeginRegistration(ctx context.Context, user *models.User) (*models.PasskeyCreation, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.PasskeyCreation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPasskeyService) FinishRegistration(ctx context.Context, user *models.User, payload *payloads.RegisterPasskeyPayload) (*models.Passkey, error) {
+	args := m.Called(ctx, user, payload)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Passkey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPasskeyService) BeginLogin(ctx context.Context) (*models.PasskeyChallenge, error) {
+	args := m.Called(ctx)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.PasskeyChallenge), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPasskeyService) FinishLogin(ctx context.Context, payload *payloads.PasskeyAssertionPayload) (*models.User, error) {
+	args := m.Called(ctx, payload)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPasskeyService) GetFromUser(ctx context.Context, userID int64) ([]*models.Passkey, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Passkey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPasskeyService) Rename(ctx context.Context, userID int64, passkeyID int64, payload *payloads.RenamePasskeyPayload) error {
+	args := m.Called(ctx, userID, passkeyID, payload)
+	return args.Error(0)
+}
+
+func (m *MockPasskeyService) Delete(ctx context.Context, userID int64, passkeyID int64) error {
+	args := m.Called(ctx, userID, passkeyID)
+	return args.Error(0)
+}
@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockPasskeyStore struct {
+	mock.Mock
+}
+
+func (m *MockPasskeyStore) CreateChallenge(ctx context.Context, challenge *models.PasskeyChallenge) error {
+	args := m.Called(ctx, challenge)
+	return args.Error(0)
+}
+
+func (m *MockPasskeyStore) ConsumeChallenge(ctx context.Context, challenge []byte, kind string) (*models.PasskeyChallenge, error) {
+	args := m.Called(ctx, challenge, kind)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.PasskeyChallenge), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPasskeyStore) Create(ctx context.Context, passkey *models.Passkey) error {
+	args := m.Called(ctx, passkey)
+	return args.Error(0)
+}
+
+func (m *MockPasskeyStore) GetByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error) {
+	args := m.Called(ctx, credentialID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Passkey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPasskeyStore) GetFromUser(ctx context.Context, userID int64) ([]*models.Passkey, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Passkey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPasskeyStore) UpdateSignCount(ctx context.Context, passkeyID int64, signCount int64) error {
+	args := m.Called(ctx, passkeyID, signCount)
+	return args.Error(0)
+}
+
+func (m *MockPasskeyStore) Rename(ctx context.Context, userID int64, passkeyID int64, name string) error {
+	args := m.Called(ctx, userID, passkeyID, name)
+	return args.Error(0)
+}
+
+func (m *MockPasskeyStore) Delete(ctx context.Context, userID int64, passkeyID int64) error {
+	args := m.Called(ctx, userID, passkeyID)
+	return args.Error(0)
+}
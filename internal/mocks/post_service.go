@@ -25,12 +25,41 @@ func (m *MockPostService) GetWithUser(ctx context.Context, postID int64) (*model
 	return nil, args.Error(1)
 }
 
-func (m *MockPostService) Delete(ctx context.Context, postID int64) error {
-	args := m.Called(ctx, postID)
+func (m *MockPostService) Delete(ctx context.Context, user *models.User, post *models.Post) error {
+	args := m.Called(ctx, user, post)
 	return args.Error(0)
 }
 
-func (m *MockPostService) Update(ctx context.Context, post *models.Post, payload *payloads.UpdatePostPayload) error {
-	args := m.Called(ctx, post, payload)
+func (m *MockPostService) Update(ctx context.Context, user *models.User, post *models.Post, payload *payloads.UpdatePostPayload) error {
+	args := m.Called(ctx, user, post, payload)
 	return args.Error(0)
 }
+
+func (m *MockPostService) Repost(ctx context.Context, user *models.User, post *models.Post) (*models.Post, error) {
+	args := m.Called(ctx, user, post)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Post), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPostService) Unrepost(ctx context.Context, user *models.User, post *models.Post) error {
+	args := m.Called(ctx, user, post)
+	return args.Error(0)
+}
+
+func (m *MockPostService) Quote(ctx context.Context, user *models.User, post *models.Post, payload *payloads.QuotePostPayload) (*models.Post, error) {
+	args := m.Called(ctx, user, post, payload)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.Post), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockPostService) GetMentions(ctx context.Context, post *models.Post) ([]models.Mention, error) {
+	args := m.Called(ctx, post)
+	if args.Get(0) != nil {
+		return args.Get(0).([]models.Mention), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
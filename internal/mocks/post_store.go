@@ -32,6 +32,10 @@ func (m *MockPostStore) UpdateByID(context.Context, *models.Post) error {
 	return nil
 }
 
+func (m *MockPostStore) DeleteRepost(context.Context, int64, int64) error {
+	return nil
+}
+
 func (m *MockPostStore) GetByUsername(ctontext context.Context, username string, timeCursor time.Time) ([]*models.Post, error) {
 	return nil, nil
 }
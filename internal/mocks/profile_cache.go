@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockProfileCache struct {
+	mock.Mock
+}
+
+func (m *MockProfileCache) Get(ctx context.Context, username string) (*models.UserProfile, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.UserProfile), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockProfileCache) Set(ctx context.Context, userProfile *models.UserProfile) error {
+	args := m.Called(ctx, userProfile)
+	return args.Error(0)
+}
+
+func (m *MockProfileCache) Delete(ctx context.Context, username string) error {
+	args := m.Called(ctx, username)
+	return args.Error(0)
+}
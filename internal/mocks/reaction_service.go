@@ -0,0 +1,33 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockReactionService struct {
+	mock.Mock
+}
+
+func (m *MockReactionService) React(ctx context.Context, userID int64, postID int64, kind string) (*models.ReactionSummary, error) {
+	args := m.Called(ctx, userID, postID, kind)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.ReactionSummary), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockReactionService) Unreact(ctx context.Context, userID int64, postID int64) error {
+	args := m.Called(ctx, userID, postID)
+	return args.Error(0)
+}
+
+func (m *MockReactionService) GetSummary(ctx context.Context, postID int64, viewerID int64) (*models.ReactionSummary, error) {
+	args := m.Called(ctx, postID, viewerID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.ReactionSummary), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
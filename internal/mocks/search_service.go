@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSearchService struct {
+	mock.Mock
+}
+
+func (m *MockSearchService) Posts(ctx context.Context, search *pagination.Search) ([]*models.Post, error) {
+	args := m.Called(ctx, search)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Post), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSearchService) Users(ctx context.Context, search *pagination.Search) ([]*models.User, error) {
+	args := m.Called(ctx, search)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
@@ -4,7 +4,19 @@ import "github.com/mochaeng/sapphire-backend/internal/services"
 
 func NewMockService() services.Service {
 	return services.Service{
-		User: &MockUserService{},
-		Post: &MockPostService{},
+		User:         &MockUserService{},
+		Auth:         &MockAuthService{},
+		Post:         &MockPostService{},
+		Comment:      &MockCommentService{},
+		Reaction:     &MockReactionService{},
+		Bookmark:     &MockBookmarkService{},
+		Search:       &MockSearchService{},
+		Tag:          &MockTagService{},
+		Message:      &MockMessageService{},
+		Notification: &MockNotificationService{},
+		TwoFactor:    &MockTwoFactorService{},
+		Passkey:      &MockPasskeyService{},
+		AccessToken:  &MockAccessTokenService{},
+		Audit:        &MockAuditService{},
 	}
 }
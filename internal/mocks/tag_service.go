@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTagService struct {
+	mock.Mock
+}
+
+func (m *MockTagService) GetPosts(ctx context.Context, tagPosts *pagination.TagPosts) ([]*models.Post, error) {
+	args := m.Called(ctx, tagPosts)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.Post), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTagService) GetTrending(ctx context.Context, limit int) ([]*models.TrendingTag, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.TrendingTag), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
@@ -0,0 +1,50 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTwoFactorService struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorService) Setup(ctx context.Context, user *models.User) (*models.TwoFactorSetup, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.TwoFactorSetup), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTwoFactorService) Enable(ctx context.Context, user *models.User, payload *payloads.EnableTwoFactorPayload) ([]string, error) {
+	args := m.Called(ctx, user, payload)
+	if args.Get(0) != nil {
+		return args.Get(0).([]string), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTwoFactorService) Disable(ctx context.Context, user *models.User, payload *payloads.DisableTwoFactorPayload) error {
+	args := m.Called(ctx, user, payload)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorService) BeginLogin(ctx context.Context, user *models.User) (*models.PendingLogin, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.PendingLogin), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTwoFactorService) CompleteLogin(ctx context.Context, payload *payloads.VerifyTwoFactorPayload, ip string) (*models.User, error) {
+	args := m.Called(ctx, payload, ip)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
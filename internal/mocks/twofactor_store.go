@@ -0,0 +1,68 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockTwoFactorStore struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorStore) Get(ctx context.Context, userID int64) (*models.TwoFactor, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.TwoFactor), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTwoFactorStore) SaveSecret(ctx context.Context, userID int64, secret []byte) error {
+	args := m.Called(ctx, userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorStore) Enable(ctx context.Context, userID int64, step int64, codeHashes []string) error {
+	args := m.Called(ctx, userID, step, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorStore) Disable(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorStore) UseStep(ctx context.Context, userID int64, step int64) error {
+	args := m.Called(ctx, userID, step)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorStore) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorStore) CreatePendingLogin(ctx context.Context, pendingLogin *models.PendingLogin) error {
+	args := m.Called(ctx, pendingLogin)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorStore) GetPendingLogin(ctx context.Context, token string) (*models.PendingLogin, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.PendingLogin), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockTwoFactorStore) AddPendingLoginAttempt(ctx context.Context, token string) (int, error) {
+	args := m.Called(ctx, token)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockTwoFactorStore) DeletePendingLogin(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
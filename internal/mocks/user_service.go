@@ -6,6 +6,7 @@ import (
 	"github.com/markbates/goth"
 	"github.com/mochaeng/sapphire-backend/internal/models"
 	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -23,6 +24,16 @@ func (m *MockUserService) Unfollow(ctx context.Context, unfollowerID int64, unfo
 	return args.Error(0)
 }
 
+func (m *MockUserService) Block(ctx context.Context, blockerID int64, blockedID int64) error {
+	args := m.Called(ctx, blockerID, blockedID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) Unblock(ctx context.Context, blockerID int64, blockedID int64) error {
+	args := m.Called(ctx, blockerID, blockedID)
+	return args.Error(0)
+}
+
 func (m *MockUserService) Activate(ctx context.Context, token string) error {
 	args := m.Called(ctx, token)
 	return args.Error(0)
@@ -43,12 +54,52 @@ func (m *MockUserService) GetProfile(ctx context.Context, username string) (*mod
 	return args.Get(0).(*models.UserProfile), args.Error(1)
 }
 
-func (m *MockUserService) GetPostsFromUsername(ctx context.Context, username string, userPosts *pagination.UserPosts) ([]*models.Post, error) {
+func (m *MockUserService) UpdateProfile(ctx context.Context, user *models.User, payload *payloads.UpdateUserPayload, avatar []byte, banner []byte) (*models.UserProfile, error) {
+	args := m.Called(ctx, user, payload, avatar, banner)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.UserProfile), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserService) GetPostsFromUsername(ctx context.Context, username string, userPosts *pagination.UserPosts) ([]*models.PostWithMetadata, error) {
 	args := m.Called(ctx, username, userPosts)
-	return args.Get(0).([]*models.Post), args.Error(1)
+	return args.Get(0).([]*models.PostWithMetadata), args.Error(1)
 }
 
-func (m *MockUserService) LinkOrCreateUserFromOAuth(ctx context.Context, gothUser *goth.User) (*models.User, error) {
+func (m *MockUserService) GetOrCreateUserFromOAuth(ctx context.Context, gothUser *goth.User) (*models.User, error) {
 	args := m.Called(ctx, gothUser)
-	return args.Get(0).(*models.User), args.Error(1)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserService) LinkOAuth(ctx context.Context, userID int64, gothUser *goth.User) (*models.OAuthAccount, error) {
+	args := m.Called(ctx, userID, gothUser)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.OAuthAccount), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserService) GetOAuthAccounts(ctx context.Context, userID int64) ([]*models.OAuthAccount, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.OAuthAccount), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserService) UnlinkOAuth(ctx context.Context, userID int64, provider, providerUserID string) error {
+	args := m.Called(ctx, userID, provider, providerUserID)
+	return args.Error(0)
+}
+
+func (m *MockUserService) GetMentions(ctx context.Context, userMentions *pagination.UserMentions) ([]*models.UserMention, error) {
+	args := m.Called(ctx, userMentions)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.UserMention), args.Error(1)
+	}
+	return nil, args.Error(1)
 }
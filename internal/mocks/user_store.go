@@ -0,0 +1,196 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserStore struct {
+	mock.Mock
+}
+
+func (m *MockUserStore) GetByID(ctx context.Context, userID int64) (*models.User, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStore) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStore) GetActivatedByUsername(ctx context.Context, username string) (*models.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStore) GetByActivatedEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.User), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStore) Follow(ctx context.Context, followerID int64, followedID int64) error {
+	args := m.Called(ctx, followerID, followedID)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) Unfollow(ctx context.Context, followerID int64, followedID int64) error {
+	args := m.Called(ctx, followerID, followedID)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) GetFollowerIDs(ctx context.Context, userID int64) ([]int64, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) != nil {
+		return args.Get(0).([]int64), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStore) Block(ctx context.Context, blockerID int64, blockedID int64) error {
+	args := m.Called(ctx, blockerID, blockedID)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) Unblock(ctx context.Context, blockerID int64, blockedID int64) error {
+	args := m.Called(ctx, blockerID, blockedID)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) HasBlock(ctx context.Context, userID int64, otherIDs []int64) (bool, error) {
+	args := m.Called(ctx, userID, otherIDs)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserStore) CreateAndInvite(ctx context.Context, userInvitation *models.UserInvitation, userProfile *models.UserProfile) error {
+	args := m.Called(ctx, userInvitation, userProfile)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) Activate(ctx context.Context, plainToken string) error {
+	args := m.Called(ctx, plainToken)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) CreatePasswordReset(ctx context.Context, passwordReset *models.PasswordReset) error {
+	args := m.Called(ctx, passwordReset)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) ResetPassword(ctx context.Context, plainToken string, user *models.User) error {
+	args := m.Called(ctx, plainToken, user)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) UpdatePasswordHash(ctx context.Context, userID int64, oldHash []byte, newHash []byte) error {
+	args := m.Called(ctx, userID, oldHash, newHash)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) CreateEmailChange(ctx context.Context, emailChange *models.EmailChange) error {
+	args := m.Called(ctx, emailChange)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) ConfirmEmailChange(ctx context.Context, plainToken string, user *models.User) error {
+	args := m.Called(ctx, plainToken, user)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) CreateMagicLink(ctx context.Context, magicLink *models.MagicLink) error {
+	args := m.Called(ctx, magicLink)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) UseMagicLink(ctx context.Context, token string, browser string, user *models.User) error {
+	args := m.Called(ctx, token, browser, user)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) GetMagicLinkEnabled(ctx context.Context, userID int64) (bool, error) {
+	args := m.Called(ctx, userID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserStore) SetMagicLinkEnabled(ctx context.Context, userID int64, isEnabled bool) error {
+	args := m.Called(ctx, userID, isEnabled)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) Delete(ctx context.Context, userID int64) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) GetProfile(ctx context.Context, username string) (*models.UserProfile, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.UserProfile), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStore) UpdateProfile(ctx context.Context, userProfile *models.UserProfile) error {
+	args := m.Called(ctx, userProfile)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) GetPostsFrom(ctx context.Context, userPosts *pagination.UserPosts) ([]*models.PostWithMetadata, string, error) {
+	args := m.Called(ctx, userPosts)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.PostWithMetadata), args.String(1), args.Error(2)
+	}
+	return nil, args.String(1), args.Error(2)
+}
+
+func (m *MockUserStore) CleanUpExpiredPendingAccounts(ctx context.Context, expiredBefore time.Time) error {
+	args := m.Called(ctx, expiredBefore)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) GetPendingInvitation(ctx context.Context, email string) (*models.UserInvitation, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.UserInvitation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockUserStore) RotateInvitation(ctx context.Context, userInvitation *models.UserInvitation) error {
+	args := m.Called(ctx, userInvitation)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) CreateProfileFull(ctx context.Context, userProfile *models.UserProfile) error {
+	args := m.Called(ctx, userProfile)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) CreateAndActivate(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"time"
+)
+
+const (
+	// AccessTokenPrefix marks the personal access tokens, so leaked ones are
+	// easy to spot
+	AccessTokenPrefix = "sph_"
+
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// AccessToken is a personal access token API clients send as a bearer token.
+// Only the hash of the token is kept, the plain one is shown once at creation
+type AccessToken struct {
+	ID         int64
+	UserID     int64
+	Name       string
+	Token      string
+	Scopes     []string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	CreatedAt  time.Time
+}
+
+func (t *AccessToken) IsExpired() bool {
+	return t.ExpiresAt.Valid && time.Now().After(t.ExpiresAt.Time)
+}
+
+// Allows tells if the token scopes cover a request with the method. Reading
+// needs the read scope, anything else the write one
+func (t *AccessToken) Allows(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return slices.Contains(t.Scopes, ScopeRead)
+	}
+	return slices.Contains(t.Scopes, ScopeWrite)
+}
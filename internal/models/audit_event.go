@@ -0,0 +1,49 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	AuditSigninSucceeded      = "signin.succeeded"
+	AuditSigninFailed         = "signin.failed"
+	AuditSignout              = "signout"
+	AuditSessionRevoked       = "session.revoked"
+	AuditOtherSessionsRevoked = "session.others_revoked"
+	AuditOAuthLinked          = "oauth.linked"
+	AuditOAuthUnlinked        = "oauth.unlinked"
+	AuditUserFollowed         = "user.followed"
+	AuditUserUnfollowed       = "user.unfollowed"
+	AuditUserBlocked          = "user.blocked"
+	AuditUserUnblocked        = "user.unblocked"
+	AuditProfileUpdated       = "profile.updated"
+	AuditAccountUnlocked      = "account.unlocked"
+	AuditPostModerated        = "post.moderated"
+	AuditPostRemoved          = "post.removed"
+	AuditCommentModerated     = "comment.moderated"
+	AuditCommentRemoved       = "comment.removed"
+)
+
+const (
+	AuditTargetUser         = "user"
+	AuditTargetSession      = "session"
+	AuditTargetOAuthAccount = "oauth_account"
+	AuditTargetPost         = "post"
+	AuditTargetComment      = "comment"
+)
+
+// AuditEvent records that ActorID did Action to the target. The actor is not
+// set when nobody was signed in, such as on a failed sign in. Events are never
+// changed once saved
+type AuditEvent struct {
+	ID         int64
+	Action     string
+	ActorID    sql.NullInt64
+	TargetType string
+	TargetID   string
+	IP         string
+	RequestID  string
+	Metadata   map[string]string
+	CreatedAt  time.Time
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// MaxConversationParticipants is the size limit of group conversations,
+// counting their creator
+const MaxConversationParticipants = 10
+
+type Conversation struct {
+	ID        int64
+	IsGroup   bool
+	Title     string
+	CreatedBy sql.NullInt64
+	// DirectKey identifies 1:1 conversations by their pair of users
+	DirectKey    sql.NullString
+	Participants []*ConversationParticipant
+	// LastMessage and UnreadCount are only loaded when listing conversations
+	LastMessage *Message
+	UnreadCount int
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// HasParticipant reports whether the user takes part in the conversation
+func (c *Conversation) HasParticipant(userID int64) bool {
+	for _, participant := range c.Participants {
+		if participant.User.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ConversationParticipant carries the read receipt of a user: the last
+// message they have seen and when
+type ConversationParticipant struct {
+	User              *User
+	LastReadMessageID sql.NullInt64
+	LastReadAt        sql.NullTime
+	JoinedAt          time.Time
+}
+
+type Message struct {
+	ID             int64
+	ConversationID int64
+	Sender         *User
+	Content        string
+	CreatedAt      time.Time
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// LoginFailure counts the sign in attempts of an account or an address since
+// they were last forgotten. Attempts are counted before the password is
+// checked, so a success takes its own one back
+type LoginFailure struct {
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+	// PreviousFailedAt is when the attempt before the last one happened, it
+	// is only known right after counting one
+	PreviousFailedAt time.Time
+}
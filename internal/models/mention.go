@@ -0,0 +1,82 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// mentionRegex matches "@username" when the "@" is not glued to a previous
+// word, so e-mail addresses are not taken as mentions
+var mentionRegex = regexp.MustCompile(`(?:^|[^a-zA-Z0-9_@])(@([a-zA-Z0-9_]+))`)
+
+type Mention struct {
+	UserID   int64
+	Username string
+	// Start and End are the rune offsets of the "@username" text in the content
+	Start int
+	End   int
+}
+
+// UserMention is a place where a user was mentioned: a post, or a comment
+// under a post
+type UserMention struct {
+	Post      *Post
+	Comment   *Comment
+	CreatedAt time.Time
+}
+
+// ParseMentions finds every "@username" in the content whose username is
+// valid. Nothing is said about the user existing
+func ParseMentions(content string) []Mention {
+	var mentions []Mention
+	for _, match := range mentionRegex.FindAllStringSubmatchIndex(content, -1) {
+		start, end := match[2], match[3]
+		username := content[match[4]:match[5]]
+		if err := ValidateUsername(username); err != nil {
+			continue
+		}
+		mentions = append(mentions, Mention{
+			Username: username,
+			Start:    utf8.RuneCountInString(content[:start]),
+			End:      utf8.RuneCountInString(content[:end]),
+		})
+	}
+	return mentions
+}
+
+// MentionedUsernames returns the distinct usernames of the mentions
+func MentionedUsernames(mentions []Mention) []string {
+	seen := make(map[string]bool, len(mentions))
+	var usernames []string
+	for _, mention := range mentions {
+		key := strings.ToLower(mention.Username)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		usernames = append(usernames, mention.Username)
+	}
+	return usernames
+}
+
+// LinkMentions keeps the parsed mentions of users that were found, filling in
+// their IDs. Usernames are compared case-insensitively
+func LinkMentions(parsed []Mention, users []Mention) []Mention {
+	found := make(map[string]Mention, len(users))
+	for _, user := range users {
+		found[strings.ToLower(user.Username)] = user
+	}
+	var linked []Mention
+	for _, mention := range parsed {
+		user, ok := found[strings.ToLower(mention.Username)]
+		if !ok {
+			continue
+		}
+		mention.UserID = user.UserID
+		mention.Username = user.Username
+		linked = append(linked, mention)
+	}
+	return linked
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"database/sql"
 	"regexp"
 	"time"
 
@@ -40,18 +41,32 @@ type UserComment struct {
 }
 
 type Comment struct {
-	ID        int64       `json:"id"`
-	PostId    int64       `json:"post_id"`
-	UserId    int64       `json:"user_id"`
-	Content   string      `json:"content"`
-	CreatedAt string      `json:"created_at"`
-	User      UserComment `json:"user"`
+	ID         int64         `json:"id"`
+	PostId     int64         `json:"post_id"`
+	UserId     int64         `json:"user_id"`
+	ParentID   sql.NullInt64 `json:"parent_comment_id"`
+	Content    string        `json:"content"`
+	ReplyCount int           `json:"reply_count"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	User       UserComment   `json:"user"`
+	Mentions   []Mention     `json:"-"`
 }
 
 type Session struct {
-	ID        string    `json:"id"`
-	UserID    int64     `json:"user_id"`
-	ExpiresAt time.Time `json:"expires_at"`
+	ID         string    `json:"id"`
+	UserID     int64     `json:"user_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+}
+
+// SessionClient is the device a session is used from
+type SessionClient struct {
+	IP        string
+	UserAgent string
 }
 
 type Role struct {
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+const (
+	NotificationFollow   = "follow"
+	NotificationComment  = "comment"
+	NotificationReply    = "reply"
+	NotificationReaction = "reaction"
+	NotificationMention  = "mention"
+)
+
+// NotificationGroupActors is how many actors of a group are loaded by name,
+// the rest are only counted
+const NotificationGroupActors = 3
+
+// Notification tells UserID that ActorID did something involving them.
+// Notifications sharing a GroupKey are shown together, and only one
+// notification per DedupeKey is ever recorded for a user
+type Notification struct {
+	ID        int64
+	UserID    int64
+	ActorID   int64
+	Kind      string
+	PostID    sql.NullInt64
+	CommentID sql.NullInt64
+	GroupKey  string
+	DedupeKey sql.NullString
+	ReadAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+// NotificationGroup is what the user sees: one or more notifications of the
+// same kind about the same target, such as "3 people followed you". ID and
+// CreatedAt come from the most recent notification of the group
+type NotificationGroup struct {
+	ID          int64
+	Kind        string
+	PostID      sql.NullInt64
+	CommentID   sql.NullInt64
+	Actors      []*User
+	ActorsCount int
+	IsRead      bool
+	CreatedAt   time.Time
+}
+
+func (group *NotificationGroup) Message() string {
+	var action string
+	switch group.Kind {
+	case NotificationFollow:
+		action = "followed you"
+	case NotificationComment:
+		action = "commented on your post"
+	case NotificationReply:
+		action = "replied to your comment"
+	case NotificationReaction:
+		action = "reacted to your post"
+	case NotificationMention:
+		action = "mentioned you"
+	default:
+		action = "interacted with you"
+	}
+
+	switch {
+	case group.ActorsCount > 2 || len(group.Actors) == 0:
+		return fmt.Sprintf("%d people %s", group.ActorsCount, action)
+	case group.ActorsCount == 2 && len(group.Actors) == 2:
+		return fmt.Sprintf("%s and %s %s", group.Actors[0].Username, group.Actors[1].Username, action)
+	default:
+		return fmt.Sprintf("%s %s", group.Actors[0].Username, action)
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationGroupMessage(t *testing.T) {
+	hutao := &User{ID: 1, Username: "hutao"}
+	qiqi := &User{ID: 2, Username: "qiqi"}
+	zhongli := &User{ID: 3, Username: "zhongli"}
+
+	tests := []struct {
+		name     string
+		group    NotificationGroup
+		expected string
+	}{
+		{
+			name:     "one actor",
+			group:    NotificationGroup{Kind: NotificationFollow, Actors: []*User{hutao}, ActorsCount: 1},
+			expected: "hutao followed you",
+		},
+		{
+			name:     "two actors",
+			group:    NotificationGroup{Kind: NotificationReaction, Actors: []*User{hutao, qiqi}, ActorsCount: 2},
+			expected: "hutao and qiqi reacted to your post",
+		},
+		{
+			name:     "more actors than shown",
+			group:    NotificationGroup{Kind: NotificationComment, Actors: []*User{hutao, qiqi, zhongli}, ActorsCount: 5},
+			expected: "5 people commented on your post",
+		},
+		{
+			name:     "unknown kind",
+			group:    NotificationGroup{Kind: "poke", Actors: []*User{hutao}, ActorsCount: 1},
+			expected: "hutao interacted with you",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.group.Message())
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package pagination
+
+import (
+	"database/sql"
+	"net/url"
+	"strconv"
+
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+)
+
+const (
+	AuditEventsLimitDefault = 50
+	AuditEventsLimitMax     = 200
+	// AuditFilterMaxSize fits the session ids, the longest targets
+	AuditFilterMaxSize = 128
+)
+
+// AuditEvents filters the audit log, every filter that is set has to match.
+// Events are ordered by id, the newest first, so the cursor is the id of the
+// last event
+type AuditEvents struct {
+	Action     sql.NullString
+	ActorID    sql.NullInt64
+	TargetType sql.NullString
+	TargetID   sql.NullString
+	IP         sql.NullString
+	Since      sql.NullTime
+	Until      sql.NullTime
+
+	Limit      int
+	Cursor     sql.NullInt64
+	NextCursor string
+}
+
+func (auditEvents *AuditEvents) Parse(query url.Values) error {
+	for param, filter := range map[string]*sql.NullString{
+		"action":      &auditEvents.Action,
+		"target_type": &auditEvents.TargetType,
+		"target_id":   &auditEvents.TargetID,
+		"ip":          &auditEvents.IP,
+	} {
+		value := query.Get(param)
+		if len(value) > AuditFilterMaxSize {
+			return httpio.ErrInvalidSearchParamType
+		}
+		if value != "" {
+			*filter = sql.NullString{String: value, Valid: true}
+		}
+	}
+
+	actorID, err := parseID(query.Get("actor_id"))
+	if err != nil {
+		return err
+	}
+	auditEvents.ActorID = *actorID
+
+	since, err := parseDate(query.Get("since"))
+	if err != nil {
+		return err
+	}
+	until, err := parseDate(query.Get("until"))
+	if err != nil {
+		return err
+	}
+	auditEvents.Since = *since
+	auditEvents.Until = *until
+
+	limit, err := parseLimit(query.Get("limit"), AuditEventsLimitDefault, AuditEventsLimitMax)
+	if err != nil {
+		return err
+	}
+	auditEvents.Limit = *limit
+
+	cursor, err := parseID(query.Get("cursor"))
+	if err != nil {
+		return err
+	}
+	auditEvents.Cursor = *cursor
+
+	return nil
+}
+
+func parseID(idParam string) (*sql.NullInt64, error) {
+	id := sql.NullInt64{}
+	if idParam == "" {
+		return &id, nil
+	}
+	parsed, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil || parsed < 1 {
+		return nil, httpio.ErrInvalidSearchParamType
+	}
+	id = sql.NullInt64{Int64: parsed, Valid: true}
+	return &id, nil
+}
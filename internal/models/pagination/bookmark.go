@@ -0,0 +1,34 @@
+package pagination
+
+import (
+	"database/sql"
+)
+
+const (
+	BookmarksLimitDefault = 20
+	BookmarksLimitMax     = 50
+)
+
+type UserBookmarks struct {
+	UserID     int64
+	Limit      int
+	Cursor     sql.NullTime
+	NextCursor string
+}
+
+func (payload *UserBookmarks) Parse(limitParam, cursorParam string) error {
+	limit, err := parseLimit(limitParam, BookmarksLimitDefault, BookmarksLimitMax)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := parseCursor(cursorParam)
+	if err != nil {
+		return err
+	}
+
+	payload.Limit = *limit
+	payload.Cursor = *cursor
+
+	return nil
+}
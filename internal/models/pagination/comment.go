@@ -0,0 +1,35 @@
+package pagination
+
+import (
+	"database/sql"
+)
+
+const (
+	CommentsLimitDefault = 20
+	CommentsLimitMax     = 50
+)
+
+type PostComments struct {
+	PostID     int64
+	ParentID   sql.NullInt64
+	Limit      int
+	Cursor     sql.NullTime
+	NextCursor string
+}
+
+func (payload *PostComments) Parse(limitParam, cursorParam string) error {
+	limit, err := parseLimit(limitParam, CommentsLimitDefault, CommentsLimitMax)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := parseCursor(cursorParam)
+	if err != nil {
+		return err
+	}
+
+	payload.Limit = *limit
+	payload.Cursor = *cursor
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package pagination
+
+import (
+	"database/sql"
+)
+
+const (
+	ConversationsLimitDefault = 20
+	ConversationsLimitMax     = 50
+	MessagesLimitDefault      = 30
+	MessagesLimitMax          = 100
+)
+
+type UserConversations struct {
+	UserID     int64
+	Limit      int
+	Cursor     sql.NullTime
+	NextCursor string
+}
+
+func (payload *UserConversations) Parse(limitParam, cursorParam string) error {
+	limit, err := parseLimit(limitParam, ConversationsLimitDefault, ConversationsLimitMax)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := parseCursor(cursorParam)
+	if err != nil {
+		return err
+	}
+
+	payload.Limit = *limit
+	payload.Cursor = *cursor
+
+	return nil
+}
+
+type ConversationMessages struct {
+	ConversationID int64
+	Limit          int
+	Cursor         sql.NullTime
+	NextCursor     string
+}
+
+func (payload *ConversationMessages) Parse(limitParam, cursorParam string) error {
+	limit, err := parseLimit(limitParam, MessagesLimitDefault, MessagesLimitMax)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := parseCursor(cursorParam)
+	if err != nil {
+		return err
+	}
+
+	payload.Limit = *limit
+	payload.Cursor = *cursor
+
+	return nil
+}
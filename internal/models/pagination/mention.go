@@ -0,0 +1,34 @@
+package pagination
+
+import (
+	"database/sql"
+)
+
+const (
+	MentionsLimitDefault = 20
+	MentionsLimitMax     = 50
+)
+
+type UserMentions struct {
+	UserID     int64
+	Limit      int
+	Cursor     sql.NullTime
+	NextCursor string
+}
+
+func (payload *UserMentions) Parse(limitParam, cursorParam string) error {
+	limit, err := parseLimit(limitParam, MentionsLimitDefault, MentionsLimitMax)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := parseCursor(cursorParam)
+	if err != nil {
+		return err
+	}
+
+	payload.Limit = *limit
+	payload.Cursor = *cursor
+
+	return nil
+}
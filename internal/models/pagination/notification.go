@@ -0,0 +1,34 @@
+package pagination
+
+import (
+	"database/sql"
+)
+
+const (
+	NotificationsLimitDefault = 20
+	NotificationsLimitMax     = 50
+)
+
+type UserNotifications struct {
+	UserID     int64
+	Limit      int
+	Cursor     sql.NullTime
+	NextCursor string
+}
+
+func (payload *UserNotifications) Parse(limitParam, cursorParam string) error {
+	limit, err := parseLimit(limitParam, NotificationsLimitDefault, NotificationsLimitMax)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := parseCursor(cursorParam)
+	if err != nil {
+		return err
+	}
+
+	payload.Limit = *limit
+	payload.Cursor = *cursor
+
+	return nil
+}
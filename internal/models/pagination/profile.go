@@ -12,7 +12,9 @@ const (
 var DefaultCursor = sql.NullTime{Valid: false}
 
 type UserPosts struct {
-	UserID     int64
+	UserID int64
+	// ViewerID is the signed in user whose reactions are returned, if any
+	ViewerID   int64
 	Limit      int
 	Cursor     sql.NullTime
 	Username   string
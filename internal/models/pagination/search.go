@@ -0,0 +1,138 @@
+package pagination
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/httpio"
+)
+
+const (
+	SearchLimitDefault = 10
+	SearchLimitMax     = 30
+	SearchQueryMaxSize = 100
+
+	SearchTypePosts = "posts"
+	SearchTypeUsers = "users"
+)
+
+// Search holds a ranked search request. Results are ordered by rank, so the
+// cursor is the rank and id of the last result instead of a date
+type Search struct {
+	Query    string
+	Type     string
+	Author   sql.NullString
+	Tag      sql.NullString
+	Since    sql.NullTime
+	Until    sql.NullTime
+	HasMedia sql.NullBool
+
+	Limit      int
+	CursorRank sql.NullFloat64
+	CursorID   int64
+	NextCursor string
+}
+
+func (search *Search) Parse(query url.Values) error {
+	search.Query = strings.TrimSpace(query.Get("q"))
+	if search.Query == "" {
+		return httpio.ErrEmptySearchParam
+	}
+	if len(search.Query) > SearchQueryMaxSize {
+		return httpio.ErrInvalidSearchParamType
+	}
+
+	search.Type = SearchTypePosts
+	if searchType := query.Get("type"); searchType != "" {
+		if searchType != SearchTypePosts && searchType != SearchTypeUsers {
+			return httpio.ErrInvalidSearchParamType
+		}
+		search.Type = searchType
+	}
+
+	if author := query.Get("author"); author != "" {
+		search.Author = sql.NullString{String: author, Valid: true}
+	}
+	if tag := query.Get("tag"); tag != "" {
+		search.Tag = sql.NullString{String: tag, Valid: true}
+	}
+
+	since, err := parseDate(query.Get("since"))
+	if err != nil {
+		return err
+	}
+	until, err := parseDate(query.Get("until"))
+	if err != nil {
+		return err
+	}
+	search.Since = *since
+	search.Until = *until
+
+	if hasMedia := query.Get("has_media"); hasMedia != "" {
+		parsed, err := strconv.ParseBool(hasMedia)
+		if err != nil {
+			return httpio.ErrInvalidSearchParamType
+		}
+		search.HasMedia = sql.NullBool{Bool: parsed, Valid: true}
+	}
+
+	limit, err := parseLimit(query.Get("limit"), SearchLimitDefault, SearchLimitMax)
+	if err != nil {
+		return err
+	}
+	search.Limit = *limit
+
+	return search.parseCursor(query.Get("cursor"))
+}
+
+// EncodeCursor builds the cursor that points right after the given result
+func (search *Search) EncodeCursor(rank float64, id int64) string {
+	return fmt.Sprintf("%s_%d", strconv.FormatFloat(rank, 'g', -1, 64), id)
+}
+
+func (search *Search) parseCursor(cursorParam string) error {
+	if cursorParam == "" {
+		return nil
+	}
+	if len(cursorParam) >= ParametersMaxSize {
+		return httpio.ErrInvalidSearchParamType
+	}
+	rankParam, idParam, ok := strings.Cut(cursorParam, "_")
+	if !ok {
+		return httpio.ErrInvalidSearchParamType
+	}
+	rank, err := strconv.ParseFloat(rankParam, 64)
+	if err != nil {
+		return httpio.ErrInvalidSearchParamType
+	}
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		return httpio.ErrInvalidSearchParamType
+	}
+	search.CursorRank = sql.NullFloat64{Float64: rank, Valid: true}
+	search.CursorID = id
+	return nil
+}
+
+// parseDate accepts both a full RFC3339 timestamp and a plain date
+func parseDate(dateParam string) (*sql.NullTime, error) {
+	date := sql.NullTime{}
+	if dateParam == "" {
+		return &date, nil
+	}
+	if len(dateParam) >= ParametersMaxSize {
+		return nil, httpio.ErrInvalidSearchParamType
+	}
+	for _, layout := range []string{time.RFC3339Nano, time.DateOnly} {
+		parsedTime, err := time.Parse(layout, dateParam)
+		if err == nil {
+			date = sql.NullTime{Time: parsedTime, Valid: true}
+			return &date, nil
+		}
+	}
+	return nil, httpio.ErrInvalidSearchParamType
+}
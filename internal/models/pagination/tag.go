@@ -0,0 +1,45 @@
+package pagination
+
+import (
+	"database/sql"
+)
+
+const (
+	TagPostsLimitDefault = 10
+	TagPostsLimitMax     = 20
+
+	TrendingTagsLimitDefault = 10
+	TrendingTagsLimitMax     = 50
+)
+
+type TagPosts struct {
+	Tag        string
+	Limit      int
+	Cursor     sql.NullTime
+	NextCursor string
+}
+
+func (payload *TagPosts) Parse(limitParam, cursorParam string) error {
+	limit, err := parseLimit(limitParam, TagPostsLimitDefault, TagPostsLimitMax)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := parseCursor(cursorParam)
+	if err != nil {
+		return err
+	}
+
+	payload.Limit = *limit
+	payload.Cursor = *cursor
+
+	return nil
+}
+
+func ParseTrendingLimit(limitParam string) (int, error) {
+	limit, err := parseLimit(limitParam, TrendingTagsLimitDefault, TrendingTagsLimitMax)
+	if err != nil {
+		return 0, err
+	}
+	return *limit, nil
+}
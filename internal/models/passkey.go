@@ -0,0 +1,43 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	PasskeyRegistration = "registration"
+	PasskeyLogin        = "login"
+)
+
+// Passkey is a WebAuthn credential a user signs in with. The public key is
+// kept COSE encoded, the way the authenticator sent it
+type Passkey struct {
+	ID           int64
+	UserID       int64
+	CredentialID []byte
+	PublicKey    []byte
+	SignCount    int64
+	Name         string
+	CreatedAt    time.Time
+	LastUsedAt   sql.NullTime
+}
+
+// PasskeyChallenge is the random value one ceremony has to sign. Registration
+// challenges belong to the user adding the passkey, login ones to nobody yet
+type PasskeyChallenge struct {
+	Challenge []byte
+	UserID    sql.NullInt64
+	Kind      string
+	ExpiresAt time.Time
+}
+
+// PasskeyCreation is what the browser needs to create a passkey for the user
+type PasskeyCreation struct {
+	Challenge  *PasskeyChallenge
+	User       *User
+	UserHandle []byte
+	// Passkeys are the ones the user already has, so an authenticator is not
+	// registered twice
+	Passkeys []*Passkey
+}
@@ -1,22 +1,203 @@
 package models
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	PasswordBcrypt   = "bcrypt"
+	PasswordArgon2id = "argon2id"
+
+	argon2SaltSize = 16
+	argon2KeySize  = 32
+)
+
+var (
+	ErrPasswordMismatch    = errors.New("password does not match")
+	ErrInvalidPasswordHash = errors.New("password hash is not valid")
+)
+
+// PasswordParams is how new password hashes are made. Hashes made before with
+// other parameters still match, and NeedsRehash tells when to replace them
+type PasswordParams struct {
+	Algorithm  string
+	BcryptCost int
+	// Argon2Memory is in KiB
+	Argon2Memory  uint32
+	Argon2Time    uint32
+	Argon2Threads uint8
+}
+
+// DefaultPasswordParams follows the OWASP recommendation for Argon2id
+var DefaultPasswordParams = PasswordParams{
+	Algorithm:     PasswordArgon2id,
+	BcryptCost:    bcrypt.DefaultCost,
+	Argon2Memory:  19 * 1024,
+	Argon2Time:    2,
+	Argon2Threads: 1,
+}
+
+// PasswordHashing is set once at startup, before any password is hashed
+var PasswordHashing = DefaultPasswordParams
+
+func (p *PasswordParams) Check() error {
+	switch p.Algorithm {
+	case PasswordBcrypt:
+		if p.BcryptCost < bcrypt.MinCost || p.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	case PasswordArgon2id:
+		if p.Argon2Memory < 8*uint32(p.Argon2Threads) || p.Argon2Time < 1 || p.Argon2Threads < 1 {
+			return fmt.Errorf("argon2id needs a time and threads of at least 1, and 8 KiB of memory per thread")
+		}
+	default:
+		return fmt.Errorf("unknown password hashing algorithm %q", p.Algorithm)
+	}
+	return nil
+}
+
+// password keeps the hash in the format of its algorithm, bcrypt's own or the
+// PHC string of argon2id, $argon2id$v=19$m=...,t=...,p=...$salt$key
 type password struct {
 	Hash []byte
 }
 
 func (p *password) Set(text string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(text), bcrypt.DefaultCost)
+	params := PasswordHashing
+	switch params.Algorithm {
+	case PasswordBcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(text), params.BcryptCost)
+		if err != nil {
+			return err
+		}
+		p.Hash = hash
+		return nil
+	case PasswordArgon2id:
+		salt := make([]byte, argon2SaltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return err
+		}
+		hash := argon2Hash{
+			memory:  params.Argon2Memory,
+			time:    params.Argon2Time,
+			threads: params.Argon2Threads,
+			salt:    salt,
+		}
+		hash.key = hash.derive(text, argon2KeySize)
+		p.Hash = []byte(hash.String())
+		return nil
+	}
+	return fmt.Errorf("unknown password hashing algorithm %q", params.Algorithm)
+}
+
+func (p *password) Compare(text string) error {
+	if !isArgon2Hash(p.Hash) {
+		if err := bcrypt.CompareHashAndPassword(p.Hash, []byte(text)); err != nil {
+			if err == bcrypt.ErrMismatchedHashAndPassword {
+				return ErrPasswordMismatch
+			}
+			return err
+		}
+		return nil
+	}
+
+	hash, err := parseArgon2Hash(string(p.Hash))
 	if err != nil {
 		return err
 	}
-	p.Hash = hash
+	key := hash.derive(text, uint32(len(hash.key)))
+	if subtle.ConstantTimeCompare(key, hash.key) != 1 {
+		return ErrPasswordMismatch
+	}
 	return nil
 }
 
-func (p *password) Compare(text string) error {
-	return bcrypt.CompareHashAndPassword(p.Hash, []byte(text))
+// NeedsRehash tells if the hash was made with another algorithm or other
+// parameters than the current ones, it should be replaced the next time the
+// password is known
+func (p *password) NeedsRehash() bool {
+	params := PasswordHashing
+	if !isArgon2Hash(p.Hash) {
+		if params.Algorithm != PasswordBcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost(p.Hash)
+		return err != nil || cost != params.BcryptCost
+	}
+
+	if params.Algorithm != PasswordArgon2id {
+		return true
+	}
+	hash, err := parseArgon2Hash(string(p.Hash))
+	if err != nil {
+		return true
+	}
+	return hash.memory != params.Argon2Memory ||
+		hash.time != params.Argon2Time ||
+		hash.threads != params.Argon2Threads ||
+		len(hash.salt) != argon2SaltSize ||
+		len(hash.key) != argon2KeySize
+}
+
+type argon2Hash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func isArgon2Hash(hash []byte) bool {
+	return strings.HasPrefix(string(hash), "$"+PasswordArgon2id+"$")
+}
+
+func (h *argon2Hash) derive(text string, size uint32) []byte {
+	return argon2.IDKey([]byte(text), h.salt, h.time, h.memory, h.threads, size)
+}
+
+func (h *argon2Hash) String() string {
+	return fmt.Sprintf(
+		"$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		PasswordArgon2id,
+		argon2.Version,
+		h.memory,
+		h.time,
+		h.threads,
+		base64.RawStdEncoding.EncodeToString(h.salt),
+		base64.RawStdEncoding.EncodeToString(h.key),
+	)
+}
+
+func parseArgon2Hash(encoded string) (*argon2Hash, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != PasswordArgon2id {
+		return nil, ErrInvalidPasswordHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrInvalidPasswordHash
+	}
+
+	var hash argon2Hash
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &hash.memory, &hash.time, &hash.threads)
+	if err != nil || hash.time < 1 || hash.threads < 1 {
+		return nil, ErrInvalidPasswordHash
+	}
+	hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil || len(hash.salt) == 0 {
+		return nil, ErrInvalidPasswordHash
+	}
+	hash.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash.key) == 0 {
+		return nil, ErrInvalidPasswordHash
+	}
+	return &hash, nil
 }
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// withPasswordHashing makes new hashes with the params for the rest of the test
+func withPasswordHashing(t *testing.T, params PasswordParams) {
+	t.Helper()
+	previous := PasswordHashing
+	PasswordHashing = params
+	t.Cleanup(func() { PasswordHashing = previous })
+}
+
+func TestPassword(t *testing.T) {
+	argon2Params := PasswordParams{Algorithm: PasswordArgon2id, Argon2Memory: 64, Argon2Time: 1, Argon2Threads: 1}
+	bcryptParams := PasswordParams{Algorithm: PasswordBcrypt, BcryptCost: bcrypt.MinCost}
+
+	t.Run("hashes with argon2id in the PHC format", func(t *testing.T) {
+		withPasswordHashing(t, argon2Params)
+
+		var p password
+		require.NoError(t, p.Set("hutao-password"))
+		assert.True(t, strings.HasPrefix(string(p.Hash), "$argon2id$v=19$m=64,t=1,p=1$"))
+		assert.NoError(t, p.Compare("hutao-password"))
+		assert.ErrorIs(t, p.Compare("chaee-password"), ErrPasswordMismatch)
+		assert.False(t, p.NeedsRehash())
+
+		var other password
+		require.NoError(t, other.Set("hutao-password"))
+		assert.NotEqual(t, p.Hash, other.Hash, "salt is random")
+	})
+
+	t.Run("still verifies bcrypt hashes and asks to rehash them", func(t *testing.T) {
+		withPasswordHashing(t, bcryptParams)
+		var p password
+		require.NoError(t, p.Set("hutao-password"))
+		assert.False(t, p.NeedsRehash())
+
+		withPasswordHashing(t, argon2Params)
+		assert.NoError(t, p.Compare("hutao-password"))
+		assert.ErrorIs(t, p.Compare("chaee-password"), ErrPasswordMismatch)
+		assert.True(t, p.NeedsRehash())
+	})
+
+	t.Run("asks to rehash when the parameters change", func(t *testing.T) {
+		withPasswordHashing(t, argon2Params)
+		var p password
+		require.NoError(t, p.Set("hutao-password"))
+
+		stronger := argon2Params
+		stronger.Argon2Time = 2
+		withPasswordHashing(t, stronger)
+		assert.NoError(t, p.Compare("hutao-password"))
+		assert.True(t, p.NeedsRehash())
+
+		withPasswordHashing(t, PasswordParams{Algorithm: PasswordBcrypt, BcryptCost: bcrypt.MinCost + 1})
+		var bcryptPassword password
+		bcryptPassword.Hash, _ = bcrypt.GenerateFromPassword([]byte("hutao-password"), bcrypt.MinCost)
+		assert.True(t, bcryptPassword.NeedsRehash())
+	})
+
+	t.Run("refuses malformed argon2id hashes", func(t *testing.T) {
+		hashes := []string{
+			"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+			"$argon2id$v=18$m=64,t=1,p=1$c2FsdA$a2V5",
+			"$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5",
+			"$argon2id$v=19$m=64,t=1,p=1$$a2V5",
+			"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$!!",
+		}
+		for _, hash := range hashes {
+			p := password{Hash: []byte(hash)}
+			assert.ErrorIs(t, p.Compare("hutao-password"), ErrInvalidPasswordHash, hash)
+			assert.True(t, p.NeedsRehash(), hash)
+		}
+	})
+
+	t.Run("checks the parameters", func(t *testing.T) {
+		assert.NoError(t, DefaultPasswordParams.Check())
+		assert.NoError(t, argon2Params.Check())
+		assert.NoError(t, bcryptParams.Check())
+
+		invalid := []PasswordParams{
+			{Algorithm: "md5"},
+			{Algorithm: PasswordBcrypt, BcryptCost: 2},
+			{Algorithm: PasswordArgon2id, Argon2Memory: 64, Argon2Time: 0, Argon2Threads: 1},
+			{Algorithm: PasswordArgon2id, Argon2Memory: 4, Argon2Time: 1, Argon2Threads: 1},
+		}
+		for _, params := range invalid {
+			assert.Error(t, params.Check(), params)
+		}
+	})
+}
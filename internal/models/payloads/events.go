@@ -0,0 +1,9 @@
+package payloads
+
+// SocketMessagePayload is sent by WebSocket clients to pick the channels they
+// receive events from. PostID is only used by the comments channel
+type SocketMessagePayload struct {
+	Action  string `json:"action" validate:"required,oneof=subscribe unsubscribe"`
+	Channel string `json:"channel" validate:"required,oneof=feed notifications messages comments"`
+	PostID  int64  `json:"post_id,omitempty" validate:"required_if=Channel comments,omitempty,min=1"`
+}
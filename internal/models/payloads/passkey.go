@@ -0,0 +1,37 @@
+package payloads
+
+// The passkey payloads follow the JSON form of PublicKeyCredential, with the
+// binary fields base64url encoded
+
+type RegisterPasskeyPayload struct {
+	Name       string                    `json:"name" validate:"max=64"`
+	Credential PasskeyAttestationPayload `json:"credential"`
+}
+
+type PasskeyAttestationPayload struct {
+	ID       string                     `json:"id" validate:"required,max=1400"`
+	Type     string                     `json:"type" validate:"eq=public-key"`
+	Response PasskeyAttestationResponse `json:"response"`
+}
+
+type PasskeyAttestationResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required,max=4096"`
+	AttestationObject string `json:"attestationObject" validate:"required,max=16384"`
+}
+
+type PasskeyAssertionPayload struct {
+	ID       string                   `json:"id" validate:"required,max=1400"`
+	Type     string                   `json:"type" validate:"eq=public-key"`
+	Response PasskeyAssertionResponse `json:"response"`
+}
+
+type PasskeyAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON" validate:"required,max=4096"`
+	AuthenticatorData string `json:"authenticatorData" validate:"required,max=4096"`
+	Signature         string `json:"signature" validate:"required,max=1024"`
+	UserHandle        string `json:"userHandle" validate:"max=128"`
+}
+
+type RenamePasskeyPayload struct {
+	Name string `json:"name" validate:"required,max=64"`
+}
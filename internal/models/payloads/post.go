@@ -10,3 +10,21 @@ type UpdatePostPayload struct {
 	Tittle  string `json:"tittle" validate:"omitempty,min=1,max=100"`
 	Content string `json:"content" validate:"omitempty,min=1,max=1000"`
 }
+
+type QuotePostPayload struct {
+	Tittle  string `json:"tittle" validate:"max=100"`
+	Content string `json:"content" validate:"required,min=1,max=1000"`
+}
+
+type CreateCommentPayload struct {
+	Content  string `json:"content" validate:"required,min=1,max=1000"`
+	ParentID *int64 `json:"parent_comment_id,omitempty" validate:"omitempty,min=1"`
+}
+
+type UpdateCommentPayload struct {
+	Content string `json:"content" validate:"required,min=1,max=1000"`
+}
+
+type ReactPayload struct {
+	Kind string `json:"kind" validate:"required,max=32"`
+}
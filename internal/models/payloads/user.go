@@ -2,7 +2,13 @@ package payloads
 
 type CreateUserPayload struct{}
 
-type UpdateUserPayload struct{}
+// UpdateUserPayload only changes the fields that are sent, an empty string
+// clears the field
+type UpdateUserPayload struct {
+	Description *string `json:"description" validate:"omitnil,max=300"`
+	Location    *string `json:"location" validate:"omitnil,max=100"`
+	UserLink    *string `json:"user_link" validate:"omitnil,max=255,eq=|http_url"`
+}
 
 type DeleteUserPayload struct{}
 
@@ -17,3 +23,60 @@ type SigninPayload struct {
 	Email    string `json:"email" validate:"required,email,max=255"`
 	Password string `json:"password" validate:"required,min=3,max=72"`
 }
+
+type MagicLinkPayload struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type MagicLinkSigninPayload struct {
+	Token string `json:"token" validate:"required,max=255"`
+}
+
+type MagicLinkPreferencePayload struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+}
+
+type ResendActivationPayload struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type ResetPasswordPayload struct {
+	Token    string `json:"token" validate:"required,max=255"`
+	Password string `json:"password" validate:"required,min=3,max=72"`
+}
+
+type ChangeEmailPayload struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+}
+
+type EnableTwoFactorPayload struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type DisableTwoFactorPayload struct {
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+type VerifyTwoFactorPayload struct {
+	Token string `json:"token" validate:"required,max=64"`
+	Code  string `json:"code" validate:"required,max=16"`
+}
+
+type CreateAccessTokenPayload struct {
+	Name          string   `json:"name" validate:"required,max=64"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,max=2,dive,oneof=read write"`
+	ExpiresInDays *int     `json:"expires_in_days" validate:"omitnil,min=1,max=365"`
+}
+
+type CreateConversationPayload struct {
+	ParticipantIDs []int64 `json:"participant_ids" validate:"required,min=1,max=9,dive,min=1"`
+	Title          string  `json:"title" validate:"max=100"`
+}
+
+type SendMessagePayload struct {
+	Content string `json:"content" validate:"required,min=1,max=2000"`
+}
@@ -15,9 +15,58 @@ type Post struct {
 	UpdatedAt time.Time
 	Comments  []Comment
 	User      *User
+
+	// RepostOfID is set when the post is a plain repost of another one
+	RepostOfID sql.NullInt64
+	// QuoteOfID is set when the post quotes another one with its own content
+	QuoteOfID sql.NullInt64
+	// Quoted is the post referenced by QuoteOfID, when it was loaded
+	Quoted *Post
+	// Mentions are the users referenced in the content, when they were loaded
+	Mentions []Mention
+}
+
+// IsRepost reports whether the post only boosts another post
+func (p *Post) IsRepost() bool {
+	return p.RepostOfID.Valid
 }
 
 type PostWithMetadata struct {
 	Post
-	CommentCount int `json:"comment_count,omitempty"`
+	CommentCount int             `json:"comment_count,omitempty"`
+	Reactions    ReactionSummary `json:"reactions"`
+	// RepostedBy is the followed user that brought the post into the feed
+	RepostedBy *User `json:"reposted_by,omitempty"`
+	// TimelineAt is when the post entered the feed: its creation or the
+	// time it was reposted. Feed cursors are based on it
+	TimelineAt time.Time `json:"timeline_at"`
+}
+
+type Reaction struct {
+	PostID    int64
+	UserID    int64
+	Kind      string
+	CreatedAt time.Time
+}
+
+type ReactionSummary struct {
+	// Counts maps each reaction kind to how many users left it on the post
+	Counts map[string]int `json:"counts"`
+	// ViewerReaction is the kind the requesting user reacted with, if any
+	ViewerReaction string `json:"viewer_reaction,omitempty"`
+}
+
+type Bookmark struct {
+	UserID    int64
+	Post      *Post
+	CreatedAt time.Time
+}
+
+type TrendingTag struct {
+	Tag string
+	// PostCount is the number of posts using the tag inside the trending window
+	PostCount int
+	// UserCount is the number of distinct users behind those posts
+	UserCount  int
+	ComputedAt time.Time
 }
@@ -0,0 +1,20 @@
+package responses
+
+import "time"
+
+type AuditEventResponse struct {
+	ID         int64             `json:"id"`
+	Action     string            `json:"action"`
+	ActorID    *int64            `json:"actor_id"`
+	TargetType string            `json:"target_type"`
+	TargetID   string            `json:"target_id"`
+	IP         string            `json:"ip"`
+	RequestID  string            `json:"request_id"`
+	Metadata   map[string]string `json:"metadata"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type GetAuditEventsResponse struct {
+	Events     []AuditEventResponse `json:"events"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
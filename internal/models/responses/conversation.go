@@ -0,0 +1,38 @@
+package responses
+
+import "time"
+
+type ConversationParticipantResponse struct {
+	User              UserResponse `json:"user"`
+	LastReadMessageID *int64       `json:"last_read_message_id,omitempty"`
+	LastReadAt        *time.Time   `json:"last_read_at,omitempty"`
+}
+
+type ConversationResponse struct {
+	ID           int64                             `json:"id"`
+	IsGroup      bool                              `json:"is_group"`
+	Title        string                            `json:"title,omitempty"`
+	Participants []ConversationParticipantResponse `json:"participants"`
+	LastMessage  *MessageResponse                  `json:"last_message,omitempty"`
+	UnreadCount  int                               `json:"unread_count"`
+	CreatedAt    time.Time                         `json:"created_at"`
+	UpdatedAt    time.Time                         `json:"updated_at"`
+}
+
+type GetConversationsResponse struct {
+	Conversations []ConversationResponse `json:"conversations"`
+	NextCursor    string                 `json:"next_cursor,omitempty"`
+}
+
+type MessageResponse struct {
+	ID             int64        `json:"id"`
+	ConversationID int64        `json:"conversation_id"`
+	Sender         UserResponse `json:"sender"`
+	Content        string       `json:"content"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+type GetMessagesResponse struct {
+	Messages   []MessageResponse `json:"messages"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
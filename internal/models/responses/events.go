@@ -0,0 +1,14 @@
+package responses
+
+import "github.com/mochaeng/sapphire-backend/internal/events"
+
+// SocketMessageResponse is sent to WebSocket clients. Type is "event" for
+// pushed events, or the action a client message asked for with an Error
+// when it could not be done
+type SocketMessageResponse struct {
+	Type    string        `json:"type"`
+	Channel string        `json:"channel,omitempty"`
+	PostID  int64         `json:"post_id,omitempty"`
+	Event   *events.Event `json:"event,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
@@ -0,0 +1,24 @@
+package responses
+
+import "time"
+
+type NotificationResponse struct {
+	ID          int64          `json:"id"`
+	Kind        string         `json:"kind"`
+	Message     string         `json:"message"`
+	PostID      *int64         `json:"post_id,omitempty"`
+	CommentID   *int64         `json:"comment_id,omitempty"`
+	Actors      []UserResponse `json:"actors"`
+	ActorsCount int            `json:"actors_count"`
+	IsRead      bool           `json:"is_read"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type GetNotificationsResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	NextCursor    string                 `json:"next_cursor,omitempty"`
+}
+
+type UnreadNotificationsResponse struct {
+	Count int `json:"count"`
+}
@@ -0,0 +1,11 @@
+package responses
+
+type OAuthAccountResponse struct {
+	Provider       string `json:"provider"`
+	ProviderUserID string `json:"provider_user_id"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type GetOAuthAccountsResponse struct {
+	Accounts []OAuthAccountResponse `json:"accounts"`
+}
@@ -3,40 +3,67 @@ package responses
 import "time"
 
 type CreatePostResponse struct {
-	ID        int64     `json:"id"`
-	Tittle    string    `json:"tittle"`
-	Content   string    `json:"content"`
-	Tags      []string  `json:"tags,omitempty"`
-	MediaURL  string    `json:"media_url,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UserID    int64     `json:"user_id"`
+	ID        int64             `json:"id"`
+	Tittle    string            `json:"tittle"`
+	Content   string            `json:"content"`
+	Tags      []string          `json:"tags,omitempty"`
+	MediaURL  string            `json:"media_url,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UserID    int64             `json:"user_id"`
+	RepostOf  *int64            `json:"repost_of_id,omitempty"`
+	QuoteOf   *int64            `json:"quote_of_id,omitempty"`
+	Mentions  []MentionResponse `json:"mentions,omitempty"`
 }
 
 type UpdatePostResponse struct {
-	Tittle    string    `json:"tittle"`
-	Content   string    `json:"content"`
-	UpdatedAt time.Time `json:"updated_at"`
+	Tittle    string            `json:"tittle"`
+	Content   string            `json:"content"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Mentions  []MentionResponse `json:"mentions,omitempty"`
+}
+
+// MentionResponse is a user referenced in a content. Start and End are the
+// rune offsets of the "@username" text
+type MentionResponse struct {
+	UserID   int64  `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
 }
 
 type PostResponse struct {
-	ID        int64         `json:"id"`
-	Tittle    string        `json:"tittle,omitempty"`
-	Content   string        `json:"content"`
-	Tags      []string      `json:"tags,omitempty"`
-	MediaURL  string        `json:"media_url,omitempty"`
-	CreatedAt time.Time     `json:"created_at"`
-	UpdatedAt time.Time     `json:"updated_at"`
-	User      *UserResponse `json:"user,omitempty"`
+	ID           int64              `json:"id"`
+	Tittle       string             `json:"tittle,omitempty"`
+	Content      string             `json:"content"`
+	Tags         []string           `json:"tags,omitempty"`
+	MediaURL     string             `json:"media_url,omitempty"`
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+	User         *UserResponse      `json:"user,omitempty"`
+	CommentCount int                `json:"comment_count,omitempty"`
+	Reactions    *ReactionsResponse `json:"reactions,omitempty"`
+	RepostedBy   *UserResponse      `json:"reposted_by,omitempty"`
+	RepostedAt   *time.Time         `json:"reposted_at,omitempty"`
+	Quote        *PostResponse      `json:"quote,omitempty"`
 }
 
 type GetPostResponse struct {
-	Tittle    string       `json:"tittle"`
-	Content   string       `json:"content"`
-	Tags      []string     `json:"tags,omitempty"`
-	MediaURL  string       `json:"media_url,omitempty"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
-	User      UserResponse `json:"user"`
+	Tittle    string             `json:"tittle"`
+	Content   string             `json:"content"`
+	Tags      []string           `json:"tags,omitempty"`
+	MediaURL  string             `json:"media_url,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+	User      UserResponse       `json:"user"`
+	Reactions *ReactionsResponse `json:"reactions,omitempty"`
+	RepostOf  *int64             `json:"repost_of_id,omitempty"`
+	QuoteOf   *int64             `json:"quote_of_id,omitempty"`
+	Mentions  []MentionResponse  `json:"mentions,omitempty"`
+}
+
+type ReactionsResponse struct {
+	Counts         map[string]int `json:"counts"`
+	ViewerReaction string         `json:"viewer_reaction,omitempty"`
 }
 
 type GetUserPostsResponse struct {
@@ -49,3 +76,64 @@ type FeedResponse struct {
 	Posts      []PostResponse `json:"posts"`
 	NextCursor string         `json:"next_cursor,omitempty"`
 }
+
+type CommentResponse struct {
+	ID         int64             `json:"id"`
+	PostID     int64             `json:"post_id"`
+	ParentID   *int64            `json:"parent_comment_id,omitempty"`
+	Content    string            `json:"content"`
+	ReplyCount int               `json:"reply_count"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+	User       *UserResponse     `json:"user,omitempty"`
+	Mentions   []MentionResponse `json:"mentions,omitempty"`
+}
+
+type GetCommentsResponse struct {
+	Comments   []CommentResponse `json:"comments"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+type BookmarkResponse struct {
+	Post      PostResponse `json:"post"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+type GetBookmarksResponse struct {
+	Bookmarks  []BookmarkResponse `json:"bookmarks"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+type SearchResponse struct {
+	Posts      []PostResponse `json:"posts,omitempty"`
+	Users      []UserResponse `json:"users,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type TagPostsResponse struct {
+	Tag        string         `json:"tag"`
+	Posts      []PostResponse `json:"posts"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+type TrendingTagResponse struct {
+	Tag       string `json:"tag"`
+	PostCount int    `json:"post_count"`
+	UserCount int    `json:"user_count"`
+}
+
+type TrendingTagsResponse struct {
+	Tags       []TrendingTagResponse `json:"tags"`
+	ComputedAt *time.Time            `json:"computed_at,omitempty"`
+}
+
+type UserMentionResponse struct {
+	Post      PostResponse     `json:"post"`
+	Comment   *CommentResponse `json:"comment,omitempty"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type GetUserMentionsResponse struct {
+	Mentions   []UserMentionResponse `json:"mentions"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,18 @@
+package responses
+
+import "time"
+
+type TwoFactorSetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type SigninChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required"`
+	Token             string    `json:"token"`
+	ExpiresAt         time.Time `json:"expires_at"`
+}
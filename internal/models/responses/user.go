@@ -46,3 +46,7 @@ type AuthMeResponse struct {
 	Username  string `json:"username"`
 	RoleName  string `json:"role_name"`
 }
+
+type MagicLinkPreferenceResponse struct {
+	Enabled bool `json:"enabled"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	RecoveryCodesCount = 10
+	// MaxPendingLoginAttempts is how many wrong codes end a pending login
+	MaxPendingLoginAttempts = 5
+)
+
+// TwoFactor is the TOTP setup of a user. The secret is kept encrypted and the
+// setup only counts once it was enabled with a valid code
+type TwoFactor struct {
+	UserID       int64
+	Secret       []byte
+	EnabledAt    sql.NullTime
+	LastUsedStep sql.NullInt64
+	CreatedAt    time.Time
+}
+
+func (t *TwoFactor) IsEnabled() bool {
+	return t != nil && t.EnabledAt.Valid
+}
+
+type TwoFactorSetup struct {
+	Secret string
+	URI    string
+}
+
+// PendingLogin is a sign in that checked the password and still waits for a
+// second factor
+type PendingLogin struct {
+	Token     string
+	User      *User
+	Attempts  int
+	ExpiresAt time.Time
+}
@@ -17,11 +17,34 @@ type User struct {
 }
 
 type UserInvitation struct {
+	User      *User         `json:"user"`
+	Token     string        `json:"token"`
+	Expired   time.Duration `json:"expired"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type PasswordReset struct {
 	User    *User         `json:"user"`
 	Token   string        `json:"token"`
 	Expired time.Duration `json:"expired"`
 }
 
+// MagicLink signs the user in once, from the browser that asked for it. Token
+// and Browser are kept hashed
+type MagicLink struct {
+	User    *User         `json:"user"`
+	Token   string        `json:"token"`
+	Browser string        `json:"browser"`
+	Expired time.Duration `json:"expired"`
+}
+
+type EmailChange struct {
+	User     *User         `json:"user"`
+	NewEmail string        `json:"new_email"`
+	Token    string        `json:"token"`
+	Expired  time.Duration `json:"expired"`
+}
+
 type UserProfile struct {
 	User          *User
 	ID            int64
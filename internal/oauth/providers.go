@@ -0,0 +1,92 @@
+// Package oauth builds the sign in providers from the configuration
+package oauth
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/markbates/goth"
+	"github.com/markbates/goth/providers/github"
+	"github.com/markbates/goth/providers/google"
+	"github.com/markbates/goth/providers/openidConnect"
+	"github.com/mochaeng/sapphire-backend/internal/config"
+)
+
+var providerNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// NewProviders builds one goth provider per configured provider, named the
+// way it appears in the URLs. OIDC providers read the discovery document of
+// their issuer, so the issuer has to be reachable
+func NewProviders(cfg *config.OAuthConfig) ([]goth.Provider, error) {
+	providers := make([]goth.Provider, 0, len(cfg.Providers))
+	seen := make(map[string]bool, len(cfg.Providers))
+	for _, providerCfg := range cfg.Providers {
+		if !providerNameRegex.MatchString(providerCfg.Name) {
+			return nil, fmt.Errorf("oauth provider name %q is not valid", providerCfg.Name)
+		}
+		if seen[providerCfg.Name] {
+			return nil, fmt.Errorf("oauth provider %q is configured twice", providerCfg.Name)
+		}
+		seen[providerCfg.Name] = true
+		if providerCfg.Key == "" || providerCfg.CallbackURI == "" {
+			return nil, fmt.Errorf("oauth provider %q needs a key and a callback URI", providerCfg.Name)
+		}
+
+		provider, err := newProvider(&providerCfg)
+		if err != nil {
+			return nil, fmt.Errorf("oauth provider %q: %w", providerCfg.Name, err)
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// SkipUnconfigured drops the providers that have no key or callback URI, so a
+// deployment without OAuth credentials still starts. It returns their names
+func SkipUnconfigured(cfg *config.OAuthConfig) []string {
+	var skipped []string
+	configured := make([]config.OAuthProviderCfg, 0, len(cfg.Providers))
+	for _, providerCfg := range cfg.Providers {
+		if providerCfg.Key == "" || providerCfg.CallbackURI == "" {
+			skipped = append(skipped, providerCfg.Name)
+			continue
+		}
+		configured = append(configured, providerCfg)
+	}
+	cfg.Providers = configured
+	return skipped
+}
+
+func newProvider(cfg *config.OAuthProviderCfg) (goth.Provider, error) {
+	switch cfg.Kind {
+	case config.OAuthGoogle:
+		scopes := withDefaultScopes(cfg.Scopes, "email", "profile")
+		provider := google.New(cfg.Key, cfg.Secret, cfg.CallbackURI, scopes...)
+		provider.SetName(cfg.Name)
+		return provider, nil
+	case config.OAuthGitHub:
+		scopes := withDefaultScopes(cfg.Scopes, "read:user", "user:email")
+		provider := github.New(cfg.Key, cfg.Secret, cfg.CallbackURI, scopes...)
+		provider.SetName(cfg.Name)
+		return provider, nil
+	case config.OAuthOIDC:
+		if cfg.DiscoveryURL == "" {
+			return nil, fmt.Errorf("missing discovery URL")
+		}
+		scopes := withDefaultScopes(cfg.Scopes, "openid", "email", "profile")
+		provider, err := openidConnect.New(cfg.Key, cfg.Secret, cfg.CallbackURI, cfg.DiscoveryURL, scopes...)
+		if err != nil {
+			return nil, err
+		}
+		provider.SetName(cfg.Name)
+		return provider, nil
+	}
+	return nil, fmt.Errorf("unknown kind %q", cfg.Kind)
+}
+
+func withDefaultScopes(scopes []string, defaults ...string) []string {
+	if len(scopes) == 0 {
+		return defaults
+	}
+	return scopes
+}
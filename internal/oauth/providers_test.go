@@ -0,0 +1,114 @@
+package oauth_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/oauth"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProviders(t *testing.T) {
+	issuer := testutils.NewOIDCIssuer("sapphire")
+	defer issuer.Close()
+
+	cfg := &config.OAuthConfig{
+		Providers: []config.OAuthProviderCfg{
+			{Name: "google", Kind: config.OAuthGoogle, Key: "key", CallbackURI: "http://api/v1/auth/google/callback"},
+			{Name: "github", Kind: config.OAuthGitHub, Key: "key", CallbackURI: "http://api/v1/auth/github/callback"},
+			{
+				Name:         "sso",
+				Kind:         config.OAuthOIDC,
+				Key:          "sapphire",
+				Secret:       "secret",
+				CallbackURI:  "http://api/v1/auth/sso/callback",
+				DiscoveryURL: issuer.DiscoveryURL(),
+			},
+		},
+	}
+
+	t.Run("names the providers as configured", func(t *testing.T) {
+		providers, err := oauth.NewProviders(cfg)
+		require.NoError(t, err)
+		require.Len(t, providers, 3)
+		assert.Equal(t, "google", providers[0].Name())
+		assert.Equal(t, "github", providers[1].Name())
+		assert.Equal(t, "sso", providers[2].Name())
+	})
+
+	t.Run("signs in against an oidc issuer", func(t *testing.T) {
+		providers, err := oauth.NewProviders(cfg)
+		require.NoError(t, err)
+		provider := providers[2]
+
+		session, err := provider.BeginAuth("state")
+		require.NoError(t, err)
+		authURL, err := session.GetAuthURL()
+		require.NoError(t, err)
+		parsed, err := url.Parse(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, issuer.URL()+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+		assert.Equal(t, "sapphire", parsed.Query().Get("client_id"))
+		assert.Equal(t, "state", parsed.Query().Get("state"))
+
+		_, err = session.Authorize(provider, url.Values{"code": {issuer.Code}})
+		require.NoError(t, err)
+		user, err := provider.FetchUser(session)
+		require.NoError(t, err)
+		assert.Equal(t, "sso", user.Provider)
+		assert.Equal(t, issuer.Subject, user.UserID)
+		assert.Equal(t, issuer.Email, user.Email)
+		assert.Equal(t, issuer.Name, user.Name)
+	})
+
+	t.Run("refuses a wrong code", func(t *testing.T) {
+		providers, err := oauth.NewProviders(cfg)
+		require.NoError(t, err)
+		provider := providers[2]
+
+		session, err := provider.BeginAuth("state")
+		require.NoError(t, err)
+		_, err = session.Authorize(provider, url.Values{"code": {"forged"}})
+		assert.Error(t, err)
+	})
+
+	t.Run("returns an error on an invalid configuration", func(t *testing.T) {
+		invalid := map[string]config.OAuthProviderCfg{
+			"unknown kind":      {Name: "gitlab", Kind: "gitlab", Key: "key", CallbackURI: "http://api"},
+			"invalid name":      {Name: "Google/", Kind: config.OAuthGoogle, Key: "key", CallbackURI: "http://api"},
+			"missing key":       {Name: "google", Kind: config.OAuthGoogle, CallbackURI: "http://api"},
+			"missing discovery": {Name: "sso", Kind: config.OAuthOIDC, Key: "key", CallbackURI: "http://api"},
+		}
+		for name, providerCfg := range invalid {
+			_, err := oauth.NewProviders(&config.OAuthConfig{Providers: []config.OAuthProviderCfg{providerCfg}})
+			assert.Error(t, err, name)
+		}
+
+		duplicated := []config.OAuthProviderCfg{cfg.Providers[0], cfg.Providers[0]}
+		_, err := oauth.NewProviders(&config.OAuthConfig{Providers: duplicated})
+		assert.Error(t, err)
+	})
+}
+
+func TestSkipUnconfigured(t *testing.T) {
+	cfg := &config.OAuthConfig{
+		Providers: []config.OAuthProviderCfg{
+			{Name: "google", Kind: config.OAuthGoogle, CallbackURI: "http://api/v1/auth/google/callback"},
+			{Name: "github", Kind: config.OAuthGitHub, Key: "key", CallbackURI: "http://api/v1/auth/github/callback"},
+			{Name: "gitlab", Kind: config.OAuthGitHub, Key: "key"},
+		},
+	}
+
+	skipped := oauth.SkipUnconfigured(cfg)
+	assert.Equal(t, []string{"google", "gitlab"}, skipped)
+	assert.False(t, cfg.IsEnabled("google"))
+	assert.True(t, cfg.IsEnabled("github"))
+
+	providers, err := oauth.NewProviders(cfg)
+	require.NoError(t, err)
+	require.Len(t, providers, 1)
+	assert.Equal(t, "github", providers[0].Name())
+}
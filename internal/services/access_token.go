@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/cryptoutils"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidAccessToken = errors.New("access token is not valid")
+
+// accessTokenUsageInterval is how often the last time an access token was
+// used is saved while it keeps being used
+const accessTokenUsageInterval = 5 * time.Minute
+
+type AccessTokenService struct {
+	store  *store.Store
+	logger *zap.SugaredLogger
+}
+
+// Create issues a new personal access token. The plain token is only in the
+// returned value, it cannot be recovered later
+func (s *AccessTokenService) Create(ctx context.Context, user *models.User, payload *payloads.CreateAccessTokenPayload) (*models.AccessToken, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+
+	secret, err := cryptoutils.GenerateRandomString(20)
+	if err != nil {
+		return nil, err
+	}
+	accessToken := &models.AccessToken{
+		UserID: user.ID,
+		Name:   payload.Name,
+		Token:  models.AccessTokenPrefix + strings.ToLower(secret),
+		Scopes: compactScopes(payload.Scopes),
+	}
+	if payload.ExpiresInDays != nil {
+		accessToken.ExpiresAt = sql.NullTime{
+			Time:  time.Now().AddDate(0, 0, *payload.ExpiresInDays),
+			Valid: true,
+		}
+	}
+
+	if err := s.store.AccessToken.Create(ctx, accessToken, cryptoutils.GetSessionID(accessToken.Token)); err != nil {
+		return nil, err
+	}
+	return accessToken, nil
+}
+
+func (s *AccessTokenService) GetFromUser(ctx context.Context, userID int64) ([]*models.AccessToken, error) {
+	return s.store.AccessToken.GetFromUser(ctx, userID)
+}
+
+func (s *AccessTokenService) Revoke(ctx context.Context, userID int64, accessTokenID int64) error {
+	return s.store.AccessToken.Delete(ctx, userID, accessTokenID)
+}
+
+// Validate finds the access token of a bearer token that was not revoked and
+// did not expire
+func (s *AccessTokenService) Validate(ctx context.Context, token string) (*models.AccessToken, error) {
+	if !strings.HasPrefix(token, models.AccessTokenPrefix) {
+		return nil, ErrInvalidAccessToken
+	}
+	accessToken, err := s.store.AccessToken.GetByHash(ctx, cryptoutils.GetSessionID(token))
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, ErrInvalidAccessToken
+		}
+		return nil, err
+	}
+	if accessToken.IsExpired() {
+		return nil, ErrInvalidAccessToken
+	}
+
+	// the usage is saved once in a while, not on every request
+	if !accessToken.LastUsedAt.Valid || time.Since(accessToken.LastUsedAt.Time) > accessTokenUsageInterval {
+		if err := s.store.AccessToken.UpdateLastUsed(ctx, accessToken.ID); err != nil {
+			s.logger.Warnw("could not update access token usage", "token", accessToken.ID, "error", err)
+		}
+	}
+	return accessToken, nil
+}
+
+// compactScopes drops the repeated scopes, keeping their order
+func compactScopes(scopes []string) []string {
+	compacted := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !slices.Contains(compacted, scope) {
+			compacted = append(compacted, scope)
+		}
+	}
+	return compacted
+}
@@ -0,0 +1,63 @@
+package services_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/cryptoutils"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessTokenValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		lastUsedAt sql.NullTime
+		isUpdated  bool
+	}{
+		{"saves the first usage", "sph_never", sql.NullTime{}, true},
+		{"saves the usage once the interval passed", "sph_idle", sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}, true},
+		{"does not save the usage on every request", "sph_recent", sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}, false},
+	}
+	for idx, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			accessTokenStore := &mocks.MockAccessTokenStore{}
+			service := newTestServices(t, &config.Cfg{}, &store.Store{AccessToken: accessTokenStore}, nil)
+
+			accessToken := &models.AccessToken{ID: int64(idx + 1), UserID: 1, LastUsedAt: test.lastUsedAt}
+			accessTokenStore.On("GetByHash", mock.Anything, cryptoutils.GetSessionID(test.token)).Return(accessToken, nil)
+			accessTokenStore.On("UpdateLastUsed", mock.Anything, accessToken.ID).Return(nil)
+
+			validated, err := service.AccessToken.Validate(context.Background(), test.token)
+			require.NoError(t, err)
+			assert.Equal(t, accessToken, validated)
+			if test.isUpdated {
+				accessTokenStore.AssertCalled(t, "UpdateLastUsed", mock.Anything, accessToken.ID)
+			} else {
+				accessTokenStore.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything)
+			}
+		})
+	}
+
+	t.Run("refuses an expired token", func(t *testing.T) {
+		accessTokenStore := &mocks.MockAccessTokenStore{}
+		service := newTestServices(t, &config.Cfg{}, &store.Store{AccessToken: accessTokenStore}, nil)
+		accessTokenStore.On("GetByHash", mock.Anything, mock.Anything).Return(&models.AccessToken{
+			ID:        9,
+			ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true},
+		}, nil)
+
+		_, err := service.AccessToken.Validate(context.Background(), "sph_expired")
+		assert.ErrorIs(t, err, services.ErrInvalidAccessToken)
+		accessTokenStore.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything)
+	})
+}
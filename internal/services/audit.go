@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"go.uber.org/zap"
+)
+
+type auditIPKey struct{}
+
+// WithAuditIP keeps the address of the request in the context, so the audit
+// events recorded while serving it point to where it came from
+func WithAuditIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, auditIPKey{}, ip)
+}
+
+// AuditLogger records who did what to accounts and content. The other
+// services share it, and the admins read it back
+type AuditLogger struct {
+	store  *store.Store
+	logger *zap.SugaredLogger
+}
+
+// Record saves the event with the address and the request ID of ctx. The
+// action already happened, so a failure is only logged
+func (a *AuditLogger) Record(ctx context.Context, auditEvent *models.AuditEvent) {
+	auditEvent.IP, _ = ctx.Value(auditIPKey{}).(string)
+	auditEvent.RequestID = middleware.GetReqID(ctx)
+
+	// a request cancelled right after the action still has to leave a trace
+	if err := a.store.AuditEvent.Create(context.WithoutCancel(ctx), auditEvent); err != nil {
+		a.logger.Errorw(
+			"could not record audit event",
+			"action", auditEvent.Action,
+			"actor", auditEvent.ActorID.Int64,
+			"target", auditEvent.TargetType+":"+auditEvent.TargetID,
+			"error", err,
+		)
+	}
+}
+
+func (a *AuditLogger) GetEvents(ctx context.Context, auditEvents *pagination.AuditEvents) ([]*models.AuditEvent, error) {
+	events, nextCursor, err := a.store.AuditEvent.Get(ctx, auditEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	auditEvents.NextCursor = nextCursor
+
+	return events, nil
+}
+
+// newAuditEvent describes an action of actorID, which is zero when nobody is
+// signed in
+func newAuditEvent(action string, actorID int64, targetType string, targetID string, metadata map[string]string) *models.AuditEvent {
+	auditEvent := &models.AuditEvent{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Metadata:   metadata,
+	}
+	if actorID != 0 {
+		auditEvent.ActorID = validInt64(actorID)
+	}
+	return auditEvent
+}
+
+func auditID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
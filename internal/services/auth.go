@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,34 +17,50 @@ import (
 	"github.com/mochaeng/sapphire-backend/internal/models"
 	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
 	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/store/cache"
 	"go.uber.org/zap"
 )
 
 const AuthTokenKey = "session-id"
+
+// the ways to sign in, as recorded in the audit log
+const (
+	signinPassword  = "password"
+	signinMagicLink = "magic_link"
+	signinPasskey   = "passkey"
+	signinOAuth     = "oauth"
+)
 const sessionExpiresIn = 30 * 24 * time.Hour
 
+// sessionActivityInterval is how often the last time a session was seen is
+// saved while it keeps being used from the same device
+const sessionActivityInterval = 5 * time.Minute
+
 var (
 	ErrSetPasswordHash  = errors.New("could not set password hash")
 	ErrEmailSending     = errors.New("could not send e-mail")
 	ErrUserTokeCreation = errors.New("could not create user token")
+	ErrResendTooSoon    = errors.New("an activation e-mail was sent recently")
 
 	ErrInvalidSession = errors.New("user session is invalid")
 )
 
 type AuthService struct {
-	store  *store.Store
-	cfg    *config.Cfg
-	mailer mailer.Client
-	logger *zap.SugaredLogger
+	store      *store.Store
+	cfg        *config.Cfg
+	mailer     mailer.Client
+	logger     *zap.SugaredLogger
+	cacheStore *cache.Store
+	audit      *AuditLogger
 }
 
-func (s *AuthService) GetCookieSession(userID int64) (*http.Cookie, error) {
+func (s *AuthService) GetCookieSession(userID int64, client models.SessionClient) (*http.Cookie, error) {
 	token, err := s.GenerateSessionToken()
 	if err != nil {
 		return nil, fmt.Errorf("could not create session token. Error: %w", err)
 	}
 
-	session, err := s.CreateSession(token, userID)
+	session, err := s.CreateSession(token, userID, client)
 	if err != nil {
 		return nil, fmt.Errorf("could not create session. Error: %w", err)
 	}
@@ -96,6 +113,46 @@ func (s *AuthService) RegisterUser(ctx context.Context, payload *payloads.Regist
 	}
 	userInvitation.Token = plainToken
 
+	if err := s.sendActivationEmail(user, plainToken); err != nil {
+		if err := s.store.User.Delete(ctx, user.ID); err != nil {
+			s.logger.Errorw("error deleting user", "error", err)
+		}
+		return nil, err
+	}
+	return userInvitation, nil
+}
+
+// ResendActivation e-mails a new activation link to an account that was not
+// activated yet, invalidating the previous one. Unknown or active addresses
+// are not reported
+func (s *AuthService) ResendActivation(ctx context.Context, payload *payloads.ResendActivationPayload) error {
+	if err := models.Validate.Struct(payload); err != nil {
+		return ErrInvalidPayload
+	}
+
+	userInvitation, err := s.store.User.GetPendingInvitation(ctx, payload.Email)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	if time.Since(userInvitation.CreatedAt) < s.cfg.Mail.ResendInterval {
+		return ErrResendTooSoon
+	}
+
+	plainToken := uuid.NewString()
+	sha256Token := sha256.Sum256([]byte(plainToken))
+	userInvitation.Token = hex.EncodeToString(sha256Token[:])
+	userInvitation.Expired = s.cfg.Mail.Expired
+	if err := s.store.User.RotateInvitation(ctx, userInvitation); err != nil {
+		return err
+	}
+
+	return s.sendActivationEmail(userInvitation.User, plainToken)
+}
+
+func (s *AuthService) sendActivationEmail(user *models.User, plainToken string) error {
 	isSandBox := s.cfg.Env == "dev"
 	activationURL := fmt.Sprintf("%s/confirm/%s", s.cfg.FrontedURL, plainToken)
 	vars := struct {
@@ -114,42 +171,248 @@ func (s *AuthService) RegisterUser(ctx context.Context, payload *payloads.Regist
 	)
 	if err != nil {
 		s.logger.Errorw("error sending welcome email", "error", err)
-		if err := s.store.User.Delete(ctx, user.ID); err != nil {
-			s.logger.Errorw("error deleting user", "error", err)
+		return ErrEmailSending
+	}
+	s.logger.Infow("Email sent", "status code", status)
+	return nil
+}
+
+// ForgotPassword e-mails a reset link when the address belongs to an active
+// user. Unknown addresses are not reported, so the endpoint cannot be used to
+// find out who has an account
+func (s *AuthService) ForgotPassword(ctx context.Context, payload *payloads.ForgotPasswordPayload) error {
+	if err := models.Validate.Struct(payload); err != nil {
+		return ErrInvalidPayload
+	}
+
+	user, err := s.store.User.GetByActivatedEmail(ctx, payload.Email)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil
 		}
-		return nil, ErrEmailSending
+		return err
+	}
+
+	plainToken := uuid.NewString()
+	sha256Token := sha256.Sum256([]byte(plainToken))
+	passwordReset := &models.PasswordReset{
+		User:    user,
+		Token:   hex.EncodeToString(sha256Token[:]),
+		Expired: s.cfg.Mail.ResetExpired,
+	}
+	if err := s.store.User.CreatePasswordReset(ctx, passwordReset); err != nil {
+		return err
+	}
+
+	isSandBox := s.cfg.Env == "dev"
+	vars := struct {
+		Username  string
+		ResetURL  string
+		ExpiresIn string
+	}{
+		Username:  user.Username,
+		ResetURL:  fmt.Sprintf("%s/reset-password/%s", s.cfg.FrontedURL, plainToken),
+		ExpiresIn: s.cfg.Mail.ResetExpired.String(),
+	}
+	status, err := s.mailer.Send(
+		mailer.PasswordResetTemplate,
+		user.Username,
+		user.Email,
+		vars,
+		isSandBox,
+	)
+	if err != nil {
+		s.logger.Errorw("error sending password reset email", "error", err)
+		return ErrEmailSending
 	}
 	s.logger.Infow("Email sent", "status code", status)
-	return userInvitation, nil
+	return nil
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword and
+// signs the user out everywhere
+func (s *AuthService) ResetPassword(ctx context.Context, payload *payloads.ResetPasswordPayload) error {
+	if err := models.Validate.Struct(payload); err != nil {
+		return ErrInvalidPayload
+	}
+
+	var user models.User
+	if err := user.Password.Set(payload.Password); err != nil {
+		return ErrSetPasswordHash
+	}
+	return s.store.User.ResetPassword(ctx, payload.Token, &user)
 }
 
-func (s *AuthService) Authenticate(ctx context.Context, payload *payloads.SigninPayload) (*models.User, error) {
+// RequestEmailChange e-mails a confirmation link to the new address and lets
+// the current one know about it. The account keeps the current address until
+// the link is used
+func (s *AuthService) RequestEmailChange(ctx context.Context, user *models.User, payload *payloads.ChangeEmailPayload) error {
+	if err := models.Validate.Struct(payload); err != nil {
+		return ErrInvalidPayload
+	}
+	if strings.EqualFold(payload.Email, user.Email) {
+		return ErrInvalidPayload
+	}
+
+	_, err := s.store.User.GetByEmail(ctx, payload.Email)
+	if err == nil {
+		return store.ErrDuplicateEmail
+	} else if err != store.ErrNotFound {
+		return err
+	}
+
+	plainToken := uuid.NewString()
+	sha256Token := sha256.Sum256([]byte(plainToken))
+	emailChange := &models.EmailChange{
+		User:     user,
+		NewEmail: payload.Email,
+		Token:    hex.EncodeToString(sha256Token[:]),
+		Expired:  s.cfg.Mail.EmailChangeExpired,
+	}
+	if err := s.store.User.CreateEmailChange(ctx, emailChange); err != nil {
+		return err
+	}
+
+	isSandBox := s.cfg.Env == "dev"
+	vars := struct {
+		Username   string
+		NewEmail   string
+		ConfirmURL string
+		ExpiresIn  string
+	}{
+		Username:   user.Username,
+		NewEmail:   payload.Email,
+		ConfirmURL: fmt.Sprintf("%s/confirm-email/%s", s.cfg.FrontedURL, plainToken),
+		ExpiresIn:  s.cfg.Mail.EmailChangeExpired.String(),
+	}
+	status, err := s.mailer.Send(
+		mailer.EmailChangeTemplate,
+		user.Username,
+		payload.Email,
+		vars,
+		isSandBox,
+	)
+	if err != nil {
+		s.logger.Errorw("error sending email change confirmation", "error", err)
+		return ErrEmailSending
+	}
+	s.logger.Infow("Email sent", "status code", status)
+
+	_, err = s.mailer.Send(
+		mailer.EmailChangeNoticeTemplate,
+		user.Username,
+		user.Email,
+		vars,
+		isSandBox,
+	)
+	if err != nil {
+		s.logger.Errorw("error sending email change notice", "error", err)
+	}
+	return nil
+}
+
+// ConfirmEmailChange moves the account to the address the token was sent to
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, token string) error {
+	var user models.User
+	if err := s.store.User.ConfirmEmailChange(ctx, token, &user); err != nil {
+		return err
+	}
+	if s.cfg.Cacher.IsEnable {
+		if err := s.cacheStore.User.Delete(ctx, user.ID); err != nil {
+			s.logger.Errorw("could not invalidate cached user", "userID", user.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// Authenticate checks the password of the account. Attempts are counted for
+// the account and for the ip they come from before the password is checked,
+// and only a success takes them back. Both have to wait longer after each
+// failure and are locked when there are too many. With two-factor on, the
+// account keeps the attempt until CompleteLogin checks the code
+func (s *AuthService) Authenticate(ctx context.Context, payload *payloads.SigninPayload, ip string) (*models.User, error) {
 	if err := models.Validate.Struct(payload); err != nil {
 		return nil, ErrInvalidPayload
 	}
+	attempt, err := s.beginLoginAttempt(ctx, payload.Email, ip)
+	if err != nil {
+		if errors.Is(err, ErrTooManyLoginAttempts) {
+			s.auditSigninFailure(ctx, signinPassword, 0, payload.Email, "throttled")
+		}
+		return nil, err
+	}
 
 	user, err := s.store.User.GetByActivatedEmail(ctx, payload.Email)
 	if err != nil {
+		if err == store.ErrNotFound {
+			s.failLoginAttempt(ctx, attempt, nil)
+			s.auditSigninFailure(ctx, signinPassword, 0, payload.Email, "unknown_email")
+		}
 		return nil, err
 	}
 
 	if err := user.Password.Compare(payload.Password); err != nil {
+		s.failLoginAttempt(ctx, attempt, user)
+		s.auditSigninFailure(ctx, signinPassword, user.ID, payload.Email, "wrong_password")
 		return nil, store.ErrNotFound
 	}
+	s.auditSignin(ctx, signinPassword, user)
+	twoFactor, err := s.store.TwoFactor.Get(ctx, user.ID)
+	if err != nil && err != store.ErrNotFound {
+		return nil, err
+	}
+	if err == nil && twoFactor.IsEnabled() {
+		s.keepLoginAttempt(ctx, attempt)
+	} else {
+		s.succeedLoginAttempt(ctx, attempt)
+	}
+	if user.Password.NeedsRehash() {
+		s.rehashPassword(ctx, user, payload.Password)
+	}
 	return user, nil
 }
 
+// auditSignin records that the user proved who they are with the method. A
+// second factor may still be asked before the session is created
+func (s *AuthService) auditSignin(ctx context.Context, method string, user *models.User) {
+	metadata := map[string]string{"method": method}
+	s.audit.Record(ctx, newAuditEvent(models.AuditSigninSucceeded, user.ID, models.AuditTargetUser, auditID(user.ID), metadata))
+}
+
+// auditSigninFailure records a refused sign in, userID is zero when the
+// account is not known
+func (s *AuthService) auditSigninFailure(ctx context.Context, method string, userID int64, email string, reason string) {
+	metadata := map[string]string{"method": method, "email": email, "reason": reason}
+	s.audit.Record(ctx, newAuditEvent(models.AuditSigninFailed, 0, models.AuditTargetUser, auditID(userID), metadata))
+}
+
+// rehashPassword hashes the password again with the current parameters. The
+// sign in does not depend on it, it is tried again on the next one
+func (s *AuthService) rehashPassword(ctx context.Context, user *models.User, text string) {
+	oldHash := user.Password.Hash
+	if err := user.Password.Set(text); err != nil {
+		s.logger.Errorw("could not rehash password", "userID", user.ID, "error", err)
+		user.Password.Hash = oldHash
+		return
+	}
+	if err := s.store.User.UpdatePasswordHash(ctx, user.ID, oldHash, user.Password.Hash); err != nil {
+		s.logger.Errorw("could not save rehashed password", "userID", user.ID, "error", err)
+	}
+}
+
 func (s *AuthService) GenerateSessionToken() (string, error) {
 	return cryptoutils.GenerateRandomString(20)
 }
 
-func (s *AuthService) CreateSession(token string, userID int64) (*models.Session, error) {
+func (s *AuthService) CreateSession(token string, userID int64, client models.SessionClient) (*models.Session, error) {
 	sessionID := cryptoutils.GetSessionID(token)
 	expiresAt := time.Now().Add(sessionExpiresIn)
 	session := models.Session{
 		ID:        sessionID,
 		UserID:    userID,
 		ExpiresAt: expiresAt,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
 	}
 
 	err := s.store.Session.Create(context.Background(), &session)
@@ -159,7 +422,7 @@ func (s *AuthService) CreateSession(token string, userID int64) (*models.Session
 	return &session, nil
 }
 
-func (s *AuthService) ValidateSessionToken(token string) (*models.Session, error) {
+func (s *AuthService) ValidateSessionToken(token string, client models.SessionClient) (*models.Session, error) {
 	sessionID := cryptoutils.GetSessionID(token)
 	ctx := context.Background()
 
@@ -173,19 +436,52 @@ func (s *AuthService) ValidateSessionToken(token string) (*models.Session, error
 		if err != nil {
 			return nil, err
 		}
-		return nil, nil
+		return nil, ErrInvalidSession
 	}
-	if time.Now().After(session.ExpiresAt.Add(-sessionExpiresIn / 2)) {
-		session.ExpiresAt = time.Now().Add(sessionExpiresIn)
-		_ = s.store.Session.UpdateExpires(ctx, session)
+
+	// the activity is saved once in a while, not on every request
+	isRefreshing := time.Now().After(session.ExpiresAt.Add(-sessionExpiresIn / 2))
+	isIdle := time.Since(session.LastSeenAt) > sessionActivityInterval
+	if isRefreshing || isIdle || session.IP != client.IP || session.UserAgent != client.UserAgent {
+		if isRefreshing {
+			session.ExpiresAt = time.Now().Add(sessionExpiresIn)
+		}
+		session.IP = client.IP
+		session.UserAgent = client.UserAgent
+		if err := s.store.Session.UpdateActivity(ctx, session); err != nil {
+			s.logger.Warnw("could not update session activity", "error", err)
+		}
 	}
 	return session, nil
 }
 
-func (s *AuthService) InvalidateSession(sessionID string) error {
-	err := s.store.Session.Delete(context.Background(), sessionID)
+func (s *AuthService) InvalidateSession(ctx context.Context, session *models.Session) error {
+	err := s.store.Session.Delete(ctx, session.ID)
 	if err != nil {
 		return err
 	}
+	s.audit.Record(ctx, newAuditEvent(models.AuditSignout, session.UserID, models.AuditTargetSession, session.ID, nil))
+	return nil
+}
+
+func (s *AuthService) GetSessions(ctx context.Context, userID int64) ([]*models.Session, error) {
+	return s.store.Session.GetFromUser(ctx, userID)
+}
+
+// RevokeSession signs the user out of one of their sessions
+func (s *AuthService) RevokeSession(ctx context.Context, userID int64, sessionID string) error {
+	if err := s.store.Session.DeleteFromUser(ctx, userID, sessionID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, newAuditEvent(models.AuditSessionRevoked, userID, models.AuditTargetSession, sessionID, nil))
+	return nil
+}
+
+// RevokeOtherSessions signs the user out everywhere but the current session
+func (s *AuthService) RevokeOtherSessions(ctx context.Context, userID int64, currentSessionID string) error {
+	if err := s.store.Session.DeleteOthers(ctx, userID, currentSessionID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, newAuditEvent(models.AuditOtherSessionsRevoked, userID, models.AuditTargetUser, auditID(userID), nil))
 	return nil
 }
@@ -0,0 +1,65 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mailer"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResendActivation(t *testing.T) {
+	cfg := &config.Cfg{
+		Mail: config.MailCfg{
+			Expired:        24 * time.Hour,
+			ResendInterval: 2 * time.Minute,
+		},
+	}
+	userStore := &mocks.MockUserStore{}
+	mailClient := &mocks.MockMailer{}
+	service := newTestServices(t, cfg, &store.Store{User: userStore}, mailClient)
+
+	recent := &models.User{ID: 1, Username: "hutao", Email: "hutao@sapphire.com"}
+	older := &models.User{ID: 2, Username: "chaee", Email: "chaee@sapphire.com"}
+	userStore.On("GetPendingInvitation", mock.Anything, recent.Email).Return(&models.UserInvitation{
+		User:      recent,
+		CreatedAt: time.Now().Add(-time.Minute),
+	}, nil)
+	userStore.On("GetPendingInvitation", mock.Anything, older.Email).Return(&models.UserInvitation{
+		User:      older,
+		CreatedAt: time.Now().Add(-3 * time.Minute),
+	}, nil)
+	userStore.On("GetPendingInvitation", mock.Anything, "unknown@sapphire.com").Return(nil, store.ErrNotFound)
+	userStore.On("RotateInvitation", mock.Anything, mock.Anything).Return(nil)
+	mailClient.On("Send", mailer.UserWelcomeTemplate, older.Username, older.Email, mock.Anything, false).Return(200, nil)
+
+	t.Run("refuses to resend before the interval", func(t *testing.T) {
+		err := service.Auth.ResendActivation(context.Background(), &payloads.ResendActivationPayload{Email: recent.Email})
+		assert.ErrorIs(t, err, services.ErrResendTooSoon)
+		userStore.AssertNotCalled(t, "RotateInvitation", mock.Anything, mock.Anything)
+		mailClient.AssertNotCalled(t, "Send", mock.Anything, recent.Username, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rotates the invitation once the interval passed", func(t *testing.T) {
+		err := service.Auth.ResendActivation(context.Background(), &payloads.ResendActivationPayload{Email: older.Email})
+		require.NoError(t, err)
+		userStore.AssertCalled(t, "RotateInvitation", mock.Anything, mock.MatchedBy(func(userInvitation *models.UserInvitation) bool {
+			return userInvitation.User == older && userInvitation.Expired == cfg.Mail.Expired && userInvitation.Token != ""
+		}))
+		mailClient.AssertNumberOfCalls(t, "Send", 1)
+	})
+
+	t.Run("does not report unknown addresses", func(t *testing.T) {
+		err := service.Auth.ResendActivation(context.Background(), &payloads.ResendActivationPayload{Email: "unknown@sapphire.com"})
+		assert.NoError(t, err)
+	})
+}
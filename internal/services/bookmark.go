@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type BookmarkService struct {
+	store *store.Store
+}
+
+// Create saves a post in the user's bookmarks. Bookmarking a repost saves the
+// original post
+func (s *BookmarkService) Create(ctx context.Context, user *models.User, post *models.Post) (*models.Bookmark, error) {
+	postID := post.ID
+	if post.IsRepost() {
+		postID = post.RepostOfID.Int64
+	}
+	bookmark := &models.Bookmark{
+		UserID: user.ID,
+		Post:   &models.Post{ID: postID},
+	}
+	if err := s.store.Bookmark.Create(ctx, bookmark); err != nil {
+		return nil, err
+	}
+	return bookmark, nil
+}
+
+func (s *BookmarkService) Delete(ctx context.Context, user *models.User, post *models.Post) error {
+	postID := post.ID
+	if post.IsRepost() {
+		postID = post.RepostOfID.Int64
+	}
+	return s.store.Bookmark.Delete(ctx, user.ID, postID)
+}
+
+func (s *BookmarkService) GetFromUser(ctx context.Context, userBookmarks *pagination.UserBookmarks) ([]*models.Bookmark, error) {
+	bookmarks, nextCursor, err := s.store.Bookmark.GetFromUser(ctx, userBookmarks)
+	if err != nil {
+		return nil, err
+	}
+
+	userBookmarks.NextCursor = nextCursor
+
+	return bookmarks, nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mochaeng/sapphire-backend/internal/events"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"go.uber.org/zap"
+)
+
+type CommentService struct {
+	store    *store.Store
+	logger   *zap.SugaredLogger
+	broker   events.Broker
+	notifier *NotificationService
+	audit    *AuditLogger
+}
+
+func (s *CommentService) Create(ctx context.Context, user *models.User, post *models.Post, payload *payloads.CreateCommentPayload) (*models.Comment, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+	comment := &models.Comment{
+		PostId:  post.ID,
+		UserId:  user.ID,
+		Content: payload.Content,
+		User: models.UserComment{
+			Username:  user.Username,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+		},
+	}
+	var parent *models.Comment
+	if payload.ParentID != nil {
+		var err error
+		parent, err = s.store.Comment.GetByID(ctx, *payload.ParentID)
+		if err != nil {
+			if err == store.ErrNotFound {
+				return nil, ErrInvalidPayload
+			}
+			return nil, err
+		}
+		if parent.PostId != post.ID {
+			return nil, ErrInvalidPayload
+		}
+		comment.ParentID = sql.NullInt64{Int64: parent.ID, Valid: true}
+	}
+	if err := s.store.Comment.Create(ctx, comment); err != nil {
+		return nil, err
+	}
+	s.setMentions(ctx, comment)
+	s.notifier.commented(comment, post, parent)
+	s.publish(ctx, comment)
+	return comment, nil
+}
+
+// publish pushes the new comment to the clients following the post comments.
+// A failure is only logged, the comment is already saved
+func (s *CommentService) publish(ctx context.Context, comment *models.Comment) {
+	data := events.CommentData{
+		ID:        comment.ID,
+		PostID:    comment.PostId,
+		UserID:    comment.UserId,
+		Username:  comment.User.Username,
+		Content:   comment.Content,
+		CreatedAt: comment.CreatedAt,
+	}
+	if comment.ParentID.Valid {
+		data.ParentID = &comment.ParentID.Int64
+	}
+	event, err := events.New(events.KindComment, data)
+	if err != nil {
+		s.logger.Errorw("could not encode comment event", "error", err)
+		return
+	}
+	topics := []string{events.PostCommentsTopic(comment.PostId)}
+	if err := s.broker.Publish(ctx, topics, event); err != nil {
+		s.logger.Errorw("could not publish comment event", "comment", comment.ID, "error", err)
+	}
+}
+
+func (s *CommentService) GetByID(ctx context.Context, commentID int64) (*models.Comment, error) {
+	return s.store.Comment.GetByID(ctx, commentID)
+}
+
+func (s *CommentService) GetThread(ctx context.Context, postComments *pagination.PostComments) ([]*models.Comment, error) {
+	comments, nextCursor, err := s.store.Comment.GetThread(ctx, postComments)
+	if err != nil {
+		return nil, err
+	}
+
+	postComments.NextCursor = nextCursor
+
+	return comments, nil
+}
+
+func (s *CommentService) Update(ctx context.Context, user *models.User, comment *models.Comment, payload *payloads.UpdateCommentPayload) error {
+	if err := models.Validate.Struct(payload); err != nil {
+		return ErrInvalidPayload
+	}
+	comment.Content = payload.Content
+	if err := s.store.Comment.UpdateByID(ctx, comment); err != nil {
+		return err
+	}
+	s.setMentions(ctx, comment)
+	s.auditModeration(ctx, models.AuditCommentModerated, user, comment)
+	return nil
+}
+
+func (s *CommentService) Delete(ctx context.Context, user *models.User, comment *models.Comment) error {
+	if err := s.store.Comment.DeleteByID(ctx, comment.ID); err != nil {
+		return err
+	}
+	s.auditModeration(ctx, models.AuditCommentRemoved, user, comment)
+	return nil
+}
+
+// auditModeration records the changes made by someone else than the author
+func (s *CommentService) auditModeration(ctx context.Context, action string, user *models.User, comment *models.Comment) {
+	if user.ID == comment.UserId {
+		return
+	}
+	metadata := map[string]string{"author": auditID(comment.UserId), "post": auditID(comment.PostId)}
+	s.audit.Record(ctx, newAuditEvent(action, user.ID, models.AuditTargetComment, auditID(comment.ID), metadata))
+}
+
+// setMentions links the users mentioned in the comment content. A failure does
+// not undo the comment, it is only left without mentions
+func (s *CommentService) setMentions(ctx context.Context, comment *models.Comment) {
+	parsed := models.ParseMentions(comment.Content)
+	users, err := s.store.Mention.SetForComment(ctx, comment.ID, comment.UserId, models.MentionedUsernames(parsed))
+	if err != nil {
+		s.logger.Errorw("could not set comment mentions", "comment", comment.ID, "error", err)
+		return
+	}
+	comment.Mentions = models.LinkMentions(parsed, users)
+	s.notifier.mentionedInComment(comment, users)
+}
@@ -0,0 +1,164 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type commentTest struct {
+	service         *services.Service
+	commentStore    *mocks.MockCommentStore
+	auditEventStore *mocks.MockAuditEventStore
+}
+
+func newCommentTest(t *testing.T) *commentTest {
+	t.Helper()
+	commentStore := &mocks.MockCommentStore{}
+	mentionStore := &mocks.MockMentionStore{}
+	mentionStore.On("SetForComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+	auditEventStore := &mocks.MockAuditEventStore{}
+	auditEventStore.On("Create", mock.Anything, mock.Anything).Return(nil)
+	testStore := &store.Store{Comment: commentStore, Mention: mentionStore, AuditEvent: auditEventStore}
+	return &commentTest{
+		service:         newTestServices(t, &config.Cfg{}, testStore, nil),
+		commentStore:    commentStore,
+		auditEventStore: auditEventStore,
+	}
+}
+
+func TestCommentCreate(t *testing.T) {
+	user := &models.User{ID: 2, Username: "chaee"}
+	post := &models.Post{ID: 101, User: &models.User{ID: 1}}
+	parentID, otherPostParentID, missingParentID := int64(7), int64(8), int64(9)
+
+	test := newCommentTest(t)
+	test.commentStore.On("GetByID", mock.Anything, parentID).Return(&models.Comment{ID: parentID, PostId: post.ID, UserId: 1}, nil)
+	test.commentStore.On("GetByID", mock.Anything, otherPostParentID).Return(&models.Comment{ID: otherPostParentID, PostId: 55}, nil)
+	test.commentStore.On("GetByID", mock.Anything, missingParentID).Return(nil, store.ErrNotFound)
+	test.commentStore.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	t.Run("creates a top-level comment", func(t *testing.T) {
+		comment, err := test.service.Comment.Create(context.Background(), user, post, &payloads.CreateCommentPayload{Content: "first!"})
+		require.NoError(t, err)
+		assert.Equal(t, post.ID, comment.PostId)
+		assert.Equal(t, user.ID, comment.UserId)
+		assert.Equal(t, user.Username, comment.User.Username)
+		assert.False(t, comment.ParentID.Valid)
+	})
+
+	t.Run("replies to a comment of the post", func(t *testing.T) {
+		comment, err := test.service.Comment.Create(context.Background(), user, post, &payloads.CreateCommentPayload{Content: "welcome", ParentID: &parentID})
+		require.NoError(t, err)
+		assert.True(t, comment.ParentID.Valid)
+		assert.Equal(t, parentID, comment.ParentID.Int64)
+	})
+
+	tests := map[string]*payloads.CreateCommentPayload{
+		"empty content":              {Content: ""},
+		"parent from another post":   {Content: "hi", ParentID: &otherPostParentID},
+		"parent that does not exist": {Content: "hi", ParentID: &missingParentID},
+	}
+	for name, payload := range tests {
+		t.Run("refuses "+name, func(t *testing.T) {
+			_, err := test.service.Comment.Create(context.Background(), user, post, payload)
+			assert.ErrorIs(t, err, services.ErrInvalidPayload)
+		})
+	}
+	test.commentStore.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestCommentUpdate(t *testing.T) {
+	author := &models.User{ID: 2}
+	moderator := &models.User{ID: 3}
+
+	t.Run("the author edit is not audited", func(t *testing.T) {
+		test := newCommentTest(t)
+		comment := &models.Comment{ID: 7, PostId: 101, UserId: author.ID, Content: "first"}
+		test.commentStore.On("UpdateByID", mock.Anything, comment).Return(nil)
+
+		err := test.service.Comment.Update(context.Background(), author, comment, &payloads.UpdateCommentPayload{Content: "edited"})
+		require.NoError(t, err)
+		assert.Equal(t, "edited", comment.Content)
+		test.auditEventStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("a moderator edit is audited", func(t *testing.T) {
+		test := newCommentTest(t)
+		comment := &models.Comment{ID: 7, PostId: 101, UserId: author.ID, Content: "first"}
+		test.commentStore.On("UpdateByID", mock.Anything, comment).Return(nil)
+
+		err := test.service.Comment.Update(context.Background(), moderator, comment, &payloads.UpdateCommentPayload{Content: "moderated"})
+		require.NoError(t, err)
+		test.auditEventStore.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(auditEvent *models.AuditEvent) bool {
+			return auditEvent.Action == models.AuditCommentModerated &&
+				auditEvent.ActorID.Int64 == moderator.ID &&
+				auditEvent.TargetID == "7" &&
+				auditEvent.Metadata["author"] == "2"
+		}))
+	})
+
+	t.Run("refuses an empty content", func(t *testing.T) {
+		test := newCommentTest(t)
+		comment := &models.Comment{ID: 7, UserId: author.ID, Content: "first"}
+
+		err := test.service.Comment.Update(context.Background(), author, comment, &payloads.UpdateCommentPayload{})
+		assert.ErrorIs(t, err, services.ErrInvalidPayload)
+		assert.Equal(t, "first", comment.Content)
+		test.commentStore.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("a comment removed meanwhile is not audited", func(t *testing.T) {
+		test := newCommentTest(t)
+		comment := &models.Comment{ID: 7, UserId: author.ID}
+		test.commentStore.On("UpdateByID", mock.Anything, comment).Return(store.ErrNotFound)
+
+		err := test.service.Comment.Update(context.Background(), moderator, comment, &payloads.UpdateCommentPayload{Content: "late"})
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		test.auditEventStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCommentDelete(t *testing.T) {
+	author := &models.User{ID: 2}
+	admin := &models.User{ID: 4}
+
+	t.Run("the author removal is not audited", func(t *testing.T) {
+		test := newCommentTest(t)
+		comment := &models.Comment{ID: 7, PostId: 101, UserId: author.ID}
+		test.commentStore.On("DeleteByID", mock.Anything, comment.ID).Return(nil)
+
+		require.NoError(t, test.service.Comment.Delete(context.Background(), author, comment))
+		test.auditEventStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("an admin removal is audited", func(t *testing.T) {
+		test := newCommentTest(t)
+		comment := &models.Comment{ID: 7, PostId: 101, UserId: author.ID}
+		test.commentStore.On("DeleteByID", mock.Anything, comment.ID).Return(nil)
+
+		require.NoError(t, test.service.Comment.Delete(context.Background(), admin, comment))
+		test.auditEventStore.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(auditEvent *models.AuditEvent) bool {
+			return auditEvent.Action == models.AuditCommentRemoved && auditEvent.ActorID.Int64 == admin.ID
+		}))
+	})
+
+	t.Run("a comment already removed is not audited", func(t *testing.T) {
+		test := newCommentTest(t)
+		comment := &models.Comment{ID: 7, UserId: author.ID}
+		test.commentStore.On("DeleteByID", mock.Anything, comment.ID).Return(store.ErrNotFound)
+
+		err := test.service.Comment.Delete(context.Background(), admin, comment)
+		assert.ErrorIs(t, err, store.ErrNotFound)
+		test.auditEventStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/mailer"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+)
+
+var ErrTooManyLoginAttempts = errors.New("too many failed sign ins")
+
+// LoginThrottledError is returned by Authenticate while the account or the
+// address has to wait before trying again
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return ErrTooManyLoginAttempts.Error()
+}
+
+func (e *LoginThrottledError) Is(target error) bool {
+	return target == ErrTooManyLoginAttempts
+}
+
+// loginFailureStore is implemented by the Redis cache and by Postgres
+type loginFailureStore interface {
+	Get(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error)
+	Add(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error)
+	Refund(ctx context.Context, key string) error
+	Delete(ctx context.Context, key string) error
+}
+
+func (s *AuthService) loginFailures() loginFailureStore {
+	if s.cfg.Cacher.IsEnable {
+		return s.cacheStore.LoginFailure
+	}
+	return s.store.LoginFailure
+}
+
+func accountLoginKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func addressLoginKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginDelay is how long to wait after the last failure before trying again
+func (s *AuthService) loginDelay(failures int, freeAttempts int, maxFailures int) time.Duration {
+	cfg := &s.cfg.Auth.Lockout
+	if failures >= maxFailures {
+		return cfg.Duration
+	}
+	if failures <= freeAttempts {
+		return 0
+	}
+	delay := cfg.BaseDelay
+	for attempt := freeAttempts + 1; attempt < failures && delay < cfg.MaxDelay; attempt++ {
+		delay *= 2
+	}
+	return min(delay, cfg.MaxDelay)
+}
+
+// loginAttempt is a sign in already counted as a failure for the account and
+// the address, until it succeeds
+type loginAttempt struct {
+	email   string
+	ip      string
+	account *models.LoginFailure
+}
+
+type loginCheck struct {
+	key          string
+	freeAttempts int
+	maxFailures  int
+}
+
+func (s *AuthService) loginChecks(email string, ip string) []loginCheck {
+	cfg := &s.cfg.Auth.Lockout
+	return []loginCheck{
+		{accountLoginKey(email), cfg.AccountFreeAttempts, cfg.MaxAccountFailures},
+		{addressLoginKey(ip), cfg.IPFreeAttempts, cfg.MaxIPFailures},
+	}
+}
+
+// beginLoginAttempt counts the attempt before the password is compared, so
+// concurrent requests cannot all pass the same check. The attempt is refused
+// while the account or the address still waits out its previous failures.
+// Refused attempts are not counted and do not move the wait, otherwise anyone
+// knowing the e-mail could keep the account locked
+func (s *AuthService) beginLoginAttempt(ctx context.Context, email string, ip string) (*loginAttempt, error) {
+	cfg := &s.cfg.Auth.Lockout
+	checks := s.loginChecks(email, ip)
+
+	var retryAfter time.Duration
+	for _, check := range checks {
+		loginFailure, err := s.loginFailures().Get(ctx, check.key, cfg.Duration)
+		if err != nil {
+			return nil, err
+		}
+		delay := s.loginDelay(loginFailure.Failures, check.freeAttempts, check.maxFailures)
+		retryAfter = max(retryAfter, time.Until(loginFailure.LastFailedAt.Add(delay)))
+	}
+	if retryAfter > 0 {
+		return nil, &LoginThrottledError{RetryAfter: retryAfter}
+	}
+
+	attempt := &loginAttempt{email: email, ip: ip}
+	for _, check := range checks {
+		loginFailure, err := s.loginFailures().Add(ctx, check.key, cfg.Duration)
+		if err != nil {
+			return nil, err
+		}
+		if check.key == accountLoginKey(email) {
+			attempt.account = loginFailure
+		}
+
+		// a concurrent attempt may have been counted since the check, then
+		// this one has to wait for its failure
+		delay := s.loginDelay(loginFailure.Failures-1, check.freeAttempts, check.maxFailures)
+		if !loginFailure.PreviousFailedAt.IsZero() && time.Since(loginFailure.PreviousFailedAt) < delay {
+			retryAfter = max(retryAfter, time.Until(loginFailure.PreviousFailedAt.Add(delay)))
+		}
+	}
+	if retryAfter > 0 {
+		for _, check := range checks {
+			if err := s.loginFailures().Refund(ctx, check.key); err != nil {
+				s.logger.Errorw("could not take back refused sign in", "error", err)
+			}
+		}
+		return nil, &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	return attempt, nil
+}
+
+// succeedLoginAttempt forgets the failures of the account and gives the
+// address its attempt back
+func (s *AuthService) succeedLoginAttempt(ctx context.Context, attempt *loginAttempt) {
+	if err := s.loginFailures().Delete(ctx, accountLoginKey(attempt.email)); err != nil {
+		s.logger.Errorw("could not forget failed sign ins", "error", err)
+	}
+	s.keepLoginAttempt(ctx, attempt)
+}
+
+// keepLoginAttempt gives the address its attempt back but keeps the one of
+// the account, the sign in still waits for its second factor. Otherwise the
+// password would start the count over before each guess of the code
+func (s *AuthService) keepLoginAttempt(ctx context.Context, attempt *loginAttempt) {
+	if err := s.loginFailures().Refund(ctx, addressLoginKey(attempt.ip)); err != nil {
+		s.logger.Errorw("could not forget failed sign ins", "error", err)
+	}
+}
+
+// failLoginAttempt warns the owner of the account, when it exists, once the
+// attempt locked it. The owner is looked up when user is nil
+func (s *AuthService) failLoginAttempt(ctx context.Context, attempt *loginAttempt, user *models.User) {
+	cfg := &s.cfg.Auth.Lockout
+	if attempt.account.Failures != cfg.MaxAccountFailures {
+		return
+	}
+	s.logger.Warnw("account locked after failed sign ins", "email", attempt.email, "ip", attempt.ip)
+	if user == nil {
+		var err error
+		if user, err = s.store.User.GetByActivatedEmail(ctx, attempt.email); err != nil {
+			return
+		}
+	}
+
+	vars := struct {
+		Username  string
+		IP        string
+		LockedFor string
+		ResetURL  string
+	}{
+		Username:  user.Username,
+		IP:        attempt.ip,
+		LockedFor: cfg.Duration.String(),
+		ResetURL:  fmt.Sprintf("%s/forgot-password", s.cfg.FrontedURL),
+	}
+	isSandBox := s.cfg.Env == "dev"
+	if _, err := s.mailer.Send(mailer.AccountLockedTemplate, user.Username, user.Email, vars, isSandBox); err != nil {
+		s.logger.Errorw("error sending account locked email", "error", err)
+	}
+}
+
+// Unlock forgets the failed sign ins of the user, who can sign in right away
+func (s *AuthService) Unlock(ctx context.Context, admin *models.User, userID int64) error {
+	user, err := s.store.User.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.loginFailures().Delete(ctx, accountLoginKey(user.Email)); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, newAuditEvent(models.AuditAccountUnlocked, admin.ID, models.AuditTargetUser, auditID(user.ID), nil))
+	return nil
+}
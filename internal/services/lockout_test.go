@@ -0,0 +1,320 @@
+package services_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mailer"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// loginFailureStore keeps the failures in memory, the way Redis and Postgres
+// count them
+type loginFailureStore struct {
+	mu       sync.Mutex
+	failures map[string]*models.LoginFailure
+}
+
+func newLoginFailureStore() *loginFailureStore {
+	return &loginFailureStore{failures: make(map[string]*models.LoginFailure)}
+}
+
+func (s *loginFailureStore) Get(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loginFailure := models.LoginFailure{Key: key}
+	if stored, ok := s.failures[key]; ok && time.Since(stored.LastFailedAt) < window {
+		loginFailure = *stored
+	}
+	return &loginFailure, nil
+}
+
+func (s *loginFailureStore) Add(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.failures[key]
+	if !ok || time.Since(stored.LastFailedAt) >= window {
+		stored = &models.LoginFailure{Key: key}
+		s.failures[key] = stored
+	}
+	stored.Failures++
+	stored.PreviousFailedAt = stored.LastFailedAt
+	stored.LastFailedAt = time.Now()
+	loginFailure := *stored
+	return &loginFailure, nil
+}
+
+func (s *loginFailureStore) Refund(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored, ok := s.failures[key]; ok && stored.Failures > 0 {
+		stored.Failures--
+	}
+	return nil
+}
+
+func (s *loginFailureStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, key)
+	return nil
+}
+
+func (s *loginFailureStore) DeleteOlderThan(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+// set makes the key look like it failed that many times, the last one ago
+// the given duration
+func (s *loginFailureStore) set(key string, failures int, ago time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[key] = &models.LoginFailure{Key: key, Failures: failures, LastFailedAt: time.Now().Add(-ago)}
+}
+
+// wait moves every failure back, as if the time went by
+func (s *loginFailureStore) wait(elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, loginFailure := range s.failures {
+		loginFailure.LastFailedAt = loginFailure.LastFailedAt.Add(-elapsed)
+	}
+}
+
+// newLockoutTest signs in a user who has two-factor on when twoFactor is
+// enabled
+func newLockoutTest(t *testing.T, twoFactor *models.TwoFactor) (*services.Service, *loginFailureStore, *mocks.MockMailer, *models.User) {
+	t.Helper()
+
+	cfg := &config.Cfg{
+		Auth: config.AuthCfg{
+			Lockout: config.LockoutCfg{
+				AccountFreeAttempts: 3,
+				IPFreeAttempts:      20,
+				BaseDelay:           time.Second,
+				MaxDelay:            5 * time.Second,
+				MaxAccountFailures:  8,
+				MaxIPFailures:       100,
+				Duration:            15 * time.Minute,
+			},
+		},
+	}
+	user := &models.User{ID: 1, Username: "hutao", Email: "hutao@sapphire.com"}
+	require.NoError(t, user.Password.Set("password"))
+
+	loginFailures := newLoginFailureStore()
+	userStore := &mocks.MockUserStore{}
+	userStore.On("GetByActivatedEmail", mock.Anything, user.Email).Return(user, nil)
+	mailClient := &mocks.MockMailer{}
+	mailClient.On("Send", mailer.AccountLockedTemplate, user.Username, user.Email, mock.Anything, false).Return(200, nil)
+
+	twoFactorStore := &mocks.MockTwoFactorStore{}
+	if twoFactor.IsEnabled() {
+		twoFactorStore.On("Get", mock.Anything, user.ID).Return(twoFactor, nil)
+	} else {
+		twoFactorStore.On("Get", mock.Anything, user.ID).Return(nil, store.ErrNotFound)
+	}
+	twoFactorStore.On("GetPendingLogin", mock.Anything, mock.Anything).Return(&models.PendingLogin{User: user}, nil)
+	twoFactorStore.On("UseRecoveryCode", mock.Anything, user.ID, hashRecoveryCode("right-code")).Return(nil)
+	twoFactorStore.On("UseRecoveryCode", mock.Anything, user.ID, mock.Anything).Return(store.ErrNotFound)
+	twoFactorStore.On("AddPendingLoginAttempt", mock.Anything, mock.Anything).Return(1, nil)
+	twoFactorStore.On("DeletePendingLogin", mock.Anything, mock.Anything).Return(nil)
+
+	service := newTestServices(t, cfg, &store.Store{
+		User:         userStore,
+		LoginFailure: loginFailures,
+		TwoFactor:    twoFactorStore,
+	}, mailClient)
+	return service, loginFailures, mailClient, user
+}
+
+func signin(service *services.Service, password string) error {
+	payload := &payloads.SigninPayload{Email: "hutao@sapphire.com", Password: password}
+	_, err := service.Auth.Authenticate(context.Background(), payload, "192.0.2.1")
+	return err
+}
+
+func verifyCode(service *services.Service, code string) error {
+	payload := &payloads.VerifyTwoFactorPayload{Token: "pending", Code: code}
+	_, err := service.TwoFactor.CompleteLogin(context.Background(), payload, "192.0.2.1")
+	return err
+}
+
+func hashRecoveryCode(code string) string {
+	hash := sha256.Sum256([]byte(strings.ReplaceAll(code, "-", "")))
+	return hex.EncodeToString(hash[:])
+}
+
+func accountFailures(t *testing.T, loginFailures *loginFailureStore) int {
+	t.Helper()
+	account, err := loginFailures.Get(context.Background(), "account:hutao@sapphire.com", time.Hour)
+	require.NoError(t, err)
+	return account.Failures
+}
+
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var throttled *services.LoginThrottledError
+	require.True(t, errors.As(err, &throttled), "expected a throttled sign in, got %v", err)
+	return throttled.RetryAfter
+}
+
+func TestLoginDelay(t *testing.T) {
+	service, loginFailures, _, _ := newLockoutTest(t, nil)
+
+	tests := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{7, 5 * time.Second},
+		{8, 15 * time.Minute},
+	}
+	for _, test := range tests {
+		loginFailures.set("account:hutao@sapphire.com", test.failures, 0)
+
+		delay := retryAfter(t, signin(service, "password"))
+		assert.InDelta(t, test.delay, delay, float64(100*time.Millisecond), "after %d failures", test.failures)
+	}
+
+	loginFailures.set("account:hutao@sapphire.com", 3, 0)
+	assert.NoError(t, signin(service, "password"), "the first failures are free")
+}
+
+func TestLockout(t *testing.T) {
+	t.Run("locks the account at the maximum failures", func(t *testing.T) {
+		service, loginFailures, _, _ := newLockoutTest(t, nil)
+
+		for range 8 {
+			err := signin(service, "wrong")
+			require.ErrorIs(t, err, store.ErrNotFound)
+			loginFailures.wait(5 * time.Second)
+		}
+
+		delay := retryAfter(t, signin(service, "password"))
+		assert.Greater(t, delay, 14*time.Minute, "the right password waits out the lockout too")
+	})
+
+	t.Run("does not count refused attempts", func(t *testing.T) {
+		service, loginFailures, _, _ := newLockoutTest(t, nil)
+		loginFailures.set("account:hutao@sapphire.com", 8, 10*time.Minute)
+
+		for range 5 {
+			delay := retryAfter(t, signin(service, "password"))
+			assert.InDelta(t, 5*time.Minute, delay, float64(time.Second), "refused attempts do not extend the lockout")
+		}
+		account, err := loginFailures.Get(context.Background(), "account:hutao@sapphire.com", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 8, account.Failures)
+
+		loginFailures.wait(5 * time.Minute)
+		assert.NoError(t, signin(service, "password"))
+	})
+
+	t.Run("forgets the failures on success", func(t *testing.T) {
+		service, loginFailures, _, _ := newLockoutTest(t, nil)
+		loginFailures.set("account:hutao@sapphire.com", 5, time.Minute)
+		loginFailures.set("ip:192.0.2.1", 5, time.Minute)
+
+		require.NoError(t, signin(service, "password"))
+		address, err := loginFailures.Get(context.Background(), "ip:192.0.2.1", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 5, address.Failures, "the address gets its attempt back")
+
+		for range 3 {
+			assert.ErrorIs(t, signin(service, "wrong"), store.ErrNotFound)
+		}
+		assert.ErrorIs(t, signin(service, "wrong"), store.ErrNotFound, "the free attempts start over")
+	})
+
+	t.Run("e-mails the owner once", func(t *testing.T) {
+		service, loginFailures, mailClient, _ := newLockoutTest(t, nil)
+		loginFailures.set("account:hutao@sapphire.com", 7, 6*time.Second)
+
+		assert.ErrorIs(t, signin(service, "wrong"), store.ErrNotFound)
+		for range 3 {
+			loginFailures.wait(time.Minute)
+			err := signin(service, "wrong")
+			assert.ErrorIs(t, err, services.ErrTooManyLoginAttempts)
+		}
+		mailClient.AssertNumberOfCalls(t, "Send", 1)
+	})
+
+	t.Run("lets a single concurrent attempt through", func(t *testing.T) {
+		service, loginFailures, _, _ := newLockoutTest(t, nil)
+		loginFailures.set("account:hutao@sapphire.com", 3, time.Minute)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 5)
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- signin(service, "wrong")
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		var compared int
+		for err := range errs {
+			if !errors.Is(err, services.ErrTooManyLoginAttempts) {
+				compared++
+			}
+		}
+		assert.Equal(t, 1, compared, "the others have to wait for its failure")
+	})
+}
+
+func TestTwoFactorLockout(t *testing.T) {
+	twoFactor := &models.TwoFactor{UserID: 1, EnabledAt: sql.NullTime{Time: time.Now(), Valid: true}}
+
+	t.Run("keeps the attempt of the account until the code", func(t *testing.T) {
+		service, loginFailures, _, _ := newLockoutTest(t, twoFactor)
+		loginFailures.set("account:hutao@sapphire.com", 3, time.Minute)
+		loginFailures.set("ip:192.0.2.1", 3, time.Minute)
+
+		require.NoError(t, signin(service, "password"))
+		assert.Equal(t, 4, accountFailures(t, loginFailures), "the password does not start the count over")
+		address, err := loginFailures.Get(context.Background(), "ip:192.0.2.1", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 3, address.Failures, "the address gets its attempt back")
+
+		loginFailures.wait(time.Minute)
+		require.NoError(t, verifyCode(service, "right-code"))
+		assert.Zero(t, accountFailures(t, loginFailures), "the code forgets the failures")
+	})
+
+	t.Run("counts wrong codes against the account", func(t *testing.T) {
+		service, loginFailures, _, _ := newLockoutTest(t, twoFactor)
+
+		for range 3 {
+			require.NoError(t, signin(service, "password"))
+			loginFailures.wait(5 * time.Second)
+			assert.ErrorIs(t, verifyCode(service, "wrong-code"), services.ErrInvalidTwoFactorCode)
+			loginFailures.wait(5 * time.Second)
+		}
+		assert.Equal(t, 6, accountFailures(t, loginFailures))
+
+		require.NoError(t, signin(service, "password"))
+		delay := retryAfter(t, verifyCode(service, "right-code"))
+		assert.Greater(t, delay, time.Duration(0), "starting the sign in over does not give more guesses")
+	})
+}
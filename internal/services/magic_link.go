@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mochaeng/sapphire-backend/internal/cryptoutils"
+	"github.com/mochaeng/sapphire-backend/internal/mailer"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+// RequestMagicLink e-mails a sign in link when the address belongs to an
+// active user who did not turn links off. The returned browser token has to be
+// kept by the browser that asked, only it can use the link. It is returned for
+// the other addresses too, so they are not reported
+func (s *AuthService) RequestMagicLink(ctx context.Context, payload *payloads.MagicLinkPayload) (string, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return "", ErrInvalidPayload
+	}
+
+	browserToken, err := cryptoutils.GenerateRandomString(20)
+	if err != nil {
+		return "", err
+	}
+
+	user, err := s.store.User.GetByActivatedEmail(ctx, payload.Email)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return browserToken, nil
+		}
+		return "", err
+	}
+	isEnabled, err := s.store.User.GetMagicLinkEnabled(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if !isEnabled {
+		return browserToken, nil
+	}
+
+	plainToken, err := cryptoutils.GenerateRandomString(20)
+	if err != nil {
+		return "", err
+	}
+	magicLink := &models.MagicLink{
+		User:    user,
+		Token:   cryptoutils.GetSessionID(plainToken),
+		Browser: cryptoutils.GetSessionID(browserToken),
+		Expired: s.cfg.Auth.MagicLink.Expired,
+	}
+	if err := s.store.User.CreateMagicLink(ctx, magicLink); err != nil {
+		return "", err
+	}
+
+	isSandBox := s.cfg.Env == "dev"
+	vars := struct {
+		Username  string
+		SigninURL string
+		ExpiresIn string
+	}{
+		Username:  user.Username,
+		SigninURL: fmt.Sprintf("%s/magic-link/%s", s.cfg.FrontedURL, plainToken),
+		ExpiresIn: s.cfg.Auth.MagicLink.Expired.String(),
+	}
+	status, err := s.mailer.Send(
+		mailer.MagicLinkTemplate,
+		user.Username,
+		user.Email,
+		vars,
+		isSandBox,
+	)
+	if err != nil {
+		s.logger.Errorw("error sending magic link email", "error", err)
+		return "", ErrEmailSending
+	}
+	s.logger.Infow("Email sent", "status code", status)
+	return browserToken, nil
+}
+
+// SigninWithMagicLink uses up the link and returns its user, as long as it is
+// opened in the browser holding browserToken
+func (s *AuthService) SigninWithMagicLink(ctx context.Context, payload *payloads.MagicLinkSigninPayload, browserToken string) (*models.User, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+	if browserToken == "" {
+		s.auditSigninFailure(ctx, signinMagicLink, 0, "", "other_browser")
+		return nil, store.ErrNotFound
+	}
+
+	var user models.User
+	token := cryptoutils.GetSessionID(payload.Token)
+	browser := cryptoutils.GetSessionID(browserToken)
+	if err := s.store.User.UseMagicLink(ctx, token, browser, &user); err != nil {
+		if err == store.ErrNotFound {
+			s.auditSigninFailure(ctx, signinMagicLink, 0, "", "invalid_link")
+		}
+		return nil, err
+	}
+	s.auditSignin(ctx, signinMagicLink, &user)
+	return &user, nil
+}
+
+// GetMagicLinkEnabled tells if the user accepts sign in links
+func (s *AuthService) GetMagicLinkEnabled(ctx context.Context, userID int64) (bool, error) {
+	return s.store.User.GetMagicLinkEnabled(ctx, userID)
+}
+
+// SetMagicLinkEnabled lets the user turn sign in links on or off, the links
+// already sent stop working when they are turned off
+func (s *AuthService) SetMagicLinkEnabled(ctx context.Context, userID int64, payload *payloads.MagicLinkPreferencePayload) error {
+	if err := models.Validate.Struct(payload); err != nil {
+		return ErrInvalidPayload
+	}
+	return s.store.User.SetMagicLinkEnabled(ctx, userID, *payload.Enabled)
+}
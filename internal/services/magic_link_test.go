@@ -0,0 +1,54 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mailer"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestMagicLink(t *testing.T) {
+	cfg := &config.Cfg{
+		Auth: config.AuthCfg{
+			MagicLink: config.MagicLinkCfg{Enabled: true, Expired: 15 * time.Minute},
+		},
+	}
+	hutao := &models.User{ID: 1, Username: "hutao", Email: "hutao@sapphire.com"}
+	chaee := &models.User{ID: 2, Username: "chaee", Email: "chaee@sapphire.com"}
+
+	userStore := &mocks.MockUserStore{}
+	userStore.On("GetByActivatedEmail", mock.Anything, hutao.Email).Return(hutao, nil)
+	userStore.On("GetByActivatedEmail", mock.Anything, chaee.Email).Return(chaee, nil)
+	userStore.On("GetMagicLinkEnabled", mock.Anything, hutao.ID).Return(true, nil)
+	userStore.On("GetMagicLinkEnabled", mock.Anything, chaee.ID).Return(false, nil)
+	userStore.On("CreateMagicLink", mock.Anything, mock.Anything).Return(nil)
+	mailClient := &mocks.MockMailer{}
+	mailClient.On("Send", mailer.MagicLinkTemplate, hutao.Username, hutao.Email, mock.Anything, false).Return(200, nil)
+	service := newTestServices(t, cfg, &store.Store{User: userStore}, mailClient)
+
+	t.Run("e-mails the link", func(t *testing.T) {
+		browserToken, err := service.Auth.RequestMagicLink(context.Background(), &payloads.MagicLinkPayload{Email: hutao.Email})
+		require.NoError(t, err)
+		assert.NotEmpty(t, browserToken)
+		mailClient.AssertNumberOfCalls(t, "Send", 1)
+	})
+
+	t.Run("does not e-mail a user who turned links off", func(t *testing.T) {
+		browserToken, err := service.Auth.RequestMagicLink(context.Background(), &payloads.MagicLinkPayload{Email: chaee.Email})
+		require.NoError(t, err)
+		assert.NotEmpty(t, browserToken, "the answer is the same as for the others")
+		userStore.AssertNotCalled(t, "CreateMagicLink", mock.Anything, mock.MatchedBy(func(magicLink *models.MagicLink) bool {
+			return magicLink.User == chaee
+		}))
+		mailClient.AssertNotCalled(t, "Send", mock.Anything, chaee.Username, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/mochaeng/sapphire-backend/internal/events"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"go.uber.org/zap"
+)
+
+type MessageService struct {
+	store  *store.Store
+	broker events.Broker
+	logger *zap.SugaredLogger
+}
+
+// CreateConversation starts a 1:1 conversation when a single participant is
+// given, returning the existing one if there is any, or a group otherwise.
+// Nobody can be put in a conversation with a user they blocked or that
+// blocked them, the creator included
+func (s *MessageService) CreateConversation(ctx context.Context, user *models.User, payload *payloads.CreateConversationPayload) (*models.Conversation, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+
+	var participantIDs []int64
+	for _, id := range payload.ParticipantIDs {
+		if id != user.ID && !slices.Contains(participantIDs, id) {
+			participantIDs = append(participantIDs, id)
+		}
+	}
+	if len(participantIDs) == 0 {
+		return nil, ErrInvalidPayload
+	}
+
+	if err := s.checkBlocks(ctx, append([]int64{user.ID}, participantIDs...)); err != nil {
+		return nil, err
+	}
+
+	conversation := &models.Conversation{
+		IsGroup:   len(participantIDs) > 1,
+		CreatedBy: sql.NullInt64{Int64: user.ID, Valid: true},
+	}
+	if conversation.IsGroup {
+		conversation.Title = payload.Title
+	} else {
+		conversation.DirectKey = sql.NullString{
+			String: directKey(user.ID, participantIDs[0]),
+			Valid:  true,
+		}
+	}
+
+	participantIDs = append([]int64{user.ID}, participantIDs...)
+	if err := s.store.Conversation.Create(ctx, conversation, participantIDs); err != nil {
+		return nil, err
+	}
+	return s.store.Conversation.GetByID(ctx, conversation.ID)
+}
+
+// GetConversation returns the conversation only to its participants, it is
+// not found for anyone else
+func (s *MessageService) GetConversation(ctx context.Context, conversationID int64, userID int64) (*models.Conversation, error) {
+	conversation, err := s.store.Conversation.GetByID(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conversation.HasParticipant(userID) {
+		return nil, store.ErrNotFound
+	}
+	return conversation, nil
+}
+
+func (s *MessageService) GetConversations(ctx context.Context, userConversations *pagination.UserConversations) ([]*models.Conversation, error) {
+	conversations, nextCursor, err := s.store.Conversation.GetFromUser(ctx, userConversations)
+	if err != nil {
+		return nil, err
+	}
+
+	userConversations.NextCursor = nextCursor
+
+	return conversations, nil
+}
+
+func (s *MessageService) GetMessages(ctx context.Context, conversationMessages *pagination.ConversationMessages) ([]*models.Message, error) {
+	messages, nextCursor, err := s.store.Conversation.GetMessages(ctx, conversationMessages)
+	if err != nil {
+		return nil, err
+	}
+
+	conversationMessages.NextCursor = nextCursor
+
+	return messages, nil
+}
+
+// Send adds a message to the conversation. A block between the sender and
+// any other participant stops any new message, in groups too
+func (s *MessageService) Send(ctx context.Context, user *models.User, conversation *models.Conversation, payload *payloads.SendMessagePayload) (*models.Message, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+
+	recipientIDs := make([]int64, 0, len(conversation.Participants))
+	for _, participant := range conversation.Participants {
+		if participant.User.ID != user.ID {
+			recipientIDs = append(recipientIDs, participant.User.ID)
+		}
+	}
+	if len(recipientIDs) > 0 {
+		blocked, err := s.store.User.HasBlock(ctx, user.ID, recipientIDs)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrOperationNotAllowed
+		}
+	}
+
+	message := &models.Message{
+		ConversationID: conversation.ID,
+		Sender:         user,
+		Content:        payload.Content,
+	}
+	if err := s.store.Conversation.CreateMessage(ctx, message); err != nil {
+		return nil, err
+	}
+	s.publish(ctx, message, recipientIDs)
+	return message, nil
+}
+
+func (s *MessageService) MarkRead(ctx context.Context, user *models.User, conversation *models.Conversation) error {
+	return s.store.Conversation.MarkRead(ctx, conversation.ID, user.ID)
+}
+
+// publish pushes the message to the other participants connections. A failure
+// is only logged, the message is already saved
+func (s *MessageService) publish(ctx context.Context, message *models.Message, recipientIDs []int64) {
+	if len(recipientIDs) == 0 {
+		return
+	}
+	event, err := events.New(events.KindMessage, events.MessageData{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		SenderID:       message.Sender.ID,
+		SenderUsername: message.Sender.Username,
+		Content:        message.Content,
+		CreatedAt:      message.CreatedAt,
+	})
+	if err != nil {
+		s.logger.Errorw("could not encode message event", "error", err)
+		return
+	}
+	topics := make([]string, len(recipientIDs))
+	for idx, id := range recipientIDs {
+		topics[idx] = events.MessagesTopic(id)
+	}
+	if err := s.broker.Publish(ctx, topics, event); err != nil {
+		s.logger.Errorw("could not publish message event", "message", message.ID, "error", err)
+	}
+}
+
+// checkBlocks refuses the participants when any of them blocked, or was
+// blocked by, another one
+func (s *MessageService) checkBlocks(ctx context.Context, participantIDs []int64) error {
+	for idx, id := range participantIDs[:len(participantIDs)-1] {
+		blocked, err := s.store.User.HasBlock(ctx, id, participantIDs[idx+1:])
+		if err != nil {
+			return err
+		}
+		if blocked {
+			return ErrOperationNotAllowed
+		}
+	}
+	return nil
+}
+
+func directKey(userID int64, otherID int64) string {
+	return fmt.Sprintf("%d:%d", min(userID, otherID), max(userID, otherID))
+}
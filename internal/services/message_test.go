@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newMessageTest(t *testing.T) (*services.Service, *mocks.MockUserStore, *mocks.MockConversationStore) {
+	t.Helper()
+
+	userStore := &mocks.MockUserStore{}
+	conversationStore := &mocks.MockConversationStore{}
+	service := newTestServices(t, &config.Cfg{}, &store.Store{User: userStore, Conversation: conversationStore}, nil)
+	return service, userStore, conversationStore
+}
+
+func newGroup(users ...*models.User) *models.Conversation {
+	conversation := &models.Conversation{ID: 1, IsGroup: true}
+	for _, user := range users {
+		conversation.Participants = append(conversation.Participants, &models.ConversationParticipant{User: user})
+	}
+	return conversation
+}
+
+func TestCreateConversation(t *testing.T) {
+	user := &models.User{ID: 1, Username: "hutao"}
+	payload := &payloads.CreateConversationPayload{ParticipantIDs: []int64{2, 3}, Title: "wangsheng"}
+
+	t.Run("refuses a group with members that blocked each other", func(t *testing.T) {
+		service, userStore, conversationStore := newMessageTest(t)
+		userStore.On("HasBlock", mock.Anything, user.ID, []int64{2, 3}).Return(false, nil)
+		userStore.On("HasBlock", mock.Anything, int64(2), []int64{3}).Return(true, nil)
+
+		_, err := service.Message.CreateConversation(context.Background(), user, payload)
+		assert.ErrorIs(t, err, services.ErrOperationNotAllowed)
+		conversationStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("refuses a group with a member the creator blocked", func(t *testing.T) {
+		service, userStore, conversationStore := newMessageTest(t)
+		userStore.On("HasBlock", mock.Anything, user.ID, []int64{2, 3}).Return(true, nil)
+
+		_, err := service.Message.CreateConversation(context.Background(), user, payload)
+		assert.ErrorIs(t, err, services.ErrOperationNotAllowed)
+		conversationStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("creates the group when nobody is blocked", func(t *testing.T) {
+		service, userStore, conversationStore := newMessageTest(t)
+		userStore.On("HasBlock", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+		conversationStore.On("Create", mock.Anything, mock.Anything, []int64{1, 2, 3}).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(1).(*models.Conversation).ID = 7
+		})
+		conversationStore.On("GetByID", mock.Anything, int64(7)).Return(&models.Conversation{ID: 7, IsGroup: true}, nil)
+
+		conversation, err := service.Message.CreateConversation(context.Background(), user, payload)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), conversation.ID)
+		userStore.AssertNumberOfCalls(t, "HasBlock", 2)
+	})
+}
+
+func TestSendMessage(t *testing.T) {
+	hutao := &models.User{ID: 1, Username: "hutao"}
+	chaee := &models.User{ID: 2, Username: "chaee"}
+	momo := &models.User{ID: 3, Username: "momo"}
+	payload := &payloads.SendMessagePayload{Content: "hello"}
+
+	t.Run("refuses group messages between blocked members", func(t *testing.T) {
+		service, userStore, conversationStore := newMessageTest(t)
+		userStore.On("HasBlock", mock.Anything, hutao.ID, []int64{chaee.ID, momo.ID}).Return(true, nil)
+
+		_, err := service.Message.Send(context.Background(), hutao, newGroup(hutao, chaee, momo), payload)
+		assert.ErrorIs(t, err, services.ErrOperationNotAllowed)
+		conversationStore.AssertNotCalled(t, "CreateMessage", mock.Anything, mock.Anything)
+	})
+
+	t.Run("sends to the group when nobody is blocked", func(t *testing.T) {
+		service, userStore, conversationStore := newMessageTest(t)
+		userStore.On("HasBlock", mock.Anything, hutao.ID, []int64{chaee.ID, momo.ID}).Return(false, nil)
+		conversationStore.On("CreateMessage", mock.Anything, mock.Anything).Return(nil)
+
+		message, err := service.Message.Send(context.Background(), hutao, newGroup(hutao, chaee, momo), payload)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", message.Content)
+		assert.Equal(t, hutao, message.Sender)
+	})
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/events"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"go.uber.org/zap"
+)
+
+// notificationQueueSize is how many notifications can wait to be saved before
+// new ones start being dropped
+const notificationQueueSize = 512
+
+// NotificationService records notifications in the background, so the
+// requests that trigger them only pay for putting them in a queue
+type NotificationService struct {
+	store  *store.Store
+	broker events.Broker
+	logger *zap.SugaredLogger
+	queue  chan *models.Notification
+}
+
+func newNotificationService(store *store.Store, broker events.Broker, logger *zap.SugaredLogger) *NotificationService {
+	return &NotificationService{
+		store:  store,
+		broker: broker,
+		logger: logger,
+		queue:  make(chan *models.Notification, notificationQueueSize),
+	}
+}
+
+func (s *NotificationService) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case notification := <-s.queue:
+				s.save(ctx, notification)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (s *NotificationService) GetFromUser(ctx context.Context, userNotifications *pagination.UserNotifications) ([]*models.NotificationGroup, error) {
+	groups, nextCursor, err := s.store.Notification.GetGroups(ctx, userNotifications)
+	if err != nil {
+		return nil, err
+	}
+
+	userNotifications.NextCursor = nextCursor
+
+	return groups, nil
+}
+
+func (s *NotificationService) CountUnread(ctx context.Context, userID int64) (int, error) {
+	return s.store.Notification.CountUnread(ctx, userID)
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID int64, notificationID int64) error {
+	return s.store.Notification.MarkGroupRead(ctx, userID, notificationID)
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID int64) error {
+	return s.store.Notification.MarkAllRead(ctx, userID)
+}
+
+func (s *NotificationService) followed(followerID int64, followedID int64) {
+	now := time.Now()
+	s.enqueue(&models.Notification{
+		UserID:    followedID,
+		ActorID:   followerID,
+		Kind:      models.NotificationFollow,
+		GroupKey:  fmt.Sprintf("follow:%s", dayOf(now)),
+		DedupeKey: validString(fmt.Sprintf("follow:%d", followerID)),
+		CreatedAt: now,
+	})
+}
+
+// commented notifies the post author, and the author of the parent comment
+// when the comment is a reply
+func (s *NotificationService) commented(comment *models.Comment, post *models.Post, parent *models.Comment) {
+	now := time.Now()
+	s.enqueue(&models.Notification{
+		UserID:    post.User.ID,
+		ActorID:   comment.UserId,
+		Kind:      models.NotificationComment,
+		PostID:    validInt64(post.ID),
+		CommentID: validInt64(comment.ID),
+		GroupKey:  fmt.Sprintf("comment:%d:%s", post.ID, dayOf(now)),
+		CreatedAt: now,
+	})
+	if parent != nil && parent.UserId != post.User.ID {
+		s.enqueue(&models.Notification{
+			UserID:    parent.UserId,
+			ActorID:   comment.UserId,
+			Kind:      models.NotificationReply,
+			PostID:    validInt64(post.ID),
+			CommentID: validInt64(comment.ID),
+			GroupKey:  fmt.Sprintf("reply:%d:%s", parent.ID, dayOf(now)),
+			CreatedAt: now,
+		})
+	}
+}
+
+// reacted notifies the author of the post, who is only looked up when the
+// notification is saved
+func (s *NotificationService) reacted(userID int64, postID int64) {
+	now := time.Now()
+	s.enqueue(&models.Notification{
+		ActorID:   userID,
+		Kind:      models.NotificationReaction,
+		PostID:    validInt64(postID),
+		GroupKey:  fmt.Sprintf("reaction:%d:%s", postID, dayOf(now)),
+		DedupeKey: validString(fmt.Sprintf("reaction:%d:%d", postID, userID)),
+		CreatedAt: now,
+	})
+}
+
+func (s *NotificationService) mentionedInPost(post *models.Post, mentions []models.Mention) {
+	now := time.Now()
+	key := fmt.Sprintf("mention:post:%d", post.ID)
+	for _, mention := range mentions {
+		s.enqueue(&models.Notification{
+			UserID:    mention.UserID,
+			ActorID:   post.User.ID,
+			Kind:      models.NotificationMention,
+			PostID:    validInt64(post.ID),
+			GroupKey:  key,
+			DedupeKey: validString(key),
+			CreatedAt: now,
+		})
+	}
+}
+
+func (s *NotificationService) mentionedInComment(comment *models.Comment, mentions []models.Mention) {
+	now := time.Now()
+	key := fmt.Sprintf("mention:comment:%d", comment.ID)
+	for _, mention := range mentions {
+		s.enqueue(&models.Notification{
+			UserID:    mention.UserID,
+			ActorID:   comment.UserId,
+			Kind:      models.NotificationMention,
+			PostID:    validInt64(comment.PostId),
+			CommentID: validInt64(comment.ID),
+			GroupKey:  key,
+			DedupeKey: validString(key),
+			CreatedAt: now,
+		})
+	}
+}
+
+// enqueue never blocks the caller, when the queue is full the notification is
+// dropped
+func (s *NotificationService) enqueue(notification *models.Notification) {
+	if notification.UserID != 0 && notification.UserID == notification.ActorID {
+		return
+	}
+	select {
+	case s.queue <- notification:
+	default:
+		s.logger.Warnw("notification queue is full, dropping notification", "kind", notification.Kind, "user", notification.UserID)
+	}
+}
+
+func (s *NotificationService) save(ctx context.Context, notification *models.Notification) {
+	if notification.UserID == 0 && notification.PostID.Valid {
+		post, err := s.store.Post.GetByID(ctx, notification.PostID.Int64)
+		if err != nil {
+			if err != store.ErrNotFound {
+				s.logger.Errorw("could not find notification recipient", "post", notification.PostID.Int64, "error", err)
+			}
+			return
+		}
+		notification.UserID = post.User.ID
+	}
+	if notification.UserID == notification.ActorID {
+		return
+	}
+	if err := s.store.Notification.Create(ctx, notification); err != nil {
+		if err != store.ErrForeignKeyViolation {
+			s.logger.Errorw("could not save notification", "kind", notification.Kind, "user", notification.UserID, "error", err)
+		}
+		return
+	}
+	if notification.ID != 0 {
+		s.publish(ctx, notification)
+	}
+}
+
+// publish pushes the saved notification to the recipient connections
+func (s *NotificationService) publish(ctx context.Context, notification *models.Notification) {
+	data := events.NotificationData{
+		ID:        notification.ID,
+		Kind:      notification.Kind,
+		ActorID:   notification.ActorID,
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.PostID.Valid {
+		data.PostID = &notification.PostID.Int64
+	}
+	if notification.CommentID.Valid {
+		data.CommentID = &notification.CommentID.Int64
+	}
+	event, err := events.New(events.KindNotification, data)
+	if err != nil {
+		s.logger.Errorw("could not encode notification event", "error", err)
+		return
+	}
+	topics := []string{events.NotificationsTopic(notification.UserID)}
+	if err := s.broker.Publish(ctx, topics, event); err != nil {
+		s.logger.Errorw("could not publish notification event", "user", notification.UserID, "error", err)
+	}
+}
+
+func dayOf(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+func validInt64(value int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: value, Valid: true}
+}
+
+func validString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: true}
+}
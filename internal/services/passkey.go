@@ -0,0 +1,271 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/webauthn"
+	"go.uber.org/zap"
+)
+
+const (
+	passkeyChallengeSize  = 32
+	defaultPasskeyName    = "Passkey"
+	passkeyUserHandleSize = 8
+)
+
+var ErrInvalidPasskey = errors.New("passkey could not be verified")
+
+type PasskeyService struct {
+	store  *store.Store
+	cfg    *config.Cfg
+	logger *zap.SugaredLogger
+	audit  *AuditLogger
+}
+
+// BeginRegistration starts adding a passkey to the user account
+func (s *PasskeyService) BeginRegistration(ctx context.Context, user *models.User) (*models.PasskeyCreation, error) {
+	passkeys, err := s.store.Passkey.GetFromUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	challenge, err := s.createChallenge(ctx, models.PasskeyRegistration, sql.NullInt64{Int64: user.ID, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+	return &models.PasskeyCreation{
+		Challenge:  challenge,
+		User:       user,
+		UserHandle: passkeyUserHandle(user.ID),
+		Passkeys:   passkeys,
+	}, nil
+}
+
+// FinishRegistration checks the credential the authenticator created and
+// saves it as a passkey of the user
+func (s *PasskeyService) FinishRegistration(ctx context.Context, user *models.User, payload *payloads.RegisterPasskeyPayload) (*models.Passkey, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+	credentialID, err := base64.RawURLEncoding.DecodeString(payload.Credential.ID)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+	rawClientData, err := base64.RawURLEncoding.DecodeString(payload.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+	attestationObject, err := base64.RawURLEncoding.DecodeString(payload.Credential.Response.AttestationObject)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+
+	clientData, err := s.consumeChallenge(ctx, rawClientData, webauthn.CeremonyCreate, models.PasskeyRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if clientData.challenge.UserID.Int64 != user.ID {
+		return nil, ErrInvalidPasskey
+	}
+
+	credential, err := s.relyingParty().VerifyRegistration(clientData.ClientData, attestationObject)
+	if err != nil {
+		s.logger.Infow("passkey registration refused", "user", user.ID, "error", err)
+		return nil, ErrInvalidPasskey
+	}
+	if !bytes.Equal(credential.ID, credentialID) {
+		return nil, ErrInvalidPasskey
+	}
+
+	name := payload.Name
+	if name == "" {
+		name = defaultPasskeyName
+	}
+	passkey := &models.Passkey{
+		UserID:       user.ID,
+		CredentialID: credential.ID,
+		PublicKey:    credential.PublicKey,
+		SignCount:    int64(credential.SignCount),
+		Name:         name,
+	}
+	if err := s.store.Passkey.Create(ctx, passkey); err != nil {
+		return nil, err
+	}
+	return passkey, nil
+}
+
+// BeginLogin starts a sign in with any passkey the browser knows for this
+// site, the account is only known once the assertion comes back
+func (s *PasskeyService) BeginLogin(ctx context.Context) (*models.PasskeyChallenge, error) {
+	return s.createChallenge(ctx, models.PasskeyLogin, sql.NullInt64{})
+}
+
+// FinishLogin checks the assertion signed by the passkey and returns its
+// user. Passkeys prove possession and, with user verification, who holds
+// them, so two-factor is not asked
+func (s *PasskeyService) FinishLogin(ctx context.Context, payload *payloads.PasskeyAssertionPayload) (*models.User, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+	credentialID, err := base64.RawURLEncoding.DecodeString(payload.ID)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+	rawClientData, err := base64.RawURLEncoding.DecodeString(payload.Response.ClientDataJSON)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+	authData, err := base64.RawURLEncoding.DecodeString(payload.Response.AuthenticatorData)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(payload.Response.Signature)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+	userHandle, err := base64.RawURLEncoding.DecodeString(payload.Response.UserHandle)
+	if err != nil {
+		return nil, ErrInvalidPayload
+	}
+
+	clientData, err := s.consumeChallenge(ctx, rawClientData, webauthn.CeremonyGet, models.PasskeyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	passkey, err := s.store.Passkey.GetByCredentialID(ctx, credentialID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, ErrInvalidPasskey
+		}
+		return nil, err
+	}
+	if len(userHandle) > 0 && !bytes.Equal(userHandle, passkeyUserHandle(passkey.UserID)) {
+		return nil, ErrInvalidPasskey
+	}
+
+	credential := &webauthn.Credential{
+		ID:        passkey.CredentialID,
+		PublicKey: passkey.PublicKey,
+		SignCount: uint32(passkey.SignCount),
+	}
+	assertion, err := s.relyingParty().VerifyAssertion(clientData.ClientData, rawClientData, authData, signature, credential)
+	if err != nil {
+		if err == webauthn.ErrSignCountRegressed {
+			s.logger.Warnw("passkey counter went backwards, it may have been cloned", "user", passkey.UserID, "passkey", passkey.ID)
+		}
+		return nil, ErrInvalidPasskey
+	}
+	// the passkey stands in for the password and the second factor, so the
+	// authenticator has to check who is holding it
+	if !assertion.UserVerified {
+		s.logger.Infow("passkey sign in refused without user verification", "user", passkey.UserID, "passkey", passkey.ID)
+		return nil, ErrInvalidPasskey
+	}
+	if err := s.store.Passkey.UpdateSignCount(ctx, passkey.ID, int64(assertion.SignCount)); err != nil {
+		if err == store.ErrConflict {
+			return nil, ErrInvalidPasskey
+		}
+		return nil, err
+	}
+
+	user, err := s.store.User.GetByID(ctx, passkey.UserID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, ErrInvalidPasskey
+		}
+		return nil, err
+	}
+	metadata := map[string]string{"method": signinPasskey, "passkey": auditID(passkey.ID)}
+	s.audit.Record(ctx, newAuditEvent(models.AuditSigninSucceeded, user.ID, models.AuditTargetUser, auditID(user.ID), metadata))
+	return user, nil
+}
+
+func (s *PasskeyService) GetFromUser(ctx context.Context, userID int64) ([]*models.Passkey, error) {
+	return s.store.Passkey.GetFromUser(ctx, userID)
+}
+
+func (s *PasskeyService) Rename(ctx context.Context, userID int64, passkeyID int64, payload *payloads.RenamePasskeyPayload) error {
+	if err := models.Validate.Struct(payload); err != nil {
+		return ErrInvalidPayload
+	}
+	return s.store.Passkey.Rename(ctx, userID, passkeyID, payload.Name)
+}
+
+// Delete removes a passkey of the user, as long as a password, a provider
+// account or another passkey is left to sign in with
+func (s *PasskeyService) Delete(ctx context.Context, userID int64, passkeyID int64) error {
+	err := s.store.Passkey.Delete(ctx, userID, passkeyID)
+	if err == store.ErrConflict {
+		return ErrLastLoginMethod
+	}
+	return err
+}
+
+func (s *PasskeyService) createChallenge(ctx context.Context, kind string, userID sql.NullInt64) (*models.PasskeyChallenge, error) {
+	value := make([]byte, passkeyChallengeSize)
+	if _, err := rand.Read(value); err != nil {
+		return nil, err
+	}
+	challenge := &models.PasskeyChallenge{
+		Challenge: value,
+		UserID:    userID,
+		Kind:      kind,
+		ExpiresAt: time.Now().Add(s.cfg.Auth.Passkey.ChallengeExpired),
+	}
+	if err := s.store.Passkey.CreateChallenge(ctx, challenge); err != nil {
+		return nil, err
+	}
+	return challenge, nil
+}
+
+type passkeyClientData struct {
+	*webauthn.ClientData
+	challenge *models.PasskeyChallenge
+}
+
+// consumeChallenge reads the client data and spends the challenge it signed,
+// which must have been handed out for the same ceremony
+func (s *PasskeyService) consumeChallenge(ctx context.Context, rawClientData []byte, ceremony string, kind string) (*passkeyClientData, error) {
+	clientData, err := webauthn.ParseClientData(rawClientData, ceremony)
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	value, err := clientData.ChallengeBytes()
+	if err != nil {
+		return nil, ErrInvalidPasskey
+	}
+	challenge, err := s.store.Passkey.ConsumeChallenge(ctx, value, kind)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, ErrInvalidPasskey
+		}
+		return nil, err
+	}
+	return &passkeyClientData{ClientData: clientData, challenge: challenge}, nil
+}
+
+func (s *PasskeyService) relyingParty() *webauthn.RelyingParty {
+	return &webauthn.RelyingParty{
+		ID:     s.cfg.Auth.Passkey.RPID,
+		Name:   s.cfg.Auth.Passkey.RPName,
+		Origin: s.cfg.Auth.Passkey.Origin,
+	}
+}
+
+// passkeyUserHandle is the opaque id authenticators keep for the account
+func passkeyUserHandle(userID int64) []byte {
+	handle := make([]byte, passkeyUserHandleSize)
+	binary.BigEndian.PutUint64(handle, uint64(userID))
+	return handle
+}
@@ -0,0 +1,81 @@
+package services_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/mochaeng/sapphire-backend/internal/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasskeyFinishLogin(t *testing.T) {
+	cfg := &config.Cfg{
+		Auth: config.AuthCfg{
+			Passkey: config.PasskeyCfg{RPID: "sapphire.com", Origin: "https://sapphire.com"},
+		},
+	}
+	relyingParty := &webauthn.RelyingParty{ID: cfg.Auth.Passkey.RPID, Origin: cfg.Auth.Passkey.Origin}
+
+	authenticator, err := testutils.NewAuthenticator(cfg.Auth.Passkey.RPID, cfg.Auth.Passkey.Origin)
+	require.NoError(t, err)
+	attestation := authenticator.Register([]byte("registration-challenge"))
+	rawClientData, err := base64.RawURLEncoding.DecodeString(attestation.Response.ClientDataJSON)
+	require.NoError(t, err)
+	attestationObject, err := base64.RawURLEncoding.DecodeString(attestation.Response.AttestationObject)
+	require.NoError(t, err)
+	clientData, err := webauthn.ParseClientData(rawClientData, webauthn.CeremonyCreate)
+	require.NoError(t, err)
+	credential, err := relyingParty.VerifyRegistration(clientData, attestationObject)
+	require.NoError(t, err)
+
+	user := &models.User{ID: 1, Username: "hutao"}
+	passkey := &models.Passkey{ID: 7, UserID: user.ID, CredentialID: credential.ID, PublicKey: credential.PublicKey}
+
+	passkeyStore := &mocks.MockPasskeyStore{}
+	userStore := &mocks.MockUserStore{}
+	service := newTestServices(t, cfg, &store.Store{Passkey: passkeyStore, User: userStore}, nil)
+
+	passkeyStore.On("ConsumeChallenge", mock.Anything, mock.Anything, models.PasskeyLogin).Return(&models.PasskeyChallenge{Kind: models.PasskeyLogin}, nil)
+	passkeyStore.On("GetByCredentialID", mock.Anything, credential.ID).Return(passkey, nil)
+	passkeyStore.On("UpdateSignCount", mock.Anything, passkey.ID, mock.Anything).Return(nil)
+	userStore.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+
+	t.Run("refuses an assertion without user verification", func(t *testing.T) {
+		authenticator.AssertionFlags = 0x01
+		assertion := authenticator.Login([]byte("login-challenge"))
+
+		_, err := service.Passkey.FinishLogin(context.Background(), &assertion)
+		assert.ErrorIs(t, err, services.ErrInvalidPasskey)
+		passkeyStore.AssertNotCalled(t, "UpdateSignCount", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("signs in when the user was verified", func(t *testing.T) {
+		authenticator.AssertionFlags = 0
+		assertion := authenticator.Login([]byte("login-challenge"))
+
+		signedIn, err := service.Passkey.FinishLogin(context.Background(), &assertion)
+		require.NoError(t, err)
+		assert.Equal(t, user, signedIn)
+		passkeyStore.AssertCalled(t, "UpdateSignCount", mock.Anything, passkey.ID, int64(authenticator.SignCount))
+	})
+}
+
+func TestPasskeyDelete(t *testing.T) {
+	passkeyStore := &mocks.MockPasskeyStore{}
+	service := newTestServices(t, &config.Cfg{}, &store.Store{Passkey: passkeyStore}, nil)
+	passkeyStore.On("Delete", mock.Anything, int64(1), int64(7)).Return(nil)
+	passkeyStore.On("Delete", mock.Anything, int64(1), int64(8)).Return(store.ErrConflict)
+
+	require.NoError(t, service.Passkey.Delete(context.Background(), 1, 7))
+	err := service.Passkey.Delete(context.Background(), 1, 8)
+	assert.ErrorIs(t, err, services.ErrLastLoginMethod, "the last way to sign in stays")
+}
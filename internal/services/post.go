@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 
 	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/events"
 	"github.com/mochaeng/sapphire-backend/internal/media"
 	"github.com/mochaeng/sapphire-backend/internal/models"
 	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
@@ -18,9 +19,12 @@ var ErrInvalidPayload = errors.New("invalid payload")
 var ErrSaveFile = errors.New("not possible to save the file")
 
 type PostService struct {
-	store  *store.Store
-	cfg    *config.Cfg
-	logger *zap.SugaredLogger
+	store    *store.Store
+	cfg      *config.Cfg
+	logger   *zap.SugaredLogger
+	broker   events.Broker
+	notifier *NotificationService
+	audit    *AuditLogger
 }
 
 func (s *PostService) Create(ctx context.Context, user *models.User, payload *payloads.CreatePostDataValuesPayload, file []byte) (*models.Post, error) {
@@ -48,26 +52,177 @@ func (s *PostService) Create(ctx context.Context, user *models.User, payload *pa
 	if err := s.store.Post.Create(ctx, post); err != nil {
 		return nil, err
 	}
+	s.setMentions(ctx, post)
+	s.publishToFollowers(post, nil)
 	return post, nil
 }
 
-func (s *PostService) Update(ctx context.Context, post *models.Post, payload *payloads.UpdatePostPayload) error {
+// Update edits the title and content of a post. Reposts have none of their
+// own, so they cannot be edited
+func (s *PostService) Update(ctx context.Context, user *models.User, post *models.Post, payload *payloads.UpdatePostPayload) error {
 	if err := models.Validate.Struct(payload); err != nil {
 		return ErrInvalidPayload
 	}
+	if post.IsRepost() {
+		return ErrOperationNotAllowed
+	}
 	if payload.Content != "" {
 		post.Content = payload.Content
 	}
 	if payload.Tittle != "" {
 		post.Tittle = payload.Tittle
 	}
-	return s.store.Post.UpdateByID(ctx, post)
+	if err := s.store.Post.UpdateByID(ctx, post); err != nil {
+		return err
+	}
+	s.setMentions(ctx, post)
+	s.auditModeration(ctx, models.AuditPostModerated, user, post)
+	return nil
+}
+
+// GetMentions returns the users mentioned in the post content
+func (s *PostService) GetMentions(ctx context.Context, post *models.Post) ([]models.Mention, error) {
+	users, err := s.store.Mention.GetByPost(ctx, post.ID)
+	if err != nil {
+		return nil, err
+	}
+	return models.LinkMentions(models.ParseMentions(post.Content), users), nil
+}
+
+// setMentions links the users mentioned in the post content. A failure does
+// not undo the post, it is only left without mentions
+func (s *PostService) setMentions(ctx context.Context, post *models.Post) {
+	parsed := models.ParseMentions(post.Content)
+	users, err := s.store.Mention.SetForPost(ctx, post.ID, post.User.ID, models.MentionedUsernames(parsed))
+	if err != nil {
+		s.logger.Errorw("could not set post mentions", "post", post.ID, "error", err)
+		return
+	}
+	post.Mentions = models.LinkMentions(parsed, users)
+	s.notifier.mentionedInPost(post, users)
+}
+
+// publishToFollowers pushes a new post to the feed of the author's followers,
+// or of the reposter's followers when it was reposted. It runs in the
+// background since the followers have to be looked up first
+func (s *PostService) publishToFollowers(post *models.Post, reposter *models.User) {
+	publisherID := post.User.ID
+	data := events.PostData{
+		ID:        post.ID,
+		UserID:    post.User.ID,
+		Username:  post.User.Username,
+		Tittle:    post.Tittle,
+		Content:   post.Content,
+		Tags:      post.Tags,
+		MediaURL:  post.Media.String,
+		CreatedAt: post.CreatedAt,
+	}
+	if reposter != nil {
+		publisherID = reposter.ID
+		data.RepostedByID = reposter.ID
+		data.RepostedBy = reposter.Username
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), store.QueryTimeoutDuration)
+		defer cancel()
+		followerIDs, err := s.store.User.GetFollowerIDs(ctx, publisherID)
+		if err != nil {
+			s.logger.Errorw("could not get followers to publish post", "post", data.ID, "error", err)
+			return
+		}
+		if len(followerIDs) == 0 {
+			return
+		}
+		event, err := events.New(events.KindPost, data)
+		if err != nil {
+			s.logger.Errorw("could not encode post event", "error", err)
+			return
+		}
+		topics := make([]string, len(followerIDs))
+		for idx, id := range followerIDs {
+			topics[idx] = events.FeedTopic(id)
+		}
+		if err := s.broker.Publish(ctx, topics, event); err != nil {
+			s.logger.Errorw("could not publish post event", "post", data.ID, "error", err)
+		}
+	}()
 }
 
 func (s *PostService) GetWithUser(ctx context.Context, postID int64) (*models.Post, error) {
 	return s.store.Post.GetByIDWithUser(ctx, postID)
 }
 
-func (s *PostService) Delete(ctx context.Context, postID int64) error {
-	return s.store.Post.DeleteByID(ctx, postID)
+func (s *PostService) Delete(ctx context.Context, user *models.User, post *models.Post) error {
+	if err := s.store.Post.DeleteByID(ctx, post.ID); err != nil {
+		return err
+	}
+	s.auditModeration(ctx, models.AuditPostRemoved, user, post)
+	return nil
+}
+
+// auditModeration records the changes made by someone else than the author
+func (s *PostService) auditModeration(ctx context.Context, action string, user *models.User, post *models.Post) {
+	if user.ID == post.User.ID {
+		return
+	}
+	metadata := map[string]string{"author": auditID(post.User.ID)}
+	s.audit.Record(ctx, newAuditEvent(action, user.ID, models.AuditTargetPost, auditID(post.ID), metadata))
+}
+
+// Repost boosts a post into the feed of the user's followers. Reposting a
+// repost boosts the original post instead
+func (s *PostService) Repost(ctx context.Context, user *models.User, post *models.Post) (*models.Post, error) {
+	original := post
+	if post.IsRepost() {
+		var err error
+		if original, err = s.store.Post.GetByIDWithUser(ctx, post.RepostOfID.Int64); err != nil {
+			return nil, err
+		}
+	}
+	repost := &models.Post{
+		User:       user,
+		RepostOfID: sql.NullInt64{Int64: original.ID, Valid: true},
+	}
+	if err := s.store.Post.Create(ctx, repost); err != nil {
+		return nil, err
+	}
+	s.publishToFollowers(original, user)
+	return repost, nil
+}
+
+func (s *PostService) Unrepost(ctx context.Context, user *models.User, post *models.Post) error {
+	originalID := post.ID
+	if post.IsRepost() {
+		originalID = post.RepostOfID.Int64
+	}
+	return s.store.Post.DeleteRepost(ctx, user.ID, originalID)
+}
+
+// Quote creates a new post with its own content that references another one.
+// Plain reposts cannot be quoted, the original post is quoted instead
+func (s *PostService) Quote(ctx context.Context, user *models.User, post *models.Post, payload *payloads.QuotePostPayload) (*models.Post, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+	quoted := post
+	if post.IsRepost() {
+		original, err := s.store.Post.GetByIDWithUser(ctx, post.RepostOfID.Int64)
+		if err != nil {
+			return nil, err
+		}
+		quoted = original
+	}
+	quote := &models.Post{
+		Tittle:    payload.Tittle,
+		Content:   payload.Content,
+		User:      user,
+		QuoteOfID: sql.NullInt64{Int64: quoted.ID, Valid: true},
+		Quoted:    quoted,
+	}
+	if err := s.store.Post.Create(ctx, quote); err != nil {
+		return nil, err
+	}
+	s.setMentions(ctx, quote)
+	s.publishToFollowers(quote, nil)
+	return quote, nil
 }
@@ -0,0 +1,70 @@
+package services_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/events"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdatePost(t *testing.T) {
+	hutao := &models.User{ID: 1, Username: "hutao"}
+	service := newTestServices(t, &config.Cfg{}, &store.Store{Post: &mocks.MockPostStore{}}, nil)
+
+	t.Run("refuses to edit a repost", func(t *testing.T) {
+		repost := &models.Post{ID: 2, User: hutao, RepostOfID: sql.NullInt64{Int64: 1, Valid: true}}
+
+		err := service.Post.Update(context.Background(), hutao, repost, &payloads.UpdatePostPayload{Content: "edited"})
+		assert.ErrorIs(t, err, services.ErrOperationNotAllowed)
+		assert.Empty(t, repost.Content)
+	})
+}
+
+func TestRepost(t *testing.T) {
+	author := &models.User{ID: 1, Username: "hutao"}
+	reposter := &models.User{ID: 2, Username: "chaee"}
+	post := &models.Post{ID: 3, Content: "wangsheng", User: author}
+
+	t.Run("publishes the original post to the reposter's followers", func(t *testing.T) {
+		userStore := &mocks.MockUserStore{}
+		userStore.On("GetFollowerIDs", mock.Anything, reposter.ID).Return([]int64{4}, nil)
+		broker := events.NewLocalBroker()
+		defer broker.Close()
+		service := newTestServicesFrom(t, &config.ServiceCfg{
+			Store:  &store.Store{User: userStore, Post: &mocks.MockPostStore{}},
+			Cfg:    &config.Cfg{},
+			Events: broker,
+		})
+		subscription, err := broker.Subscribe(events.FeedTopic(4))
+		require.NoError(t, err)
+
+		repost, err := service.Post.Repost(context.Background(), reposter, post)
+		require.NoError(t, err)
+		assert.Equal(t, post.ID, repost.RepostOfID.Int64)
+
+		select {
+		case event := <-subscription.Events():
+			assert.Equal(t, events.KindPost, event.Kind)
+			var data events.PostData
+			require.NoError(t, json.Unmarshal(event.Data, &data))
+			assert.Equal(t, post.ID, data.ID)
+			assert.Equal(t, "wangsheng", data.Content)
+			assert.Equal(t, reposter.ID, data.RepostedByID)
+			assert.Equal(t, "chaee", data.RepostedBy)
+		case <-time.After(time.Second):
+			t.Fatal("the repost was not published")
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type ReactionService struct {
+	store    *store.Store
+	cfg      *config.Cfg
+	notifier *NotificationService
+}
+
+// React leaves a reaction of the given kind on a post, replacing any previous
+// reaction the user had left there
+func (s *ReactionService) React(ctx context.Context, userID int64, postID int64, kind string) (*models.ReactionSummary, error) {
+	if !slices.Contains(s.cfg.Reactions.Kinds, kind) {
+		return nil, ErrInvalidPayload
+	}
+	reaction := &models.Reaction{
+		PostID: postID,
+		UserID: userID,
+		Kind:   kind,
+	}
+	if err := s.store.Reaction.Upsert(ctx, reaction); err != nil {
+		return nil, err
+	}
+	s.notifier.reacted(userID, postID)
+	return s.store.Reaction.GetSummary(ctx, postID, userID)
+}
+
+func (s *ReactionService) Unreact(ctx context.Context, userID int64, postID int64) error {
+	return s.store.Reaction.Delete(ctx, postID, userID)
+}
+
+// GetSummary returns the reaction counts of a post. The viewerID can be zero
+// for anonymous requests
+func (s *ReactionService) GetSummary(ctx context.Context, postID int64, viewerID int64) (*models.ReactionSummary, error) {
+	return s.store.Reaction.GetSummary(ctx, postID, viewerID)
+}
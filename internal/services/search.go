@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type SearchService struct {
+	store *store.Store
+}
+
+func (s *SearchService) Posts(ctx context.Context, search *pagination.Search) ([]*models.Post, error) {
+	if search.Author.Valid {
+		if err := models.ValidateUsername(search.Author.String); err != nil {
+			return nil, ErrInvalidPayload
+		}
+	}
+	posts, nextCursor, err := s.store.Search.Posts(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	search.NextCursor = nextCursor
+
+	return posts, nil
+}
+
+func (s *SearchService) Users(ctx context.Context, search *pagination.Search) ([]*models.User, error) {
+	users, nextCursor, err := s.store.Search.Users(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	search.NextCursor = nextCursor
+
+	return users, nil
+}
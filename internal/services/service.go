@@ -15,54 +15,201 @@ type Service struct {
 	User interface {
 		Follow(ctx context.Context, followerID int64, followedID int64) error
 		Unfollow(ctx context.Context, unfollowerID int64, unfollowedID int64) error
+		Block(ctx context.Context, blockerID int64, blockedID int64) error
+		Unblock(ctx context.Context, blockerID int64, blockedID int64) error
 		Activate(ctx context.Context, token string) error
 		GetByUsername(ctx context.Context, username string) (*models.User, error)
 		GetCached(ctx context.Context, userID int64) (*models.User, error)
 		GetProfile(ctx context.Context, username string) (*models.UserProfile, error)
-		GetPostsFromUsername(ctx context.Context, username string, userPosts *pagination.UserPosts) ([]*models.Post, error)
-		LinkOrCreateUserFromOAuth(ctx context.Context, gothUser *goth.User) (*models.User, error)
+		UpdateProfile(ctx context.Context, user *models.User, payload *payloads.UpdateUserPayload, avatar []byte, banner []byte) (*models.UserProfile, error)
+		GetPostsFromUsername(ctx context.Context, username string, userPosts *pagination.UserPosts) ([]*models.PostWithMetadata, error)
+		GetOrCreateUserFromOAuth(ctx context.Context, gothUser *goth.User) (*models.User, error)
+		LinkOAuth(ctx context.Context, userID int64, gothUser *goth.User) (*models.OAuthAccount, error)
+		GetOAuthAccounts(ctx context.Context, userID int64) ([]*models.OAuthAccount, error)
+		UnlinkOAuth(ctx context.Context, userID int64, provider, providerUserID string) error
+		GetMentions(ctx context.Context, userMentions *pagination.UserMentions) ([]*models.UserMention, error)
 	}
 	Post interface {
 		Create(ctx context.Context, user *models.User, payload *payloads.CreatePostDataValuesPayload, file []byte) (*models.Post, error)
 		GetWithUser(ctx context.Context, postID int64) (*models.Post, error)
-		Delete(ctx context.Context, postID int64) error
-		Update(ctx context.Context, post *models.Post, payload *payloads.UpdatePostPayload) error
+		// Delete and Update record an audit event when the user is not the
+		// author, but a moderator acting on the post
+		Delete(ctx context.Context, user *models.User, post *models.Post) error
+		Update(ctx context.Context, user *models.User, post *models.Post, payload *payloads.UpdatePostPayload) error
+		Repost(ctx context.Context, user *models.User, post *models.Post) (*models.Post, error)
+		Unrepost(ctx context.Context, user *models.User, post *models.Post) error
+		Quote(ctx context.Context, user *models.User, post *models.Post, payload *payloads.QuotePostPayload) (*models.Post, error)
+		GetMentions(ctx context.Context, post *models.Post) ([]models.Mention, error)
 	}
 	Auth interface {
 		// GetCookieSession creates a token and a user_session in the database, and returns a HTTPOnlyCookie with the token value
-		GetCookieSession(userID int64) (*http.Cookie, error)
+		GetCookieSession(userID int64, client models.SessionClient) (*http.Cookie, error)
 
 		RegisterUser(ctx context.Context, payload *payloads.RegisterUserPayload) (*models.UserInvitation, error)
-		Authenticate(ctx context.Context, payload *payloads.SigninPayload) (*models.User, error)
+		Authenticate(ctx context.Context, payload *payloads.SigninPayload, ip string) (*models.User, error)
+		// Unlock lifts the lockout of an account after too many failed sign
+		// ins, on behalf of the admin
+		Unlock(ctx context.Context, admin *models.User, userID int64) error
+		ResendActivation(ctx context.Context, payload *payloads.ResendActivationPayload) error
+		ForgotPassword(ctx context.Context, payload *payloads.ForgotPasswordPayload) error
+		ResetPassword(ctx context.Context, payload *payloads.ResetPasswordPayload) error
+		// RequestMagicLink returns the token the requesting browser keeps to
+		// be able to use the link
+		RequestMagicLink(ctx context.Context, payload *payloads.MagicLinkPayload) (string, error)
+		SigninWithMagicLink(ctx context.Context, payload *payloads.MagicLinkSigninPayload, browserToken string) (*models.User, error)
+		GetMagicLinkEnabled(ctx context.Context, userID int64) (bool, error)
+		SetMagicLinkEnabled(ctx context.Context, userID int64, payload *payloads.MagicLinkPreferencePayload) error
+		RequestEmailChange(ctx context.Context, user *models.User, payload *payloads.ChangeEmailPayload) error
+		ConfirmEmailChange(ctx context.Context, token string) error
 		GenerateSessionToken() (string, error)
-		CreateSession(token string, userID int64) (*models.Session, error)
-		ValidateSessionToken(token string) (*models.Session, error)
-		InvalidateSession(sessionID string) error
+		CreateSession(token string, userID int64, client models.SessionClient) (*models.Session, error)
+		ValidateSessionToken(token string, client models.SessionClient) (*models.Session, error)
+		InvalidateSession(ctx context.Context, session *models.Session) error
+		GetSessions(ctx context.Context, userID int64) ([]*models.Session, error)
+		RevokeSession(ctx context.Context, userID int64, sessionID string) error
+		RevokeOtherSessions(ctx context.Context, userID int64, currentSessionID string) error
+	}
+	TwoFactor interface {
+		Setup(ctx context.Context, user *models.User) (*models.TwoFactorSetup, error)
+		Enable(ctx context.Context, user *models.User, payload *payloads.EnableTwoFactorPayload) ([]string, error)
+		Disable(ctx context.Context, user *models.User, payload *payloads.DisableTwoFactorPayload) error
+		BeginLogin(ctx context.Context, user *models.User) (*models.PendingLogin, error)
+		CompleteLogin(ctx context.Context, payload *payloads.VerifyTwoFactorPayload, ip string) (*models.User, error)
+	}
+	Passkey interface {
+		BeginRegistration(ctx context.Context, user *models.User) (*models.PasskeyCreation, error)
+		FinishRegistration(ctx context.Context, user *models.User, payload *payloads.RegisterPasskeyPayload) (*models.Passkey, error)
+		BeginLogin(ctx context.Context) (*models.PasskeyChallenge, error)
+		FinishLogin(ctx context.Context, payload *payloads.PasskeyAssertionPayload) (*models.User, error)
+		GetFromUser(ctx context.Context, userID int64) ([]*models.Passkey, error)
+		Rename(ctx context.Context, userID int64, passkeyID int64, payload *payloads.RenamePasskeyPayload) error
+		Delete(ctx context.Context, userID int64, passkeyID int64) error
+	}
+	AccessToken interface {
+		Create(ctx context.Context, user *models.User, payload *payloads.CreateAccessTokenPayload) (*models.AccessToken, error)
+		GetFromUser(ctx context.Context, userID int64) ([]*models.AccessToken, error)
+		Revoke(ctx context.Context, userID int64, accessTokenID int64) error
+		Validate(ctx context.Context, token string) (*models.AccessToken, error)
 	}
 	Feed interface {
 		Get(ctx context.Context, userID int64, feedQuery *pagination.PaginateFeedQuery) ([]*models.PostWithMetadata, error)
 	}
+	Comment interface {
+		Create(ctx context.Context, user *models.User, post *models.Post, payload *payloads.CreateCommentPayload) (*models.Comment, error)
+		GetByID(ctx context.Context, commentID int64) (*models.Comment, error)
+		GetThread(ctx context.Context, postComments *pagination.PostComments) ([]*models.Comment, error)
+		Update(ctx context.Context, user *models.User, comment *models.Comment, payload *payloads.UpdateCommentPayload) error
+		Delete(ctx context.Context, user *models.User, comment *models.Comment) error
+	}
+	Reaction interface {
+		React(ctx context.Context, userID int64, postID int64, kind string) (*models.ReactionSummary, error)
+		Unreact(ctx context.Context, userID int64, postID int64) error
+		GetSummary(ctx context.Context, postID int64, viewerID int64) (*models.ReactionSummary, error)
+	}
+	Bookmark interface {
+		Create(ctx context.Context, user *models.User, post *models.Post) (*models.Bookmark, error)
+		Delete(ctx context.Context, user *models.User, post *models.Post) error
+		GetFromUser(ctx context.Context, userBookmarks *pagination.UserBookmarks) ([]*models.Bookmark, error)
+	}
+	Search interface {
+		Posts(ctx context.Context, search *pagination.Search) ([]*models.Post, error)
+		Users(ctx context.Context, search *pagination.Search) ([]*models.User, error)
+	}
+	Tag interface {
+		GetPosts(ctx context.Context, tagPosts *pagination.TagPosts) ([]*models.Post, error)
+		GetTrending(ctx context.Context, limit int) ([]*models.TrendingTag, error)
+	}
+	Message interface {
+		CreateConversation(ctx context.Context, user *models.User, payload *payloads.CreateConversationPayload) (*models.Conversation, error)
+		GetConversation(ctx context.Context, conversationID int64, userID int64) (*models.Conversation, error)
+		GetConversations(ctx context.Context, userConversations *pagination.UserConversations) ([]*models.Conversation, error)
+		GetMessages(ctx context.Context, conversationMessages *pagination.ConversationMessages) ([]*models.Message, error)
+		Send(ctx context.Context, user *models.User, conversation *models.Conversation, payload *payloads.SendMessagePayload) (*models.Message, error)
+		MarkRead(ctx context.Context, user *models.User, conversation *models.Conversation) error
+	}
+	Notification interface {
+		// Start saves the notifications generated by the other services in the
+		// background until the context is done
+		Start(ctx context.Context)
+
+		GetFromUser(ctx context.Context, userNotifications *pagination.UserNotifications) ([]*models.NotificationGroup, error)
+		CountUnread(ctx context.Context, userID int64) (int, error)
+		MarkRead(ctx context.Context, userID int64, notificationID int64) error
+		MarkAllRead(ctx context.Context, userID int64) error
+	}
+	Audit interface {
+		GetEvents(ctx context.Context, auditEvents *pagination.AuditEvents) ([]*models.AuditEvent, error)
+	}
 }
 
 func NewServices(serviceCfg *config.ServiceCfg) *Service {
+	notification := newNotificationService(serviceCfg.Store, serviceCfg.Events, serviceCfg.Logger)
+	audit := &AuditLogger{serviceCfg.Store, serviceCfg.Logger}
+	auth := &AuthService{
+		serviceCfg.Store,
+		serviceCfg.Cfg,
+		serviceCfg.Mailer,
+		serviceCfg.Logger,
+		serviceCfg.CacheStore,
+		audit,
+	}
 	return &Service{
 		User: &UserService{
 			serviceCfg.Store,
 			serviceCfg.Cfg,
 			serviceCfg.Logger,
 			serviceCfg.CacheStore,
+			serviceCfg.Events,
+			notification,
+			audit,
 		},
 		Post: &PostService{
 			serviceCfg.Store,
 			serviceCfg.Cfg,
 			serviceCfg.Logger,
+			serviceCfg.Events,
+			notification,
+			audit,
 		},
-		Auth: &AuthService{
+		Auth: auth,
+		TwoFactor: &TwoFactorService{
 			serviceCfg.Store,
 			serviceCfg.Cfg,
-			serviceCfg.Mailer,
+			serviceCfg.Logger,
+			auth,
+		},
+		Passkey: &PasskeyService{
+			serviceCfg.Store,
+			serviceCfg.Cfg,
+			serviceCfg.Logger,
+			audit,
+		},
+		AccessToken: &AccessTokenService{
+			serviceCfg.Store,
 			serviceCfg.Logger,
 		},
 		Feed: &FeedService{serviceCfg.Store},
+		Comment: &CommentService{
+			serviceCfg.Store,
+			serviceCfg.Logger,
+			serviceCfg.Events,
+			notification,
+			audit,
+		},
+		Reaction: &ReactionService{
+			serviceCfg.Store,
+			serviceCfg.Cfg,
+			notification,
+		},
+		Bookmark: &BookmarkService{serviceCfg.Store},
+		Search:   &SearchService{serviceCfg.Store},
+		Tag:      &TagService{serviceCfg.Store},
+		Message: &MessageService{
+			serviceCfg.Store,
+			serviceCfg.Events,
+			serviceCfg.Logger,
+		},
+		Notification: notification,
+		Audit:        audit,
 	}
 }
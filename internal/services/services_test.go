@@ -0,0 +1,44 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/events"
+	"github.com/mochaeng/sapphire-backend/internal/mailer"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/store/cache"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// newTestServices builds the services on top of the given mocked stores. The
+// audit log accepts every event unless the test mocks it
+func newTestServices(t *testing.T, cfg *config.Cfg, testStore *store.Store, mailClient mailer.Client) *services.Service {
+	t.Helper()
+	return newTestServicesFrom(t, &config.ServiceCfg{Store: testStore, Cfg: cfg, Mailer: mailClient})
+}
+
+// newTestServicesFrom is newTestServices for tests that also need the cache
+// or the event broker, whatever is left empty gets a default
+func newTestServicesFrom(t *testing.T, serviceCfg *config.ServiceCfg) *services.Service {
+	t.Helper()
+
+	if serviceCfg.Store.AuditEvent == nil {
+		auditEventStore := &mocks.MockAuditEventStore{}
+		auditEventStore.On("Create", mock.Anything, mock.Anything).Return(nil)
+		serviceCfg.Store.AuditEvent = auditEventStore
+	}
+	if serviceCfg.Logger == nil {
+		serviceCfg.Logger = zap.NewNop().Sugar()
+	}
+	if serviceCfg.CacheStore == nil {
+		serviceCfg.CacheStore = &cache.Store{}
+	}
+	if serviceCfg.Events == nil {
+		serviceCfg.Events = events.NewLocalBroker()
+	}
+	return services.NewServices(serviceCfg)
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+const maxTagSize = 255
+
+type TagService struct {
+	store *store.Store
+}
+
+func (s *TagService) GetPosts(ctx context.Context, tagPosts *pagination.TagPosts) ([]*models.Post, error) {
+	if tagPosts.Tag == "" || len(tagPosts.Tag) > maxTagSize {
+		return nil, ErrInvalidPayload
+	}
+	posts, nextCursor, err := s.store.Tag.GetPosts(ctx, tagPosts)
+	if err != nil {
+		return nil, err
+	}
+
+	tagPosts.NextCursor = nextCursor
+
+	return posts, nil
+}
+
+// GetTrending returns the last ranking computed by the trending tags job
+func (s *TagService) GetTrending(ctx context.Context, limit int) ([]*models.TrendingTag, error) {
+	return s.store.Tag.GetTrending(ctx, limit)
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/cryptoutils"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrTwoFactorUnavailable = errors.New("two-factor authentication is not available")
+	ErrInvalidTwoFactorCode = errors.New("two-factor code is invalid")
+	ErrInvalidPendingLogin  = errors.New("sign in expired, start again")
+	ErrInvalidCredentials   = errors.New("credentials are invalid")
+)
+
+type TwoFactorService struct {
+	store  *store.Store
+	cfg    *config.Cfg
+	logger *zap.SugaredLogger
+	auth   *AuthService
+}
+
+// Setup creates a new TOTP secret for the user. Two-factor only starts being
+// asked once Enable receives a code generated from it
+func (s *TwoFactorService) Setup(ctx context.Context, user *models.User) (*models.TwoFactorSetup, error) {
+	key := s.cfg.Auth.TwoFactor.EncryptionKey
+	if len(key) == 0 {
+		return nil, ErrTwoFactorUnavailable
+	}
+
+	secret, err := cryptoutils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := cryptoutils.Encrypt(key, []byte(secret))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.TwoFactor.SaveSecret(ctx, user.ID, encrypted); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSetup{
+		Secret: secret,
+		URI:    cryptoutils.TOTPProvisioningURI(s.cfg.Auth.TwoFactor.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Enable turns two-factor on after checking a code from the authenticator and
+// returns the recovery codes, which are not shown again
+func (s *TwoFactorService) Enable(ctx context.Context, user *models.User, payload *payloads.EnableTwoFactorPayload) ([]string, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+
+	twoFactor, err := s.store.TwoFactor.Get(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if twoFactor.IsEnabled() {
+		return nil, store.ErrConflict
+	}
+	secret, err := s.decryptSecret(twoFactor)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := cryptoutils.ValidateTOTP(secret, payload.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, models.RecoveryCodesCount)
+	hashes := make([]string, models.RecoveryCodesCount)
+	for idx := range codes {
+		code, err := cryptoutils.GenerateRandomSuffix(10)
+		if err != nil {
+			return nil, err
+		}
+		codes[idx] = code[:5] + "-" + code[5:]
+		hashes[idx] = hashRecoveryCode(codes[idx])
+	}
+	if err := s.store.TwoFactor.Enable(ctx, user.ID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable turns two-factor off once the user confirms their password
+func (s *TwoFactorService) Disable(ctx context.Context, user *models.User, payload *payloads.DisableTwoFactorPayload) error {
+	if err := models.Validate.Struct(payload); err != nil {
+		return ErrInvalidPayload
+	}
+
+	// the user in the request context comes from the cache, without password
+	storedUser, err := s.store.User.GetByID(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := storedUser.Password.Compare(payload.Password); err != nil {
+		return ErrInvalidCredentials
+	}
+	return s.store.TwoFactor.Disable(ctx, user.ID)
+}
+
+// BeginLogin starts a pending login when the user has two-factor on. Without
+// it there is nothing else to check and no pending login is returned
+func (s *TwoFactorService) BeginLogin(ctx context.Context, user *models.User) (*models.PendingLogin, error) {
+	twoFactor, err := s.store.TwoFactor.Get(ctx, user.ID)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !twoFactor.IsEnabled() {
+		return nil, nil
+	}
+
+	plainToken, err := cryptoutils.GenerateRandomString(20)
+	if err != nil {
+		return nil, err
+	}
+	pendingLogin := &models.PendingLogin{
+		Token:     cryptoutils.GetSessionID(plainToken),
+		User:      user,
+		ExpiresAt: time.Now().Add(s.cfg.Auth.TwoFactor.PendingLoginExpired),
+	}
+	if err := s.store.TwoFactor.CreatePendingLogin(ctx, pendingLogin); err != nil {
+		return nil, err
+	}
+	pendingLogin.Token = plainToken
+	return pendingLogin, nil
+}
+
+// CompleteLogin checks the TOTP or recovery code of a pending login and
+// returns its user. Too many wrong codes end the pending login. Each code is
+// also a sign in attempt of the account and of the ip, so starting pending
+// logins over does not give unlimited guesses
+func (s *TwoFactorService) CompleteLogin(ctx context.Context, payload *payloads.VerifyTwoFactorPayload, ip string) (*models.User, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+
+	token := cryptoutils.GetSessionID(payload.Token)
+	pendingLogin, err := s.store.TwoFactor.GetPendingLogin(ctx, token)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, ErrInvalidPendingLogin
+		}
+		return nil, err
+	}
+
+	attempt, err := s.auth.beginLoginAttempt(ctx, pendingLogin.User.Email, ip)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCode(ctx, pendingLogin.User.ID, payload.Code); err != nil {
+		if err != ErrInvalidTwoFactorCode {
+			return nil, err
+		}
+		s.auth.failLoginAttempt(ctx, attempt, pendingLogin.User)
+		attempts, attemptErr := s.store.TwoFactor.AddPendingLoginAttempt(ctx, token)
+		if attemptErr != nil {
+			return nil, attemptErr
+		}
+		if attempts >= models.MaxPendingLoginAttempts {
+			if err := s.store.TwoFactor.DeletePendingLogin(ctx, token); err != nil {
+				return nil, err
+			}
+			return nil, ErrInvalidPendingLogin
+		}
+		return nil, err
+	}
+
+	if err := s.store.TwoFactor.DeletePendingLogin(ctx, token); err != nil {
+		return nil, err
+	}
+	s.auth.succeedLoginAttempt(ctx, attempt)
+	return pendingLogin.User, nil
+}
+
+func (s *TwoFactorService) checkCode(ctx context.Context, userID int64, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) != cryptoutils.TOTPDigits {
+		err := s.store.TwoFactor.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+		if err == store.ErrNotFound {
+			return ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+
+	twoFactor, err := s.store.TwoFactor.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	secret, err := s.decryptSecret(twoFactor)
+	if err != nil {
+		return err
+	}
+	step, ok := cryptoutils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	// a code that was already used is refused, even inside its time window
+	if err := s.store.TwoFactor.UseStep(ctx, userID, step); err != nil {
+		if err == store.ErrConflict {
+			return ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *TwoFactorService) decryptSecret(twoFactor *models.TwoFactor) (string, error) {
+	key := s.cfg.Auth.TwoFactor.EncryptionKey
+	if len(key) == 0 {
+		return "", ErrTwoFactorUnavailable
+	}
+	secret, err := cryptoutils.Decrypt(key, twoFactor.Secret)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// hashRecoveryCode ignores case, spaces and dashes so codes can be typed the
+// way they are read
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	hash := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(hash[:])
+}
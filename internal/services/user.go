@@ -2,15 +2,19 @@ package services
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"path/filepath"
 	"strings"
 
 	"github.com/google/uuid"
 	"github.com/markbates/goth"
 	"github.com/mochaeng/sapphire-backend/internal/config"
 	"github.com/mochaeng/sapphire-backend/internal/cryptoutils"
+	"github.com/mochaeng/sapphire-backend/internal/events"
+	"github.com/mochaeng/sapphire-backend/internal/media"
 	"github.com/mochaeng/sapphire-backend/internal/models"
 	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
 	"github.com/mochaeng/sapphire-backend/internal/store"
 	"github.com/mochaeng/sapphire-backend/internal/store/cache"
 	"go.uber.org/zap"
@@ -21,66 +25,125 @@ type UserService struct {
 	cfg        *config.Cfg
 	logger     *zap.SugaredLogger
 	cacheStore *cache.Store
+	broker     events.Broker
+	notifier   *NotificationService
+	audit      *AuditLogger
 }
 
-func (s *UserService) LinkOrCreateUserFromOAuth(ctx context.Context, gothUser *goth.User) (*models.User, error) {
+var (
+	// ErrOAuthEmailTaken means the e-mail of a provider account that was
+	// never linked belongs to a user already. Providers do not always verify
+	// e-mails, so the owner has to sign in and link the account explicitly
+	ErrOAuthEmailTaken  = errors.New("e-mail is used by an account, sign in to link this provider")
+	ErrInvalidOAuthUser = errors.New("provider did not return the account id and e-mail")
+	// ErrLastLoginMethod means unlinking a provider account or removing a
+	// passkey would leave the user without a way to sign in
+	ErrLastLoginMethod = errors.New("account needs another way to sign in before removing this one")
+)
+
+// GetOrCreateUserFromOAuth signs in the user linked to the provider account,
+// or creates one when nobody uses its e-mail yet
+func (s *UserService) GetOrCreateUserFromOAuth(ctx context.Context, gothUser *goth.User) (*models.User, error) {
 	if gothUser.Provider == "" || gothUser.Email == "" || gothUser.UserID == "" {
-		return nil, fmt.Errorf("empty field from OAuth provider")
+		return nil, ErrInvalidOAuthUser
 	}
 
-	var user *models.User
-
-	existingUser, err := s.store.User.GetByEmail(ctx, gothUser.Email)
+	userID, err := s.store.OAuth.GetUserID(ctx, gothUser.Provider, gothUser.UserID)
 	if err != nil && err != store.ErrNotFound {
 		return nil, err
 	}
+	if userID != nil {
+		user, err := s.store.User.GetByID(ctx, *userID)
+		if err != nil {
+			return nil, err
+		}
+		s.auditOAuthSignin(ctx, user, gothUser)
+		return user, nil
+	}
+
+	_, err = s.store.User.GetByEmail(ctx, gothUser.Email)
+	if err == nil {
+		return nil, ErrOAuthEmailTaken
+	}
+	if err != store.ErrNotFound {
+		return nil, err
+	}
 
+	randomUsername, err := s.generateUniqueUsername(ctx)
+	if err != nil {
+		return nil, err
+	}
+	newUser := models.User{
+		Username:  randomUsername,
+		FirstName: gothUser.FirstName,
+		LastName:  gothUser.LastName,
+		Email:     gothUser.Email,
+		IsActive:  true,
+		Role: models.Role{
+			ID: config.Roles["user"].ID,
+		},
+	}
+	userProfile := models.UserProfile{
+		User:      &newUser,
+		AvatarURL: gothUser.AvatarURL,
+	}
 	oauthAccount := models.OAuthAccount{
 		ProviderID:     gothUser.Provider,
 		ProviderUserID: gothUser.UserID,
 	}
+	if err := s.store.OAuth.CreateWithUser(ctx, &oauthAccount, &newUser, &userProfile); err != nil {
+		return nil, err
+	}
+	s.auditOAuthSignin(ctx, &newUser, gothUser)
+	return &newUser, nil
+}
 
-	if existingUser != nil {
-		id, err := s.store.OAuth.GetUserID(ctx, gothUser.Provider, gothUser.UserID)
-		if err != nil && err != store.ErrNotFound {
-			return nil, err
-		}
-		if id == nil {
-			oauthAccount.UserID = existingUser.ID
-			err := s.store.OAuth.CreateWithUserActivation(ctx, &oauthAccount, existingUser)
-			if err != nil {
-				return nil, err
-			}
-		}
-		user = existingUser
-	} else {
-		randomUsername, err := s.generateUniqueUsername(ctx)
-		if err != nil {
-			return nil, err
-		}
+func (s *UserService) auditOAuthSignin(ctx context.Context, user *models.User, gothUser *goth.User) {
+	metadata := map[string]string{"method": signinOAuth, "provider": gothUser.Provider}
+	s.audit.Record(ctx, newAuditEvent(models.AuditSigninSucceeded, user.ID, models.AuditTargetUser, auditID(user.ID), metadata))
+}
 
-		newUser := models.User{
-			Username:  randomUsername,
-			FirstName: gothUser.FirstName,
-			LastName:  gothUser.LastName,
-			Email:     gothUser.Email,
-			IsActive:  true,
-			Role: models.Role{
-				ID: config.Roles["user"].ID,
-			},
-		}
-		userProfile := models.UserProfile{
-			User:      &newUser,
-			AvatarURL: gothUser.AvatarURL,
-		}
-		err = s.store.OAuth.CreateWithUser(ctx, &oauthAccount, &newUser, &userProfile)
-		if err != nil {
-			return nil, err
-		}
-		user = &newUser
+func oauthAccountAuditID(provider string, providerUserID string) string {
+	return provider + ":" + providerUserID
+}
+
+// LinkOAuth links a provider account to a signed in user, whatever its
+// e-mail is. It returns store.ErrConflict when the provider account is
+// linked already
+func (s *UserService) LinkOAuth(ctx context.Context, userID int64, gothUser *goth.User) (*models.OAuthAccount, error) {
+	if gothUser.Provider == "" || gothUser.UserID == "" {
+		return nil, ErrInvalidOAuthUser
+	}
+	oauthAccount := &models.OAuthAccount{
+		ProviderID:     gothUser.Provider,
+		ProviderUserID: gothUser.UserID,
+		UserID:         userID,
+	}
+	if err := s.store.OAuth.Create(ctx, oauthAccount); err != nil {
+		return nil, err
 	}
+	targetID := oauthAccountAuditID(oauthAccount.ProviderID, oauthAccount.ProviderUserID)
+	s.audit.Record(ctx, newAuditEvent(models.AuditOAuthLinked, userID, models.AuditTargetOAuthAccount, targetID, nil))
+	return oauthAccount, nil
+}
 
-	return user, nil
+func (s *UserService) GetOAuthAccounts(ctx context.Context, userID int64) ([]*models.OAuthAccount, error) {
+	return s.store.OAuth.GetFromUser(ctx, userID)
+}
+
+// UnlinkOAuth removes a provider account from the user, as long as a password,
+// a passkey or another provider account is left to sign in with
+func (s *UserService) UnlinkOAuth(ctx context.Context, userID int64, provider, providerUserID string) error {
+	err := s.store.OAuth.Delete(ctx, userID, provider, providerUserID)
+	if err != nil {
+		if err == store.ErrConflict {
+			return ErrLastLoginMethod
+		}
+		return err
+	}
+	targetID := oauthAccountAuditID(provider, providerUserID)
+	s.audit.Record(ctx, newAuditEvent(models.AuditOAuthUnlinked, userID, models.AuditTargetOAuthAccount, targetID, nil))
+	return nil
 }
 
 func (s *UserService) GetCached(ctx context.Context, userID int64) (*models.User, error) {
@@ -139,28 +202,171 @@ func (s *UserService) GetProfile(ctx context.Context, username string) (*models.
 	if err := models.ValidateUsername(username); err != nil {
 		return nil, ErrInvalidPayload
 	}
-	return s.store.User.GetProfile(ctx, username)
+	if !s.cfg.Cacher.IsEnable {
+		return s.store.User.GetProfile(ctx, username)
+	}
+	profile, err := s.cacheStore.Profile.Get(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		profile, err = s.store.User.GetProfile(ctx, username)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.cacheStore.Profile.Set(ctx, profile); err != nil {
+			return nil, err
+		}
+	}
+	return profile, nil
+}
+
+// UpdateProfile changes the profile fields sent in the payload. The avatar and
+// banner, when present, replace the previous files
+func (s *UserService) UpdateProfile(ctx context.Context, user *models.User, payload *payloads.UpdateUserPayload, avatar []byte, banner []byte) (*models.UserProfile, error) {
+	if err := models.Validate.Struct(payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+	for _, file := range [][]byte{avatar, banner} {
+		if file != nil && !media.IsImage(file) {
+			return nil, ErrInvalidPayload
+		}
+	}
+
+	profile, err := s.store.User.GetProfile(ctx, user.Username)
+	if err != nil {
+		return nil, err
+	}
+	profile.User.ID = user.ID
+	var changed []string
+	if payload.Description != nil {
+		profile.Description = *payload.Description
+		changed = append(changed, "description")
+	}
+	if payload.Location != nil {
+		profile.Location = *payload.Location
+		changed = append(changed, "location")
+	}
+	if payload.UserLink != nil {
+		profile.UserLink = *payload.UserLink
+		changed = append(changed, "user_link")
+	}
+
+	var oldFiles, newFiles []string
+	for _, upload := range []struct {
+		field string
+		file  []byte
+		url   *string
+	}{
+		{"avatar", avatar, &profile.AvatarURL},
+		{"banner", banner, &profile.BannerURL},
+	} {
+		if upload.file == nil {
+			continue
+		}
+		changed = append(changed, upload.field)
+		filename, err := media.SaveFileToServer(upload.file, s.cfg.MediaFolder)
+		if err != nil {
+			s.removeFiles(newFiles)
+			return nil, ErrSaveFile
+		}
+		oldFiles = append(oldFiles, *upload.url)
+		*upload.url = filepath.Join(s.cfg.MediaFolder, filename)
+		newFiles = append(newFiles, *upload.url)
+	}
+
+	if err := s.store.User.UpdateProfile(ctx, profile); err != nil {
+		s.removeFiles(newFiles)
+		return nil, err
+	}
+	s.removeFiles(oldFiles)
+	metadata := map[string]string{"fields": strings.Join(changed, ",")}
+	s.audit.Record(ctx, newAuditEvent(models.AuditProfileUpdated, user.ID, models.AuditTargetUser, auditID(user.ID), metadata))
+
+	if s.cfg.Cacher.IsEnable {
+		if err := s.cacheStore.Profile.Delete(ctx, user.Username); err != nil {
+			s.logger.Errorw("could not invalidate cached profile", "username", user.Username, "error", err)
+		}
+	}
+
+	return profile, nil
+}
+
+// removeFiles deletes the media files saved by the server. Other urls, such as
+// avatars from OAuth providers, are left alone
+func (s *UserService) removeFiles(urls []string) {
+	for _, url := range urls {
+		if url == "" || !strings.HasPrefix(url, filepath.Clean(s.cfg.MediaFolder)+string(filepath.Separator)) {
+			continue
+		}
+		if err := media.RemoveFileFromServer(url); err != nil {
+			s.logger.Warnw("could not remove media file", "file", url, "error", err)
+		}
+	}
 }
 
 func (s *UserService) Follow(ctx context.Context, followerID int64, followedID int64) error {
 	if followerID == followedID {
 		return ErrOperationNotAllowed
 	}
-	return s.store.User.Follow(ctx, followerID, followedID)
+	if err := s.store.User.Follow(ctx, followerID, followedID); err != nil {
+		return err
+	}
+	s.notifier.followed(followerID, followedID)
+	s.publishFollow(ctx, followerID, followedID)
+	s.audit.Record(ctx, newAuditEvent(models.AuditUserFollowed, followerID, models.AuditTargetUser, auditID(followedID), nil))
+	return nil
+}
+
+// publishFollow lets the followed user connections know right away. A
+// failure is only logged, the follow is already saved
+func (s *UserService) publishFollow(ctx context.Context, followerID int64, followedID int64) {
+	event, err := events.New(events.KindFollow, events.FollowData{FollowerID: followerID})
+	if err != nil {
+		s.logger.Errorw("could not encode follow event", "error", err)
+		return
+	}
+	topics := []string{events.NotificationsTopic(followedID)}
+	if err := s.broker.Publish(ctx, topics, event); err != nil {
+		s.logger.Errorw("could not publish follow event", "user", followedID, "error", err)
+	}
 }
 
 func (s *UserService) Unfollow(ctx context.Context, unfollowerID int64, unfollowedID int64) error {
 	if unfollowerID == unfollowedID {
 		return ErrOperationNotAllowed
 	}
-	return s.store.User.Unfollow(ctx, unfollowerID, unfollowedID)
+	if err := s.store.User.Unfollow(ctx, unfollowerID, unfollowedID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, newAuditEvent(models.AuditUserUnfollowed, unfollowerID, models.AuditTargetUser, auditID(unfollowedID), nil))
+	return nil
+}
+
+func (s *UserService) Block(ctx context.Context, blockerID int64, blockedID int64) error {
+	if blockerID == blockedID {
+		return ErrOperationNotAllowed
+	}
+	if err := s.store.User.Block(ctx, blockerID, blockedID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, newAuditEvent(models.AuditUserBlocked, blockerID, models.AuditTargetUser, auditID(blockedID), nil))
+	return nil
+}
+
+func (s *UserService) Unblock(ctx context.Context, blockerID int64, blockedID int64) error {
+	if err := s.store.User.Unblock(ctx, blockerID, blockedID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, newAuditEvent(models.AuditUserUnblocked, blockerID, models.AuditTargetUser, auditID(blockedID), nil))
+	return nil
 }
 
 func (s *UserService) Activate(ctx context.Context, token string) error {
 	return s.store.User.Activate(ctx, token)
 }
 
-func (s *UserService) GetPostsFromUsername(ctx context.Context, username string, userPosts *pagination.UserPosts) ([]*models.Post, error) {
+func (s *UserService) GetPostsFromUsername(ctx context.Context, username string, userPosts *pagination.UserPosts) ([]*models.PostWithMetadata, error) {
 	user, err := s.GetByUsername(ctx, username)
 	if err != nil {
 		return nil, ErrInvalidPayload
@@ -180,3 +386,16 @@ func (s *UserService) GetPostsFromUsername(ctx context.Context, username string,
 
 	return posts, nil
 }
+
+// GetMentions returns the posts and comments where the user was mentioned,
+// the most recent first
+func (s *UserService) GetMentions(ctx context.Context, userMentions *pagination.UserMentions) ([]*models.UserMention, error) {
+	mentions, nextCursor, err := s.store.Mention.GetMentionsOf(ctx, userMentions)
+	if err != nil {
+		return nil, err
+	}
+
+	userMentions.NextCursor = nextCursor
+
+	return mentions, nil
+}
@@ -0,0 +1,130 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/config"
+	"github.com/mochaeng/sapphire-backend/internal/mocks"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/services"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/mochaeng/sapphire-backend/internal/store/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// pngImage starts like a PNG file, which is all the upload check looks at
+var pngImage = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// newMediaFolder creates a media folder relative to the working directory,
+// the way the server saves its uploads
+func newMediaFolder(t *testing.T) string {
+	t.Helper()
+	folder, err := os.MkdirTemp(".", "media-")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(folder) })
+	return filepath.Base(folder)
+}
+
+func mediaFiles(t *testing.T, folder string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(folder)
+	require.NoError(t, err)
+	var files []string
+	for _, entry := range entries {
+		files = append(files, filepath.Join(folder, entry.Name()))
+	}
+	return files
+}
+
+func TestUpdateProfile(t *testing.T) {
+	user := &models.User{ID: 1, Username: "hutao"}
+	newProfile := func() *models.UserProfile {
+		return &models.UserProfile{
+			User:        &models.User{Username: user.Username},
+			Description: "director of the wangsheng funeral parlor",
+			Location:    "liyue",
+		}
+	}
+	description := "77th director"
+
+	t.Run("only changes the fields sent", func(t *testing.T) {
+		userStore := &mocks.MockUserStore{}
+		userStore.On("GetProfile", mock.Anything, user.Username).Return(newProfile(), nil)
+		userStore.On("UpdateProfile", mock.Anything, mock.Anything).Return(nil)
+		service := newTestServices(t, &config.Cfg{}, &store.Store{User: userStore}, nil)
+
+		profile, err := service.User.UpdateProfile(
+			context.Background(), user, &payloads.UpdateUserPayload{Description: &description}, nil, nil,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, description, profile.Description)
+		assert.Equal(t, "liyue", profile.Location, "fields left out keep their value")
+		assert.Equal(t, user.ID, profile.User.ID)
+	})
+
+	t.Run("replaces the previous avatar", func(t *testing.T) {
+		mediaFolder := newMediaFolder(t)
+		oldAvatar := filepath.Join(mediaFolder, "old.png")
+		require.NoError(t, os.WriteFile(oldAvatar, pngImage, 0o600))
+		profile := newProfile()
+		profile.AvatarURL = oldAvatar
+
+		userStore := &mocks.MockUserStore{}
+		userStore.On("GetProfile", mock.Anything, user.Username).Return(profile, nil)
+		userStore.On("UpdateProfile", mock.Anything, mock.Anything).Return(nil)
+		service := newTestServices(t, &config.Cfg{MediaFolder: mediaFolder}, &store.Store{User: userStore}, nil)
+
+		updated, err := service.User.UpdateProfile(context.Background(), user, &payloads.UpdateUserPayload{}, pngImage, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{updated.AvatarURL}, mediaFiles(t, mediaFolder), "the old avatar is removed")
+	})
+
+	t.Run("removes the uploads when the profile is not saved", func(t *testing.T) {
+		mediaFolder := newMediaFolder(t)
+		userStore := &mocks.MockUserStore{}
+		userStore.On("GetProfile", mock.Anything, user.Username).Return(newProfile(), nil)
+		userStore.On("UpdateProfile", mock.Anything, mock.Anything).Return(errors.New("connection reset"))
+		service := newTestServices(t, &config.Cfg{MediaFolder: mediaFolder}, &store.Store{User: userStore}, nil)
+
+		_, err := service.User.UpdateProfile(context.Background(), user, &payloads.UpdateUserPayload{}, pngImage, pngImage)
+		assert.Error(t, err)
+		assert.Empty(t, mediaFiles(t, mediaFolder))
+	})
+
+	t.Run("refuses files that are not images", func(t *testing.T) {
+		userStore := &mocks.MockUserStore{}
+		service := newTestServices(t, &config.Cfg{}, &store.Store{User: userStore}, nil)
+
+		_, err := service.User.UpdateProfile(
+			context.Background(), user, &payloads.UpdateUserPayload{}, []byte("#!/bin/sh"), nil,
+		)
+		assert.ErrorIs(t, err, services.ErrInvalidPayload)
+		userStore.AssertNotCalled(t, "UpdateProfile", mock.Anything, mock.Anything)
+	})
+
+	t.Run("evicts the cached profile", func(t *testing.T) {
+		userStore := &mocks.MockUserStore{}
+		userStore.On("GetProfile", mock.Anything, user.Username).Return(newProfile(), nil)
+		userStore.On("UpdateProfile", mock.Anything, mock.Anything).Return(nil)
+		profileCache := &mocks.MockProfileCache{}
+		profileCache.On("Delete", mock.Anything, user.Username).Return(nil)
+		service := newTestServicesFrom(t, &config.ServiceCfg{
+			Store:      &store.Store{User: userStore},
+			CacheStore: &cache.Store{Profile: profileCache},
+			Cfg:        &config.Cfg{Cacher: config.CacheCfg{IsEnable: true}},
+		})
+
+		_, err := service.User.UpdateProfile(
+			context.Background(), user, &payloads.UpdateUserPayload{Description: &description}, nil, nil,
+		)
+		require.NoError(t, err)
+		profileCache.AssertCalled(t, "Delete", mock.Anything, user.Username)
+	})
+}
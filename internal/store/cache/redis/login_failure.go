@@ -0,0 +1,94 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+type LoginFailureStore struct {
+	rdb *redis.Client
+}
+
+// the window is enforced by the key expiration, which every failure pushes
+// back
+func (s *LoginFailureStore) Get(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error) {
+	values, err := s.rdb.HGetAll(ctx, loginFailureKey(key)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseLoginFailure(key, values), nil
+}
+
+// Add reads the previous failure and counts the new one in a single
+// transaction, so concurrent attempts count one after the other
+func (s *LoginFailureStore) Add(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error) {
+	redisKey := loginFailureKey(key)
+	now := time.Now()
+	var previousFailedAt *redis.StringCmd
+	var failures *redis.IntCmd
+	_, err := s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		previousFailedAt = pipe.HGet(ctx, redisKey, "last_failed_at")
+		failures = pipe.HIncrBy(ctx, redisKey, "failures", 1)
+		pipe.HSet(ctx, redisKey, "last_failed_at", now.UnixMilli())
+		pipe.PExpire(ctx, redisKey, window)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	if err := failures.Err(); err != nil {
+		return nil, err
+	}
+	loginFailure := &models.LoginFailure{
+		Key:          key,
+		Failures:     int(failures.Val()),
+		LastFailedAt: now,
+	}
+	if previous, err := previousFailedAt.Int64(); err == nil {
+		loginFailure.PreviousFailedAt = time.UnixMilli(previous)
+	}
+	return loginFailure, nil
+}
+
+// refundScript decrements the failures only while the key is alive, a key
+// that expired in the meantime must not come back without its expiration
+var refundScript = redis.NewScript(`
+local failures = tonumber(redis.call("hget", KEYS[1], "failures"))
+if failures and failures > 0 then
+	return redis.call("hincrby", KEYS[1], "failures", -1)
+end
+return 0
+`)
+
+// Refund takes back one counted failure, for an attempt that succeeded or
+// was refused
+func (s *LoginFailureStore) Refund(ctx context.Context, key string) error {
+	return refundScript.Run(ctx, s.rdb, []string{loginFailureKey(key)}).Err()
+}
+
+func (s *LoginFailureStore) Delete(ctx context.Context, key string) error {
+	return s.rdb.Del(ctx, loginFailureKey(key)).Err()
+}
+
+func loginFailureKey(key string) string {
+	return "login-failure-" + key
+}
+
+func parseLoginFailure(key string, values map[string]string) *models.LoginFailure {
+	loginFailure := &models.LoginFailure{Key: key}
+	failures, err := strconv.Atoi(values["failures"])
+	if err != nil {
+		return loginFailure
+	}
+	lastFailedAt, err := strconv.ParseInt(values["last_failed_at"], 10, 64)
+	if err != nil {
+		return loginFailure
+	}
+	loginFailure.Failures = failures
+	loginFailure.LastFailedAt = time.UnixMilli(lastFailedAt)
+	return loginFailure
+}
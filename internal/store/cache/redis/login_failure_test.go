@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoginFailureStoreTestSuite struct {
+	suite.Suite
+	redisContainer    *testutils.RedisTestContainer
+	loginFailureStore *LoginFailureStore
+	ctx               context.Context
+}
+
+func (suite *LoginFailureStoreTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	redisContainer, err := testutils.CreateRedisContainer(suite.ctx)
+	require.NoError(suite.T(), err)
+	suite.redisContainer = redisContainer
+
+	options, err := redis.ParseURL(redisContainer.ConnStrin)
+	require.NoError(suite.T(), err)
+	suite.loginFailureStore = &LoginFailureStore{rdb: redis.NewClient(options)}
+}
+
+func (suite *LoginFailureStoreTestSuite) TearDownSuite() {
+	if err := suite.loginFailureStore.rdb.Close(); err != nil {
+		log.Fatalf("could not close redis connection, error: %s", err)
+	}
+	if err := suite.redisContainer.Terminate(suite.ctx); err != nil {
+		log.Fatalf("could not terminating redis container, error: %s", err)
+	}
+}
+
+func (suite *LoginFailureStoreTestSuite) TestCountsFailures() {
+	t := suite.T()
+	key := "account:momo@mail.com"
+
+	loginFailure, err := suite.loginFailureStore.Get(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, loginFailure.Failures)
+
+	for failures := 1; failures <= 3; failures++ {
+		loginFailure, err = suite.loginFailureStore.Add(suite.ctx, key, time.Hour)
+		require.NoError(t, err, "could not add failure")
+		assert.Equal(t, failures, loginFailure.Failures)
+	}
+
+	loginFailure, err = suite.loginFailureStore.Get(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 3, loginFailure.Failures)
+	assert.WithinDuration(t, time.Now(), loginFailure.LastFailedAt, time.Second)
+
+	require.NoError(t, suite.loginFailureStore.Delete(suite.ctx, key))
+	loginFailure, err = suite.loginFailureStore.Get(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, loginFailure.Failures)
+}
+
+func (suite *LoginFailureStoreTestSuite) TestForgetsFailuresAfterTheWindow() {
+	t := suite.T()
+	key := "ip:192.0.2.1"
+
+	_, err := suite.loginFailureStore.Add(suite.ctx, key, 100*time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+
+	loginFailure, err := suite.loginFailureStore.Get(suite.ctx, key, 100*time.Millisecond)
+	require.NoError(t, err)
+	assert.Zero(t, loginFailure.Failures)
+}
+
+func (suite *LoginFailureStoreTestSuite) TestRemembersThePreviousFailure() {
+	t := suite.T()
+	key := "account:chaee@mail.com"
+
+	first, err := suite.loginFailureStore.Add(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, first.PreviousFailedAt.IsZero(), "the first failure has none before it")
+
+	second, err := suite.loginFailureStore.Add(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, first.LastFailedAt, second.PreviousFailedAt, time.Millisecond)
+}
+
+func (suite *LoginFailureStoreTestSuite) TestRefundsOneFailure() {
+	t := suite.T()
+	key := "ip:192.0.2.2"
+
+	for range 2 {
+		_, err := suite.loginFailureStore.Add(suite.ctx, key, time.Hour)
+		require.NoError(t, err)
+	}
+	for range 3 {
+		require.NoError(t, suite.loginFailureStore.Refund(suite.ctx, key))
+	}
+
+	loginFailure, err := suite.loginFailureStore.Get(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, loginFailure.Failures, "failures never go below zero")
+
+	require.NoError(t, suite.loginFailureStore.Refund(suite.ctx, "ip:192.0.2.3"), "refunding an unknown key does nothing")
+}
+
+func TestLoginFailureStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(LoginFailureStoreTestSuite))
+}
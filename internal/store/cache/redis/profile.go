@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/redis/go-redis/v9"
+)
+
+const ProfileExpTime = time.Minute
+
+type ProfileStore struct {
+	rdb *redis.Client
+}
+
+func (s *ProfileStore) Get(ctx context.Context, username string) (*models.UserProfile, error) {
+	data, err := s.rdb.Get(ctx, profileKey(username)).Result()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var profile models.UserProfile
+	if err := json.Unmarshal([]byte(data), &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (s *ProfileStore) Set(ctx context.Context, userProfile *models.UserProfile) error {
+	json, err := json.Marshal(userProfile)
+	if err != nil {
+		return err
+	}
+	return s.rdb.SetEx(ctx, profileKey(userProfile.User.Username), json, ProfileExpTime).Err()
+}
+
+func (s *ProfileStore) Delete(ctx context.Context, username string) error {
+	return s.rdb.Del(ctx, profileKey(username)).Err()
+}
+
+func profileKey(username string) string {
+	return fmt.Sprintf("profile-%v", username)
+}
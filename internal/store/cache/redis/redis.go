@@ -15,6 +15,8 @@ func NewRedisClient(addr string, password string, db int) *redis.Client {
 
 func NewRedisStore(rdb *redis.Client) *cache.Store {
 	return &cache.Store{
-		User: &UserStore{rdb: rdb},
+		User:         &UserStore{rdb: rdb},
+		Profile:      &ProfileStore{rdb: rdb},
+		LoginFailure: &LoginFailureStore{rdb: rdb},
 	}
 }
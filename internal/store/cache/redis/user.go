@@ -43,6 +43,7 @@ func (s *UserStore) Set(ctx context.Context, user *models.User) error {
 	return s.rdb.SetEx(ctx, key, json, UserExpTime).Err()
 }
 
-func (s *UserStore) Invalidate(ctx context.Context, user *models.User) {
-	// s.rdb.Del(ctx, key)
+func (s *UserStore) Delete(ctx context.Context, userID int64) error {
+	key := fmt.Sprintf("user-%v", userID)
+	return s.rdb.Del(ctx, key).Err()
 }
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"context"
+	"time"
 
 	"github.com/mochaeng/sapphire-backend/internal/models"
 )
@@ -10,5 +11,19 @@ type Store struct {
 	User interface {
 		Get(ctx context.Context, userID int64) (*models.User, error)
 		Set(ctx context.Context, user *models.User) error
+		Delete(ctx context.Context, userID int64) error
+	}
+	Profile interface {
+		Get(ctx context.Context, username string) (*models.UserProfile, error)
+		Set(ctx context.Context, userProfile *models.UserProfile) error
+		Delete(ctx context.Context, username string) error
+	}
+	// LoginFailure counts failed sign ins, failures are forgotten once the
+	// window passed since the last one
+	LoginFailure interface {
+		Get(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error)
+		Add(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error)
+		Refund(ctx context.Context, key string) error
+		Delete(ctx context.Context, key string) error
 	}
 }
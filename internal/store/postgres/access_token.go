@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type AccessTokenStore struct {
+	db *sql.DB
+}
+
+func (s *AccessTokenStore) Create(ctx context.Context, accessToken *models.AccessToken, tokenHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		insert into "user_access_token" (user_id, name, token_hash, scopes, expires_at)
+		values ($1, $2, $3, $4, $5)
+		returning id, created_at
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		accessToken.UserID,
+		accessToken.Name,
+		tokenHash,
+		pq.Array(accessToken.Scopes),
+		accessToken.ExpiresAt,
+	).Scan(&accessToken.ID, &accessToken.CreatedAt)
+	return errorAccessTokenTransform(err)
+}
+
+func (s *AccessTokenStore) GetByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select id, user_id, name, scopes, expires_at, last_used_at, created_at
+		from "user_access_token"
+		where token_hash = $1
+	`
+	var accessToken models.AccessToken
+	err := s.db.QueryRowContext(ctx, query, tokenHash).Scan(
+		&accessToken.ID,
+		&accessToken.UserID,
+		&accessToken.Name,
+		pq.Array(&accessToken.Scopes),
+		&accessToken.ExpiresAt,
+		&accessToken.LastUsedAt,
+		&accessToken.CreatedAt,
+	)
+	if err != nil {
+		return nil, errorAccessTokenTransform(err)
+	}
+	return &accessToken, nil
+}
+
+func (s *AccessTokenStore) GetFromUser(ctx context.Context, userID int64) ([]*models.AccessToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select id, user_id, name, scopes, expires_at, last_used_at, created_at
+		from "user_access_token"
+		where user_id = $1
+		order by created_at desc, id desc
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errorAccessTokenTransform(err)
+	}
+	defer rows.Close()
+
+	var accessTokens []*models.AccessToken
+	for rows.Next() {
+		var accessToken models.AccessToken
+		err := rows.Scan(
+			&accessToken.ID,
+			&accessToken.UserID,
+			&accessToken.Name,
+			pq.Array(&accessToken.Scopes),
+			&accessToken.ExpiresAt,
+			&accessToken.LastUsedAt,
+			&accessToken.CreatedAt,
+		)
+		if err != nil {
+			return nil, errorAccessTokenTransform(err)
+		}
+		accessTokens = append(accessTokens, &accessToken)
+	}
+	return accessTokens, rows.Err()
+}
+
+// UpdateLastUsed records that the token was used, at most once per interval
+// so busy clients do not write on every request
+func (s *AccessTokenStore) UpdateLastUsed(ctx context.Context, accessTokenID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "user_access_token"
+		set last_used_at = now()
+		where id = $1 and (last_used_at is null or last_used_at < now() - interval '5 minutes')
+	`
+	_, err := s.db.ExecContext(ctx, query, accessTokenID)
+	return errorAccessTokenTransform(err)
+}
+
+func (s *AccessTokenStore) Delete(ctx context.Context, userID int64, accessTokenID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `delete from "user_access_token" where id = $2 and user_id = $1`
+	result, err := s.db.ExecContext(ctx, query, userID, accessTokenID)
+	if err != nil {
+		return errorAccessTokenTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type AuditEventStore struct {
+	db *sql.DB
+}
+
+func (s *AuditEventStore) Create(ctx context.Context, auditEvent *models.AuditEvent) error {
+	metadata := []byte("{}")
+	if auditEvent.Metadata != nil {
+		var err error
+		if metadata, err = json.Marshal(auditEvent.Metadata); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		insert into "audit_event" (action, actor_id, target_type, target_id, ip, request_id, metadata)
+		values ($1, $2, $3, $4, $5, $6, $7)
+		returning id, created_at
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		auditEvent.Action,
+		auditEvent.ActorID,
+		auditEvent.TargetType,
+		auditEvent.TargetID,
+		auditEvent.IP,
+		auditEvent.RequestID,
+		metadata,
+	).Scan(&auditEvent.ID, &auditEvent.CreatedAt)
+	return errorAuditEventTransform(err)
+}
+
+func (s *AuditEventStore) Get(ctx context.Context, auditEvents *pagination.AuditEvents) ([]*models.AuditEvent, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select id, action, actor_id, target_type, target_id, ip, request_id, metadata, created_at
+		from "audit_event"
+		where ($1::varchar is null or action = $1)
+			and ($2::bigint is null or actor_id = $2)
+			and ($3::varchar is null or target_type = $3)
+			and ($4::varchar is null or target_id = $4)
+			and ($5::varchar is null or ip = $5)
+			and ($6::timestamptz is null or created_at >= $6)
+			and ($7::timestamptz is null or created_at < $7)
+			and ($8::bigint is null or id < $8)
+		order by id desc
+		limit $9
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		auditEvents.Action,
+		auditEvents.ActorID,
+		auditEvents.TargetType,
+		auditEvents.TargetID,
+		auditEvents.IP,
+		auditEvents.Since,
+		auditEvents.Until,
+		auditEvents.Cursor,
+		auditEvents.Limit+1,
+	)
+	if err != nil {
+		return nil, "", errorAuditEventTransform(err)
+	}
+	defer rows.Close()
+
+	var events []*models.AuditEvent
+	for rows.Next() {
+		var auditEvent models.AuditEvent
+		var metadata []byte
+		err := rows.Scan(
+			&auditEvent.ID,
+			&auditEvent.Action,
+			&auditEvent.ActorID,
+			&auditEvent.TargetType,
+			&auditEvent.TargetID,
+			&auditEvent.IP,
+			&auditEvent.RequestID,
+			&metadata,
+			&auditEvent.CreatedAt,
+		)
+		if err != nil {
+			return nil, "", errorAuditEventTransform(err)
+		}
+		if err := json.Unmarshal(metadata, &auditEvent.Metadata); err != nil {
+			return nil, "", err
+		}
+		events = append(events, &auditEvent)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(events) > auditEvents.Limit {
+		nextCursor = strconv.FormatInt(events[auditEvents.Limit-1].ID, 10)
+		events = events[:auditEvents.Limit]
+	}
+
+	return events, nextCursor, nil
+}
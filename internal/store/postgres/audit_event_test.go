@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type AuditEventStoreTestSuite struct {
+	storeTestSuite
+	auditEventStore *AuditEventStore
+}
+
+func (suite *AuditEventStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.auditEventStore = &AuditEventStore{suite.db}
+}
+
+func (suite *AuditEventStoreTestSuite) TestCreateAndFilter() {
+	t := suite.T()
+	auditEvents := []*models.AuditEvent{
+		{
+			Action:     models.AuditSigninFailed,
+			TargetType: models.AuditTargetUser,
+			TargetID:   "1",
+			IP:         "192.0.2.1",
+			Metadata:   map[string]string{"reason": "wrong_password"},
+		},
+		{
+			Action:     models.AuditSigninSucceeded,
+			ActorID:    sql.NullInt64{Int64: 1, Valid: true},
+			TargetType: models.AuditTargetUser,
+			TargetID:   "1",
+		},
+		{
+			Action:     models.AuditSigninFailed,
+			TargetType: models.AuditTargetUser,
+			TargetID:   "2",
+		},
+	}
+	for _, auditEvent := range auditEvents {
+		require.NoError(t, suite.auditEventStore.Create(suite.ctx, auditEvent), "could not record event")
+		assert.NotZero(t, auditEvent.ID)
+	}
+
+	filters := &pagination.AuditEvents{
+		Action: sql.NullString{String: models.AuditSigninFailed, Valid: true},
+		Limit:  1,
+	}
+	events, nextCursor, err := suite.auditEventStore.Get(suite.ctx, filters)
+	require.NoError(t, err, "could not get events")
+	require.Len(t, events, 1)
+	assert.Equal(t, auditEvents[2].ID, events[0].ID, "the newest event comes first")
+	require.NotEmpty(t, nextCursor)
+
+	filters = &pagination.AuditEvents{
+		Action: sql.NullString{String: models.AuditSigninFailed, Valid: true},
+		Cursor: sql.NullInt64{Int64: events[0].ID, Valid: true},
+		Limit:  10,
+	}
+	events, _, err = suite.auditEventStore.Get(suite.ctx, filters)
+	require.NoError(t, err, "could not get next page")
+	require.Len(t, events, 1)
+	assert.Equal(t, auditEvents[0].ID, events[0].ID)
+	assert.False(t, events[0].ActorID.Valid)
+	assert.Equal(t, "wrong_password", events[0].Metadata["reason"])
+
+	filters = &pagination.AuditEvents{ActorID: sql.NullInt64{Int64: 1, Valid: true}, Limit: 10}
+	events, _, err = suite.auditEventStore.Get(suite.ctx, filters)
+	require.NoError(t, err, "could not filter by actor")
+	require.Len(t, events, 1)
+	assert.Equal(t, models.AuditSigninSucceeded, events[0].Action)
+}
+
+func (suite *AuditEventStoreTestSuite) TestEventsCannotBeChanged() {
+	t := suite.T()
+	auditEvent := &models.AuditEvent{Action: models.AuditSignout, TargetType: models.AuditTargetUser, TargetID: "1"}
+	require.NoError(t, suite.auditEventStore.Create(suite.ctx, auditEvent), "could not record event")
+
+	_, err := suite.db.ExecContext(suite.ctx, `update "audit_event" set action = 'changed' where id = $1`, auditEvent.ID)
+	assert.Error(t, err)
+	_, err = suite.db.ExecContext(suite.ctx, `delete from "audit_event" where id = $1`, auditEvent.ID)
+	assert.Error(t, err)
+}
+
+func TestAuditEventStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditEventStoreTestSuite))
+}
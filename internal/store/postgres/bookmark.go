@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type BookmarkStore struct {
+	db *sql.DB
+}
+
+// Create saves the bookmark. Bookmarking a post again keeps the bookmark as it
+// was, with its first created_at
+func (s *BookmarkStore) Create(ctx context.Context, bookmark *models.Bookmark) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		with inserted as (
+			insert into "bookmark" (user_id, post_id)
+			values ($1, $2)
+			on conflict (user_id, post_id) do nothing
+			returning created_at
+		)
+		select created_at from inserted
+		union all
+		select created_at from "bookmark" where user_id = $1 and post_id = $2
+		limit 1
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		bookmark.UserID,
+		bookmark.Post.ID,
+	).Scan(&bookmark.CreatedAt)
+	if err != nil {
+		return errorBookmarkTransform(err)
+	}
+	return nil
+}
+
+func (s *BookmarkStore) Delete(ctx context.Context, userID int64, postID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		delete from "bookmark"
+		where user_id = $1 and post_id = $2
+	`
+	result, err := s.db.ExecContext(ctx, query, userID, postID)
+	if err != nil {
+		return errorBookmarkTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *BookmarkStore) GetFromUser(ctx context.Context, userBookmarks *pagination.UserBookmarks) ([]*models.Bookmark, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select
+			b.created_at, p.id, p.user_id, p.tittle, p."content", p.media_url, p.tags,
+			p.created_at, p.updated_at, u.username, u.first_name, u.last_name
+		from "bookmark" b
+		join post p on p.id = b.post_id
+		join "user" u on u.id = p.user_id
+		where b.user_id = $1
+			and b.created_at < coalesce($2::timestamp, now())
+		order by b.created_at desc, p.id desc
+		limit $3;
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userBookmarks.UserID,
+		userBookmarks.Cursor,
+		userBookmarks.Limit+1,
+	)
+	if err != nil {
+		return nil, "", errorBookmarkTransform(err)
+	}
+	defer rows.Close()
+
+	var bookmarks []*models.Bookmark
+	for rows.Next() {
+		bookmark := &models.Bookmark{
+			UserID: userBookmarks.UserID,
+			Post:   &models.Post{User: &models.User{}},
+		}
+		err := rows.Scan(
+			&bookmark.CreatedAt,
+			&bookmark.Post.ID,
+			&bookmark.Post.User.ID,
+			&bookmark.Post.Tittle,
+			&bookmark.Post.Content,
+			&bookmark.Post.Media,
+			pq.Array(&bookmark.Post.Tags),
+			&bookmark.Post.CreatedAt,
+			&bookmark.Post.UpdatedAt,
+			&bookmark.Post.User.Username,
+			&bookmark.Post.User.FirstName,
+			&bookmark.Post.User.LastName,
+		)
+		if err != nil {
+			return nil, "", errorBookmarkTransform(err)
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(bookmarks) > userBookmarks.Limit {
+		nextCursor = bookmarks[userBookmarks.Limit-1].CreatedAt.Format(time.RFC3339Nano)
+		bookmarks = bookmarks[:userBookmarks.Limit]
+	}
+
+	return bookmarks, nextCursor, nil
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type BookmarkStoreTestSuite struct {
+	storeTestSuite
+	bookmarkStore *BookmarkStore
+}
+
+func (suite *BookmarkStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.bookmarkStore = &BookmarkStore{suite.db}
+}
+
+func (suite *BookmarkStoreTestSuite) TestBookmarks() {
+	t := suite.T()
+	userID := suite.createUser("bookmarker")
+	first := suite.createPost(1, "first", 0)
+	second := suite.createPost(1, "second", 0)
+
+	for _, postID := range []int64{first, second} {
+		bookmark := &models.Bookmark{UserID: userID, Post: &models.Post{ID: postID}}
+		require.NoError(t, suite.bookmarkStore.Create(suite.ctx, bookmark), "could not bookmark post")
+		assert.False(t, bookmark.CreatedAt.IsZero())
+	}
+
+	again := &models.Bookmark{UserID: userID, Post: &models.Post{ID: first}}
+	require.NoError(t, suite.bookmarkStore.Create(suite.ctx, again), "bookmarking again is not an error")
+	assert.False(t, again.CreatedAt.IsZero(), "the existing bookmark is returned")
+
+	userBookmarks := &pagination.UserBookmarks{UserID: userID, Limit: 1}
+	bookmarks, nextCursor, err := suite.bookmarkStore.GetFromUser(suite.ctx, userBookmarks)
+	require.NoError(t, err, "could not list bookmarks")
+	require.Len(t, bookmarks, 1)
+	assert.Equal(t, second, bookmarks[0].Post.ID, "the latest bookmark comes first")
+	assert.Equal(t, "momo", bookmarks[0].Post.User.Username)
+	assert.NotEmpty(t, nextCursor)
+
+	require.NoError(t, suite.bookmarkStore.Delete(suite.ctx, userID, second), "could not delete bookmark")
+	err = suite.bookmarkStore.Delete(suite.ctx, userID, second)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	userBookmarks = &pagination.UserBookmarks{UserID: userID, Limit: 10}
+	bookmarks, _, err = suite.bookmarkStore.GetFromUser(suite.ctx, userBookmarks)
+	require.NoError(t, err, "could not list bookmarks")
+	require.Len(t, bookmarks, 1)
+	assert.Equal(t, first, bookmarks[0].Post.ID)
+}
+
+func TestBookmarkStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(BookmarkStoreTestSuite))
+}
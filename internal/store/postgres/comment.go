@@ -3,8 +3,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
 	"github.com/mochaeng/sapphire-backend/internal/store"
 )
 
@@ -49,9 +51,9 @@ func (s *CommentStore) GetByPostID(ctx context.Context, postID int64) (*[]models
 
 func (s *CommentStore) Create(ctx context.Context, comment *models.Comment) error {
 	query := `
-		insert into comment (post_id, user_id, content)
-		values ($1, $2, $3)
-		returning id, created_at
+		insert into comment (post_id, user_id, content, parent_comment_id)
+		values ($1, $2, $3, $4)
+		returning id, created_at, updated_at
 	`
 	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
 	defer cancel()
@@ -60,12 +62,154 @@ func (s *CommentStore) Create(ctx context.Context, comment *models.Comment) erro
 		query, comment.PostId,
 		comment.UserId,
 		comment.Content,
+		comment.ParentID,
 	).Scan(
 		&comment.ID,
 		&comment.CreatedAt,
+		&comment.UpdatedAt,
 	)
 	if err != nil {
-		return errorUserTransform(err)
+		return errorCommentTransform(err)
+	}
+	return nil
+}
+
+func (s *CommentStore) GetByID(ctx context.Context, commentID int64) (*models.Comment, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select
+			c.id, c.post_id, c.user_id, c.parent_comment_id, c."content", c.created_at, c.updated_at,
+			u.username, u.first_name, u.last_name,
+			(select count(*) from "comment" r where r.parent_comment_id = c.id) as reply_count
+		from "comment" c
+		join "user" u on u.id = c.user_id
+		where c.id = $1
+	`
+	var comment models.Comment
+	err := s.db.QueryRowContext(ctx, query, commentID).Scan(
+		&comment.ID,
+		&comment.PostId,
+		&comment.UserId,
+		&comment.ParentID,
+		&comment.Content,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+		&comment.User.Username,
+		&comment.User.FirstName,
+		&comment.User.LastName,
+		&comment.ReplyCount,
+	)
+	if err != nil {
+		return nil, errorCommentTransform(err)
+	}
+	return &comment, nil
+}
+
+func (s *CommentStore) GetThread(ctx context.Context, postComments *pagination.PostComments) ([]*models.Comment, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select
+			c.id, c.post_id, c.user_id, c.parent_comment_id, c."content", c.created_at, c.updated_at,
+			u.username, u.first_name, u.last_name,
+			(select count(*) from "comment" r where r.parent_comment_id = c.id) as reply_count
+		from "comment" c
+		join "user" u on u.id = c.user_id
+		where c.post_id = $1
+			and c.parent_comment_id is not distinct from $2
+			and c.created_at < coalesce($3::timestamp, now())
+		order by c.created_at desc, c.id desc
+		limit $4;
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		postComments.PostID,
+		postComments.ParentID,
+		postComments.Cursor,
+		postComments.Limit+1,
+	)
+	if err != nil {
+		return nil, "", errorCommentTransform(err)
+	}
+	defer rows.Close()
+
+	var comments []*models.Comment
+	for rows.Next() {
+		comment := &models.Comment{}
+		err := rows.Scan(
+			&comment.ID,
+			&comment.PostId,
+			&comment.UserId,
+			&comment.ParentID,
+			&comment.Content,
+			&comment.CreatedAt,
+			&comment.UpdatedAt,
+			&comment.User.Username,
+			&comment.User.FirstName,
+			&comment.User.LastName,
+			&comment.ReplyCount,
+		)
+		if err != nil {
+			return nil, "", errorCommentTransform(err)
+		}
+		comments = append(comments, comment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(comments) > postComments.Limit {
+		nextCursor = comments[postComments.Limit-1].CreatedAt.Format(time.RFC3339Nano)
+		comments = comments[:postComments.Limit]
+	}
+
+	return comments, nextCursor, nil
+}
+
+func (s *CommentStore) UpdateByID(ctx context.Context, comment *models.Comment) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "comment"
+		set "content" = $2, updated_at = now()
+		where id = $1
+		returning "content", updated_at
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		comment.ID,
+		comment.Content,
+	).Scan(
+		&comment.Content,
+		&comment.UpdatedAt,
+	)
+	if err != nil {
+		return errorCommentTransform(err)
+	}
+	return nil
+}
+
+func (s *CommentStore) DeleteByID(ctx context.Context, commentID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		delete from "comment"
+		where id = $1
+	`
+	result, err := s.db.ExecContext(ctx, query, commentID)
+	if err != nil {
+		return errorCommentTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrNotFound
 	}
 	return nil
 }
@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommentStoreTestSuite struct {
+	storeTestSuite
+	commentStore *CommentStore
+}
+
+func (suite *CommentStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.commentStore = &CommentStore{suite.db}
+}
+
+// createComment adds a comment made age ago, a reply when parentID is not zero
+func (suite *CommentStoreTestSuite) createComment(postID, userID, parentID int64, content string, age time.Duration) int64 {
+	var commentID int64
+	query := `
+		insert into "comment" (post_id, user_id, parent_comment_id, "content", created_at)
+		values ($1, $2, nullif($3::bigint, 0), $4, $5)
+		returning id
+	`
+	err := suite.db.QueryRowContext(suite.ctx, query, postID, userID, parentID, content, time.Now().Add(-age)).Scan(&commentID)
+	require.NoError(suite.T(), err, "could not create comment")
+	return commentID
+}
+
+func (suite *CommentStoreTestSuite) TestGetThread() {
+	t := suite.T()
+	userID := suite.createUser("furina")
+	postID := suite.createPost(userID, "thread", 0)
+	oldest := suite.createComment(postID, userID, 0, "oldest", 3*time.Hour)
+	middle := suite.createComment(postID, userID, 0, "middle", 2*time.Hour)
+	newest := suite.createComment(postID, userID, 0, "newest", time.Hour)
+	suite.createComment(postID, userID, middle, "first reply", 90*time.Minute)
+	suite.createComment(postID, userID, middle, "second reply", 30*time.Minute)
+
+	postComments := &pagination.PostComments{PostID: postID, Limit: 2}
+	comments, nextCursor, err := suite.commentStore.GetThread(suite.ctx, postComments)
+	require.NoError(t, err)
+	require.Len(t, comments, 2, "the replies are not top-level comments")
+	assert.Equal(t, newest, comments[0].ID)
+	assert.Equal(t, middle, comments[1].ID)
+	assert.Equal(t, 2, comments[1].ReplyCount)
+	assert.Equal(t, "furina", comments[1].User.Username)
+	require.NotEmpty(t, nextCursor)
+
+	cursor, err := time.Parse(time.RFC3339Nano, nextCursor)
+	require.NoError(t, err)
+	postComments.Cursor = sql.NullTime{Time: cursor, Valid: true}
+	comments, nextCursor, err = suite.commentStore.GetThread(suite.ctx, postComments)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, oldest, comments[0].ID)
+	assert.Empty(t, nextCursor, "the last page has no cursor")
+
+	replies, _, err := suite.commentStore.GetThread(suite.ctx, &pagination.PostComments{
+		PostID:   postID,
+		ParentID: sql.NullInt64{Int64: middle, Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, replies, 2)
+	assert.Equal(t, "second reply", replies[0].Content)
+	assert.Equal(t, "first reply", replies[1].Content)
+	assert.Equal(t, middle, replies[0].ParentID.Int64)
+}
+
+func (suite *CommentStoreTestSuite) TestUpdateByID() {
+	t := suite.T()
+	userID := suite.createUser("navia")
+	postID := suite.createPost(userID, "update", 0)
+	commentID := suite.createComment(postID, userID, 0, "first", time.Hour)
+
+	comment := &models.Comment{ID: commentID, Content: "edited"}
+	require.NoError(t, suite.commentStore.UpdateByID(suite.ctx, comment))
+	assert.WithinDuration(t, time.Now(), comment.UpdatedAt, time.Minute)
+
+	saved, err := suite.commentStore.GetByID(suite.ctx, commentID)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", saved.Content)
+
+	err = suite.commentStore.UpdateByID(suite.ctx, &models.Comment{ID: -1, Content: "edited"})
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func (suite *CommentStoreTestSuite) TestDeleteByID() {
+	t := suite.T()
+	userID := suite.createUser("clorinde")
+	postID := suite.createPost(userID, "delete", 0)
+	commentID := suite.createComment(postID, userID, 0, "first", time.Hour)
+	replyID := suite.createComment(postID, userID, commentID, "reply", time.Minute)
+
+	require.NoError(t, suite.commentStore.DeleteByID(suite.ctx, commentID))
+
+	_, err := suite.commentStore.GetByID(suite.ctx, replyID)
+	assert.ErrorIs(t, err, store.ErrNotFound, "the replies go with the comment")
+	err = suite.commentStore.DeleteByID(suite.ctx, commentID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestCommentStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(CommentStoreTestSuite))
+}
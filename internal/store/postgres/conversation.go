@@ -0,0 +1,346 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type ConversationStore struct {
+	db *sql.DB
+}
+
+// Create saves the conversation and its participants. A 1:1 conversation
+// that already exists for the same pair of users is reused
+func (s *ConversationStore) Create(ctx context.Context, conversation *models.Conversation, participantIDs []int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+			insert into "conversation" (is_group, title, created_by, direct_key)
+			values ($1, $2, $3, $4)
+			on conflict (direct_key) do update set direct_key = excluded.direct_key
+			returning id, created_at, updated_at
+		`
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			conversation.IsGroup,
+			conversation.Title,
+			conversation.CreatedBy,
+			conversation.DirectKey,
+		).Scan(&conversation.ID, &conversation.CreatedAt, &conversation.UpdatedAt)
+		if err != nil {
+			return errorConversationTransform(err)
+		}
+
+		query = `
+			insert into "conversation_participant" (conversation_id, user_id)
+			select $1::bigint, unnest($2::bigint[])
+			on conflict do nothing
+		`
+		_, err = tx.ExecContext(ctx, query, conversation.ID, pq.Array(participantIDs))
+		return errorConversationTransform(err)
+	})
+}
+
+func (s *ConversationStore) GetByID(ctx context.Context, conversationID int64) (*models.Conversation, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select id, is_group, title, created_by, direct_key, created_at, updated_at
+		from "conversation"
+		where id = $1
+	`
+	var conversation models.Conversation
+	err := s.db.QueryRowContext(ctx, query, conversationID).Scan(
+		&conversation.ID,
+		&conversation.IsGroup,
+		&conversation.Title,
+		&conversation.CreatedBy,
+		&conversation.DirectKey,
+		&conversation.CreatedAt,
+		&conversation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, errorConversationTransform(err)
+	}
+	if err := s.loadParticipants(ctx, []*models.Conversation{&conversation}); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+func (s *ConversationStore) GetFromUser(ctx context.Context, userConversations *pagination.UserConversations) ([]*models.Conversation, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select
+			c.id, c.is_group, c.title, c.created_by, c.direct_key, c.created_at, c.updated_at,
+			m.id, m.content, m.created_at, su.id, su.username, su.first_name, su.last_name,
+			(
+				select count(*) from "message" um
+				where um.conversation_id = c.id
+					and um.id > coalesce(cp.last_read_message_id, 0)
+					and um.sender_id <> cp.user_id
+			)
+		from "conversation_participant" cp
+		join "conversation" c on c.id = cp.conversation_id
+		left join lateral (
+			select id, sender_id, content, created_at from "message"
+			where conversation_id = c.id
+			order by created_at desc, id desc
+			limit 1
+		) m on true
+		left join "user" su on su.id = m.sender_id
+		where cp.user_id = $1
+			and c.updated_at < coalesce($2::timestamp, now())
+		order by c.updated_at desc, c.id desc
+		limit $3;
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userConversations.UserID,
+		userConversations.Cursor,
+		userConversations.Limit+1,
+	)
+	if err != nil {
+		return nil, "", errorConversationTransform(err)
+	}
+	defer rows.Close()
+
+	var conversations []*models.Conversation
+	for rows.Next() {
+		var conversation models.Conversation
+		var messageID, senderID sql.NullInt64
+		var content, senderUsername, senderFirstName, senderLastName sql.NullString
+		var messageCreatedAt sql.NullTime
+		err := rows.Scan(
+			&conversation.ID,
+			&conversation.IsGroup,
+			&conversation.Title,
+			&conversation.CreatedBy,
+			&conversation.DirectKey,
+			&conversation.CreatedAt,
+			&conversation.UpdatedAt,
+			&messageID,
+			&content,
+			&messageCreatedAt,
+			&senderID,
+			&senderUsername,
+			&senderFirstName,
+			&senderLastName,
+			&conversation.UnreadCount,
+		)
+		if err != nil {
+			return nil, "", errorConversationTransform(err)
+		}
+		if messageID.Valid {
+			conversation.LastMessage = &models.Message{
+				ID:             messageID.Int64,
+				ConversationID: conversation.ID,
+				Content:        content.String,
+				CreatedAt:      messageCreatedAt.Time,
+				Sender: &models.User{
+					ID:        senderID.Int64,
+					Username:  senderUsername.String,
+					FirstName: senderFirstName.String,
+					LastName:  senderLastName.String,
+				},
+			}
+		}
+		conversations = append(conversations, &conversation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(conversations) > userConversations.Limit {
+		nextCursor = conversations[userConversations.Limit-1].UpdatedAt.Format(time.RFC3339Nano)
+		conversations = conversations[:userConversations.Limit]
+	}
+
+	if err := s.loadParticipants(ctx, conversations); err != nil {
+		return nil, "", err
+	}
+
+	return conversations, nextCursor, nil
+}
+
+// CreateMessage saves the message, bumps the conversation to the top of its
+// participants' lists and marks it as read by its sender
+func (s *ConversationStore) CreateMessage(ctx context.Context, message *models.Message) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+			insert into "message" (conversation_id, sender_id, content)
+			values ($1, $2, $3)
+			returning id, created_at
+		`
+		err := tx.QueryRowContext(
+			ctx,
+			query,
+			message.ConversationID,
+			message.Sender.ID,
+			message.Content,
+		).Scan(&message.ID, &message.CreatedAt)
+		if err != nil {
+			return errorConversationTransform(err)
+		}
+
+		query = `
+			update "conversation"
+			set updated_at = $2
+			where id = $1
+		`
+		if _, err := tx.ExecContext(ctx, query, message.ConversationID, message.CreatedAt); err != nil {
+			return errorConversationTransform(err)
+		}
+
+		query = `
+			update "conversation_participant"
+			set last_read_message_id = $3, last_read_at = now()
+			where conversation_id = $1 and user_id = $2
+		`
+		_, err = tx.ExecContext(ctx, query, message.ConversationID, message.Sender.ID, message.ID)
+		return errorConversationTransform(err)
+	})
+}
+
+func (s *ConversationStore) GetMessages(ctx context.Context, conversationMessages *pagination.ConversationMessages) ([]*models.Message, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select m.id, m.content, m.created_at, u.id, u.username, u.first_name, u.last_name
+		from "message" m
+		join "user" u on u.id = m.sender_id
+		where m.conversation_id = $1
+			and m.created_at < coalesce($2::timestamp, now())
+		order by m.created_at desc, m.id desc
+		limit $3;
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		conversationMessages.ConversationID,
+		conversationMessages.Cursor,
+		conversationMessages.Limit+1,
+	)
+	if err != nil {
+		return nil, "", errorConversationTransform(err)
+	}
+	defer rows.Close()
+
+	var messages []*models.Message
+	for rows.Next() {
+		message := &models.Message{
+			ConversationID: conversationMessages.ConversationID,
+			Sender:         &models.User{},
+		}
+		err := rows.Scan(
+			&message.ID,
+			&message.Content,
+			&message.CreatedAt,
+			&message.Sender.ID,
+			&message.Sender.Username,
+			&message.Sender.FirstName,
+			&message.Sender.LastName,
+		)
+		if err != nil {
+			return nil, "", errorConversationTransform(err)
+		}
+		messages = append(messages, message)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(messages) > conversationMessages.Limit {
+		nextCursor = messages[conversationMessages.Limit-1].CreatedAt.Format(time.RFC3339Nano)
+		messages = messages[:conversationMessages.Limit]
+	}
+
+	return messages, nextCursor, nil
+}
+
+// MarkRead moves the user read receipt to the latest message of the
+// conversation
+func (s *ConversationStore) MarkRead(ctx context.Context, conversationID int64, userID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "conversation_participant" cp
+		set last_read_message_id = latest.id, last_read_at = now()
+		from (select max(id) as id from "message" where conversation_id = $1) latest
+		where cp.conversation_id = $1 and cp.user_id = $2
+	`
+	result, err := s.db.ExecContext(ctx, query, conversationID, userID)
+	if err != nil {
+		return errorConversationTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *ConversationStore) loadParticipants(ctx context.Context, conversations []*models.Conversation) error {
+	if len(conversations) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(conversations))
+	byID := make(map[int64]*models.Conversation, len(conversations))
+	for idx, conversation := range conversations {
+		ids[idx] = conversation.ID
+		byID[conversation.ID] = conversation
+	}
+
+	query := `
+		select
+			cp.conversation_id, u.id, u.username, u.first_name, u.last_name,
+			cp.last_read_message_id, cp.last_read_at, cp.joined_at
+		from "conversation_participant" cp
+		join "user" u on u.id = cp.user_id
+		where cp.conversation_id = any($1::bigint[])
+		order by cp.joined_at, u.id
+	`
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return errorConversationTransform(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var conversationID int64
+		participant := &models.ConversationParticipant{User: &models.User{}}
+		err := rows.Scan(
+			&conversationID,
+			&participant.User.ID,
+			&participant.User.Username,
+			&participant.User.FirstName,
+			&participant.User.LastName,
+			&participant.LastReadMessageID,
+			&participant.LastReadAt,
+			&participant.JoinedAt,
+		)
+		if err != nil {
+			return errorConversationTransform(err)
+		}
+		conversation := byID[conversationID]
+		conversation.Participants = append(conversation.Participants, participant)
+	}
+	return rows.Err()
+}
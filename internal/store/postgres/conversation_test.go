@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConversationStoreTestSuite struct {
+	storeTestSuite
+	conversationStore *ConversationStore
+}
+
+func (suite *ConversationStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.conversationStore = &ConversationStore{suite.db}
+}
+
+func (suite *ConversationStoreTestSuite) TestCreateReusesDirectConversation() {
+	t := suite.T()
+	first := &models.Conversation{
+		CreatedBy: sql.NullInt64{Int64: 1, Valid: true},
+		DirectKey: sql.NullString{String: "1:2", Valid: true},
+	}
+	require.NoError(t, suite.conversationStore.Create(suite.ctx, first, []int64{1, 2}))
+
+	second := &models.Conversation{
+		CreatedBy: sql.NullInt64{Int64: 2, Valid: true},
+		DirectKey: sql.NullString{String: "1:2", Valid: true},
+	}
+	require.NoError(t, suite.conversationStore.Create(suite.ctx, second, []int64{2, 1}))
+	assert.Equal(t, first.ID, second.ID)
+
+	conversation, err := suite.conversationStore.GetByID(suite.ctx, first.ID)
+	require.NoError(t, err, "could not get conversation")
+	assert.Len(t, conversation.Participants, 2)
+}
+
+func (suite *ConversationStoreTestSuite) TestMessagesAndReadReceipts() {
+	t := suite.T()
+	senderID := suite.createUser("sender")
+	readerID := suite.createUser("reader")
+	conversation := &models.Conversation{
+		IsGroup:   true,
+		Title:     "group",
+		CreatedBy: sql.NullInt64{Int64: senderID, Valid: true},
+	}
+	require.NoError(t, suite.conversationStore.Create(suite.ctx, conversation, []int64{senderID, readerID}))
+
+	for _, content := range []string{"hello", "there"} {
+		message := &models.Message{
+			ConversationID: conversation.ID,
+			Sender:         &models.User{ID: senderID},
+			Content:        content,
+		}
+		require.NoError(t, suite.conversationStore.CreateMessage(suite.ctx, message), "could not send message")
+	}
+
+	conversations, _, err := suite.conversationStore.GetFromUser(suite.ctx, &pagination.UserConversations{UserID: readerID, Limit: 10})
+	require.NoError(t, err, "could not list conversations")
+	require.Len(t, conversations, 1)
+	assert.Equal(t, 2, conversations[0].UnreadCount)
+	require.NotNil(t, conversations[0].LastMessage)
+	assert.Equal(t, "there", conversations[0].LastMessage.Content)
+
+	conversations, _, err = suite.conversationStore.GetFromUser(suite.ctx, &pagination.UserConversations{UserID: senderID, Limit: 10})
+	require.NoError(t, err, "could not list conversations")
+	require.Len(t, conversations, 1)
+	assert.Zero(t, conversations[0].UnreadCount, "the sender read their own messages")
+
+	require.NoError(t, suite.conversationStore.MarkRead(suite.ctx, conversation.ID, readerID))
+	conversations, _, err = suite.conversationStore.GetFromUser(suite.ctx, &pagination.UserConversations{UserID: readerID, Limit: 10})
+	require.NoError(t, err, "could not list conversations")
+	assert.Zero(t, conversations[0].UnreadCount)
+
+	conversationMessages := &pagination.ConversationMessages{ConversationID: conversation.ID, Limit: 1}
+	messages, nextCursor, err := suite.conversationStore.GetMessages(suite.ctx, conversationMessages)
+	require.NoError(t, err, "could not get messages")
+	require.Len(t, messages, 1)
+	assert.Equal(t, "there", messages[0].Content)
+	assert.NotEmpty(t, nextCursor)
+}
+
+func TestConversationStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(ConversationStoreTestSuite))
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+// CreateEmailChange saves the address waiting for confirmation, replacing
+// the change the user asked for before
+func (s *UserStore) CreateEmailChange(ctx context.Context, emailChange *models.EmailChange) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		insert into "email_change"(token, user_id, new_email, expired)
+		values ($1, $2, $3, $4)
+		on conflict (user_id) do update
+		set token = excluded.token, new_email = excluded.new_email, expired = excluded.expired, created_at = now()
+	`
+	_, err := s.db.ExecContext(
+		ctx,
+		query,
+		emailChange.Token,
+		emailChange.User.ID,
+		emailChange.NewEmail,
+		time.Now().Add(emailChange.Expired),
+	)
+	return errorUserTransform(err)
+}
+
+// ConfirmEmailChange moves the owner of the token to the new address. The
+// magic links sent to the old one are deleted
+func (s *UserStore) ConfirmEmailChange(ctx context.Context, plainToken string, user *models.User) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+			select u.id, u.username, u.first_name, u.last_name, ec.new_email from "user" u
+			join "email_change" ec on u.id = ec.user_id
+			where ec.token = $1 and ec.expired > $2
+		`
+		hash256 := sha256.Sum256([]byte(plainToken))
+		hashToken := hex.EncodeToString(hash256[:])
+		err := tx.QueryRowContext(ctx, query, hashToken, time.Now()).Scan(
+			&user.ID,
+			&user.Username,
+			&user.FirstName,
+			&user.LastName,
+			&user.Email,
+		)
+		if err != nil {
+			return errorUserTransform(err)
+		}
+
+		query = `update "user" set email = $2 where id = $1`
+		if _, err := tx.ExecContext(ctx, query, user.ID, user.Email); err != nil {
+			return errorUserTransform(err)
+		}
+		if err := s.deleteMagicLinks(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		query = `delete from "email_change" where user_id = $1`
+		_, err = tx.ExecContext(ctx, query, user.ID)
+		return errorUserTransform(err)
+	})
+}
@@ -44,6 +44,12 @@ func errorPostTransform(err error) error {
 		if err == sql.ErrNoRows {
 			return store.ErrNotFound
 		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == UniqueViolation {
+			return store.ErrConflict
+		} else if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
 		return err
 	}
 	return nil
@@ -60,6 +66,150 @@ func errorSessionTransform(err error) error {
 }
 
 func errorOAuthTransform(err error) error {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == UniqueViolation {
+			return store.ErrConflict
+		} else if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
+		return err
+	}
+	return nil
+}
+
+func errorCommentTransform(err error) error {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
+		return err
+	}
+	return nil
+}
+
+func errorReactionTransform(err error) error {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
+		return err
+	}
+	return nil
+}
+
+func errorBookmarkTransform(err error) error {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == UniqueViolation {
+			return store.ErrConflict
+		} else if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
+		return err
+	}
+	return nil
+}
+
+func errorMentionTransform(err error) error {
+	if err != nil {
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
+		return err
+	}
+	return nil
+}
+
+func errorNotificationTransform(err error) error {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
+		return err
+	}
+	return nil
+}
+
+func errorConversationTransform(err error) error {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
+		return err
+	}
+	return nil
+}
+
+func errorTwoFactorTransform(err error) error {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
+		return err
+	}
+	return nil
+}
+
+func errorPasskeyTransform(err error) error {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
+		if ok && pqErr.Code == UniqueViolation {
+			return store.ErrConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func errorAccessTokenTransform(err error) error {
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrNotFound
+		}
+		pqErr, ok := err.(*pq.Error)
+		if ok && pqErr.Code == ForeignKeyViolation {
+			return store.ErrForeignKeyViolation
+		}
+		return err
+	}
+	return nil
+}
+
+func errorAuditEventTransform(err error) error {
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return store.ErrNotFound
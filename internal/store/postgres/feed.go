@@ -3,6 +3,7 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/mochaeng/sapphire-backend/internal/models"
@@ -18,17 +19,64 @@ func (s *FeedStore) Get(ctx context.Context, userID int64, paginateQuery paginat
 	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
 	defer cancel()
 
+	// a post can reach the feed more than once: by its author and by each
+	// followed user that reposted it. Only its latest occurrence is kept, even
+	// when it is newer than the cursor, so a post never shows up in two
+	// different pages. The page is read from the newest rows before the cursor
+	// on, instead of ranking the whole timeline first
 	query := `
+		with followed as (
+			select f.followed_id as user_id from follower f where f.follower_id = $1
+			union all
+			select $1::bigint
+		),
+		latest as (
+			select
+				coalesce(p.repost_of_id, p.id) as post_id,
+				case when p.repost_of_id is not null then p.user_id end as reposter_id,
+				p.created_at as timeline_at
+			from post p
+			where p.user_id in (select user_id from followed)
+				and p.created_at < coalesce($2::timestamp, now())
+				and not exists (
+					select 1 from post later
+					where coalesce(later.repost_of_id, later.id) = coalesce(p.repost_of_id, p.id)
+						and later.user_id in (select user_id from followed)
+						and (later.created_at, later.repost_of_id is null, later.id)
+							> (p.created_at, p.repost_of_id is null, p.id)
+				)
+			order by p.created_at desc, post_id desc
+			limit $3
+		)
 		select
 			p.id, p.user_id, p."content", p.created_at, p.media_url , u.username,
-	 	u.first_name, u.last_name
-		from post p
+			u.first_name, u.last_name,
+			(select count(*) from "comment" c where c.post_id = p.id) as comment_count,
+			coalesce((
+				select json_object_agg(rc.kind, rc.total)
+				from (
+					select pr.kind, count(*) as total
+					from post_reaction pr
+					where pr.post_id = p.id
+					group by pr.kind
+				) rc
+			), '{}') as reaction_counts,
+			coalesce((
+				select pr.kind from post_reaction pr
+				where pr.post_id = p.id and pr.user_id = $1
+			), '') as viewer_reaction,
+			t.timeline_at, t.reposter_id, coalesce(ru.username, ''),
+			coalesce(ru.first_name, ''), coalesce(ru.last_name, ''),
+			p.quote_of_id, coalesce(q."content", ''), q.created_at, q.media_url,
+			q.user_id, coalesce(qu.username, ''), coalesce(qu.first_name, ''),
+			coalesce(qu.last_name, '')
+		from latest t
+		join post p on p.id = t.post_id
 		left join "user" u on p.user_id = u.id
-		left join follower f on f.followed_id = p.user_id and f.follower_id = $1
-		where (p.user_id = $1 or f.follower_id is not null)
-			and p.created_at < coalesce($2::timestamp, now())
-		order by p.created_at desc, p.id desc
-		limit $3;
+		left join "user" ru on ru.id = t.reposter_id
+		left join post q on q.id = p.quote_of_id
+		left join "user" qu on qu.id = q.user_id
+		order by t.timeline_at desc, p.id desc;
 	`
 	rows, err := s.db.QueryContext(
 		ctx,
@@ -46,6 +94,11 @@ func (s *FeedStore) Get(ctx context.Context, userID int64, paginateQuery paginat
 	for rows.Next() {
 		post := &models.PostWithMetadata{}
 		post.User = &models.User{}
+		var reactionCounts []byte
+		var reposterID, quotedUserID sql.NullInt64
+		var reposter, quotedUser models.User
+		var quoted models.Post
+		var quotedCreatedAt sql.NullTime
 		err := rows.Scan(
 			&post.ID,
 			&post.User.ID,
@@ -55,10 +108,40 @@ func (s *FeedStore) Get(ctx context.Context, userID int64, paginateQuery paginat
 			&post.User.Username,
 			&post.User.FirstName,
 			&post.User.LastName,
+			&post.CommentCount,
+			&reactionCounts,
+			&post.Reactions.ViewerReaction,
+			&post.TimelineAt,
+			&reposterID,
+			&reposter.Username,
+			&reposter.FirstName,
+			&reposter.LastName,
+			&post.QuoteOfID,
+			&quoted.Content,
+			&quotedCreatedAt,
+			&quoted.Media,
+			&quotedUserID,
+			&quotedUser.Username,
+			&quotedUser.FirstName,
+			&quotedUser.LastName,
 		)
 		if err != nil {
 			return nil, "", err
 		}
+		if err := json.Unmarshal(reactionCounts, &post.Reactions.Counts); err != nil {
+			return nil, "", err
+		}
+		if reposterID.Valid {
+			reposter.ID = reposterID.Int64
+			post.RepostedBy = &reposter
+		}
+		if post.QuoteOfID.Valid && quotedCreatedAt.Valid {
+			quoted.ID = post.QuoteOfID.Int64
+			quoted.CreatedAt = quotedCreatedAt.Time
+			quotedUser.ID = quotedUserID.Int64
+			quoted.User = &quotedUser
+			post.Quoted = &quoted
+		}
 		posts = append(posts, post)
 	}
 	if err = rows.Err(); err != nil {
@@ -67,7 +150,7 @@ func (s *FeedStore) Get(ctx context.Context, userID int64, paginateQuery paginat
 
 	var nextCursor string
 	if len(posts) > paginateQuery.Limit {
-		nextCursor = posts[paginateQuery.Limit-1].CreatedAt.Format(time.RFC3339Nano)
+		nextCursor = posts[paginateQuery.Limit-1].TimelineAt.Format(time.RFC3339Nano)
 		posts = posts[:paginateQuery.Limit]
 	}
 
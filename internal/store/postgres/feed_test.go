@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type FeedStoreTestSuite struct {
+	storeTestSuite
+	feedStore *FeedStore
+}
+
+func (suite *FeedStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.feedStore = &FeedStore{suite.db}
+}
+
+func (suite *FeedStoreTestSuite) TestFeedShowsRepostsOnce() {
+	t := suite.T()
+	readerID := suite.createUser("feedreader")
+	authorID := suite.createUser("feedauthor")
+	reposterID := suite.createUser("feedreposter")
+	userStore := &UserStore{suite.db}
+	require.NoError(t, userStore.Follow(suite.ctx, readerID, authorID))
+	require.NoError(t, userStore.Follow(suite.ctx, readerID, reposterID))
+
+	postID := suite.createPost(authorID, "original", 0)
+	suite.createPost(reposterID, "", postID)
+	suite.createPost(suite.createUser("stranger"), "not followed", 0)
+
+	posts, nextCursor, err := suite.feedStore.Get(suite.ctx, readerID, pagination.PaginateFeedQuery{Limit: 10})
+	require.NoError(t, err, "could not get feed")
+	require.Len(t, posts, 1)
+	assert.Empty(t, nextCursor)
+	assert.Equal(t, postID, posts[0].ID)
+	assert.Equal(t, "original", posts[0].Content)
+	require.NotNil(t, posts[0].RepostedBy, "the latest occurrence is the repost")
+	assert.Equal(t, reposterID, posts[0].RepostedBy.ID)
+}
+
+func (suite *FeedStoreTestSuite) TestFeedPages() {
+	t := suite.T()
+	readerID := suite.createUser("pagereader")
+	first := suite.createPost(readerID, "first", 0)
+	second := suite.createPost(readerID, "second", 0)
+
+	posts, nextCursor, err := suite.feedStore.Get(suite.ctx, readerID, pagination.PaginateFeedQuery{Limit: 1})
+	require.NoError(t, err, "could not get feed")
+	require.Len(t, posts, 1)
+	assert.Equal(t, second, posts[0].ID)
+	require.NotEmpty(t, nextCursor)
+
+	feedQuery := pagination.PaginateFeedQuery{Limit: 1}
+	require.NoError(t, feedQuery.Parse("1", nextCursor))
+	posts, _, err = suite.feedStore.Get(suite.ctx, readerID, feedQuery)
+	require.NoError(t, err, "could not get next page")
+	require.Len(t, posts, 1)
+	assert.Equal(t, first, posts[0].ID)
+}
+
+func (suite *FeedStoreTestSuite) TestFeedPagesShowRepostsOnce() {
+	t := suite.T()
+	readerID := suite.createUser("repostpagereader")
+	authorID := suite.createUser("repostpageauthor")
+	reposterID := suite.createUser("repostpagereposter")
+	userStore := &UserStore{suite.db}
+	require.NoError(t, userStore.Follow(suite.ctx, readerID, authorID))
+	require.NoError(t, userStore.Follow(suite.ctx, readerID, reposterID))
+
+	reposted := suite.createPost(authorID, "reposted", 0)
+	other := suite.createPost(authorID, "other", 0)
+	suite.createPost(reposterID, "", reposted)
+
+	var seen []int64
+	feedQuery := pagination.PaginateFeedQuery{Limit: 1}
+	for {
+		posts, nextCursor, err := suite.feedStore.Get(suite.ctx, readerID, feedQuery)
+		require.NoError(t, err, "could not get feed")
+		for _, post := range posts {
+			seen = append(seen, post.ID)
+		}
+		if nextCursor == "" {
+			break
+		}
+		require.NoError(t, feedQuery.Parse("1", nextCursor))
+	}
+	assert.Equal(t, []int64{reposted, other}, seen, "the original post is not shown again")
+}
+
+func TestFeedStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(FeedStoreTestSuite))
+}
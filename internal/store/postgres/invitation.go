@@ -28,3 +28,53 @@ func (s *UserStore) createUserInvitation(ctx context.Context, tx *sql.Tx, userIn
 	}
 	return nil
 }
+
+// GetPendingInvitation finds the invitation of the account with the e-mail,
+// as long as it was not activated yet
+func (s *UserStore) GetPendingInvitation(ctx context.Context, email string) (*models.UserInvitation, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select u.id, u.username, u.email, u.first_name, u.last_name, ui.created_at
+		from "user" u
+		join "user_invitation" ui on u.id = ui.user_id
+		where u.email = $1 and u.is_active = false
+	`
+	userInvitation := models.UserInvitation{User: &models.User{}}
+	err := s.db.QueryRowContext(ctx, query, email).Scan(
+		&userInvitation.User.ID,
+		&userInvitation.User.Username,
+		&userInvitation.User.Email,
+		&userInvitation.User.FirstName,
+		&userInvitation.User.LastName,
+		&userInvitation.CreatedAt,
+	)
+	if err != nil {
+		return nil, errorUserTransform(err)
+	}
+	return &userInvitation, nil
+}
+
+// RotateInvitation replaces the token of the user invitation, so only the
+// link from the last e-mail works, and restarts its expiration
+func (s *UserStore) RotateInvitation(ctx context.Context, userInvitation *models.UserInvitation) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "user_invitation"
+		set token = $2, expired = $3, created_at = now()
+		where user_id = $1
+		returning created_at
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		userInvitation.User.ID,
+		userInvitation.Token,
+		time.Now().Add(userInvitation.Expired),
+	).Scan(&userInvitation.CreatedAt)
+	if err != nil {
+		return errorUserTransform(err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type LoginFailureStore struct {
+	db *sql.DB
+}
+
+func (s *LoginFailureStore) Get(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select failures, last_failed_at
+		from "login_failure"
+		where key = $1 and last_failed_at > now() - $2 * interval '1 second'
+	`
+	loginFailure := models.LoginFailure{Key: key}
+	err := s.db.QueryRowContext(ctx, query, key, window.Seconds()).Scan(
+		&loginFailure.Failures,
+		&loginFailure.LastFailedAt,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &loginFailure, nil
+}
+
+// Add counts a failure, starting over when the last one is older than the
+// window. The row lock makes concurrent attempts count one after the other
+func (s *LoginFailureStore) Add(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		insert into "login_failure" (key, failures, last_failed_at)
+		values ($1, 1, now())
+		on conflict (key) do update set
+			failures = case
+				when "login_failure".last_failed_at > now() - $2 * interval '1 second'
+				then "login_failure".failures + 1
+				else 1
+			end,
+			previous_failed_at = case
+				when "login_failure".last_failed_at > now() - $2 * interval '1 second'
+				then "login_failure".last_failed_at
+			end,
+			last_failed_at = now()
+		returning failures, last_failed_at, previous_failed_at
+	`
+	loginFailure := models.LoginFailure{Key: key}
+	var previousFailedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, query, key, window.Seconds()).Scan(
+		&loginFailure.Failures,
+		&loginFailure.LastFailedAt,
+		&previousFailedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	loginFailure.PreviousFailedAt = previousFailedAt.Time
+	return &loginFailure, nil
+}
+
+// Refund takes back one counted failure, for an attempt that succeeded or
+// was refused
+func (s *LoginFailureStore) Refund(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "login_failure"
+		set failures = greatest(failures - 1, 0)
+		where key = $1
+	`
+	_, err := s.db.ExecContext(ctx, query, key)
+	return err
+}
+
+func (s *LoginFailureStore) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, `delete from "login_failure" where key = $1`, key)
+	return err
+}
+
+// DeleteOlderThan forgets the failures whose last one happened before the
+// time, they no longer count
+func (s *LoginFailureStore) DeleteOlderThan(ctx context.Context, before time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	_, err := s.db.ExecContext(ctx, `delete from "login_failure" where last_failed_at < $1`, before)
+	return err
+}
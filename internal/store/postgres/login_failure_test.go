@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoginFailureStoreTestSuite struct {
+	storeTestSuite
+	loginFailureStore *LoginFailureStore
+}
+
+func (suite *LoginFailureStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.loginFailureStore = &LoginFailureStore{suite.db}
+}
+
+func (suite *LoginFailureStoreTestSuite) TestCountsFailuresInsideTheWindow() {
+	t := suite.T()
+	key := "account:momo@mail.com"
+
+	loginFailure, err := suite.loginFailureStore.Get(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, loginFailure.Failures)
+
+	for failures := 1; failures <= 3; failures++ {
+		loginFailure, err = suite.loginFailureStore.Add(suite.ctx, key, time.Hour)
+		require.NoError(t, err, "could not add failure")
+		assert.Equal(t, failures, loginFailure.Failures)
+	}
+
+	loginFailure, err = suite.loginFailureStore.Get(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 3, loginFailure.Failures)
+
+	require.NoError(t, suite.loginFailureStore.Delete(suite.ctx, key))
+	loginFailure, err = suite.loginFailureStore.Get(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, loginFailure.Failures)
+}
+
+func (suite *LoginFailureStoreTestSuite) TestStartsOverAfterTheWindow() {
+	t := suite.T()
+	key := "ip:192.0.2.1"
+
+	for range 3 {
+		_, err := suite.loginFailureStore.Add(suite.ctx, key, time.Hour)
+		require.NoError(t, err, "could not add failure")
+	}
+	_, err := suite.db.ExecContext(
+		suite.ctx,
+		`update "login_failure" set last_failed_at = now() - interval '2 hours' where key = $1`,
+		key,
+	)
+	require.NoError(t, err)
+
+	loginFailure, err := suite.loginFailureStore.Get(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, loginFailure.Failures, "old failures do not count")
+
+	loginFailure, err = suite.loginFailureStore.Add(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, loginFailure.Failures)
+
+	require.NoError(t, suite.loginFailureStore.DeleteOlderThan(suite.ctx, time.Now().Add(time.Minute)))
+	var count int
+	err = suite.db.QueryRowContext(suite.ctx, `select count(*) from "login_failure" where key = $1`, key).Scan(&count)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func (suite *LoginFailureStoreTestSuite) TestRemembersThePreviousFailure() {
+	t := suite.T()
+	key := "account:chaee@mail.com"
+
+	first, err := suite.loginFailureStore.Add(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, first.PreviousFailedAt.IsZero(), "the first failure has none before it")
+
+	second, err := suite.loginFailureStore.Add(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, first.LastFailedAt, second.PreviousFailedAt, time.Millisecond)
+}
+
+func (suite *LoginFailureStoreTestSuite) TestRefundsOneFailure() {
+	t := suite.T()
+	key := "ip:192.0.2.2"
+
+	for range 2 {
+		_, err := suite.loginFailureStore.Add(suite.ctx, key, time.Hour)
+		require.NoError(t, err)
+	}
+	for range 3 {
+		require.NoError(t, suite.loginFailureStore.Refund(suite.ctx, key))
+	}
+
+	loginFailure, err := suite.loginFailureStore.Get(suite.ctx, key, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, loginFailure.Failures, "failures never go below zero")
+
+	require.NoError(t, suite.loginFailureStore.Refund(suite.ctx, "ip:192.0.2.3"), "refunding an unknown key does nothing")
+}
+
+func TestLoginFailureStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(LoginFailureStoreTestSuite))
+}
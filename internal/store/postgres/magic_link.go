@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+// CreateMagicLink saves a new sign in link, replacing the ones the user asked
+// for before
+func (s *UserStore) CreateMagicLink(ctx context.Context, magicLink *models.MagicLink) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.deleteMagicLinks(ctx, tx, magicLink.User.ID); err != nil {
+			return err
+		}
+		query := `
+			insert into "magic_link"(token, browser, user_id, expired)
+			values ($1, $2, $3, $4)
+		`
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			magicLink.Token,
+			magicLink.Browser,
+			magicLink.User.ID,
+			time.Now().Add(magicLink.Expired),
+		)
+		return errorUserTransform(err)
+	})
+}
+
+// UseMagicLink deletes the link while reading its user, so it cannot be used
+// twice. A link opened in another browser is left for the one that asked, and
+// one sent before the user turned links off no longer works
+func (s *UserStore) UseMagicLink(ctx context.Context, token string, browser string, user *models.User) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		with used as (
+			delete from "magic_link"
+			where token = $1 and browser = $2 and expired > $3
+			returning user_id
+		)
+		select u.id, u.username, u.email, u.first_name, u.last_name from "user" u
+		join used on u.id = used.user_id
+		where u.is_active = true and u.magic_link_enabled = true
+	`
+	err := s.db.QueryRowContext(ctx, query, token, browser, time.Now()).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.FirstName,
+		&user.LastName,
+	)
+	return errorUserTransform(err)
+}
+
+func (s *UserStore) GetMagicLinkEnabled(ctx context.Context, userID int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `select magic_link_enabled from "user" where id = $1`
+	var isEnabled bool
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(&isEnabled)
+	return isEnabled, errorUserTransform(err)
+}
+
+func (s *UserStore) SetMagicLinkEnabled(ctx context.Context, userID int64, isEnabled bool) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `update "user" set magic_link_enabled = $2 where id = $1`
+		result, err := tx.ExecContext(ctx, query, userID, isEnabled)
+		if err != nil {
+			return errorUserTransform(err)
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return store.ErrNotFound
+		}
+		if isEnabled {
+			return nil
+		}
+		return s.deleteMagicLinks(ctx, tx, userID)
+	})
+}
+
+// deleteMagicLinks deletes the sign in links the user was sent, for when they
+// should no longer work
+func (s *UserStore) deleteMagicLinks(ctx context.Context, tx *sql.Tx, userID int64) error {
+	query := `delete from "magic_link" where user_id = $1`
+	_, err := tx.ExecContext(ctx, query, userID)
+	return errorUserTransform(err)
+}
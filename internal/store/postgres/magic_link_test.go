@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type MagicLinkStoreTestSuite struct {
+	storeTestSuite
+	userStore *UserStore
+}
+
+func (suite *MagicLinkStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.userStore = &UserStore{suite.db}
+}
+
+func (suite *MagicLinkStoreTestSuite) TestUseMagicLink() {
+	t := suite.T()
+	magicLink := &models.MagicLink{
+		User:    &models.User{ID: 1},
+		Token:   "token",
+		Browser: "browser",
+		Expired: time.Hour,
+	}
+	require.NoError(t, suite.userStore.CreateMagicLink(suite.ctx, magicLink), "could not create link")
+
+	var user models.User
+	err := suite.userStore.UseMagicLink(suite.ctx, "token", "other-browser", &user)
+	assert.ErrorIs(t, err, store.ErrNotFound, "another browser cannot use the link")
+
+	err = suite.userStore.UseMagicLink(suite.ctx, "token", "browser", &user)
+	require.NoError(t, err, "could not use link")
+	assert.Equal(t, int64(1), user.ID)
+	assert.Equal(t, "momo", user.Username)
+
+	err = suite.userStore.UseMagicLink(suite.ctx, "token", "browser", &user)
+	assert.ErrorIs(t, err, store.ErrNotFound, "the link is used up")
+}
+
+func (suite *MagicLinkStoreTestSuite) TestCreateMagicLinkReplacesThePreviousOne() {
+	t := suite.T()
+	for _, token := range []string{"first", "second"} {
+		magicLink := &models.MagicLink{User: &models.User{ID: 2}, Token: token, Browser: "browser", Expired: time.Hour}
+		require.NoError(t, suite.userStore.CreateMagicLink(suite.ctx, magicLink), "could not create link")
+	}
+
+	var user models.User
+	err := suite.userStore.UseMagicLink(suite.ctx, "first", "browser", &user)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+	require.NoError(t, suite.userStore.UseMagicLink(suite.ctx, "second", "browser", &user))
+	assert.Equal(t, int64(2), user.ID)
+}
+
+func (suite *MagicLinkStoreTestSuite) TestExpiredMagicLink() {
+	t := suite.T()
+	magicLink := &models.MagicLink{User: &models.User{ID: 1}, Token: "expired", Browser: "browser", Expired: -time.Minute}
+	require.NoError(t, suite.userStore.CreateMagicLink(suite.ctx, magicLink), "could not create link")
+
+	var user models.User
+	err := suite.userStore.UseMagicLink(suite.ctx, "expired", "browser", &user)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestMagicLinkStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(MagicLinkStoreTestSuite))
+}
+
+func (suite *MagicLinkStoreTestSuite) TestTurningMagicLinksOffDeletesThem() {
+	t := suite.T()
+	userID := suite.createUser("keqing")
+	magicLink := &models.MagicLink{User: &models.User{ID: userID}, Token: "keqing", Browser: "browser", Expired: time.Hour}
+	require.NoError(t, suite.userStore.CreateMagicLink(suite.ctx, magicLink), "could not create link")
+
+	isEnabled, err := suite.userStore.GetMagicLinkEnabled(suite.ctx, userID)
+	require.NoError(t, err)
+	assert.True(t, isEnabled, "links are on by default")
+
+	require.NoError(t, suite.userStore.SetMagicLinkEnabled(suite.ctx, userID, false))
+	isEnabled, err = suite.userStore.GetMagicLinkEnabled(suite.ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, isEnabled)
+
+	require.NoError(t, suite.userStore.SetMagicLinkEnabled(suite.ctx, userID, true))
+	var user models.User
+	err = suite.userStore.UseMagicLink(suite.ctx, "keqing", "browser", &user)
+	assert.ErrorIs(t, err, store.ErrNotFound, "the link sent before turning them off is gone")
+
+	err = suite.userStore.SetMagicLinkEnabled(suite.ctx, 9999, false)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func (suite *MagicLinkStoreTestSuite) TestResetPasswordDeletesMagicLinks() {
+	t := suite.T()
+	userID := suite.createUser("ningguang")
+	magicLink := &models.MagicLink{User: &models.User{ID: userID}, Token: "ningguang", Browser: "browser", Expired: time.Hour}
+	require.NoError(t, suite.userStore.CreateMagicLink(suite.ctx, magicLink), "could not create link")
+	hash := sha256.Sum256([]byte("reset-token"))
+	passwordReset := &models.PasswordReset{User: &models.User{ID: userID}, Token: hex.EncodeToString(hash[:]), Expired: time.Hour}
+	require.NoError(t, suite.userStore.CreatePasswordReset(suite.ctx, passwordReset), "could not create reset")
+
+	var user models.User
+	require.NoError(t, user.Password.Set("new-password"))
+	require.NoError(t, suite.userStore.ResetPassword(suite.ctx, "reset-token", &user))
+
+	err := suite.userStore.UseMagicLink(suite.ctx, "ningguang", "browser", &user)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func (suite *MagicLinkStoreTestSuite) TestConfirmEmailChangeDeletesMagicLinks() {
+	t := suite.T()
+	userID := suite.createUser("beidou")
+	magicLink := &models.MagicLink{User: &models.User{ID: userID}, Token: "beidou", Browser: "browser", Expired: time.Hour}
+	require.NoError(t, suite.userStore.CreateMagicLink(suite.ctx, magicLink), "could not create link")
+	hash := sha256.Sum256([]byte("change-token"))
+	emailChange := &models.EmailChange{
+		User:     &models.User{ID: userID},
+		NewEmail: "beidou@sapphire.com",
+		Token:    hex.EncodeToString(hash[:]),
+		Expired:  time.Hour,
+	}
+	require.NoError(t, suite.userStore.CreateEmailChange(suite.ctx, emailChange), "could not create change")
+
+	var user models.User
+	require.NoError(t, suite.userStore.ConfirmEmailChange(suite.ctx, "change-token", &user))
+
+	err := suite.userStore.UseMagicLink(suite.ctx, "beidou", "browser", &user)
+	assert.ErrorIs(t, err, store.ErrNotFound, "the link sent to the old address is gone")
+}
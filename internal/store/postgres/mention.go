@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+const (
+	mentionPostColumn    = "post_id"
+	mentionCommentColumn = "comment_id"
+)
+
+type MentionStore struct {
+	db *sql.DB
+}
+
+func (s *MentionStore) SetForPost(ctx context.Context, postID int64, authorID int64, usernames []string) ([]models.Mention, error) {
+	return s.set(ctx, mentionPostColumn, postID, authorID, usernames)
+}
+
+func (s *MentionStore) SetForComment(ctx context.Context, commentID int64, authorID int64, usernames []string) ([]models.Mention, error) {
+	return s.set(ctx, mentionCommentColumn, commentID, authorID, usernames)
+}
+
+func (s *MentionStore) GetByPost(ctx context.Context, postID int64) ([]models.Mention, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return s.getByTarget(ctx, s.db, mentionPostColumn, postID)
+}
+
+func (s *MentionStore) GetMentionsOf(ctx context.Context, userMentions *pagination.UserMentions) ([]*models.UserMention, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select
+			m.created_at,
+			p.id, p.user_id, p.tittle, p."content", p.media_url, p.created_at, p.updated_at,
+			pu.username, pu.first_name, pu.last_name,
+			c.id, c.user_id, coalesce(c."content", ''), c.created_at, c.updated_at,
+			coalesce(cu.username, ''), coalesce(cu.first_name, ''), coalesce(cu.last_name, '')
+		from mention m
+		left join "comment" c on c.id = m.comment_id
+		join post p on p.id = coalesce(m.post_id, c.post_id)
+		join "user" pu on pu.id = p.user_id
+		left join "user" cu on cu.id = c.user_id
+		where m.mentioned_user_id = $1
+			and m.created_at < coalesce($2::timestamp, now())
+		order by m.created_at desc, m.id desc
+		limit $3;
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userMentions.UserID,
+		userMentions.Cursor,
+		userMentions.Limit+1,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var mentions []*models.UserMention
+	for rows.Next() {
+		mention := &models.UserMention{
+			Post: &models.Post{User: &models.User{}},
+		}
+		var commentID, commentUserID sql.NullInt64
+		var commentCreatedAt, commentUpdatedAt sql.NullTime
+		var comment models.Comment
+		err := rows.Scan(
+			&mention.CreatedAt,
+			&mention.Post.ID,
+			&mention.Post.User.ID,
+			&mention.Post.Tittle,
+			&mention.Post.Content,
+			&mention.Post.Media,
+			&mention.Post.CreatedAt,
+			&mention.Post.UpdatedAt,
+			&mention.Post.User.Username,
+			&mention.Post.User.FirstName,
+			&mention.Post.User.LastName,
+			&commentID,
+			&commentUserID,
+			&comment.Content,
+			&commentCreatedAt,
+			&commentUpdatedAt,
+			&comment.User.Username,
+			&comment.User.FirstName,
+			&comment.User.LastName,
+		)
+		if err != nil {
+			return nil, "", err
+		}
+		if commentID.Valid {
+			comment.ID = commentID.Int64
+			comment.PostId = mention.Post.ID
+			comment.UserId = commentUserID.Int64
+			comment.CreatedAt = commentCreatedAt.Time
+			comment.UpdatedAt = commentUpdatedAt.Time
+			mention.Comment = &comment
+		}
+		mentions = append(mentions, mention)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(mentions) > userMentions.Limit {
+		nextCursor = mentions[userMentions.Limit-1].CreatedAt.Format(time.RFC3339Nano)
+		mentions = mentions[:userMentions.Limit]
+	}
+
+	return mentions, nextCursor, nil
+}
+
+// set makes the mentions of a post or comment match the given usernames.
+// Mentions that are kept retain their creation date, so editing a post does
+// not bump it in the mentioned users' timeline. Unknown, inactive users and
+// the author are skipped
+func (s *MentionStore) set(ctx context.Context, column string, targetID int64, authorID int64, usernames []string) ([]models.Mention, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	// a nil slice binds null, which would keep every mention
+	if usernames == nil {
+		usernames = []string{}
+	}
+	var mentions []models.Mention
+	err := store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		deleteQuery := fmt.Sprintf(`
+			delete from mention m
+			using "user" u
+			where m.%s = $1 and u.id = m.mentioned_user_id
+				and not (u.username = any($2::citext[]))
+		`, column)
+		if _, err := tx.ExecContext(ctx, deleteQuery, targetID, pq.Array(usernames)); err != nil {
+			return err
+		}
+		insertQuery := fmt.Sprintf(`
+			insert into mention (%s, author_id, mentioned_user_id)
+			select $1::bigint, $2::bigint, u.id
+			from "user" u
+			where u.username = any($3::citext[]) and u.is_active = true and u.id <> $2
+			on conflict do nothing
+		`, column)
+		if _, err := tx.ExecContext(ctx, insertQuery, targetID, authorID, pq.Array(usernames)); err != nil {
+			return errorMentionTransform(err)
+		}
+		var err error
+		mentions, err = s.getByTarget(ctx, tx, column, targetID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mentions, nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func (s *MentionStore) getByTarget(ctx context.Context, db queryer, column string, targetID int64) ([]models.Mention, error) {
+	query := fmt.Sprintf(`
+		select u.id, u.username
+		from mention m
+		join "user" u on u.id = m.mentioned_user_id
+		where m.%s = $1
+	`, column)
+	rows, err := db.QueryContext(ctx, query, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mentions []models.Mention
+	for rows.Next() {
+		var mention models.Mention
+		if err := rows.Scan(&mention.UserID, &mention.Username); err != nil {
+			return nil, err
+		}
+		mentions = append(mentions, mention)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return mentions, nil
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type MentionStoreTestSuite struct {
+	storeTestSuite
+	mentionStore *MentionStore
+}
+
+func (suite *MentionStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.mentionStore = &MentionStore{suite.db}
+}
+
+func (suite *MentionStoreTestSuite) TestSetForPost() {
+	t := suite.T()
+	postID := suite.createPost(1, "@chaee @momo @nobody", 0)
+
+	mentions, err := suite.mentionStore.SetForPost(suite.ctx, postID, 1, []string{"chaee", "momo", "nobody"})
+	require.NoError(t, err, "could not set mentions")
+	require.Len(t, mentions, 1, "the author and unknown users are skipped")
+	assert.Equal(t, "chaee", mentions[0].Username)
+
+	userMentions := &pagination.UserMentions{UserID: 2, Limit: 10}
+	mentionsOf, _, err := suite.mentionStore.GetMentionsOf(suite.ctx, userMentions)
+	require.NoError(t, err, "could not get mentions of user")
+	require.NotEmpty(t, mentionsOf)
+	assert.Equal(t, postID, mentionsOf[0].Post.ID)
+}
+
+func (suite *MentionStoreTestSuite) TestSetForPostRemovesEveryMention() {
+	t := suite.T()
+	postID := suite.createPost(1, "@chaee", 0)
+
+	_, err := suite.mentionStore.SetForPost(suite.ctx, postID, 1, []string{"chaee"})
+	require.NoError(t, err, "could not set mentions")
+
+	mentions, err := suite.mentionStore.SetForPost(suite.ctx, postID, 1, nil)
+	require.NoError(t, err, "could not remove mentions")
+	assert.Empty(t, mentions)
+
+	mentions, err = suite.mentionStore.GetByPost(suite.ctx, postID)
+	require.NoError(t, err, "could not get mentions")
+	assert.Empty(t, mentions)
+}
+
+func TestMentionStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(MentionStoreTestSuite))
+}
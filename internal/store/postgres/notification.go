@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type NotificationStore struct {
+	db *sql.DB
+}
+
+func (s *NotificationStore) Create(ctx context.Context, notification *models.Notification) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		insert into "notification" (user_id, actor_id, kind, post_id, comment_id, group_key, dedupe_key, created_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8)
+		on conflict (user_id, dedupe_key) where dedupe_key is not null do nothing
+		returning id
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		notification.UserID,
+		notification.ActorID,
+		notification.Kind,
+		notification.PostID,
+		notification.CommentID,
+		notification.GroupKey,
+		notification.DedupeKey,
+		notification.CreatedAt,
+	).Scan(&notification.ID)
+	if err == sql.ErrNoRows {
+		// the user was already notified with the same dedupe key
+		return nil
+	}
+	return errorNotificationTransform(err)
+}
+
+func (s *NotificationStore) GetGroups(ctx context.Context, userNotifications *pagination.UserNotifications) ([]*models.NotificationGroup, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select
+			max(n.id) as latest_id, n.kind, max(n.post_id), max(n.comment_id),
+			max(n.created_at) as latest_at, count(distinct n.actor_id),
+			bool_and(n.read_at is not null),
+			array_agg(u.id order by n.created_at desc, n.id desc),
+			array_agg(u.username order by n.created_at desc, n.id desc)
+		from "notification" n
+		join "user" u on u.id = n.actor_id
+		where n.user_id = $1
+		group by n.group_key, n.kind
+		having max(n.created_at) < coalesce($2::timestamp, now())
+		order by latest_at desc, latest_id desc
+		limit $3;
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		userNotifications.UserID,
+		userNotifications.Cursor,
+		userNotifications.Limit+1,
+	)
+	if err != nil {
+		return nil, "", errorNotificationTransform(err)
+	}
+	defer rows.Close()
+
+	var groups []*models.NotificationGroup
+	for rows.Next() {
+		var group models.NotificationGroup
+		var actorIDs []int64
+		var actorUsernames []string
+		err := rows.Scan(
+			&group.ID,
+			&group.Kind,
+			&group.PostID,
+			&group.CommentID,
+			&group.CreatedAt,
+			&group.ActorsCount,
+			&group.IsRead,
+			pq.Array(&actorIDs),
+			pq.Array(&actorUsernames),
+		)
+		if err != nil {
+			return nil, "", errorNotificationTransform(err)
+		}
+		group.Actors = latestActors(actorIDs, actorUsernames)
+		groups = append(groups, &group)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(groups) > userNotifications.Limit {
+		nextCursor = groups[userNotifications.Limit-1].CreatedAt.Format(time.RFC3339Nano)
+		groups = groups[:userNotifications.Limit]
+	}
+
+	return groups, nextCursor, nil
+}
+
+func (s *NotificationStore) CountUnread(ctx context.Context, userID int64) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select count(distinct group_key)
+		from "notification"
+		where user_id = $1 and read_at is null
+	`
+	var count int
+	if err := s.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, errorNotificationTransform(err)
+	}
+	return count, nil
+}
+
+func (s *NotificationStore) MarkGroupRead(ctx context.Context, userID int64, notificationID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	var groupKey string
+	query := `
+		select group_key from "notification"
+		where id = $1 and user_id = $2
+	`
+	if err := s.db.QueryRowContext(ctx, query, notificationID, userID).Scan(&groupKey); err != nil {
+		return errorNotificationTransform(err)
+	}
+	query = `
+		update "notification"
+		set read_at = now()
+		where user_id = $1 and group_key = $2 and read_at is null
+	`
+	_, err := s.db.ExecContext(ctx, query, userID, groupKey)
+	return errorNotificationTransform(err)
+}
+
+func (s *NotificationStore) MarkAllRead(ctx context.Context, userID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "notification"
+		set read_at = now()
+		where user_id = $1 and read_at is null
+	`
+	_, err := s.db.ExecContext(ctx, query, userID)
+	return errorNotificationTransform(err)
+}
+
+// latestActors keeps the first distinct actors of a group, which come sorted
+// from the most recent
+func latestActors(ids []int64, usernames []string) []*models.User {
+	var actors []*models.User
+	seen := make(map[int64]bool)
+	for idx, id := range ids {
+		if seen[id] || idx >= len(usernames) {
+			continue
+		}
+		seen[id] = true
+		actors = append(actors, &models.User{ID: id, Username: usernames[idx]})
+		if len(actors) == models.NotificationGroupActors {
+			break
+		}
+	}
+	return actors
+}
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type NotificationStoreTestSuite struct {
+	storeTestSuite
+	notificationStore *NotificationStore
+}
+
+func (suite *NotificationStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.notificationStore = &NotificationStore{suite.db}
+}
+
+// follow records that the actor followed the user, the way the service does
+func (suite *NotificationStoreTestSuite) follow(userID int64, actorID int64, at time.Time) {
+	notification := &models.Notification{
+		UserID:    userID,
+		ActorID:   actorID,
+		Kind:      models.NotificationFollow,
+		GroupKey:  "follow:" + at.Format(time.DateOnly),
+		DedupeKey: sql.NullString{String: fmt.Sprintf("follow:%d", actorID), Valid: true},
+		CreatedAt: at,
+	}
+	require.NoError(suite.T(), suite.notificationStore.Create(suite.ctx, notification), "could not create notification")
+}
+
+func (suite *NotificationStoreTestSuite) groups(userID int64) []*models.NotificationGroup {
+	groups, _, err := suite.notificationStore.GetGroups(suite.ctx, &pagination.UserNotifications{UserID: userID, Limit: 10})
+	require.NoError(suite.T(), err, "could not get notifications")
+	return groups
+}
+
+func (suite *NotificationStoreTestSuite) TestGroupsTheSameTarget() {
+	t := suite.T()
+	userID := suite.createUser("grouped")
+	first := suite.createUser("grouped_first")
+	second := suite.createUser("grouped_second")
+	now := time.Now().Add(-time.Minute).Truncate(time.Second)
+	suite.follow(userID, first, now.Add(-time.Second))
+	suite.follow(userID, second, now)
+
+	groups := suite.groups(userID)
+	require.Len(t, groups, 1)
+	assert.Equal(t, 2, groups[0].ActorsCount)
+	require.Len(t, groups[0].Actors, 2)
+	assert.Equal(t, second, groups[0].Actors[0].ID, "the latest actor comes first")
+	assert.False(t, groups[0].IsRead)
+}
+
+func (suite *NotificationStoreTestSuite) TestRecordsADedupeKeyOnce() {
+	t := suite.T()
+	userID := suite.createUser("deduped")
+	actorID := suite.createUser("deduped_actor")
+	now := time.Now().Add(-time.Minute).Truncate(time.Second)
+	suite.follow(userID, actorID, now)
+	suite.follow(userID, actorID, now)
+
+	groups := suite.groups(userID)
+	require.Len(t, groups, 1)
+	assert.Equal(t, 1, groups[0].ActorsCount)
+}
+
+func (suite *NotificationStoreTestSuite) TestMarksGroupsRead() {
+	t := suite.T()
+	userID := suite.createUser("reader")
+	actorID := suite.createUser("reader_actor")
+	postID := suite.createPost(userID, "reacted", 0)
+	suite.follow(userID, actorID, time.Now().Add(-time.Minute).Truncate(time.Second))
+	reaction := &models.Notification{
+		UserID:    userID,
+		ActorID:   actorID,
+		Kind:      models.NotificationReaction,
+		PostID:    sql.NullInt64{Int64: postID, Valid: true},
+		GroupKey:  "reaction",
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+	require.NoError(t, suite.notificationStore.Create(suite.ctx, reaction))
+
+	count, err := suite.notificationStore.CountUnread(suite.ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count, "groups are counted, not notifications")
+
+	groups := suite.groups(userID)
+	require.Len(t, groups, 2)
+	require.NoError(t, suite.notificationStore.MarkGroupRead(suite.ctx, userID, groups[0].ID))
+	count, err = suite.notificationStore.CountUnread(suite.ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	err = suite.notificationStore.MarkGroupRead(suite.ctx, actorID, groups[1].ID)
+	assert.ErrorIs(t, err, store.ErrNotFound, "only the recipient can read a notification")
+
+	require.NoError(t, suite.notificationStore.MarkAllRead(suite.ctx, userID))
+	count, err = suite.notificationStore.CountUnread(suite.ctx, userID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestNotificationStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(NotificationStoreTestSuite))
+}
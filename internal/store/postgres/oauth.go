@@ -13,18 +13,9 @@ type OAuthStore struct {
 	userStore *UserStore
 }
 
-func (s *OAuthStore) CreateWithUserActivation(ctx context.Context, oauthAccount *models.OAuthAccount, user *models.User) error {
+func (s *OAuthStore) Create(ctx context.Context, oauthAccount *models.OAuthAccount) error {
 	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
-		if err := s.create(ctx, tx, oauthAccount); err != nil {
-			return err
-		}
-		if !user.IsActive {
-			user.IsActive = true
-			if err := s.userStore.update(ctx, tx, user); err != nil {
-				return err
-			}
-		}
-		return nil
+		return s.create(ctx, tx, oauthAccount)
 	})
 }
 
@@ -66,6 +57,87 @@ func (s *OAuthStore) GetUserID(ctx context.Context, provider, providerUserID str
 	return &userID, nil
 }
 
+func (s *OAuthStore) GetFromUser(ctx context.Context, userID int64) ([]*models.OAuthAccount, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+
+	query := `
+		select provider_id, provider_user_id, user_id, created_at
+		from oauth_account
+		where user_id = $1
+		order by created_at, provider_id
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errorOAuthTransform(err)
+	}
+	defer rows.Close()
+
+	var oauthAccounts []*models.OAuthAccount
+	for rows.Next() {
+		var oauthAccount models.OAuthAccount
+		err := rows.Scan(
+			&oauthAccount.ProviderID,
+			&oauthAccount.ProviderUserID,
+			&oauthAccount.UserID,
+			&oauthAccount.CreatedAt,
+		)
+		if err != nil {
+			return nil, errorOAuthTransform(err)
+		}
+		oauthAccounts = append(oauthAccounts, &oauthAccount)
+	}
+	return oauthAccounts, rows.Err()
+}
+
+// Delete unlinks a provider account from the user. It returns
+// store.ErrConflict when the account is the last way left to sign in, that
+// is, the user has no password, passkey or other provider account
+func (s *OAuthStore) Delete(ctx context.Context, userID int64, provider, providerUserID string) error {
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+		defer cancel()
+
+		// the user row is locked so two unlinks can not each see the other
+		// account as the one left
+		query := `select password is not null from "user" where id = $1 for update`
+		var hasPassword bool
+		if err := tx.QueryRowContext(ctx, query, userID).Scan(&hasPassword); err != nil {
+			return errorOAuthTransform(err)
+		}
+
+		query = `delete from oauth_account where user_id = $1 and provider_id = $2 and provider_user_id = $3`
+		result, err := tx.ExecContext(ctx, query, userID, provider, providerUserID)
+		if err != nil {
+			return errorOAuthTransform(err)
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return store.ErrNotFound
+		}
+		if hasPassword {
+			return nil
+		}
+
+		query = `
+			select
+				(select count(*) from oauth_account where user_id = $1) +
+				(select count(*) from user_passkey where user_id = $1)
+		`
+		var remaining int
+		if err := tx.QueryRowContext(ctx, query, userID).Scan(&remaining); err != nil {
+			return errorOAuthTransform(err)
+		}
+		if remaining == 0 {
+			return store.ErrConflict
+		}
+		return nil
+	})
+}
+
 func (s *OAuthStore) create(ctx context.Context, tx *sql.Tx, oauthAccount *models.OAuthAccount) error {
 	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
 	defer cancel()
@@ -0,0 +1,237 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type PasskeyStore struct {
+	db *sql.DB
+}
+
+// CreateChallenge saves the challenge of a new ceremony and drops the ones
+// that were never finished
+func (s *PasskeyStore) CreateChallenge(ctx context.Context, challenge *models.PasskeyChallenge) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `delete from "passkey_challenge" where expires_at < now()`
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return errorPasskeyTransform(err)
+		}
+		query = `
+			insert into "passkey_challenge" (challenge, user_id, kind, expires_at)
+			values ($1, $2, $3, $4)
+		`
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			challenge.Challenge,
+			challenge.UserID,
+			challenge.Kind,
+			challenge.ExpiresAt,
+		)
+		return errorPasskeyTransform(err)
+	})
+}
+
+// ConsumeChallenge removes a challenge that has not expired, so each one is
+// only answered once
+func (s *PasskeyStore) ConsumeChallenge(ctx context.Context, challenge []byte, kind string) (*models.PasskeyChallenge, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		delete from "passkey_challenge"
+		where challenge = $1 and kind = $2 and expires_at > now()
+		returning challenge, user_id, kind, expires_at
+	`
+	var passkeyChallenge models.PasskeyChallenge
+	err := s.db.QueryRowContext(ctx, query, challenge, kind).Scan(
+		&passkeyChallenge.Challenge,
+		&passkeyChallenge.UserID,
+		&passkeyChallenge.Kind,
+		&passkeyChallenge.ExpiresAt,
+	)
+	if err != nil {
+		return nil, errorPasskeyTransform(err)
+	}
+	return &passkeyChallenge, nil
+}
+
+func (s *PasskeyStore) Create(ctx context.Context, passkey *models.Passkey) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		insert into "user_passkey" (user_id, credential_id, public_key, sign_count, name)
+		values ($1, $2, $3, $4, $5)
+		returning id, created_at
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		passkey.UserID,
+		passkey.CredentialID,
+		passkey.PublicKey,
+		passkey.SignCount,
+		passkey.Name,
+	).Scan(&passkey.ID, &passkey.CreatedAt)
+	return errorPasskeyTransform(err)
+}
+
+func (s *PasskeyStore) GetByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select id, user_id, credential_id, public_key, sign_count, name, created_at, last_used_at
+		from "user_passkey"
+		where credential_id = $1
+	`
+	var passkey models.Passkey
+	err := s.db.QueryRowContext(ctx, query, credentialID).Scan(
+		&passkey.ID,
+		&passkey.UserID,
+		&passkey.CredentialID,
+		&passkey.PublicKey,
+		&passkey.SignCount,
+		&passkey.Name,
+		&passkey.CreatedAt,
+		&passkey.LastUsedAt,
+	)
+	if err != nil {
+		return nil, errorPasskeyTransform(err)
+	}
+	return &passkey, nil
+}
+
+func (s *PasskeyStore) GetFromUser(ctx context.Context, userID int64) ([]*models.Passkey, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select id, user_id, credential_id, public_key, sign_count, name, created_at, last_used_at
+		from "user_passkey"
+		where user_id = $1
+		order by created_at, id
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errorPasskeyTransform(err)
+	}
+	defer rows.Close()
+
+	var passkeys []*models.Passkey
+	for rows.Next() {
+		var passkey models.Passkey
+		err := rows.Scan(
+			&passkey.ID,
+			&passkey.UserID,
+			&passkey.CredentialID,
+			&passkey.PublicKey,
+			&passkey.SignCount,
+			&passkey.Name,
+			&passkey.CreatedAt,
+			&passkey.LastUsedAt,
+		)
+		if err != nil {
+			return nil, errorPasskeyTransform(err)
+		}
+		passkeys = append(passkeys, &passkey)
+	}
+	return passkeys, rows.Err()
+}
+
+// UpdateSignCount records a sign in. The counter only moves forward, a stale
+// value fails with store.ErrConflict
+func (s *PasskeyStore) UpdateSignCount(ctx context.Context, passkeyID int64, signCount int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "user_passkey"
+		set sign_count = $2, last_used_at = now()
+		where id = $1 and (sign_count < $2 or $2 = 0)
+	`
+	result, err := s.db.ExecContext(ctx, query, passkeyID, signCount)
+	if err != nil {
+		return errorPasskeyTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+func (s *PasskeyStore) Rename(ctx context.Context, userID int64, passkeyID int64, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "user_passkey"
+		set name = $3
+		where id = $2 and user_id = $1
+	`
+	result, err := s.db.ExecContext(ctx, query, userID, passkeyID, name)
+	if err != nil {
+		return errorPasskeyTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a passkey of the user. It returns store.ErrConflict when the
+// passkey is the last way left to sign in, that is, the user has no password,
+// provider account or other passkey
+func (s *PasskeyStore) Delete(ctx context.Context, userID int64, passkeyID int64) error {
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+		defer cancel()
+
+		// the user row is locked so removing two passkeys, or a passkey and a
+		// provider account, can not each see the other as the one left
+		query := `select password is not null from "user" where id = $1 for update`
+		var hasPassword bool
+		if err := tx.QueryRowContext(ctx, query, userID).Scan(&hasPassword); err != nil {
+			return errorPasskeyTransform(err)
+		}
+
+		query = `delete from "user_passkey" where id = $2 and user_id = $1`
+		result, err := tx.ExecContext(ctx, query, userID, passkeyID)
+		if err != nil {
+			return errorPasskeyTransform(err)
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return store.ErrNotFound
+		}
+		if hasPassword {
+			return nil
+		}
+
+		query = `
+			select
+				(select count(*) from oauth_account where user_id = $1) +
+				(select count(*) from user_passkey where user_id = $1)
+		`
+		var remaining int
+		if err := tx.QueryRowContext(ctx, query, userID).Scan(&remaining); err != nil {
+			return errorPasskeyTransform(err)
+		}
+		if remaining == 0 {
+			return store.ErrConflict
+		}
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type PasskeyStoreTestSuite struct {
+	storeTestSuite
+	passkeyStore *PasskeyStore
+}
+
+func (suite *PasskeyStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.passkeyStore = &PasskeyStore{suite.db}
+}
+
+func (suite *PasskeyStoreTestSuite) createPasskey(userID int64, name string) int64 {
+	passkey := &models.Passkey{
+		UserID:       userID,
+		CredentialID: []byte(name),
+		PublicKey:    []byte("public-key"),
+		Name:         name,
+	}
+	require.NoError(suite.T(), suite.passkeyStore.Create(suite.ctx, passkey), "could not create passkey")
+	return passkey.ID
+}
+
+func (suite *PasskeyStoreTestSuite) TestDeleteKeepsTheLastLoginMethod() {
+	t := suite.T()
+	userID := suite.createUser("yelan")
+	_, err := suite.db.ExecContext(suite.ctx, `update "user" set "password" = null where id = $1`, userID)
+	require.NoError(t, err)
+	laptop := suite.createPasskey(userID, "laptop")
+	phone := suite.createPasskey(userID, "phone")
+
+	require.NoError(t, suite.passkeyStore.Delete(suite.ctx, userID, laptop))
+	err = suite.passkeyStore.Delete(suite.ctx, userID, phone)
+	assert.ErrorIs(t, err, store.ErrConflict, "the last passkey of a user without password stays")
+
+	passkeys, err := suite.passkeyStore.GetFromUser(suite.ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, passkeys, 1)
+}
+
+func (suite *PasskeyStoreTestSuite) TestDeleteWithPassword() {
+	t := suite.T()
+	userID := suite.createUser("xiangling")
+	passkeyID := suite.createPasskey(userID, "xiangling-laptop")
+
+	require.NoError(t, suite.passkeyStore.Delete(suite.ctx, userID, passkeyID), "the password is left to sign in")
+	err := suite.passkeyStore.Delete(suite.ctx, userID, passkeyID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func TestPasskeyStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(PasskeyStoreTestSuite))
+}
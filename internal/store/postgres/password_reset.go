@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+// CreatePasswordReset saves a new reset token, replacing the ones the user
+// asked for before
+func (s *UserStore) CreatePasswordReset(ctx context.Context, passwordReset *models.PasswordReset) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := s.deletePasswordResets(ctx, tx, passwordReset.User.ID); err != nil {
+			return err
+		}
+		query := `
+			insert into "password_reset"(token, user_id, expired)
+			values ($1, $2, $3)
+		`
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			passwordReset.Token,
+			passwordReset.User.ID,
+			time.Now().Add(passwordReset.Expired),
+		)
+		return errorUserTransform(err)
+	})
+}
+
+// ResetPassword sets the new password of the user owning the token. The
+// token is used up and every session, access token and magic link of the user
+// is deleted
+func (s *UserStore) ResetPassword(ctx context.Context, plainToken string, user *models.User) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+			select u.id, u.username, u.email, u.first_name, u.last_name from "user" u
+			join "password_reset" pr on u.id = pr.user_id
+			where pr.token = $1 and pr.expired > $2 and u.is_active = true
+		`
+		hash256 := sha256.Sum256([]byte(plainToken))
+		hashToken := hex.EncodeToString(hash256[:])
+		err := tx.QueryRowContext(ctx, query, hashToken, time.Now()).Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.FirstName,
+			&user.LastName,
+		)
+		if err != nil {
+			return errorUserTransform(err)
+		}
+
+		query = `update "user" set "password" = $2 where id = $1`
+		if _, err := tx.ExecContext(ctx, query, user.ID, user.Password.Hash); err != nil {
+			return errorUserTransform(err)
+		}
+		if err := s.deletePasswordResets(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		if err := s.deleteMagicLinks(ctx, tx, user.ID); err != nil {
+			return err
+		}
+		query = `delete from "user_session" where user_id = $1`
+		if _, err := tx.ExecContext(ctx, query, user.ID); err != nil {
+			return errorSessionTransform(err)
+		}
+		query = `delete from "user_access_token" where user_id = $1`
+		_, err = tx.ExecContext(ctx, query, user.ID)
+		return errorAccessTokenTransform(err)
+	})
+}
+
+func (s *UserStore) deletePasswordResets(ctx context.Context, tx *sql.Tx, userID int64) error {
+	query := `delete from "password_reset" where user_id = $1`
+	_, err := tx.ExecContext(ctx, query, userID)
+	return errorUserTransform(err)
+}
+
+// UpdatePasswordHash replaces the hash of the same password, made with older
+// parameters. Nothing is saved when the password changed since oldHash was read
+func (s *UserStore) UpdatePasswordHash(ctx context.Context, userID int64, oldHash []byte, newHash []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `update "user" set "password" = $3 where id = $1 and "password" = $2`
+	_, err := s.db.ExecContext(ctx, query, userID, oldHash, newHash)
+	return errorUserTransform(err)
+}
@@ -24,8 +24,8 @@ func (s *PostStore) Create(ctx context.Context, post *models.Post) error {
 	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
 	defer cancel()
 	query := `
-		INSERT INTO post (content, tittle, user_id, media_url, tags)
-		VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at
+		INSERT INTO post (content, tittle, user_id, media_url, tags, repost_of_id, quote_of_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at, updated_at
 	`
 	err := s.db.QueryRowContext(
 		ctx,
@@ -35,6 +35,8 @@ func (s *PostStore) Create(ctx context.Context, post *models.Post) error {
 		post.User.ID,
 		post.Media,
 		pq.Array(post.Tags),
+		post.RepostOfID,
+		post.QuoteOfID,
 	).Scan(&post.ID, &post.CreatedAt, &post.UpdatedAt)
 	if err != nil {
 		return errorPostTransform(err)
@@ -46,7 +48,7 @@ func (s *PostStore) GetByID(ctx context.Context, postID int64) (*models.Post, er
 	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
 	defer cancel()
 	query := `
-		SELECT id, user_id, tittle, content, media_url, tags, created_at, updated_at
+		SELECT id, user_id, tittle, content, media_url, tags, created_at, updated_at, repost_of_id, quote_of_id
 		FROM post
 		WHERE id = $1
 	`
@@ -61,6 +63,8 @@ func (s *PostStore) GetByID(ctx context.Context, postID int64) (*models.Post, er
 		pq.Array(&post.Tags),
 		&post.CreatedAt,
 		&post.UpdatedAt,
+		&post.RepostOfID,
+		&post.QuoteOfID,
 	)
 	if err != nil {
 		return nil, errorPostTransform(err)
@@ -72,7 +76,8 @@ func (s *PostStore) GetByIDWithUser(ctx context.Context, postID int64) (*models.
 	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
 	defer cancel()
 	query := `
-		SELECT p.id, p.user_id, p.tittle, p.content, p.media_url, p.tags, p.created_at, p.updated_at, u.username, u.first_name , u.last_name
+		SELECT p.id, p.user_id, p.tittle, p.content, p.media_url, p.tags, p.created_at, p.updated_at, u.username, u.first_name , u.last_name,
+			p.repost_of_id, p.quote_of_id
 		FROM post p
 		join "user" u on p.user_id = u.id
 		WHERE p.id = $1;
@@ -91,6 +96,8 @@ func (s *PostStore) GetByIDWithUser(ctx context.Context, postID int64) (*models.
 		&post.User.Username,
 		&post.User.FirstName,
 		&post.User.LastName,
+		&post.RepostOfID,
+		&post.QuoteOfID,
 	)
 	if err != nil {
 		return nil, errorPostTransform(err)
@@ -144,3 +151,24 @@ func (s *PostStore) UpdateByID(ctx context.Context, post *models.Post) error {
 	}
 	return nil
 }
+
+func (s *PostStore) DeleteRepost(ctx context.Context, userID int64, postID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		delete from post
+		where user_id = $1 and repost_of_id = $2
+	`
+	result, err := s.db.ExecContext(ctx, query, userID, postID)
+	if err != nil {
+		return errorPostTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -9,12 +9,24 @@ import (
 func NewPostgresStore(db *sql.DB) *store.Store {
 	userStore := &UserStore{db: db}
 	return &store.Store{
-		Post:    &PostStore{db: db},
-		User:    userStore,
-		Comment: &CommentStore{db: db},
-		Feed:    &FeedStore{db: db},
-		Session: &SessionStore{db: db},
-		OAuth:   &OAuthStore{db: db, userStore: userStore},
+		Post:         &PostStore{db: db},
+		User:         userStore,
+		Comment:      &CommentStore{db: db},
+		Feed:         &FeedStore{db: db},
+		Session:      &SessionStore{db: db},
+		TwoFactor:    &TwoFactorStore{db: db},
+		Passkey:      &PasskeyStore{db: db},
+		AccessToken:  &AccessTokenStore{db: db},
+		LoginFailure: &LoginFailureStore{db: db},
+		OAuth:        &OAuthStore{db: db, userStore: userStore},
+		Reaction:     &ReactionStore{db: db},
+		Bookmark:     &BookmarkStore{db: db},
+		Search:       &SearchStore{db: db},
+		Tag:          &TagStore{db: db},
+		Mention:      &MentionStore{db: db},
+		Notification: &NotificationStore{db: db},
+		Conversation: &ConversationStore{db: db},
+		AuditEvent:   &AuditEventStore{db: db},
 	}
 }
 
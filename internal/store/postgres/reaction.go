@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type ReactionStore struct {
+	db *sql.DB
+}
+
+func (s *ReactionStore) Upsert(ctx context.Context, reaction *models.Reaction) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		insert into "post_reaction" (post_id, user_id, kind)
+		values ($1, $2, $3)
+		on conflict (post_id, user_id)
+		do update set kind = excluded.kind, created_at = now()
+		returning created_at
+	`
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		reaction.PostID,
+		reaction.UserID,
+		reaction.Kind,
+	).Scan(&reaction.CreatedAt)
+	if err != nil {
+		return errorReactionTransform(err)
+	}
+	return nil
+}
+
+func (s *ReactionStore) Delete(ctx context.Context, postID int64, userID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		delete from "post_reaction"
+		where post_id = $1 and user_id = $2
+	`
+	result, err := s.db.ExecContext(ctx, query, postID, userID)
+	if err != nil {
+		return errorReactionTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *ReactionStore) GetSummary(ctx context.Context, postID int64, viewerID int64) (*models.ReactionSummary, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select
+			coalesce((
+				select json_object_agg(rc.kind, rc.total)
+				from (
+					select pr.kind, count(*) as total
+					from post_reaction pr
+					where pr.post_id = $1
+					group by pr.kind
+				) rc
+			), '{}') as reaction_counts,
+			coalesce((
+				select pr.kind from post_reaction pr
+				where pr.post_id = $1 and pr.user_id = $2
+			), '') as viewer_reaction
+	`
+	var summary models.ReactionSummary
+	var counts []byte
+	err := s.db.QueryRowContext(ctx, query, postID, viewerID).Scan(
+		&counts,
+		&summary.ViewerReaction,
+	)
+	if err != nil {
+		return nil, errorReactionTransform(err)
+	}
+	if err := json.Unmarshal(counts, &summary.Counts); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
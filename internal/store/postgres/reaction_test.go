@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ReactionStoreTestSuite struct {
+	storeTestSuite
+	reactionStore *ReactionStore
+}
+
+func (suite *ReactionStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.reactionStore = &ReactionStore{suite.db}
+}
+
+func (suite *ReactionStoreTestSuite) TestReplacesThePreviousReaction() {
+	t := suite.T()
+	userID := suite.createUser("reaction_replacer")
+	postID := suite.createPost(userID, "reacted twice", 0)
+
+	reaction := &models.Reaction{PostID: postID, UserID: userID, Kind: "like"}
+	require.NoError(t, suite.reactionStore.Upsert(suite.ctx, reaction))
+	reaction.Kind = "love"
+	require.NoError(t, suite.reactionStore.Upsert(suite.ctx, reaction), "could not replace reaction")
+
+	summary, err := suite.reactionStore.GetSummary(suite.ctx, postID, userID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"love": 1}, summary.Counts, "a user has one reaction per post")
+	assert.Equal(t, "love", summary.ViewerReaction)
+}
+
+func (suite *ReactionStoreTestSuite) TestSummarizesEveryKind() {
+	t := suite.T()
+	authorID := suite.createUser("reaction_author")
+	postID := suite.createPost(authorID, "popular", 0)
+	for username, kind := range map[string]string{"hutao": "like", "qiqi": "like", "zhongli": "love"} {
+		userID := suite.createUser(username)
+		reaction := &models.Reaction{PostID: postID, UserID: userID, Kind: kind}
+		require.NoError(t, suite.reactionStore.Upsert(suite.ctx, reaction))
+	}
+
+	summary, err := suite.reactionStore.GetSummary(suite.ctx, postID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"like": 2, "love": 1}, summary.Counts)
+	assert.Empty(t, summary.ViewerReaction, "anonymous viewers have no reaction")
+
+	unreacted := suite.createPost(authorID, "ignored", 0)
+	summary, err = suite.reactionStore.GetSummary(suite.ctx, unreacted, 0)
+	require.NoError(t, err)
+	assert.Empty(t, summary.Counts)
+}
+
+func (suite *ReactionStoreTestSuite) TestDeleteReturnsNotFoundWithoutReaction() {
+	t := suite.T()
+	userID := suite.createUser("reaction_remover")
+	postID := suite.createPost(userID, "reacted once", 0)
+	reaction := &models.Reaction{PostID: postID, UserID: userID, Kind: "like"}
+	require.NoError(t, suite.reactionStore.Upsert(suite.ctx, reaction))
+
+	require.NoError(t, suite.reactionStore.Delete(suite.ctx, postID, userID))
+	err := suite.reactionStore.Delete(suite.ctx, postID, userID)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func (suite *ReactionStoreTestSuite) TestUpsertRefusesAMissingPost() {
+	t := suite.T()
+	userID := suite.createUser("reaction_lost")
+
+	err := suite.reactionStore.Upsert(suite.ctx, &models.Reaction{PostID: 987654, UserID: userID, Kind: "like"})
+	assert.ErrorIs(t, err, store.ErrForeignKeyViolation)
+}
+
+func TestReactionStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(ReactionStoreTestSuite))
+}
@@ -0,0 +1,189 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type SearchStore struct {
+	db *sql.DB
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes the wildcards of a like pattern match themselves, so the
+// user input is only ever taken literally
+func escapeLike(input string) string {
+	return likeEscaper.Replace(input)
+}
+
+// Posts ranks posts by full-text match on their title and content, trigram
+// similarity and exact tag hits. Reposts are left out since they have no
+// content of their own
+func (s *SearchStore) Posts(ctx context.Context, search *pagination.Search) ([]*models.Post, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select r.id, r.user_id, r.tittle, r."content", r.media_url, r.tags, r.created_at,
+			r.updated_at, r.username, r.first_name, r.last_name, r.rank
+		from (
+			select
+				p.id, p.user_id, p.tittle, p."content", p.media_url, p.tags, p.created_at,
+				p.updated_at, u.username, u.first_name, u.last_name,
+				(
+					ts_rank(to_tsvector('simple', p.tittle || ' ' || p."content"), plainto_tsquery('simple', $1))
+					+ greatest(similarity(p.tittle, $1), word_similarity($1, p."content"))
+					+ case when lower($1) = any(p.tags) then 1 else 0 end
+				)::float8 as rank
+			from post p
+			join "user" u on u.id = p.user_id
+			where p.repost_of_id is null
+				and (
+					to_tsvector('simple', p.tittle || ' ' || p."content") @@ plainto_tsquery('simple', $1)
+					or p.tittle % $1
+					or $1 <% p."content"
+					or lower($1) = any(p.tags)
+				)
+				and ($2::text is null or u.username = $2)
+				and ($3::text is null or $3 = any(p.tags))
+				and ($4::timestamptz is null or p.created_at >= $4)
+				and ($5::timestamptz is null or p.created_at < $5)
+				and ($6::boolean is null or (p.media_url is not null) = $6)
+		) r
+		where $7::float8 is null or (r.rank, r.id) < ($7, $8)
+		order by r.rank desc, r.id desc
+		limit $9;
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		search.Query,
+		search.Author,
+		search.Tag,
+		search.Since,
+		search.Until,
+		search.HasMedia,
+		search.CursorRank,
+		search.CursorID,
+		search.Limit+1,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var posts []*models.Post
+	var ranks []float64
+	for rows.Next() {
+		post := &models.Post{}
+		post.User = &models.User{}
+		var rank float64
+		err := rows.Scan(
+			&post.ID,
+			&post.User.ID,
+			&post.Tittle,
+			&post.Content,
+			&post.Media,
+			pq.Array(&post.Tags),
+			&post.CreatedAt,
+			&post.UpdatedAt,
+			&post.User.Username,
+			&post.User.FirstName,
+			&post.User.LastName,
+			&rank,
+		)
+		if err != nil {
+			return nil, "", errorPostTransform(err)
+		}
+		posts = append(posts, post)
+		ranks = append(ranks, rank)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(posts) > search.Limit {
+		nextCursor = search.EncodeCursor(ranks[search.Limit-1], posts[search.Limit-1].ID)
+		posts = posts[:search.Limit]
+	}
+
+	return posts, nextCursor, nil
+}
+
+// Users ranks activated users by trigram similarity of their username and
+// full name
+func (s *SearchStore) Users(ctx context.Context, search *pagination.Search) ([]*models.User, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select r.id, r.username, r.first_name, r.last_name, r.rank
+		from (
+			select
+				u.id, u.username, u.first_name, coalesce(u.last_name, '') as last_name,
+				greatest(
+					similarity(u.username::text, $1),
+					similarity(u.first_name || ' ' || coalesce(u.last_name, ''), $1)
+				)::float8 as rank
+			from "user" u
+			where u.is_active = true
+				and (
+					u.username::text % $1
+					or (u.first_name || ' ' || coalesce(u.last_name, '')) % $1
+					or u.username ilike ($5 || '%') escape '\'
+				)
+		) r
+		where $2::float8 is null or (r.rank, r.id) < ($2, $3)
+		order by r.rank desc, r.id desc
+		limit $4;
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		search.Query,
+		search.CursorRank,
+		search.CursorID,
+		search.Limit+1,
+		escapeLike(search.Query),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	var ranks []float64
+	for rows.Next() {
+		user := &models.User{}
+		var rank float64
+		err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.FirstName,
+			&user.LastName,
+			&rank,
+		)
+		if err != nil {
+			return nil, "", errorUserTransform(err)
+		}
+		users = append(users, user)
+		ranks = append(ranks, rank)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(users) > search.Limit {
+		nextCursor = search.EncodeCursor(ranks[search.Limit-1], users[search.Limit-1].ID)
+		users = users[:search.Limit]
+	}
+
+	return users, nextCursor, nil
+}
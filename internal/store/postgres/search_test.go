@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"momo":      "momo",
+		"100%":      `100\%`,
+		"snake_":    `snake\_`,
+		`back\`:     `back\\`,
+		`%_\`:       `\%\_\\`,
+		"":          "",
+		"hu tao ٩◔": "hu tao ٩◔",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, escapeLike(input), "input %q", input)
+	}
+}
+
+type SearchStoreTestSuite struct {
+	storeTestSuite
+	searchStore *SearchStore
+}
+
+func (suite *SearchStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.searchStore = &SearchStore{suite.db}
+}
+
+func (suite *SearchStoreTestSuite) TestUsersTakesWildcardsLiterally() {
+	t := suite.T()
+	suite.createUser("snake_case")
+
+	users, _, err := suite.searchStore.Users(suite.ctx, &pagination.Search{Query: "%", Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, users, "a lone wildcard does not list every user")
+
+	users, _, err = suite.searchStore.Users(suite.ctx, &pagination.Search{Query: "snake_", Limit: 10})
+	require.NoError(t, err)
+	var usernames []string
+	for _, user := range users {
+		usernames = append(usernames, user.Username)
+	}
+	assert.Contains(t, usernames, "snake_case", "an underscore matches itself")
+}
+
+func TestSearchStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(SearchStoreTestSuite))
+}
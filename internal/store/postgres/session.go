@@ -16,19 +16,21 @@ func (s *SessionStore) Create(ctx context.Context, session *models.Session) erro
 	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
 	defer cancel()
 	query := `
-		insert into "user_session" (id, user_id, expires_at)
-		values($1, $2, $3)
+		insert into "user_session" (id, user_id, expires_at, ip, user_agent)
+		values($1, $2, $3, $4, $5)
+		returning created_at, last_seen_at
 	`
-	result, err := s.db.ExecContext(ctx, query, session.ID, session.UserID, session.ExpiresAt)
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		session.ID,
+		session.UserID,
+		session.ExpiresAt,
+		session.IP,
+		session.UserAgent,
+	).Scan(&session.CreatedAt, &session.LastSeenAt)
 	if err != nil {
-		return err
-	}
-	count, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if count == 0 {
-		return err
+		return errorSessionTransform(err)
 	}
 	return nil
 }
@@ -37,7 +39,9 @@ func (s *SessionStore) Get(ctx context.Context, sessionID string) (*models.Sessi
 	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
 	defer cancel()
 	query := `
-		select user_session.id, user_session.user_id, user_session.expires_at
+		select
+			user_session.id, user_session.user_id, user_session.expires_at, user_session.created_at,
+			user_session.last_seen_at, user_session.ip, user_session.user_agent
 		from user_session
 		inner join "user" on "user".id = user_session.user_id
 		where user_session.id = $1
@@ -47,21 +51,73 @@ func (s *SessionStore) Get(ctx context.Context, sessionID string) (*models.Sessi
 		&session.ID,
 		&session.UserID,
 		&session.ExpiresAt,
+		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.IP,
+		&session.UserAgent,
 	)
 	if err != nil {
-		errorSessionTransform(err)
+		return nil, errorSessionTransform(err)
 	}
 	return &session, nil
 }
 
-func (s *SessionStore) UpdateExpires(ctx context.Context, session *models.Session) error {
+// GetFromUser returns the sessions of the user that did not expire, the most
+// recently used first
+func (s *SessionStore) GetFromUser(ctx context.Context, userID int64) ([]*models.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select id, user_id, expires_at, created_at, last_seen_at, ip, user_agent
+		from user_session
+		where user_id = $1 and expires_at > now()
+		order by last_seen_at desc
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errorSessionTransform(err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		var session models.Session
+		err := rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.ExpiresAt,
+			&session.CreatedAt,
+			&session.LastSeenAt,
+			&session.IP,
+			&session.UserAgent,
+		)
+		if err != nil {
+			return nil, errorSessionTransform(err)
+		}
+		sessions = append(sessions, &session)
+	}
+	return sessions, rows.Err()
+}
+
+// UpdateActivity saves when and from where the session was last used,
+// together with its expiration
+func (s *SessionStore) UpdateActivity(ctx context.Context, session *models.Session) error {
 	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
 	defer cancel()
 	query := `
-		update "user_session" set expires_at = $1 where id = $2
-		returning "user_session".user_id
+		update "user_session"
+		set expires_at = $2, last_seen_at = now(), ip = $3, user_agent = $4
+		where id = $1
+		returning last_seen_at
 	`
-	err := s.db.QueryRowContext(ctx, query, session.ExpiresAt, session.ID).Scan(&session.UserID)
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		session.ID,
+		session.ExpiresAt,
+		session.IP,
+		session.UserAgent,
+	).Scan(&session.LastSeenAt)
 	if err != nil {
 		return errorSessionTransform(err)
 	}
@@ -87,3 +143,35 @@ func (s *SessionStore) Delete(ctx context.Context, sessionID string) error {
 	}
 	return nil
 }
+
+// DeleteFromUser deletes the session only when it belongs to the user
+func (s *SessionStore) DeleteFromUser(ctx context.Context, userID int64, sessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		delete from user_session where id = $1 and user_id = $2
+	`
+	result, err := s.db.ExecContext(ctx, query, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteOthers deletes every session of the user except the one given
+func (s *SessionStore) DeleteOthers(ctx context.Context, userID int64, keepSessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		delete from user_session where user_id = $1 and id <> $2
+	`
+	_, err := s.db.ExecContext(ctx, query, userID, keepSessionID)
+	return errorSessionTransform(err)
+}
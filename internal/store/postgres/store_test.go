@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// storeTestSuite runs the migrations and the unit seed on a new postgres
+// container. The store suites embed it and build their store on db
+type storeTestSuite struct {
+	suite.Suite
+	pgContainer *testutils.PostgresTestContainer
+	db          *sql.DB
+	ctx         context.Context
+}
+
+func (suite *storeTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	pgContainer, err := testutils.CreatePostgresContainer(suite.ctx)
+	require.NoError(suite.T(), err)
+	suite.pgContainer = pgContainer
+	suite.db = testutils.NewPostgresConnection(pgContainer.ConnString)
+
+	driver, err := postgres.WithInstance(suite.db, &postgres.Config{})
+	require.NoError(suite.T(), err)
+
+	migrator, err := migrate.NewWithDatabaseInstance(migrationsPath, "postgres", driver)
+	require.NoError(suite.T(), err)
+
+	err = migrator.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		suite.T().Fatalf("could not apply up migrations, err: %s", err)
+	}
+
+	err = testutils.RunTestSeed(suite.db, unitSeedPath)
+	require.NoError(suite.T(), err, "could not seed test database")
+}
+
+func (suite *storeTestSuite) TearDownSuite() {
+	if err := suite.db.Close(); err != nil {
+		log.Fatalf("could not close db connection, error: %s", err)
+	}
+	if err := suite.pgContainer.Terminate(suite.ctx); err != nil {
+		log.Fatalf("could not terminating postgres container, error: %s", err)
+	}
+}
+
+// createUser adds an active user besides the seeded ones
+func (suite *storeTestSuite) createUser(username string) int64 {
+	var userID int64
+	query := `
+		insert into "user" (first_name, last_name, email, username, is_active, "password", role_id)
+		values ($1, $1, $1 || '@mail.com', $1, true, '123', 1)
+		returning id
+	`
+	err := suite.db.QueryRowContext(suite.ctx, query, username).Scan(&userID)
+	require.NoError(suite.T(), err, "could not create user")
+	return userID
+}
+
+// createPost adds a post of the user, a repost when repostOfID is not zero
+func (suite *storeTestSuite) createPost(userID int64, content string, repostOfID int64) int64 {
+	var postID int64
+	query := `
+		insert into post (tittle, "content", user_id, repost_of_id)
+		values ('', $1, $2, nullif($3::bigint, 0))
+		returning id
+	`
+	err := suite.db.QueryRowContext(suite.ctx, query, content, userID, repostOfID).Scan(&postID)
+	require.NoError(suite.T(), err, "could not create post")
+	return postID
+}
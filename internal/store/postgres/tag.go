@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type TagStore struct {
+	db *sql.DB
+}
+
+func (s *TagStore) GetPosts(ctx context.Context, tagPosts *pagination.TagPosts) ([]*models.Post, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select
+			p.id, p.user_id, p.tittle, p."content", p.media_url, p.tags, p.created_at,
+			p.updated_at, u.username, u.first_name, u.last_name
+		from post p
+		join "user" u on u.id = p.user_id
+		where p.tags @> array[$1]::varchar[]
+			and p.created_at < coalesce($2::timestamp, now())
+		order by p.created_at desc, p.id desc
+		limit $3;
+	`
+	rows, err := s.db.QueryContext(
+		ctx,
+		query,
+		tagPosts.Tag,
+		tagPosts.Cursor,
+		tagPosts.Limit+1,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var posts []*models.Post
+	for rows.Next() {
+		post := &models.Post{}
+		post.User = &models.User{}
+		err := rows.Scan(
+			&post.ID,
+			&post.User.ID,
+			&post.Tittle,
+			&post.Content,
+			&post.Media,
+			pq.Array(&post.Tags),
+			&post.CreatedAt,
+			&post.UpdatedAt,
+			&post.User.Username,
+			&post.User.FirstName,
+			&post.User.LastName,
+		)
+		if err != nil {
+			return nil, "", errorPostTransform(err)
+		}
+		posts = append(posts, post)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(posts) > tagPosts.Limit {
+		nextCursor = posts[tagPosts.Limit-1].CreatedAt.Format(time.RFC3339Nano)
+		posts = posts[:tagPosts.Limit]
+	}
+
+	return posts, nextCursor, nil
+}
+
+func (s *TagStore) GetTrending(ctx context.Context, limit int) ([]*models.TrendingTag, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select tag, post_count, user_count, computed_at
+		from trending_tag
+		order by user_count desc, post_count desc, tag
+		limit $1
+	`
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []*models.TrendingTag
+	for rows.Next() {
+		tag := &models.TrendingTag{}
+		err := rows.Scan(
+			&tag.Tag,
+			&tag.PostCount,
+			&tag.UserCount,
+			&tag.ComputedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// RefreshTrending recomputes the trending tags from the posts created since
+// the given time, replacing the previous ranking in a single transaction
+func (s *TagStore) RefreshTrending(ctx context.Context, since time.Time, size int) error {
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+		defer cancel()
+		if _, err := tx.ExecContext(ctx, `delete from trending_tag`); err != nil {
+			return err
+		}
+		query := `
+			insert into trending_tag (tag, post_count, user_count)
+			select t.tag, count(distinct p.id), count(distinct p.user_id)
+			from post p
+			cross join lateral unnest(p.tags) as t(tag)
+			where p.created_at >= $1 and p.repost_of_id is null
+			group by t.tag
+			order by count(distinct p.user_id) desc, count(distinct p.id) desc
+			limit $2
+		`
+		_, err := tx.ExecContext(ctx, query, since, size)
+		return err
+	})
+}
@@ -0,0 +1,244 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/store"
+)
+
+type TwoFactorStore struct {
+	db *sql.DB
+}
+
+func (s *TwoFactorStore) Get(ctx context.Context, userID int64) (*models.TwoFactor, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select user_id, secret, enabled_at, last_used_step, created_at
+		from "user_totp"
+		where user_id = $1
+	`
+	var twoFactor models.TwoFactor
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&twoFactor.UserID,
+		&twoFactor.Secret,
+		&twoFactor.EnabledAt,
+		&twoFactor.LastUsedStep,
+		&twoFactor.CreatedAt,
+	)
+	if err != nil {
+		return nil, errorTwoFactorTransform(err)
+	}
+	return &twoFactor, nil
+}
+
+// SaveSecret starts a new setup, replacing one that was never enabled. It
+// fails with store.ErrConflict when two-factor is already on
+func (s *TwoFactorStore) SaveSecret(ctx context.Context, userID int64, secret []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		insert into "user_totp" (user_id, secret)
+		values ($1, $2)
+		on conflict (user_id) do update
+		set secret = excluded.secret, last_used_step = null, created_at = now()
+		where "user_totp".enabled_at is null
+	`
+	result, err := s.db.ExecContext(ctx, query, userID, secret)
+	if err != nil {
+		return errorTwoFactorTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+// Enable turns the setup on and replaces the recovery codes of the user
+func (s *TwoFactorStore) Enable(ctx context.Context, userID int64, step int64, codeHashes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+			update "user_totp"
+			set enabled_at = now(), last_used_step = $2
+			where user_id = $1 and enabled_at is null
+		`
+		result, err := tx.ExecContext(ctx, query, userID, step)
+		if err != nil {
+			return errorTwoFactorTransform(err)
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return store.ErrNotFound
+		}
+
+		query = `delete from "user_recovery_code" where user_id = $1`
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return errorTwoFactorTransform(err)
+		}
+		query = `
+			insert into "user_recovery_code" (user_id, code_hash)
+			select $1, unnest($2::text[])
+		`
+		_, err = tx.ExecContext(ctx, query, userID, pq.Array(codeHashes))
+		return errorTwoFactorTransform(err)
+	})
+}
+
+func (s *TwoFactorStore) Disable(ctx context.Context, userID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `delete from "user_recovery_code" where user_id = $1`
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return errorTwoFactorTransform(err)
+		}
+		query = `delete from "user_totp" where user_id = $1`
+		result, err := tx.ExecContext(ctx, query, userID)
+		if err != nil {
+			return errorTwoFactorTransform(err)
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return store.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// UseStep records the time step of an accepted code. A code from the same
+// step, or an older one, fails with store.ErrConflict so it cannot be replayed
+func (s *TwoFactorStore) UseStep(ctx context.Context, userID int64, step int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "user_totp"
+		set last_used_step = $2
+		where user_id = $1
+			and enabled_at is not null
+			and (last_used_step is null or last_used_step < $2)
+	`
+	result, err := s.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return errorTwoFactorTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrConflict
+	}
+	return nil
+}
+
+// UseRecoveryCode spends one of the recovery codes of the user
+func (s *TwoFactorStore) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "user_recovery_code"
+		set used_at = now()
+		where user_id = $1 and code_hash = $2 and used_at is null
+	`
+	result, err := s.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return errorTwoFactorTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// CreatePendingLogin saves the pending login, removing the expired ones
+func (s *TwoFactorStore) CreatePendingLogin(ctx context.Context, pendingLogin *models.PendingLogin) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `delete from "pending_login" where expires_at < now()`
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return errorTwoFactorTransform(err)
+		}
+		query = `
+			insert into "pending_login" (token, user_id, expires_at)
+			values ($1, $2, $3)
+		`
+		_, err := tx.ExecContext(
+			ctx,
+			query,
+			pendingLogin.Token,
+			pendingLogin.User.ID,
+			pendingLogin.ExpiresAt,
+		)
+		return errorTwoFactorTransform(err)
+	})
+}
+
+func (s *TwoFactorStore) GetPendingLogin(ctx context.Context, token string) (*models.PendingLogin, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select pl.token, pl.attempts, pl.expires_at, u.id, u.username, u.email, u.first_name, u.last_name
+		from "pending_login" pl
+		join "user" u on u.id = pl.user_id
+		where pl.token = $1 and pl.expires_at > now()
+	`
+	pendingLogin := models.PendingLogin{User: &models.User{}}
+	err := s.db.QueryRowContext(ctx, query, token).Scan(
+		&pendingLogin.Token,
+		&pendingLogin.Attempts,
+		&pendingLogin.ExpiresAt,
+		&pendingLogin.User.ID,
+		&pendingLogin.User.Username,
+		&pendingLogin.User.Email,
+		&pendingLogin.User.FirstName,
+		&pendingLogin.User.LastName,
+	)
+	if err != nil {
+		return nil, errorTwoFactorTransform(err)
+	}
+	return &pendingLogin, nil
+}
+
+// AddPendingLoginAttempt counts a wrong code and returns how many were given
+func (s *TwoFactorStore) AddPendingLoginAttempt(ctx context.Context, token string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		update "pending_login" set attempts = attempts + 1
+		where token = $1
+		returning attempts
+	`
+	var attempts int
+	if err := s.db.QueryRowContext(ctx, query, token).Scan(&attempts); err != nil {
+		return 0, errorTwoFactorTransform(err)
+	}
+	return attempts, nil
+}
+
+func (s *TwoFactorStore) DeletePendingLogin(ctx context.Context, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `delete from "pending_login" where token = $1`
+	_, err := s.db.ExecContext(ctx, query, token)
+	return errorTwoFactorTransform(err)
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
 	"time"
 
 	"github.com/lib/pq"
@@ -203,6 +204,90 @@ func (s *UserStore) Unfollow(ctx context.Context, followerID int64, followedID i
 	return nil
 }
 
+// Block also removes the follows between both users
+func (s *UserStore) Block(ctx context.Context, blockerID int64, blockedID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		query := `
+			insert into "user_block"(blocker_id, blocked_id)
+			values ($1, $2)
+		`
+		if _, err := tx.ExecContext(ctx, query, blockerID, blockedID); err != nil {
+			return errorUserTransform(err)
+		}
+		query = `
+			delete from "follower"
+			where (follower_id = $1 and followed_id = $2)
+				or (follower_id = $2 and followed_id = $1)
+		`
+		_, err := tx.ExecContext(ctx, query, blockerID, blockedID)
+		return errorUserTransform(err)
+	})
+}
+
+func (s *UserStore) Unblock(ctx context.Context, blockerID int64, blockedID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		delete from "user_block"
+		where blocker_id = $1 and blocked_id = $2
+	`
+	result, err := s.db.ExecContext(ctx, query, blockerID, blockedID)
+	if err != nil {
+		return errorUserTransform(err)
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *UserStore) HasBlock(ctx context.Context, userID int64, otherIDs []int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select exists (
+			select 1 from "user_block"
+			where (blocker_id = $1 and blocked_id = any($2::bigint[]))
+				or (blocked_id = $1 and blocker_id = any($2::bigint[]))
+		)
+	`
+	var blocked bool
+	if err := s.db.QueryRowContext(ctx, query, userID, pq.Array(otherIDs)).Scan(&blocked); err != nil {
+		return false, errorUserTransform(err)
+	}
+	return blocked, nil
+}
+
+func (s *UserStore) GetFollowerIDs(ctx context.Context, userID int64) ([]int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	query := `
+		select follower_id from "follower"
+		where followed_id = $1
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, errorUserTransform(err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 func (s *UserStore) Activate(ctx context.Context, plainToken string) error {
 	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
 		user, err := s.getUserFromInvitationToken(ctx, tx, plainToken)
@@ -254,7 +339,7 @@ func (s *UserStore) GetProfile(ctx context.Context, username string) (*models.Us
 		from "user" u
 		left join user_profile up on up.user_id = u.id
 		left join follower f on (f.follower_id = u.id or f.followed_id = u.id)
-		left join post p on p.user_id = u.id
+		left join post p on p.user_id = u.id and p.repost_of_id is null
 		where username = $1
 		group by u.id, up.id;
 	`
@@ -289,15 +374,75 @@ func (s *UserStore) GetProfile(ctx context.Context, username string) (*models.Us
 	return &profile, nil
 }
 
-func (s *UserStore) GetPostsFrom(ctx context.Context, userPosts *pagination.UserPosts) ([]*models.Post, string, error) {
+// UpdateProfile saves the editable profile fields and bumps its updated_at,
+// creating the profile of users who still do not have one
+func (s *UserStore) UpdateProfile(ctx context.Context, userProfile *models.UserProfile) error {
+	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
+	defer cancel()
+	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
+		args := []any{
+			userProfile.User.ID,
+			userProfile.Description,
+			userProfile.AvatarURL,
+			userProfile.BannerURL,
+			userProfile.Location,
+			userProfile.UserLink,
+		}
+		query := `
+			update user_profile
+			set description = $2, avatar_url = $3, banner_url = $4, "location" = $5, user_link = $6, updated_at = now()
+			where user_id = $1
+			returning id, created_at, updated_at
+		`
+		err := tx.QueryRowContext(ctx, query, args...).Scan(
+			&userProfile.ID,
+			&userProfile.CreatedAt,
+			&userProfile.UpdatedAt,
+		)
+		if err != sql.ErrNoRows {
+			return errorUserTransform(err)
+		}
+
+		query = `
+			insert into user_profile (user_id, description, avatar_url, banner_url, "location", user_link)
+			values ($1, $2, $3, $4, $5, $6)
+			returning id, created_at, updated_at
+		`
+		err = tx.QueryRowContext(ctx, query, args...).Scan(
+			&userProfile.ID,
+			&userProfile.CreatedAt,
+			&userProfile.UpdatedAt,
+		)
+		return errorUserTransform(err)
+	})
+}
+
+// GetPostsFrom returns a page of the user's own posts with their comment count
+// and reactions, the viewer's reaction included
+func (s *UserStore) GetPostsFrom(ctx context.Context, userPosts *pagination.UserPosts) ([]*models.PostWithMetadata, string, error) {
 	ctx, cancel := context.WithTimeout(ctx, store.QueryTimeoutDuration)
 	defer cancel()
 
 	query := `
 		select p.id, p.tittle, p.content, p.media_url, p.tags, p.created_at,
-			   p.updated_at
+			   p.updated_at,
+			   (select count(*) from "comment" c where c.post_id = p.id) as comment_count,
+			   coalesce((
+				   select json_object_agg(rc.kind, rc.total)
+				   from (
+					   select pr.kind, count(*) as total
+					   from post_reaction pr
+					   where pr.post_id = p.id
+					   group by pr.kind
+				   ) rc
+			   ), '{}') as reaction_counts,
+			   coalesce((
+				   select pr.kind from post_reaction pr
+				   where pr.post_id = p.id and pr.user_id = $4
+			   ), '') as viewer_reaction
 		from post p
-		where p.user_id = $1 and p.created_at < coalesce($2::timestamp, now())
+		where p.user_id = $1 and p.repost_of_id is null
+			and p.created_at < coalesce($2::timestamp, now())
 		order by created_at desc
 		limit $3;
 	`
@@ -307,16 +452,18 @@ func (s *UserStore) GetPostsFrom(ctx context.Context, userPosts *pagination.User
 		userPosts.UserID,
 		userPosts.Cursor,
 		userPosts.Limit+1,
+		userPosts.ViewerID,
 	)
 	if err != nil {
 		return nil, "", err
 	}
 	defer rows.Close()
 
-	var posts []*models.Post
+	var posts []*models.PostWithMetadata
 	for rows.Next() {
-		post := &models.Post{}
+		post := &models.PostWithMetadata{}
 		post.User = &models.User{}
+		var reactionCounts []byte
 		err := rows.Scan(
 			&post.ID,
 			&post.Tittle,
@@ -325,10 +472,16 @@ func (s *UserStore) GetPostsFrom(ctx context.Context, userPosts *pagination.User
 			pq.Array(&post.Tags),
 			&post.CreatedAt,
 			&post.UpdatedAt,
+			&post.CommentCount,
+			&reactionCounts,
+			&post.Reactions.ViewerReaction,
 		)
 		if err != nil {
 			return nil, "", errorPostTransform(err)
 		}
+		if err := json.Unmarshal(reactionCounts, &post.Reactions.Counts); err != nil {
+			return nil, "", err
+		}
 		posts = append(posts, post)
 	}
 	if err = rows.Err(); err != nil {
@@ -344,8 +497,9 @@ func (s *UserStore) GetPostsFrom(ctx context.Context, userPosts *pagination.User
 	return posts, nextCursor, nil
 }
 
-// Deletes unconfirmed user accounts whose invitation has expired.
-func (s *UserStore) CleanUpExpiredPendingAccounts(ctx context.Context) error {
+// Deletes unconfirmed user accounts whose invitation expired before the given
+// time.
+func (s *UserStore) CleanUpExpiredPendingAccounts(ctx context.Context, expiredBefore time.Time) error {
 	return store.WithTx(ctx, s.db, func(tx *sql.Tx) error {
 		query := `
 			SELECT ui.user_id
@@ -354,7 +508,7 @@ func (s *UserStore) CleanUpExpiredPendingAccounts(ctx context.Context) error {
 			WHERE ui.expired < $1
 			  AND u.is_active = false;
 		`
-		rows, err := tx.QueryContext(ctx, query, time.Now())
+		rows, err := tx.QueryContext(ctx, query, expiredBefore)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/mochaeng/sapphire-backend/internal/models/pagination"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserPostsStoreTestSuite struct {
+	storeTestSuite
+	userStore     *UserStore
+	reactionStore *ReactionStore
+}
+
+func (suite *UserPostsStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.userStore = &UserStore{suite.db}
+	suite.reactionStore = &ReactionStore{suite.db}
+}
+
+func (suite *UserPostsStoreTestSuite) TestReturnsTheReactionSummaries() {
+	t := suite.T()
+	authorID := suite.createUser("posts_author")
+	viewerID := suite.createUser("posts_viewer")
+	otherID := suite.createUser("posts_other")
+	postID := suite.createPost(authorID, "reacted post", 0)
+
+	for userID, kind := range map[int64]string{viewerID: "love", otherID: "like"} {
+		reaction := &models.Reaction{PostID: postID, UserID: userID, Kind: kind}
+		require.NoError(t, suite.reactionStore.Upsert(suite.ctx, reaction))
+	}
+	_, err := suite.db.ExecContext(
+		suite.ctx, `insert into comment (post_id, user_id, content) values ($1, $2, 'nice')`, postID, otherID,
+	)
+	require.NoError(t, err)
+
+	userPosts := &pagination.UserPosts{UserID: authorID, ViewerID: viewerID, Limit: pagination.ProfileLimitDefault}
+	posts, _, err := suite.userStore.GetPostsFrom(suite.ctx, userPosts)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, map[string]int{"love": 1, "like": 1}, posts[0].Reactions.Counts)
+	assert.Equal(t, "love", posts[0].Reactions.ViewerReaction)
+	assert.Equal(t, 1, posts[0].CommentCount)
+
+	userPosts.ViewerID = 0
+	posts, _, err = suite.userStore.GetPostsFrom(suite.ctx, userPosts)
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Empty(t, posts[0].Reactions.ViewerReaction, "anonymous viewers have no reaction")
+}
+
+func TestUserPostsStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(UserPostsStoreTestSuite))
+}
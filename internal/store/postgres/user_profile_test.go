@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type UserProfileStoreTestSuite struct {
+	storeTestSuite
+	userStore *UserStore
+}
+
+func (suite *UserProfileStoreTestSuite) SetupSuite() {
+	suite.storeTestSuite.SetupSuite()
+	suite.userStore = &UserStore{suite.db}
+}
+
+func (suite *UserProfileStoreTestSuite) TestCreatesTheMissingProfile() {
+	t := suite.T()
+	userID := suite.createUser("profileless")
+
+	profile := &models.UserProfile{
+		User:        &models.User{ID: userID},
+		Description: "director of the wangsheng funeral parlor",
+		AvatarURL:   "data/avatar.png",
+	}
+	require.NoError(t, suite.userStore.UpdateProfile(suite.ctx, profile), "could not create profile")
+	assert.NotZero(t, profile.ID)
+
+	saved, err := suite.userStore.GetProfile(suite.ctx, "profileless")
+	require.NoError(t, err)
+	assert.Equal(t, "director of the wangsheng funeral parlor", saved.Description)
+	assert.Equal(t, "data/avatar.png", saved.AvatarURL)
+}
+
+func (suite *UserProfileStoreTestSuite) TestUpdatesTheExistingProfile() {
+	t := suite.T()
+	userID := suite.createUser("profiled")
+
+	profile := &models.UserProfile{User: &models.User{ID: userID}, Location: "liyue"}
+	require.NoError(t, suite.userStore.UpdateProfile(suite.ctx, profile))
+	createdID := profile.ID
+
+	profile.Description = "77th director"
+	require.NoError(t, suite.userStore.UpdateProfile(suite.ctx, profile), "could not update profile")
+	assert.Equal(t, createdID, profile.ID, "the profile is updated in place")
+	assert.NotEmpty(t, profile.UpdatedAt)
+
+	saved, err := suite.userStore.GetProfile(suite.ctx, "profiled")
+	require.NoError(t, err)
+	assert.Equal(t, "77th director", saved.Description)
+	assert.Equal(t, "liyue", saved.Location)
+}
+
+func TestUserProfileStoreTestSuite(t *testing.T) {
+	suite.Run(t, new(UserProfileStoreTestSuite))
+}
@@ -25,6 +25,9 @@ type Store struct {
 		GetByIDWithUser(context.Context, int64) (*models.Post, error)
 		DeleteByID(context.Context, int64) error
 		UpdateByID(context.Context, *models.Post) error
+
+		// DeleteRepost removes the repost the user made of the post
+		DeleteRepost(ctx context.Context, userID int64, postID int64) error
 	}
 	User interface {
 		GetByID(context.Context, int64) (*models.User, error)
@@ -40,12 +43,48 @@ type Store struct {
 		GetByActivatedEmail(ctx context.Context, email string) (*models.User, error)
 		Follow(ctx context.Context, followerID int64, followedID int64) error
 		Unfollow(ctx context.Context, followerID int64, followedID int64) error
+		GetFollowerIDs(ctx context.Context, userID int64) ([]int64, error)
+		Block(ctx context.Context, blockerID int64, blockedID int64) error
+		Unblock(ctx context.Context, blockerID int64, blockedID int64) error
+
+		// HasBlock reports whether the user blocked, or was blocked by, any of
+		// the other users
+		HasBlock(ctx context.Context, userID int64, otherIDs []int64) (bool, error)
 		CreateAndInvite(ctx context.Context, userInvitation *models.UserInvitation, userProfile *models.UserProfile) error
 		Activate(ctx context.Context, plainToken string) error
+		CreatePasswordReset(ctx context.Context, passwordReset *models.PasswordReset) error
+
+		// ResetPassword fills the user with the owner of the token and saves
+		// its new password hash
+		ResetPassword(ctx context.Context, plainToken string, user *models.User) error
+
+		// UpdatePasswordHash replaces the password hash of the user with one
+		// of the same password, as long as it is still oldHash
+		UpdatePasswordHash(ctx context.Context, userID int64, oldHash []byte, newHash []byte) error
+		CreateEmailChange(ctx context.Context, emailChange *models.EmailChange) error
+
+		// ConfirmEmailChange fills the user with the owner of the token and
+		// moves it to the new address
+		ConfirmEmailChange(ctx context.Context, plainToken string, user *models.User) error
+		CreateMagicLink(ctx context.Context, magicLink *models.MagicLink) error
+
+		// UseMagicLink fills the user with the active owner of the hashed
+		// token, which has to come from the same browser. The link is used up
+		UseMagicLink(ctx context.Context, token string, browser string, user *models.User) error
+		GetMagicLinkEnabled(ctx context.Context, userID int64) (bool, error)
+
+		// SetMagicLinkEnabled saves if the user accepts sign in links, turning
+		// them off deletes the links already sent
+		SetMagicLinkEnabled(ctx context.Context, userID int64, isEnabled bool) error
 		Delete(ctx context.Context, userID int64) error
 		GetProfile(ctx context.Context, username string) (*models.UserProfile, error)
-		GetPostsFrom(ctx context.Context, userPosts *pagination.UserPosts) ([]*models.Post, string, error)
-		CleanUpExpiredPendingAccounts(ctx context.Context) error
+		UpdateProfile(ctx context.Context, userProfile *models.UserProfile) error
+		GetPostsFrom(ctx context.Context, userPosts *pagination.UserPosts) ([]*models.PostWithMetadata, string, error)
+		// CleanUpExpiredPendingAccounts deletes the accounts never activated
+		// whose invitation expired before the given time
+		CleanUpExpiredPendingAccounts(ctx context.Context, expiredBefore time.Time) error
+		GetPendingInvitation(ctx context.Context, email string) (*models.UserInvitation, error)
+		RotateInvitation(ctx context.Context, userInvitation *models.UserInvitation) error
 
 		// this function should only be called during seed
 		CreateProfileFull(ctx context.Context, userProfile *models.UserProfile) error
@@ -55,20 +94,139 @@ type Store struct {
 	Feed interface {
 		Get(ctx context.Context, userID int64, paginateQuery pagination.PaginateFeedQuery) ([]*models.PostWithMetadata, string, error)
 	}
+	TwoFactor interface {
+		Get(ctx context.Context, userID int64) (*models.TwoFactor, error)
+		SaveSecret(ctx context.Context, userID int64, secret []byte) error
+		Enable(ctx context.Context, userID int64, step int64, codeHashes []string) error
+		Disable(ctx context.Context, userID int64) error
+		UseStep(ctx context.Context, userID int64, step int64) error
+		UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+		CreatePendingLogin(ctx context.Context, pendingLogin *models.PendingLogin) error
+		GetPendingLogin(ctx context.Context, token string) (*models.PendingLogin, error)
+		AddPendingLoginAttempt(ctx context.Context, token string) (int, error)
+		DeletePendingLogin(ctx context.Context, token string) error
+	}
+	Passkey interface {
+		CreateChallenge(ctx context.Context, challenge *models.PasskeyChallenge) error
+		ConsumeChallenge(ctx context.Context, challenge []byte, kind string) (*models.PasskeyChallenge, error)
+		Create(ctx context.Context, passkey *models.Passkey) error
+		GetByCredentialID(ctx context.Context, credentialID []byte) (*models.Passkey, error)
+		GetFromUser(ctx context.Context, userID int64) ([]*models.Passkey, error)
+		UpdateSignCount(ctx context.Context, passkeyID int64, signCount int64) error
+		Rename(ctx context.Context, userID int64, passkeyID int64, name string) error
+		Delete(ctx context.Context, userID int64, passkeyID int64) error
+	}
+	AccessToken interface {
+		Create(ctx context.Context, accessToken *models.AccessToken, tokenHash string) error
+		GetByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error)
+		GetFromUser(ctx context.Context, userID int64) ([]*models.AccessToken, error)
+		UpdateLastUsed(ctx context.Context, accessTokenID int64) error
+		Delete(ctx context.Context, userID int64, accessTokenID int64) error
+	}
 	Session interface {
 		Create(ctx context.Context, session *models.Session) error
 		Get(ctx context.Context, sessionID string) (*models.Session, error)
-		UpdateExpires(ctx context.Context, session *models.Session) error
+		GetFromUser(ctx context.Context, userID int64) ([]*models.Session, error)
+		UpdateActivity(ctx context.Context, session *models.Session) error
 		Delete(ctx context.Context, sessionID string) error
+		DeleteFromUser(ctx context.Context, userID int64, sessionID string) error
+		DeleteOthers(ctx context.Context, userID int64, keepSessionID string) error
 	}
 	Comment interface {
 		GetByPostID(context.Context, int64) (*[]models.Comment, error)
 		Create(context.Context, *models.Comment) error
+		GetByID(context.Context, int64) (*models.Comment, error)
+		UpdateByID(context.Context, *models.Comment) error
+		DeleteByID(context.Context, int64) error
+
+		// GetThread returns a page of comments under a post whose parent is
+		// postComments.ParentID, or the top-level ones when it is not valid
+		GetThread(ctx context.Context, postComments *pagination.PostComments) ([]*models.Comment, string, error)
+	}
+	// LoginFailure counts failed sign ins, failures are forgotten once the
+	// window passed since the last one
+	LoginFailure interface {
+		Get(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error)
+		Add(ctx context.Context, key string, window time.Duration) (*models.LoginFailure, error)
+		Refund(ctx context.Context, key string) error
+		Delete(ctx context.Context, key string) error
+		DeleteOlderThan(ctx context.Context, before time.Time) error
 	}
 	OAuth interface {
-		CreateWithUserActivation(ctx context.Context, oauthAccount *models.OAuthAccount, user *models.User) error
+		Create(ctx context.Context, oauthAccount *models.OAuthAccount) error
 		CreateWithUser(ctx context.Context, oauthAccount *models.OAuthAccount, user *models.User, userProfile *models.UserProfile) error
 		GetUserID(ctx context.Context, provider, providerUserID string) (*int64, error)
+		GetFromUser(ctx context.Context, userID int64) ([]*models.OAuthAccount, error)
+		Delete(ctx context.Context, userID int64, provider, providerUserID string) error
+	}
+	Reaction interface {
+		// Upsert creates the user's reaction on a post or replaces its kind
+		Upsert(ctx context.Context, reaction *models.Reaction) error
+		Delete(ctx context.Context, postID int64, userID int64) error
+		GetSummary(ctx context.Context, postID int64, viewerID int64) (*models.ReactionSummary, error)
+	}
+	Bookmark interface {
+		// Create keeps the existing bookmark when the post was already saved
+		Create(ctx context.Context, bookmark *models.Bookmark) error
+		Delete(ctx context.Context, userID int64, postID int64) error
+
+		// GetFromUser returns a page of the user's bookmarks, the most recently
+		// saved first. Bookmarks of deleted posts are removed along with them
+		GetFromUser(ctx context.Context, userBookmarks *pagination.UserBookmarks) ([]*models.Bookmark, string, error)
+	}
+	Search interface {
+		Posts(ctx context.Context, search *pagination.Search) ([]*models.Post, string, error)
+		Users(ctx context.Context, search *pagination.Search) ([]*models.User, string, error)
+	}
+	Tag interface {
+		GetPosts(ctx context.Context, tagPosts *pagination.TagPosts) ([]*models.Post, string, error)
+		GetTrending(ctx context.Context, limit int) ([]*models.TrendingTag, error)
+
+		// RefreshTrending should only be called by the trending tags job
+		RefreshTrending(ctx context.Context, since time.Time, size int) error
+	}
+	Mention interface {
+		// SetForPost makes the post mentions match the usernames, returning
+		// the users that were actually mentioned
+		SetForPost(ctx context.Context, postID int64, authorID int64, usernames []string) ([]models.Mention, error)
+		SetForComment(ctx context.Context, commentID int64, authorID int64, usernames []string) ([]models.Mention, error)
+		GetByPost(ctx context.Context, postID int64) ([]models.Mention, error)
+		GetMentionsOf(ctx context.Context, userMentions *pagination.UserMentions) ([]*models.UserMention, string, error)
+	}
+	Conversation interface {
+		// Create reuses the 1:1 conversation that already exists for the
+		// same direct key, filling the conversation with it
+		Create(ctx context.Context, conversation *models.Conversation, participantIDs []int64) error
+		GetByID(ctx context.Context, conversationID int64) (*models.Conversation, error)
+
+		// GetFromUser returns a page of the user's conversations, the most
+		// recently active first
+		GetFromUser(ctx context.Context, userConversations *pagination.UserConversations) ([]*models.Conversation, string, error)
+		CreateMessage(ctx context.Context, message *models.Message) error
+		GetMessages(ctx context.Context, conversationMessages *pagination.ConversationMessages) ([]*models.Message, string, error)
+		MarkRead(ctx context.Context, conversationID int64, userID int64) error
+	}
+	Notification interface {
+		// Create records the notification unless the user already got one
+		// with the same dedupe key, in which case its ID is left as zero
+		Create(ctx context.Context, notification *models.Notification) error
+
+		// GetGroups returns a page of the user's notifications grouped by
+		// their group key, the most recently updated group first
+		GetGroups(ctx context.Context, userNotifications *pagination.UserNotifications) ([]*models.NotificationGroup, string, error)
+		CountUnread(ctx context.Context, userID int64) (int, error)
+
+		// MarkGroupRead marks as read every notification in the same group as
+		// the given one
+		MarkGroupRead(ctx context.Context, userID int64, notificationID int64) error
+		MarkAllRead(ctx context.Context, userID int64) error
+	}
+	AuditEvent interface {
+		Create(ctx context.Context, auditEvent *models.AuditEvent) error
+
+		// Get returns a page of the events matching the filters, the newest
+		// first
+		Get(ctx context.Context, auditEvents *pagination.AuditEvents) ([]*models.AuditEvent, string, error)
 	}
 }
 
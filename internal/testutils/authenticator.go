@@ -0,0 +1,170 @@
+package testutils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+)
+
+// Authenticator is a software passkey for tests. It answers the WebAuthn
+// ceremonies the way a browser would, signing with an ES256 key
+type Authenticator struct {
+	RPID         string
+	Origin       string
+	CredentialID []byte
+	UserHandle   []byte
+	// SignCount is bumped before every assertion
+	SignCount uint32
+	// AssertionFlags are the authenticator data flags of the assertions, user
+	// present and verified when left as zero
+	AssertionFlags byte
+	key            *ecdsa.PrivateKey
+}
+
+func NewAuthenticator(rpID string, origin string) (*Authenticator, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		return nil, err
+	}
+	return &Authenticator{
+		RPID:         rpID,
+		Origin:       origin,
+		CredentialID: credentialID,
+		key:          key,
+	}, nil
+}
+
+// Register creates the credential for the challenge of a registration
+func (a *Authenticator) Register(challenge []byte) payloads.PasskeyAttestationPayload {
+	clientData := a.clientData("webauthn.create", challenge)
+
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+	publicKey := cborMap(
+		cborInt(1), cborInt(2),
+		cborInt(3), cborInt(-7),
+		cborInt(-1), cborInt(1),
+		cborInt(-2), cborBytes(x),
+		cborInt(-3), cborBytes(y),
+	)
+
+	// attested credential data: aaguid, credential id length and id, key
+	attested := make([]byte, 18)
+	binary.BigEndian.PutUint16(attested[16:], uint16(len(a.CredentialID)))
+	attested = append(attested, a.CredentialID...)
+	attested = append(attested, publicKey...)
+	authData := append(a.authenticatorData(0x45), attested...)
+
+	attestationObject := cborMap(
+		cborText("fmt"), cborText("none"),
+		cborText("attStmt"), cborMap(),
+		cborText("authData"), cborBytes(authData),
+	)
+	return payloads.PasskeyAttestationPayload{
+		ID:   encode(a.CredentialID),
+		Type: "public-key",
+		Response: payloads.PasskeyAttestationResponse{
+			ClientDataJSON:    encode(clientData),
+			AttestationObject: encode(attestationObject),
+		},
+	}
+}
+
+// Login signs the challenge of a sign in
+func (a *Authenticator) Login(challenge []byte) payloads.PasskeyAssertionPayload {
+	a.SignCount++
+	clientData := a.clientData("webauthn.get", challenge)
+	flags := a.AssertionFlags
+	if flags == 0 {
+		flags = 0x05
+	}
+	authData := a.authenticatorData(flags)
+
+	clientDataHash := sha256.Sum256(clientData)
+	signed := sha256.Sum256(append(authData, clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, signed[:])
+	if err != nil {
+		panic(err)
+	}
+	return payloads.PasskeyAssertionPayload{
+		ID:   encode(a.CredentialID),
+		Type: "public-key",
+		Response: payloads.PasskeyAssertionResponse{
+			ClientDataJSON:    encode(clientData),
+			AuthenticatorData: encode(authData),
+			Signature:         encode(signature),
+			UserHandle:        encode(a.UserHandle),
+		},
+	}
+}
+
+func (a *Authenticator) clientData(ceremony string, challenge []byte) []byte {
+	clientData, _ := json.Marshal(map[string]any{
+		"type":        ceremony,
+		"challenge":   encode(challenge),
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	return clientData
+}
+
+func (a *Authenticator) authenticatorData(flags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	authData := append(rpIDHash[:], flags, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(authData[33:], a.SignCount)
+	return authData
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func cborHeader(major byte, value uint64) []byte {
+	switch {
+	case value < 24:
+		return []byte{major<<5 | byte(value)}
+	case value <= 0xff:
+		return []byte{major<<5 | 24, byte(value)}
+	case value <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(value))
+	case value <= 0xffffffff:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(value))
+	}
+	return binary.BigEndian.AppendUint64([]byte{major<<5 | 27}, value)
+}
+
+func cborInt(value int64) []byte {
+	if value < 0 {
+		return cborHeader(1, uint64(-1-value))
+	}
+	return cborHeader(0, uint64(value))
+}
+
+func cborBytes(value []byte) []byte {
+	return append(cborHeader(2, uint64(len(value))), value...)
+}
+
+func cborText(value string) []byte {
+	return append(cborHeader(3, uint64(len(value))), value...)
+}
+
+// cborMap encodes its arguments as key and value pairs, in the given order
+func cborMap(items ...[]byte) []byte {
+	encoded := cborHeader(5, uint64(len(items)/2))
+	for _, item := range items {
+		encoded = append(encoded, item...)
+	}
+	return encoded
+}
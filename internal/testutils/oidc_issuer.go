@@ -0,0 +1,104 @@
+package testutils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+// OIDCIssuer is a local OpenID Connect issuer for tests. It serves the
+// discovery document, exchanges Code for tokens and answers the userinfo
+// endpoint for a single user. The id_token is not signed
+type OIDCIssuer struct {
+	Server   *httptest.Server
+	ClientID string
+	Code     string
+	Subject  string
+	Email    string
+	Name     string
+}
+
+const oidcAccessToken = "issuer-access-token"
+
+func NewOIDCIssuer(clientID string) *OIDCIssuer {
+	issuer := &OIDCIssuer{
+		ClientID: clientID,
+		Code:     "issuer-code",
+		Subject:  "issuer-user-1",
+		Email:    "hutao@sapphire.com",
+		Name:     "Hu Tao",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", issuer.discoveryHandler)
+	mux.HandleFunc("POST /token", issuer.tokenHandler)
+	mux.HandleFunc("GET /userinfo", issuer.userInfoHandler)
+	issuer.Server = httptest.NewServer(mux)
+	return issuer
+}
+
+func (i *OIDCIssuer) URL() string {
+	return i.Server.URL
+}
+
+func (i *OIDCIssuer) DiscoveryURL() string {
+	return i.Server.URL + "/.well-known/openid-configuration"
+}
+
+func (i *OIDCIssuer) Close() {
+	i.Server.Close()
+}
+
+func (i *OIDCIssuer) discoveryHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{
+		"issuer":                 i.URL(),
+		"authorization_endpoint": i.URL() + "/authorize",
+		"token_endpoint":         i.URL() + "/token",
+		"userinfo_endpoint":      i.URL() + "/userinfo",
+	})
+}
+
+func (i *OIDCIssuer) tokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != i.Code {
+		w.WriteHeader(http.StatusBadRequest)
+		writeJSON(w, map[string]any{"error": "invalid_grant"})
+		return
+	}
+
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   i.URL(),
+		"aud":   i.ClientID,
+		"sub":   i.Subject,
+		"email": i.Email,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	})
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	idToken := header + "." + base64.RawURLEncoding.EncodeToString(claims) + "."
+
+	writeJSON(w, map[string]any{
+		"access_token": oidcAccessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func (i *OIDCIssuer) userInfoHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+oidcAccessToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"sub":            i.Subject,
+		"email":          i.Email,
+		"email_verified": true,
+		"name":           i.Name,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(data)
+}
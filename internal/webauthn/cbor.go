@@ -0,0 +1,133 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// maxCBORDepth bounds the nesting the decoder accepts, authenticator data is
+// never more than a few levels deep
+const maxCBORDepth = 8
+
+// maxCBORSize bounds the input, attestation objects stay far below it even
+// with their certificates
+const maxCBORSize = 64 << 10
+
+var ErrInvalidCBOR = errors.New("invalid cbor")
+
+// decodeCBOR decodes the first CBOR item of data and returns it with the
+// number of bytes it took. It only knows the subset WebAuthn uses: integers,
+// byte and text strings, arrays, maps and the simple values, all with definite
+// lengths. Integers are returned as int64, maps as map[any]any
+func decodeCBOR(data []byte) (any, int, error) {
+	if len(data) > maxCBORSize {
+		return nil, 0, ErrInvalidCBOR
+	}
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, int, error) {
+	if depth > maxCBORDepth || len(data) == 0 {
+		return nil, 0, ErrInvalidCBOR
+	}
+	major := data[0] >> 5
+	argument, offset, err := decodeCBORArgument(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch major {
+	case 0:
+		if argument > 1<<63-1 {
+			return nil, 0, ErrInvalidCBOR
+		}
+		return int64(argument), offset, nil
+	case 1:
+		if argument > 1<<63-1 {
+			return nil, 0, ErrInvalidCBOR
+		}
+		return -1 - int64(argument), offset, nil
+	case 2, 3:
+		if argument > uint64(len(data)-offset) {
+			return nil, 0, ErrInvalidCBOR
+		}
+		end := offset + int(argument)
+		if major == 3 {
+			return string(data[offset:end]), end, nil
+		}
+		value := make([]byte, argument)
+		copy(value, data[offset:end])
+		return value, end, nil
+	case 4:
+		// every item takes at least one byte
+		if argument > uint64(len(data)-offset) {
+			return nil, 0, ErrInvalidCBOR
+		}
+		items := make([]any, argument)
+		for idx := range items {
+			item, size, err := decodeCBORItem(data[offset:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			items[idx] = item
+			offset += size
+		}
+		return items, offset, nil
+	case 5:
+		if argument > uint64(len(data)-offset)/2 {
+			return nil, 0, ErrInvalidCBOR
+		}
+		items := make(map[any]any, argument)
+		for range argument {
+			key, size, err := decodeCBORItem(data[offset:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += size
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, 0, ErrInvalidCBOR
+			}
+			if _, ok := items[key]; ok {
+				return nil, 0, ErrInvalidCBOR
+			}
+			value, size, err := decodeCBORItem(data[offset:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += size
+			items[key] = value
+		}
+		return items, offset, nil
+	case 7:
+		switch data[0] & 0x1f {
+		case 20:
+			return false, offset, nil
+		case 21:
+			return true, offset, nil
+		case 22:
+			return nil, offset, nil
+		}
+	}
+	return nil, 0, ErrInvalidCBOR
+}
+
+// decodeCBORArgument reads the length or value that follows the type of an
+// item and returns it with the size of the header
+func decodeCBORArgument(data []byte) (uint64, int, error) {
+	info := data[0] & 0x1f
+	switch {
+	case info < 24:
+		return uint64(info), 1, nil
+	case info == 24 && len(data) >= 2:
+		return uint64(data[1]), 2, nil
+	case info == 25 && len(data) >= 3:
+		return uint64(binary.BigEndian.Uint16(data[1:])), 3, nil
+	case info == 26 && len(data) >= 5:
+		return uint64(binary.BigEndian.Uint32(data[1:])), 5, nil
+	case info == 27 && len(data) >= 9:
+		return binary.BigEndian.Uint64(data[1:]), 9, nil
+	}
+	return 0, 0, ErrInvalidCBOR
+}
@@ -0,0 +1,144 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeHex(t testing.TB, value string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(value)
+	require.NoError(t, err)
+	return data
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected any
+	}{
+		{"small integer", "17", int64(23)},
+		{"integer with a byte", "1818", int64(24)},
+		{"largest integer", "1b7fffffffffffffff", int64(1<<63 - 1)},
+		{"negative integer", "3863", int64(-100)},
+		{"byte string", "43010203", []byte{1, 2, 3}},
+		{"text string", "6461757468", "auth"},
+		{"array", "83010203", []any{int64(1), int64(2), int64(3)}},
+		{"map", "a201026161f5", map[any]any{int64(1): int64(2), "a": true}},
+		{"simple values", "82f4f6", []any{false, nil}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := decodeHex(t, test.data)
+			value, size, err := decodeCBOR(data)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, value)
+			assert.Equal(t, len(data), size)
+		})
+	}
+
+	t.Run("stops after the first item", func(t *testing.T) {
+		value, size, err := decodeCBOR(decodeHex(t, "0102"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), value)
+		assert.Equal(t, 1, size)
+	})
+}
+
+func TestDecodeCBORRefusesInvalidInput(t *testing.T) {
+	t.Run("truncated", func(t *testing.T) {
+		data := decodeHex(t, "a3016161024362797403826161190100")
+		_, size, err := decodeCBOR(data)
+		require.NoError(t, err)
+		require.Equal(t, len(data), size)
+
+		for end := range len(data) {
+			_, _, err := decodeCBOR(data[:end])
+			assert.ErrorIs(t, err, ErrInvalidCBOR, "%x", data[:end])
+		}
+	})
+
+	t.Run("nested too deep", func(t *testing.T) {
+		deepest := append(bytes.Repeat([]byte{0x81}, maxCBORDepth), 0x00)
+		_, _, err := decodeCBOR(deepest)
+		assert.NoError(t, err, "the maximum depth is accepted")
+
+		tooDeep := append(bytes.Repeat([]byte{0x81}, maxCBORDepth+1), 0x00)
+		_, _, err = decodeCBOR(tooDeep)
+		assert.ErrorIs(t, err, ErrInvalidCBOR)
+
+		mapsTooDeep := append(bytes.Repeat([]byte{0xa1, 0x01}, maxCBORDepth+1), 0x00)
+		_, _, err = decodeCBOR(mapsTooDeep)
+		assert.ErrorIs(t, err, ErrInvalidCBOR)
+	})
+
+	t.Run("oversized", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data string
+		}{
+			{"byte string longer than the input", "5affffffff00"},
+			{"text string longer than the input", "7b7fffffffffffffff61"},
+			{"array with more items than bytes", "9affffffff00"},
+			{"map with more items than bytes", "bbffffffffffffffff0000"},
+			{"integer out of range", "1bffffffffffffffff"},
+			{"negative integer out of range", "3bffffffffffffffff"},
+		}
+		for _, test := range tests {
+			_, _, err := decodeCBOR(decodeHex(t, test.data))
+			assert.ErrorIs(t, err, ErrInvalidCBOR, test.name)
+		}
+
+		large := append([]byte{0x5a, 0x00, 0x01, 0x00, 0x00}, make([]byte, maxCBORSize)...)
+		_, _, err := decodeCBOR(large)
+		assert.ErrorIs(t, err, ErrInvalidCBOR, "input larger than the limit")
+	})
+
+	t.Run("outside the subset", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data string
+		}{
+			{"empty", ""},
+			{"indefinite byte string", "5f4101ff"},
+			{"indefinite array", "9f01ff"},
+			{"reserved length", "1c"},
+			{"tag", "c074323031332d30332d32315432303a30343a30305a"},
+			{"float", "f93c00"},
+			{"duplicate map key", "a201010102"},
+			{"array as map key", "a1800000"},
+		}
+		for _, test := range tests {
+			_, _, err := decodeCBOR(decodeHex(t, test.data))
+			assert.ErrorIs(t, err, ErrInvalidCBOR, test.name)
+		}
+	})
+}
+
+func FuzzDecodeCBOR(f *testing.F) {
+	for _, seed := range []string{
+		"17", "3863", "43010203", "6461757468", "83010203", "a201026161f5",
+		"a3016161024362797403826161190100", "5affffffff00", "9f01ff",
+	} {
+		f.Add(decodeHex(f, seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, size, err := decodeCBOR(data)
+		if err != nil {
+			return
+		}
+		if size <= 0 || size > len(data) {
+			t.Fatalf("decoded %d bytes of %d", size, len(data))
+		}
+		// the item does not depend on what follows it
+		_, again, err := decodeCBOR(data[:size])
+		if err != nil || again != size {
+			t.Fatalf("the item alone decoded %d bytes, err %v", again, err)
+		}
+	})
+}
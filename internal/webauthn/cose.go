@@ -0,0 +1,112 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// COSE algorithms a passkey may be created with, in order of preference
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+var Algorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1
+	coseX         = -2
+	coseY         = -3
+	// RSA keys use their own labels
+	coseRSAModulus  = -1
+	coseRSAExponent = -2
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+
+	// RSA keys from 2048 to 4096 bits are accepted, larger ones only make
+	// checking signatures slower
+	minRSAModulusSize = 256
+	maxRSAModulusSize = 512
+)
+
+var ErrUnsupportedKey = errors.New("public key type is not supported")
+
+type publicKey struct {
+	verify func(message, signature []byte) bool
+}
+
+func parsePublicKey(data []byte) (*publicKey, error) {
+	decoded, size, err := decodeCBOR(data)
+	if err != nil || size != len(data) {
+		return nil, ErrUnsupportedKey
+	}
+	key, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+	keyType, _ := key[int64(coseKeyType)].(int64)
+	algorithm, _ := key[int64(coseAlgorithm)].(int64)
+	curve, _ := key[int64(coseCurve)].(int64)
+	x, _ := key[int64(coseX)].([]byte)
+	y, _ := key[int64(coseY)].([]byte)
+
+	switch {
+	case keyType == coseKeyTypeEC2 && algorithm == AlgES256 && curve == coseCurveP256:
+		if len(x) != 32 || len(y) != 32 {
+			return nil, ErrUnsupportedKey
+		}
+		point := append(append([]byte{4}, x...), y...)
+		// ecdh refuses points that are not on the curve
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, ErrUnsupportedKey
+		}
+		ecdsaKey := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		return &publicKey{verify: func(message, signature []byte) bool {
+			hash := sha256.Sum256(message)
+			return ecdsa.VerifyASN1(ecdsaKey, hash[:], signature)
+		}}, nil
+
+	case keyType == coseKeyTypeOKP && algorithm == AlgEdDSA && curve == coseCurveEd25519:
+		if len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		ed25519Key := ed25519.PublicKey(x)
+		return &publicKey{verify: func(message, signature []byte) bool {
+			return ed25519.Verify(ed25519Key, message, signature)
+		}}, nil
+
+	case keyType == coseKeyTypeRSA && algorithm == AlgRS256:
+		modulus, _ := key[int64(coseRSAModulus)].([]byte)
+		encodedExponent, _ := key[int64(coseRSAExponent)].([]byte)
+		exponent := new(big.Int).SetBytes(encodedExponent)
+		// the modulus has no leading zero, so its length is its size
+		if len(modulus) < minRSAModulusSize || len(modulus) > maxRSAModulusSize || modulus[0] == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+			return nil, ErrUnsupportedKey
+		}
+		rsaKey := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: int(exponent.Int64())}
+		return &publicKey{verify: func(message, signature []byte) bool {
+			hash := sha256.Sum256(message)
+			return rsa.VerifyPKCS1v15(rsaKey, crypto.SHA256, hash[:], signature) == nil
+		}}, nil
+	}
+	return nil, ErrUnsupportedKey
+}
@@ -0,0 +1,176 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cborBytes encodes a CBOR byte string, up to 65535 bytes long
+func cborBytes(value []byte) []byte {
+	switch {
+	case len(value) < 24:
+		return append([]byte{0x40 | byte(len(value))}, value...)
+	case len(value) < 256:
+		return append([]byte{0x58, byte(len(value))}, value...)
+	}
+	return append([]byte{0x59, byte(len(value) >> 8), byte(len(value))}, value...)
+}
+
+// ec2Key encodes {1: 2, 3: -7, -1: 1, -2: x, -3: y}
+func ec2Key(x, y []byte) []byte {
+	key := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21}
+	key = append(key, cborBytes(x)...)
+	key = append(key, 0x22)
+	return append(key, cborBytes(y)...)
+}
+
+// okpKey encodes {1: 1, 3: -8, -1: 6, -2: x}
+func okpKey(x []byte) []byte {
+	key := []byte{0xa4, 0x01, 0x01, 0x03, 0x27, 0x20, 0x06, 0x21}
+	return append(key, cborBytes(x)...)
+}
+
+// rsaKey encodes {1: 3, 3: -257, -1: modulus, -2: exponent}
+func rsaKey(modulus, exponent []byte) []byte {
+	key := []byte{0xa4, 0x01, 0x03, 0x03, 0x39, 0x01, 0x00, 0x20}
+	key = append(key, cborBytes(modulus)...)
+	key = append(key, 0x21)
+	return append(key, cborBytes(exponent)...)
+}
+
+func newES256Key(t testing.TB) (*ecdsa.PrivateKey, []byte) {
+	t.Helper()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return private, ec2Key(private.X.FillBytes(make([]byte, 32)), private.Y.FillBytes(make([]byte, 32)))
+}
+
+func TestParsePublicKey(t *testing.T) {
+	message := []byte("signed data")
+	hash := sha256.Sum256(message)
+
+	t.Run("ES256", func(t *testing.T) {
+		private, data := newES256Key(t)
+		signature, err := ecdsa.SignASN1(rand.Reader, private, hash[:])
+		require.NoError(t, err)
+
+		key, err := parsePublicKey(data)
+		require.NoError(t, err)
+		assert.True(t, key.verify(message, signature))
+		assert.False(t, key.verify([]byte("other data"), signature))
+	})
+
+	t.Run("EdDSA", func(t *testing.T) {
+		public, private, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+
+		key, err := parsePublicKey(okpKey(public))
+		require.NoError(t, err)
+		assert.True(t, key.verify(message, ed25519.Sign(private, message)))
+		assert.False(t, key.verify([]byte("other data"), ed25519.Sign(private, message)))
+	})
+
+	t.Run("RS256", func(t *testing.T) {
+		private, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, private, crypto.SHA256, hash[:])
+		require.NoError(t, err)
+
+		exponent := big.NewInt(int64(private.E)).Bytes()
+		key, err := parsePublicKey(rsaKey(private.N.Bytes(), exponent))
+		require.NoError(t, err)
+		assert.True(t, key.verify(message, signature))
+		assert.False(t, key.verify([]byte("other data"), signature))
+	})
+}
+
+func TestParsePublicKeyRefusesInvalidKeys(t *testing.T) {
+	_, es256 := newES256Key(t)
+	rs256, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	t.Run("truncated", func(t *testing.T) {
+		for end := range len(es256) {
+			_, err := parsePublicKey(es256[:end])
+			assert.ErrorIs(t, err, ErrUnsupportedKey, "%x", es256[:end])
+		}
+	})
+
+	t.Run("trailing data", func(t *testing.T) {
+		_, err := parsePublicKey(append(es256, 0x00))
+		assert.ErrorIs(t, err, ErrUnsupportedKey)
+	})
+
+	t.Run("nested too deep", func(t *testing.T) {
+		data := []byte{0xa1, 0x01}
+		for range maxCBORDepth {
+			data = append(data, 0x81)
+		}
+		_, err := parsePublicKey(append(data, 0x00))
+		assert.ErrorIs(t, err, ErrUnsupportedKey)
+	})
+
+	t.Run("oversized", func(t *testing.T) {
+		private, err := rsa.GenerateKey(rand.Reader, 1024)
+		require.NoError(t, err)
+		large := make([]byte, maxRSAModulusSize+1)
+		large[0] = 0x80
+		padded := append([]byte{0}, rs256.N.Bytes()...)
+
+		for name, data := range map[string][]byte{
+			"small RSA modulus":    rsaKey(private.N.Bytes(), []byte{1, 0, 1}),
+			"large RSA modulus":    rsaKey(large, []byte{1, 0, 1}),
+			"padded RSA modulus":   rsaKey(padded[:minRSAModulusSize], []byte{1, 0, 1}),
+			"large RSA exponent":   rsaKey(rs256.N.Bytes(), []byte{1, 0, 0, 0, 0, 0, 0, 0, 1}),
+			"longer EC coordinate": ec2Key(make([]byte, 33), make([]byte, 32)),
+			"longer EdDSA key":     okpKey(make([]byte, ed25519.PublicKeySize+1)),
+		} {
+			_, err := parsePublicKey(data)
+			assert.ErrorIs(t, err, ErrUnsupportedKey, name)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		offCurve := make([]byte, 32)
+		offCurve[31] = 1
+		tests := map[string][]byte{
+			"not a map":       {0x80},
+			"point off curve": ec2Key(offCurve, offCurve),
+			"another curve":   append([]byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x02}, es256[7:]...),
+			"another key":     {0xa2, 0x01, 0x04, 0x03, 0x26},
+		}
+		for name, data := range tests {
+			_, err := parsePublicKey(data)
+			assert.ErrorIs(t, err, ErrUnsupportedKey, name)
+		}
+	})
+}
+
+func FuzzParsePublicKey(f *testing.F) {
+	_, es256 := newES256Key(f)
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(f, err)
+	f.Add(es256)
+	f.Add(okpKey(public))
+	rs256, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(f, err)
+	f.Add(rsaKey(rs256.N.Bytes(), []byte{1, 0, 1}))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		key, err := parsePublicKey(data)
+		if err != nil {
+			return
+		}
+		// checking a signature never panics, whatever the key held
+		key.verify(data, data)
+	})
+}
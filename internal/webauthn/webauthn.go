@@ -0,0 +1,190 @@
+// Package webauthn verifies the WebAuthn ceremonies behind passkeys. It only
+// covers what passkeys need: attestation is not checked and the public keys
+// are ES256, EdDSA or RS256
+package webauthn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	CeremonyCreate = "webauthn.create"
+	CeremonyGet    = "webauthn.get"
+)
+
+const (
+	flagUserPresent     = 0x01
+	flagUserVerified    = 0x04
+	flagAttestedData    = 0x40
+	authenticatorHeader = 37 // rpIdHash, flags and signCount
+)
+
+var (
+	ErrInvalidClientData        = errors.New("client data is invalid")
+	ErrInvalidAuthenticatorData = errors.New("authenticator data is invalid")
+	ErrUserNotPresent           = errors.New("user presence was not confirmed")
+	ErrInvalidSignature         = errors.New("signature is invalid")
+	// ErrSignCountRegressed means the authenticator counter went backwards,
+	// which happens when a credential was cloned
+	ErrSignCountRegressed = errors.New("signature counter went backwards")
+)
+
+// RelyingParty is the site the credentials are scoped to
+type RelyingParty struct {
+	// ID is the domain the credentials belong to
+	ID   string
+	Name string
+	// Origin is where the browser runs the ceremonies from
+	Origin string
+}
+
+// ClientData is what the browser signed over, decoded from clientDataJSON
+type ClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// Credential is a public key registered by an authenticator
+type Credential struct {
+	ID []byte
+	// PublicKey is the COSE encoded key
+	PublicKey    []byte
+	SignCount    uint32
+	UserVerified bool
+}
+
+// Assertion is the result of a valid sign in with a credential
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+// ParseClientData decodes clientDataJSON and checks it belongs to the
+// expected ceremony. The challenge is left for the caller to match
+func ParseClientData(raw []byte, ceremony string) (*ClientData, error) {
+	var clientData ClientData
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return nil, ErrInvalidClientData
+	}
+	if clientData.Type != ceremony || clientData.Challenge == "" {
+		return nil, ErrInvalidClientData
+	}
+	return &clientData, nil
+}
+
+// ChallengeBytes is the challenge the browser received in the ceremony options
+func (c *ClientData) ChallengeBytes() ([]byte, error) {
+	challenge, err := base64.RawURLEncoding.DecodeString(c.Challenge)
+	if err != nil {
+		return nil, ErrInvalidClientData
+	}
+	return challenge, nil
+}
+
+// VerifyRegistration checks a new credential created for this relying party.
+// The attestation statement is ignored, passkeys are trusted the way a new
+// password would be
+func (rp *RelyingParty) VerifyRegistration(clientData *ClientData, attestationObject []byte) (*Credential, error) {
+	if clientData.Type != CeremonyCreate || clientData.Origin != rp.Origin {
+		return nil, ErrInvalidClientData
+	}
+
+	decoded, size, err := decodeCBOR(attestationObject)
+	if err != nil || size != len(attestationObject) {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	authData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidAuthenticatorData
+	}
+
+	flags, signCount, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if flags&flagAttestedData == 0 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+
+	// aaguid, credential id length, credential id and its public key
+	attested := authData[authenticatorHeader:]
+	if len(attested) < 18 {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	idLength := int(binary.BigEndian.Uint16(attested[16:18]))
+	if idLength == 0 || len(attested) < 18+idLength {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	credentialID := attested[18 : 18+idLength]
+	keyData := attested[18+idLength:]
+	_, keySize, err := decodeCBOR(keyData)
+	if err != nil {
+		return nil, ErrInvalidAuthenticatorData
+	}
+	publicKey := keyData[:keySize]
+	if _, err := parsePublicKey(publicKey); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:           bytes.Clone(credentialID),
+		PublicKey:    bytes.Clone(publicKey),
+		SignCount:    signCount,
+		UserVerified: flags&flagUserVerified != 0,
+	}, nil
+}
+
+// VerifyAssertion checks the signature of a sign in made with a stored
+// credential and that its counter moved forward. Authenticators that do not
+// keep a counter always send zero
+func (rp *RelyingParty) VerifyAssertion(clientData *ClientData, rawClientData, authData, signature []byte, credential *Credential) (*Assertion, error) {
+	if clientData.Type != CeremonyGet || clientData.Origin != rp.Origin {
+		return nil, ErrInvalidClientData
+	}
+
+	flags, signCount, err := rp.parseAuthenticatorData(authData)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(bytes.Clone(authData), clientDataHash[:]...)
+	if !publicKey.verify(signed, signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	if (signCount != 0 || credential.SignCount != 0) && signCount <= credential.SignCount {
+		return nil, ErrSignCountRegressed
+	}
+	return &Assertion{SignCount: signCount, UserVerified: flags&flagUserVerified != 0}, nil
+}
+
+func (rp *RelyingParty) parseAuthenticatorData(authData []byte) (byte, uint32, error) {
+	if len(authData) < authenticatorHeader {
+		return 0, 0, ErrInvalidAuthenticatorData
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if subtle.ConstantTimeCompare(authData[:32], rpIDHash[:]) != 1 {
+		return 0, 0, ErrInvalidAuthenticatorData
+	}
+	flags := authData[32]
+	if flags&flagUserPresent == 0 {
+		return 0, 0, ErrUserNotPresent
+	}
+	return flags, binary.BigEndian.Uint32(authData[33:37]), nil
+}
@@ -0,0 +1,109 @@
+package webauthn_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/mochaeng/sapphire-backend/internal/models/payloads"
+	"github.com/mochaeng/sapphire-backend/internal/testutils"
+	"github.com/mochaeng/sapphire-backend/internal/webauthn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var relyingParty = &webauthn.RelyingParty{
+	ID:     "sapphire.com",
+	Name:   "Sapphire",
+	Origin: "https://sapphire.com",
+}
+
+func decode(t *testing.T, value string) []byte {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	require.NoError(t, err)
+	return data
+}
+
+func register(t *testing.T, authenticator *testutils.Authenticator, challenge []byte) (*webauthn.Credential, error) {
+	t.Helper()
+	attestation := authenticator.Register(challenge)
+	clientData, err := webauthn.ParseClientData(decode(t, attestation.Response.ClientDataJSON), webauthn.CeremonyCreate)
+	require.NoError(t, err)
+	return relyingParty.VerifyRegistration(clientData, decode(t, attestation.Response.AttestationObject))
+}
+
+func login(t *testing.T, assertion payloads.PasskeyAssertionPayload, credential *webauthn.Credential) (*webauthn.Assertion, error) {
+	t.Helper()
+	rawClientData := decode(t, assertion.Response.ClientDataJSON)
+	clientData, err := webauthn.ParseClientData(rawClientData, webauthn.CeremonyGet)
+	require.NoError(t, err)
+	return relyingParty.VerifyAssertion(
+		clientData,
+		rawClientData,
+		decode(t, assertion.Response.AuthenticatorData),
+		decode(t, assertion.Response.Signature),
+		credential,
+	)
+}
+
+func TestPasskeyCeremonies(t *testing.T) {
+	authenticator, err := testutils.NewAuthenticator(relyingParty.ID, relyingParty.Origin)
+	require.NoError(t, err)
+
+	challenge := []byte("registration-challenge")
+	credential, err := register(t, authenticator, challenge)
+	require.NoError(t, err)
+	assert.Equal(t, authenticator.CredentialID, credential.ID)
+	assert.Zero(t, credential.SignCount)
+
+	t.Run("gives back the challenge signed by the browser", func(t *testing.T) {
+		attestation := authenticator.Register(challenge)
+		clientData, err := webauthn.ParseClientData(decode(t, attestation.Response.ClientDataJSON), webauthn.CeremonyCreate)
+		require.NoError(t, err)
+		value, err := clientData.ChallengeBytes()
+		require.NoError(t, err)
+		assert.Equal(t, challenge, value)
+	})
+
+	t.Run("signs in with the registered credential", func(t *testing.T) {
+		assertion, err := login(t, authenticator.Login([]byte("login-challenge")), credential)
+		require.NoError(t, err)
+		assert.Equal(t, authenticator.SignCount, assertion.SignCount)
+		assert.True(t, assertion.UserVerified)
+		credential.SignCount = assertion.SignCount
+	})
+
+	t.Run("refuses a counter that went backwards", func(t *testing.T) {
+		authenticator.SignCount = 0
+		_, err := login(t, authenticator.Login([]byte("login-challenge")), credential)
+		assert.ErrorIs(t, err, webauthn.ErrSignCountRegressed)
+	})
+
+	t.Run("refuses a signature from another key", func(t *testing.T) {
+		other, err := testutils.NewAuthenticator(relyingParty.ID, relyingParty.Origin)
+		require.NoError(t, err)
+		other.SignCount = 100
+		_, err = login(t, other.Login([]byte("login-challenge")), credential)
+		assert.ErrorIs(t, err, webauthn.ErrInvalidSignature)
+	})
+
+	t.Run("refuses another origin", func(t *testing.T) {
+		phishing, err := testutils.NewAuthenticator(relyingParty.ID, "https://sapphire.evil")
+		require.NoError(t, err)
+		_, err = register(t, phishing, challenge)
+		assert.ErrorIs(t, err, webauthn.ErrInvalidClientData)
+	})
+
+	t.Run("refuses a credential for another site", func(t *testing.T) {
+		other, err := testutils.NewAuthenticator("evil.com", relyingParty.Origin)
+		require.NoError(t, err)
+		_, err = register(t, other, challenge)
+		assert.ErrorIs(t, err, webauthn.ErrInvalidAuthenticatorData)
+	})
+
+	t.Run("refuses the wrong ceremony", func(t *testing.T) {
+		attestation := authenticator.Register(challenge)
+		_, err := webauthn.ParseClientData(decode(t, attestation.Response.ClientDataJSON), webauthn.CeremonyGet)
+		assert.ErrorIs(t, err, webauthn.ErrInvalidClientData)
+	})
+}
@@ -0,0 +1,6 @@
+drop index if exists idx_comment_parent_id;
+drop index if exists idx_comment_post_parent_created;
+
+alter table "comment" drop constraint if exists fk_parent_comment;
+alter table "comment" drop column if exists updated_at;
+alter table "comment" drop column if exists parent_comment_id;
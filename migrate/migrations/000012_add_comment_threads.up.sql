@@ -0,0 +1,7 @@
+alter table "comment" add column if not exists parent_comment_id bigint;
+alter table "comment" add column if not exists updated_at timestamp(0) with time zone not null default now();
+
+alter table "comment" add constraint fk_parent_comment foreign key (parent_comment_id) references "comment"(id) on delete cascade;
+
+create index if not exists idx_comment_post_parent_created on "comment" (post_id, parent_comment_id, created_at desc);
+create index if not exists idx_comment_parent_id on "comment" (parent_comment_id);
@@ -0,0 +1,2 @@
+drop index if exists idx_post_reaction_post_kind;
+drop table if exists "post_reaction";
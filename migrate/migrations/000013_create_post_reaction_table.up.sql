@@ -0,0 +1,12 @@
+create table if not exists "post_reaction"(
+    post_id bigint not null,
+    user_id bigint not null,
+    kind varchar(32) not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    primary key (post_id, user_id),
+    constraint fk_post foreign key (post_id) references "post"(id) on delete cascade,
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
+
+create index if not exists idx_post_reaction_post_kind on "post_reaction" (post_id, kind);
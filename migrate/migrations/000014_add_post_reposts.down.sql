@@ -0,0 +1,9 @@
+drop index if exists idx_post_quote_of;
+drop index if exists idx_post_user_repost_of;
+
+delete from "post" where repost_of_id is not null;
+
+alter table "post" drop constraint if exists fk_quote_of;
+alter table "post" drop constraint if exists fk_repost_of;
+alter table "post" drop column if exists quote_of_id;
+alter table "post" drop column if exists repost_of_id;
@@ -0,0 +1,8 @@
+alter table "post" add column if not exists repost_of_id bigint;
+alter table "post" add column if not exists quote_of_id bigint;
+
+alter table "post" add constraint fk_repost_of foreign key (repost_of_id) references "post"(id) on delete cascade;
+alter table "post" add constraint fk_quote_of foreign key (quote_of_id) references "post"(id) on delete set null;
+
+create unique index if not exists idx_post_user_repost_of on "post" (user_id, repost_of_id) where repost_of_id is not null;
+create index if not exists idx_post_quote_of on "post" (quote_of_id) where quote_of_id is not null;
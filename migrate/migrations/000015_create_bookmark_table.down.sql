@@ -0,0 +1,2 @@
+drop index if exists idx_bookmark_user_created;
+drop table if exists "bookmark";
@@ -0,0 +1,11 @@
+create table if not exists "bookmark"(
+    user_id bigint not null,
+    post_id bigint not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    primary key (user_id, post_id),
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade,
+    constraint fk_post foreign key (post_id) references "post"(id) on delete cascade
+);
+
+create index if not exists idx_bookmark_user_created on "bookmark" (user_id, created_at desc);
@@ -0,0 +1,4 @@
+drop index if exists idx_user_full_name_trgm;
+drop index if exists idx_user_username_trgm;
+drop index if exists idx_post_content;
+drop index if exists idx_post_search_document;
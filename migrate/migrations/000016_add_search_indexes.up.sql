@@ -0,0 +1,7 @@
+create index if not exists idx_post_search_document on "post"
+    using gin (to_tsvector('simple', tittle || ' ' || content));
+create index if not exists idx_post_content on "post" using gin (content gin_trgm_ops);
+
+create index if not exists idx_user_username_trgm on "user" using gin ((username::text) gin_trgm_ops);
+create index if not exists idx_user_full_name_trgm on "user"
+    using gin ((first_name || ' ' || coalesce(last_name, '')) gin_trgm_ops);
@@ -0,0 +1,2 @@
+drop index if exists idx_post_created_at;
+drop table if exists "trending_tag";
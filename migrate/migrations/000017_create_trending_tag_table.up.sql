@@ -0,0 +1,8 @@
+create table if not exists "trending_tag"(
+    tag varchar(255) primary key,
+    post_count int not null,
+    user_count int not null,
+    computed_at timestamp(0) with time zone not null default now()
+);
+
+create index if not exists idx_post_created_at on "post" (created_at desc);
@@ -0,0 +1,4 @@
+drop index if exists idx_mention_user_created;
+drop index if exists idx_mention_comment_user;
+drop index if exists idx_mention_post_user;
+drop table if exists "mention";
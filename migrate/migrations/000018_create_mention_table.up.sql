@@ -0,0 +1,18 @@
+create table if not exists "mention"(
+    id bigserial primary key,
+    post_id bigint,
+    comment_id bigint,
+    author_id bigint not null,
+    mentioned_user_id bigint not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    constraint fk_post foreign key (post_id) references "post"(id) on delete cascade,
+    constraint fk_comment foreign key (comment_id) references "comment"(id) on delete cascade,
+    constraint fk_author foreign key (author_id) references "user"(id) on delete cascade,
+    constraint fk_mentioned_user foreign key (mentioned_user_id) references "user"(id) on delete cascade,
+    constraint mention_single_target check ((post_id is null) <> (comment_id is null))
+);
+
+create unique index if not exists idx_mention_post_user on "mention" (post_id, mentioned_user_id) where post_id is not null;
+create unique index if not exists idx_mention_comment_user on "mention" (comment_id, mentioned_user_id) where comment_id is not null;
+create index if not exists idx_mention_user_created on "mention" (mentioned_user_id, created_at desc);
@@ -0,0 +1,5 @@
+drop index if exists idx_notification_user_dedupe;
+drop index if exists idx_notification_user_unread;
+drop index if exists idx_notification_user_created;
+drop index if exists idx_notification_user_group;
+drop table if exists "notification";
@@ -0,0 +1,22 @@
+create table if not exists "notification"(
+    id bigserial primary key,
+    user_id bigint not null,
+    actor_id bigint not null,
+    kind varchar(32) not null,
+    post_id bigint,
+    comment_id bigint,
+    group_key varchar(128) not null,
+    dedupe_key varchar(128),
+    read_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone not null default now(),
+
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade,
+    constraint fk_actor foreign key (actor_id) references "user"(id) on delete cascade,
+    constraint fk_post foreign key (post_id) references "post"(id) on delete cascade,
+    constraint fk_comment foreign key (comment_id) references "comment"(id) on delete cascade
+);
+
+create index if not exists idx_notification_user_group on "notification" (user_id, group_key);
+create index if not exists idx_notification_user_created on "notification" (user_id, created_at desc);
+create index if not exists idx_notification_user_unread on "notification" (user_id) where read_at is null;
+create unique index if not exists idx_notification_user_dedupe on "notification" (user_id, dedupe_key) where dedupe_key is not null;
@@ -0,0 +1,7 @@
+drop index if exists idx_message_conversation_created;
+drop table if exists "message";
+drop index if exists idx_conversation_participant_user;
+drop table if exists "conversation_participant";
+drop table if exists "conversation";
+drop index if exists idx_user_block_blocked;
+drop table if exists "user_block";
@@ -0,0 +1,52 @@
+create table if not exists "user_block"(
+    blocker_id bigint not null,
+    blocked_id bigint not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    primary key (blocker_id, blocked_id),
+    constraint fk_blocker foreign key (blocker_id) references "user"(id) on delete cascade,
+    constraint fk_blocked foreign key (blocked_id) references "user"(id) on delete cascade
+);
+
+create index if not exists idx_user_block_blocked on "user_block" (blocked_id);
+
+create table if not exists "conversation"(
+    id bigserial primary key,
+    is_group boolean not null default false,
+    title varchar(100) not null default '',
+    created_by bigint,
+    -- direct_key is "<lowest user id>:<highest user id>" for 1:1 conversations,
+    -- so there is only one per pair of users
+    direct_key varchar(64) unique,
+    created_at timestamp(0) with time zone not null default now(),
+    updated_at timestamp with time zone not null default now(),
+
+    constraint fk_created_by foreign key (created_by) references "user"(id) on delete set null
+);
+
+create table if not exists "conversation_participant"(
+    conversation_id bigint not null,
+    user_id bigint not null,
+    last_read_message_id bigint,
+    last_read_at timestamp(0) with time zone,
+    joined_at timestamp(0) with time zone not null default now(),
+
+    primary key (conversation_id, user_id),
+    constraint fk_conversation foreign key (conversation_id) references "conversation"(id) on delete cascade,
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
+
+create index if not exists idx_conversation_participant_user on "conversation_participant" (user_id);
+
+create table if not exists "message"(
+    id bigserial primary key,
+    conversation_id bigint not null,
+    sender_id bigint not null,
+    content text not null,
+    created_at timestamp with time zone not null default now(),
+
+    constraint fk_conversation foreign key (conversation_id) references "conversation"(id) on delete cascade,
+    constraint fk_sender foreign key (sender_id) references "user"(id) on delete cascade
+);
+
+create index if not exists idx_message_conversation_created on "message" (conversation_id, created_at desc);
@@ -0,0 +1,2 @@
+drop index if exists idx_password_reset_user_id;
+drop table if exists "password_reset";
@@ -0,0 +1,10 @@
+create table if not exists "password_reset"(
+    token bytea primary key,
+    user_id bigint not null,
+    expired timestamp(0) with time zone not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
+
+create index if not exists idx_password_reset_user_id on "password_reset" (user_id);
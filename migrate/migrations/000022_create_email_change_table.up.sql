@@ -0,0 +1,9 @@
+create table if not exists "email_change"(
+    token bytea primary key,
+    user_id bigint not null unique,
+    new_email citext not null,
+    expired timestamp(0) with time zone not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
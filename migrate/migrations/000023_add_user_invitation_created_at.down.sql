@@ -0,0 +1 @@
+alter table "user_invitation" drop column if exists created_at;
@@ -0,0 +1,2 @@
+alter table "user_invitation"
+add column if not exists created_at timestamp(0) with time zone not null default now();
@@ -0,0 +1,7 @@
+drop index if exists idx_user_session_user_id;
+
+alter table "user_session"
+drop column if exists user_agent,
+drop column if exists ip,
+drop column if exists last_seen_at,
+drop column if exists created_at;
@@ -0,0 +1,7 @@
+alter table "user_session"
+add column if not exists created_at timestamptz not null default now(),
+add column if not exists last_seen_at timestamptz not null default now(),
+add column if not exists ip text not null default '',
+add column if not exists user_agent text not null default '';
+
+create index if not exists idx_user_session_user_id on "user_session" (user_id, last_seen_at desc);
@@ -0,0 +1,4 @@
+drop table if exists "pending_login";
+drop index if exists idx_user_recovery_code_hash;
+drop table if exists "user_recovery_code";
+drop table if exists "user_totp";
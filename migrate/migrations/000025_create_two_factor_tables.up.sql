@@ -0,0 +1,29 @@
+create table if not exists "user_totp"(
+    user_id bigint primary key,
+    secret bytea not null,
+    enabled_at timestamp(0) with time zone,
+    last_used_step bigint,
+    created_at timestamp(0) with time zone not null default now(),
+
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
+
+create table if not exists "user_recovery_code"(
+    id bigserial primary key,
+    user_id bigint not null,
+    code_hash text not null,
+    used_at timestamp(0) with time zone,
+
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
+
+create unique index if not exists idx_user_recovery_code_hash on "user_recovery_code" (user_id, code_hash);
+
+create table if not exists "pending_login"(
+    token bytea primary key,
+    user_id bigint not null,
+    attempts int not null default 0,
+    expires_at timestamp(0) with time zone not null,
+
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
@@ -0,0 +1,3 @@
+drop table if exists "passkey_challenge";
+drop index if exists idx_user_passkey_user_id;
+drop table if exists "user_passkey";
@@ -0,0 +1,23 @@
+create table if not exists "user_passkey"(
+    id bigserial primary key,
+    user_id bigint not null,
+    credential_id bytea not null unique,
+    public_key bytea not null,
+    sign_count bigint not null default 0,
+    name varchar(64) not null,
+    created_at timestamp(0) with time zone not null default now(),
+    last_used_at timestamp(0) with time zone,
+
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
+
+create index if not exists idx_user_passkey_user_id on "user_passkey" (user_id);
+
+create table if not exists "passkey_challenge"(
+    challenge bytea primary key,
+    user_id bigint,
+    kind varchar(16) not null,
+    expires_at timestamp(0) with time zone not null,
+
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
@@ -0,0 +1,2 @@
+drop index if exists idx_user_access_token_user_id;
+drop table if exists "user_access_token";
@@ -0,0 +1,14 @@
+create table if not exists "user_access_token"(
+    id bigserial primary key,
+    user_id bigint not null,
+    name varchar(64) not null,
+    token_hash bytea not null unique,
+    scopes varchar(16)[] not null,
+    expires_at timestamp(0) with time zone,
+    last_used_at timestamp(0) with time zone,
+    created_at timestamp(0) with time zone not null default now(),
+
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
+
+create index if not exists idx_user_access_token_user_id on "user_access_token" (user_id);
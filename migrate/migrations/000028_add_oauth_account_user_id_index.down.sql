@@ -0,0 +1 @@
+drop index if exists idx_oauth_account_user_id;
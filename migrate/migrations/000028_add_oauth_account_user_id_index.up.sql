@@ -0,0 +1 @@
+create index if not exists idx_oauth_account_user_id on "oauth_account" (user_id);
@@ -0,0 +1,2 @@
+drop index if exists idx_login_failure_last_failed_at;
+drop table if exists "login_failure";
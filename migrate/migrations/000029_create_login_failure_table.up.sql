@@ -0,0 +1,7 @@
+create table if not exists "login_failure"(
+    key varchar(320) primary key,
+    failures int not null,
+    last_failed_at timestamp(0) with time zone not null
+);
+
+create index if not exists idx_login_failure_last_failed_at on "login_failure" (last_failed_at);
@@ -0,0 +1,2 @@
+drop index if exists idx_magic_link_user_id;
+drop table if exists "magic_link";
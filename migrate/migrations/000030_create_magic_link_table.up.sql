@@ -0,0 +1,11 @@
+create table if not exists "magic_link"(
+    token text primary key,
+    browser text not null,
+    user_id bigint not null,
+    expired timestamp(0) with time zone not null,
+    created_at timestamp(0) with time zone not null default now(),
+
+    constraint fk_user foreign key (user_id) references "user"(id) on delete cascade
+);
+
+create index if not exists idx_magic_link_user_id on "magic_link" (user_id);
@@ -0,0 +1,8 @@
+drop trigger if exists audit_event_no_truncate on "audit_event";
+drop trigger if exists audit_event_no_change on "audit_event";
+drop function if exists audit_event_append_only;
+drop index if exists idx_audit_event_created;
+drop index if exists idx_audit_event_action;
+drop index if exists idx_audit_event_target;
+drop index if exists idx_audit_event_actor;
+drop table if exists "audit_event";
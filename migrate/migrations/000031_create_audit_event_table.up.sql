@@ -0,0 +1,31 @@
+create table if not exists "audit_event"(
+    id bigserial primary key,
+    action varchar(64) not null,
+    -- no foreign keys, the events outlive the users and objects they are about
+    actor_id bigint,
+    target_type varchar(32) not null,
+    target_id varchar(255) not null default '',
+    ip varchar(64) not null default '',
+    request_id varchar(255) not null default '',
+    metadata jsonb not null default '{}',
+    created_at timestamp with time zone not null default now()
+);
+
+create index if not exists idx_audit_event_actor on "audit_event" (actor_id, id desc);
+create index if not exists idx_audit_event_target on "audit_event" (target_type, target_id, id desc);
+create index if not exists idx_audit_event_action on "audit_event" (action, id desc);
+create index if not exists idx_audit_event_created on "audit_event" (created_at);
+
+create or replace function audit_event_append_only() returns trigger as $$
+begin
+    raise exception 'audit_event is append-only';
+end;
+$$ language plpgsql;
+
+create trigger audit_event_no_change
+before update or delete on "audit_event"
+for each row execute function audit_event_append_only();
+
+create trigger audit_event_no_truncate
+before truncate on "audit_event"
+for each statement execute function audit_event_append_only();
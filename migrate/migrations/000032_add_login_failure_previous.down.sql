@@ -0,0 +1,2 @@
+alter table "login_failure" drop column if exists previous_failed_at;
+alter table "login_failure" alter column last_failed_at type timestamp(0) with time zone;
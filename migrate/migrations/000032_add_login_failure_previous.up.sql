@@ -0,0 +1,2 @@
+alter table "login_failure" alter column last_failed_at type timestamp with time zone;
+alter table "login_failure" add column if not exists previous_failed_at timestamp with time zone;
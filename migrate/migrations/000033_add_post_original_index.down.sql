@@ -0,0 +1 @@
+drop index if exists idx_post_original;
@@ -0,0 +1 @@
+create index if not exists idx_post_original on "post" ((coalesce(repost_of_id, id)), created_at desc);
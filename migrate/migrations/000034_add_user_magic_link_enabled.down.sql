@@ -0,0 +1 @@
+alter table "user" drop column if exists magic_link_enabled;
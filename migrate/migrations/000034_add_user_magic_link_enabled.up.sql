@@ -0,0 +1 @@
+alter table "user" add column if not exists magic_link_enabled boolean not null default true;